@@ -25,17 +25,15 @@ import (
 	"time"
 
 	"github.com/tricksterproxy/trickster/pkg/cache"
-	"github.com/tricksterproxy/trickster/pkg/cache/memory"
-	"github.com/tricksterproxy/trickster/pkg/cache/providers"
-	"github.com/tricksterproxy/trickster/pkg/cache/registration"
 	"github.com/tricksterproxy/trickster/pkg/config"
+	"github.com/tricksterproxy/trickster/pkg/config/reload"
 	ro "github.com/tricksterproxy/trickster/pkg/config/reload/options"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
 	"github.com/tricksterproxy/trickster/pkg/proxy/handlers"
-	th "github.com/tricksterproxy/trickster/pkg/proxy/handlers"
 	"github.com/tricksterproxy/trickster/pkg/routing"
 	"github.com/tricksterproxy/trickster/pkg/runtime"
 	tr "github.com/tricksterproxy/trickster/pkg/tracing/registration"
+	"github.com/tricksterproxy/trickster/pkg/trickster"
 	"github.com/tricksterproxy/trickster/pkg/util/metrics"
 
 	"github.com/gorilla/mux"
@@ -43,8 +41,8 @@ import (
 
 var cfgLock = &sync.Mutex{}
 
-func runConfig(oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
-	oldCaches map[string]cache.Cache, args []string, errorsFatal bool) error {
+func runConfig(oldConf *config.Config, oldHandler *trickster.Handler, wg *sync.WaitGroup,
+	log *tl.Logger, args []string, errorsFatal bool) error {
 
 	metrics.BuildInfo.WithLabelValues(applicationGoVersion,
 		applicationGitCommitID, applicationVersion).Set(1)
@@ -80,12 +78,21 @@ func runConfig(oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
 		os.Exit(0)
 	}
 
-	return applyConfig(conf, oldConf, wg, log, oldCaches, args, errorsFatal)
+	return applyConfig(conf, oldConf, oldHandler, wg, log, args, errorsFatal)
 
 }
 
-func applyConfig(conf, oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
-	oldCaches map[string]cache.Cache, args []string, errorsFatal bool) error {
+// reloader returns a ReloaderFunc that replaces the provided Handler with one built
+// from the reloaded configuration
+func reloader(h *trickster.Handler) reload.ReloaderFunc {
+	return func(oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
+		args []string, errorsFatal bool) error {
+		return runConfig(oldConf, h, wg, log, args, errorsFatal)
+	}
+}
+
+func applyConfig(conf, oldConf *config.Config, oldHandler *trickster.Handler,
+	wg *sync.WaitGroup, log *tl.Logger, args []string, errorsFatal bool) error {
 
 	if conf == nil {
 		return nil
@@ -106,29 +113,18 @@ func applyConfig(conf, oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logge
 		tl.Warn(log, w, tl.Pairs{})
 	}
 
-	//Register Tracing Configurations
-	tracers, err := tr.RegisterAll(conf, log, false)
-	if err != nil {
-		handleStartupIssue("tracing registration failed", tl.Pairs{"detail": err.Error()},
-			log, errorsFatal)
-		return err
-	}
-
-	// every config (re)load is a new router
-	router := mux.NewRouter()
-	router.HandleFunc(conf.Main.PingHandlerPath, th.PingHandleFunc(conf)).Methods(http.MethodGet)
-
-	var caches = applyCachingConfig(conf, oldConf, log, oldCaches)
-	rh := handlers.ReloadHandleFunc(runConfig, conf, wg, log, caches, args)
-
-	_, err = routing.RegisterProxyRoutes(conf, router, caches, tracers, log, false)
+	// every config (re)load is a new handler, which takes over the unchanged caches
+	// of the previous handler and retires it once the drain timeout has elapsed
+	h, err := trickster.New(conf, trickster.WithLogger(log), trickster.WithPrevious(oldHandler))
 	if err != nil {
-		handleStartupIssue("route registration failed", tl.Pairs{"detail": err.Error()},
+		handleStartupIssue("handler initialization failed", tl.Pairs{"detail": err.Error()},
 			log, errorsFatal)
 		return err
 	}
 
-	applyListenerConfigs(conf, oldConf, router, http.HandlerFunc(rh), log, tracers)
+	rh := handlers.ReloadHandleFunc(reloader(h), conf, wg, log, args)
+	applyListenerConfigs(conf, oldConf, h, http.HandlerFunc(rh), h.PurgeHandler(), log,
+		h.Tracers())
 
 	metrics.LastReloadSuccessfulTimestamp.Set(float64(time.Now().Unix()))
 	metrics.LastReloadSuccessful.Set(1)
@@ -136,7 +132,7 @@ func applyConfig(conf, oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logge
 	if oldConf != nil && oldConf.Resources != nil {
 		oldConf.Resources.QuitChan <- true // this signals the old hup monitor goroutine to exit
 	}
-	startHupMonitor(conf, wg, log, caches, args)
+	startHupMonitor(conf, h, wg, log, args)
 
 	return nil
 }
@@ -177,62 +173,6 @@ func applyLoggingConfig(c, oc *config.Config, oldLog *tl.Logger) *tl.Logger {
 	return initLogger(c)
 }
 
-func applyCachingConfig(c, oc *config.Config, logger *tl.Logger,
-	oldCaches map[string]cache.Cache) map[string]cache.Cache {
-
-	if c == nil {
-		return nil
-	}
-
-	caches := make(map[string]cache.Cache)
-
-	if oc == nil || oldCaches == nil {
-		for k, v := range c.Caches {
-			caches[k] = registration.NewCache(k, v, logger)
-		}
-		return caches
-	}
-
-	for k, v := range c.Caches {
-
-		if w, ok := oldCaches[k]; ok {
-
-			ocfg := w.Configuration()
-
-			// if a cache is in both the old and new config, and unchanged, pass the
-			// pre-existing object instead of making a new one
-			if v.Equal(ocfg) {
-				caches[k] = w
-				continue
-			}
-
-			// if the new and old caches with the same name are the same type, then assume
-			// the cache should be preserved between reconfigurations, but only if the Index
-			// is the only change. In this case, we'll apply the new index configuration,
-			// then add the old cache with the new index config to the new cache map
-			if ocfg.ProviderID == v.ProviderID &&
-				ocfg.ProviderID == providers.Memory {
-				if v.Index != nil {
-					mc := w.(*memory.Cache)
-					mc.Index.UpdateOptions(v.Index)
-				}
-				caches[k] = w
-				continue
-			}
-
-			// if we got to this point, the cache won't be used, so lets close it
-			go func() {
-				time.Sleep(time.Millisecond * time.Duration(c.ReloadConfig.DrainTimeoutMS))
-				w.Close()
-			}()
-		}
-
-		// the newly-named cache is not in the old config or couldn't be reused, so make it anew
-		caches[k] = registration.NewCache(k, v, logger)
-	}
-	return caches
-}
-
 func initLogger(c *config.Config) *tl.Logger {
 	log := tl.New(c)
 	tl.Info(log, "application loaded from configuration",
@@ -299,7 +239,7 @@ func validateConfig(conf *config.Config) error {
 		return err
 	}
 
-	_, err = routing.RegisterProxyRoutes(conf, router, caches, tracers, log, true)
+	_, err = routing.RegisterProxyRoutes(conf, router, caches, tracers, nil, log, true)
 	if err != nil {
 		return err
 	}
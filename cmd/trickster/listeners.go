@@ -23,6 +23,7 @@ import (
 
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
 	ph "github.com/tricksterproxy/trickster/pkg/proxy/handlers"
 	"github.com/tricksterproxy/trickster/pkg/proxy/listener"
 	ttls "github.com/tricksterproxy/trickster/pkg/proxy/tls"
@@ -34,7 +35,7 @@ import (
 var lg = listener.NewListenerGroup()
 
 func applyListenerConfigs(conf, oldConf *config.Config,
-	router, reloadHandler http.Handler, log *tl.Logger,
+	router, reloadHandler, purgeHandler http.Handler, log *tl.Logger,
 	tracers tracing.Tracers) {
 
 	var err error
@@ -44,8 +45,11 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 		return
 	}
 
+	configHandler := adminHandler(conf, http.HandlerFunc(ph.ConfigHandleFunc(conf)))
+
 	adminRouter := http.NewServeMux()
-	adminRouter.Handle(conf.ReloadConfig.HandlerPath, reloadHandler)
+	adminRouter.Handle(conf.ReloadConfig.HandlerPath, adminHandler(conf, reloadHandler))
+	registerPurgeHandler(conf, adminRouter, purgeHandler)
 
 	// No changes in frontend config
 	if oldConf != nil && oldConf.Frontend != nil &&
@@ -135,7 +139,7 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 		lg.DrainAndClose("metricsListener", 0)
 		mr := http.NewServeMux()
 		mr.Handle("/metrics", metrics.Handler())
-		mr.HandleFunc(conf.Main.ConfigHandlerPath, ph.ConfigHandleFunc(conf))
+		mr.Handle(conf.Main.ConfigHandlerPath, configHandler)
 		if conf.Main.PprofServer == "both" || conf.Main.PprofServer == "metrics" {
 			routing.RegisterPprofRoutes("metrics", mr, log)
 		}
@@ -146,7 +150,7 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 	} else {
 		mr := http.NewServeMux()
 		mr.Handle("/metrics", metrics.Handler())
-		mr.HandleFunc(conf.Main.ConfigHandlerPath, ph.ConfigHandleFunc(conf))
+		mr.Handle(conf.Main.ConfigHandlerPath, configHandler)
 		lg.UpdateRouter("metricsListener", mr)
 	}
 
@@ -157,8 +161,9 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 		wg.Add(1)
 		lg.DrainAndClose("reloadListener", time.Millisecond*500)
 		mr := http.NewServeMux()
-		mr.HandleFunc(conf.Main.ConfigHandlerPath, ph.ConfigHandleFunc(conf))
-		mr.Handle(conf.ReloadConfig.HandlerPath, reloadHandler)
+		mr.Handle(conf.Main.ConfigHandlerPath, configHandler)
+		mr.Handle(conf.ReloadConfig.HandlerPath, adminHandler(conf, reloadHandler))
+		registerPurgeHandler(conf, mr, purgeHandler)
 		if conf.Main.PprofServer == "both" || conf.Main.PprofServer == "reload" {
 			routing.RegisterPprofRoutes("reload", mr, log)
 		}
@@ -167,8 +172,27 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 			conf.Frontend.ConnectionsLimit, nil, mr, wg, nil, true, 0, log)
 	} else {
 		mr := http.NewServeMux()
-		mr.HandleFunc(conf.Main.ConfigHandlerPath, ph.ConfigHandleFunc(conf))
-		mr.Handle(conf.ReloadConfig.HandlerPath, reloadHandler)
+		mr.Handle(conf.Main.ConfigHandlerPath, configHandler)
+		mr.Handle(conf.ReloadConfig.HandlerPath, adminHandler(conf, reloadHandler))
+		registerPurgeHandler(conf, mr, purgeHandler)
 		lg.UpdateRouter("reloadListener", mr)
 	}
 }
+
+// registerPurgeHandler registers the Cache Purge Handler with the reload listener's mux,
+// provided the Purge Handler Path and either the Purge Auth Token or Admin Authenticator are configured
+func registerPurgeHandler(conf *config.Config, mr *http.ServeMux, purgeHandler http.Handler) {
+	if purgeHandler == nil || conf.Main.PurgeHandlerPath == "" ||
+		(conf.Main.PurgeAuthToken == "" && conf.Main.AdminAuthenticatorName == "") {
+		return
+	}
+	mr.Handle(conf.Main.PurgeHandlerPath, adminHandler(conf, purgeHandler))
+}
+
+// adminHandler wraps an admin endpoint's handler with the configured admin authenticator, if any
+func adminHandler(conf *config.Config, h http.Handler) http.Handler {
+	if conf.Main.AdminAuthenticatorName == "" {
+		return h
+	}
+	return authenticator.Handler(conf.CompiledAuthenticators[conf.Main.AdminAuthenticatorName], h)
+}
@@ -22,9 +22,9 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/trickster"
 )
 
 var hups = make(chan os.Signal, 1)
@@ -33,8 +33,8 @@ func init() {
 	signal.Notify(hups, syscall.SIGHUP)
 }
 
-func startHupMonitor(conf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
-	caches map[string]cache.Cache, args []string) {
+func startHupMonitor(conf *config.Config, h *trickster.Handler, wg *sync.WaitGroup,
+	log *tl.Logger, args []string) {
 	if conf == nil || conf.Resources == nil {
 		return
 	}
@@ -46,7 +46,7 @@ func startHupMonitor(conf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
 				conf.Main.ReloaderLock.Lock()
 				if conf.IsStale() {
 					tl.Warn(log, "configuration reload starting now", tl.Pairs{"source": "sighup"})
-					err := runConfig(conf, wg, log, caches, args, false)
+					err := runConfig(conf, h, wg, log, args, false)
 					if err == nil {
 						conf.Main.ReloaderLock.Unlock()
 						return // runConfig will start a new HupMonitor in place of this one
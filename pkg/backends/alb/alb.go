@@ -0,0 +1,197 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package alb provides the Application Load Balancer backend provider, which distributes
+// requests across a pool of other configured backends
+package alb
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/proxy/methods"
+	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+)
+
+var _ backends.Client = (*Client)(nil)
+
+// Client Implements the Proxy Client Interface
+type Client struct {
+	name               string
+	options            *oo.Options
+	handlers           map[string]http.Handler
+	handlersRegistered bool
+
+	// this exists so the ALB can route requests to its pool members by backend name
+	clients backends.Backends
+
+	pool   *pool
+	router http.Handler
+}
+
+// NewClient returns a new ALB client reference
+func NewClient(name string, options *oo.Options, router http.Handler,
+	clients backends.Backends) (*Client, error) {
+	return &Client{
+		name:    name,
+		options: options,
+		clients: clients,
+		router:  router,
+	}, nil
+}
+
+// Clients is a list of *alb.Client
+type Clients []*Client
+
+// ValidatePools ensures that ALB clients' pools are fully loaded, which can't be done
+// until all backends are processed, so the pool's member names can be mapped to their
+// respective clients. Each pool member is registered with the provided HealthChecker
+func ValidatePools(clients backends.Backends, hc healthcheck.HealthChecker,
+	logger interface{}) error {
+	albClients := make(Clients, 0, len(clients))
+	for _, c := range clients {
+		if ac, ok := c.(*Client); ok {
+			albClients = append(albClients, ac)
+		}
+	}
+	for _, c := range albClients {
+		if err := c.validatePool(hc, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) validatePool(hc healthcheck.HealthChecker, logger interface{}) error {
+	if c.options == nil || c.options.ALBOptions == nil {
+		return fmt.Errorf("alb client %s failed to parse nil options", c.name)
+	}
+	o := c.options.ALBOptions
+	m, ok := Names[strings.ToLower(o.MechanismName)]
+	if !ok {
+		return fmt.Errorf("invalid mechanism name %s in alb %s", o.MechanismName, c.name)
+	}
+	if len(o.Pool) == 0 {
+		return fmt.Errorf("alb %s has an empty pool", c.name)
+	}
+	members := make([]*poolMember, 0, len(o.Pool))
+	var provider string
+	for _, n := range o.Pool {
+		if n == c.name {
+			return fmt.Errorf("alb %s cannot include itself in its pool", c.name)
+		}
+		mc := c.clients.Get(n)
+		if mc == nil || mc.Router() == nil {
+			return fmt.Errorf("invalid pool member %s in alb %s", n, c.name)
+		}
+		if m == TimeseriesMerge {
+			if _, ok := mc.(backends.TimeseriesClient); !ok {
+				return fmt.Errorf("pool member %s in alb %s does not support timeseries merging", n, c.name)
+			}
+			// merged timeseries must share a provider, so they can be merged by the same Modeler
+			mp := mc.Configuration().Provider
+			if provider == "" {
+				provider = mp
+			} else if mp != provider {
+				return fmt.Errorf("pool member %s in alb %s has mismatched provider %s", n, c.name, mp)
+			}
+		}
+		// requests are sent directly to the upstream origin of each first good response member
+		if m == FirstGoodResponse && mc.Configuration().Host == "" {
+			return fmt.Errorf("pool member %s in alb %s has no origin url", n, c.name)
+		}
+		pm := &poolMember{name: n, client: mc, handler: mc.Router()}
+		if hc != nil {
+			s, err := hc.Register(n, mc.Configuration(), mc.HTTPClient(), logger)
+			if err != nil {
+				return err
+			}
+			pm.status = s
+		}
+		members = append(members, pm)
+	}
+	p, err := newPool(m, members, o.HashSource, o.HashKey)
+	if err != nil {
+		return fmt.Errorf("%s in alb %s", err.Error(), c.name)
+	}
+	c.pool = p
+	return nil
+}
+
+// Configuration returns the Client Configuration
+func (c *Client) Configuration() *oo.Options {
+	return c.options
+}
+
+// DefaultPathConfigs returns the default PathConfigs for the given Provider
+func (c *Client) DefaultPathConfigs(oc *oo.Options) map[string]*po.Options {
+	m := methods.AllHTTPMethods()
+	paths := map[string]*po.Options{
+		"/" + strings.Join(m, "-"): {
+			Path:          "/",
+			HandlerName:   "alb",
+			Methods:       m,
+			MatchType:     matching.PathMatchTypePrefix,
+			MatchTypeName: "prefix",
+		},
+	}
+	return paths
+}
+
+func (c *Client) registerHandlers() {
+	c.handlersRegistered = true
+	c.handlers = make(map[string]http.Handler)
+	// This is the registry of handlers that Trickster supports for the ALB,
+	// and are able to be referenced by name (map key) in Config Files
+	c.handlers["alb"] = http.HandlerFunc(c.Handler)
+}
+
+// Handlers returns a map of the HTTP Handlers the client has registered
+func (c *Client) Handlers() map[string]http.Handler {
+	if !c.handlersRegistered {
+		c.registerHandlers()
+	}
+	return c.handlers
+}
+
+// HTTPClient is not used by the ALB, and is present to conform to the Client interface
+func (c *Client) HTTPClient() *http.Client {
+	return nil
+}
+
+// Cache is not used by the ALB, and is present to conform to the Client interface
+func (c *Client) Cache() cache.Cache {
+	return nil
+}
+
+// Name returns the name of the upstream Configuration proxied by the Client
+func (c *Client) Name() string {
+	return c.name
+}
+
+// SetCache is not used by the ALB, and is present to conform to the Client interface
+func (c *Client) SetCache(cc cache.Cache) {}
+
+// Router returns the http.Handler that handles request routing for this Client
+func (c *Client) Router() http.Handler {
+	return c.router
+}
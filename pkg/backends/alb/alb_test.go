@@ -0,0 +1,238 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	ao "github.com/tricksterproxy/trickster/pkg/backends/alb/options"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus"
+	"github.com/tricksterproxy/trickster/pkg/backends/reverseproxy"
+	tc "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+)
+
+// newTestMember returns a backend client whose router responds with the provided header
+// value, so tests can identify which pool member served a request
+func newTestMember(name string) backends.Client {
+	c, _ := reverseproxy.NewClient(name, oo.New(),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Test-Member", name)
+			w.WriteHeader(http.StatusOK)
+		}))
+	return c
+}
+
+// newTestTimeseriesMember returns a timeseries backend client whose router records the
+// request's merge group to the provided pointer
+func newTestTimeseriesMember(name, provider string, mg **engines.TimeseriesMergeGroup) backends.Client {
+	o := oo.New()
+	o.Provider = provider
+	c, _ := prometheus.NewClient(name, o,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if mg != nil {
+				*mg, _ = tc.BackendGroup(r.Context()).(*engines.TimeseriesMergeGroup)
+			}
+			w.Header().Set("Test-Member", name)
+			w.WriteHeader(http.StatusOK)
+		}), nil, nil)
+	return c
+}
+
+func newTestALBOptions(mechanism string, pool ...string) *oo.Options {
+	o := oo.New()
+	o.Provider = "alb"
+	o.ALBOptions = ao.New()
+	o.ALBOptions.MechanismName = mechanism
+	o.ALBOptions.Pool = pool
+	return o
+}
+
+func newTestClient(mechanism string) (*Client, error) {
+	clients := backends.Backends{
+		"test-1": newTestMember("test-1"),
+		"test-2": newTestMember("test-2"),
+		"test-3": newTestMember("test-3"),
+	}
+	c, _ := NewClient("test-alb", newTestALBOptions(mechanism, "test-1", "test-2", "test-3"),
+		nil, clients)
+	clients["test-alb"] = c
+	err := ValidatePools(clients, nil, nil)
+	return c, err
+}
+
+func TestNewClient(t *testing.T) {
+	c, err := NewClient("test", oo.New(), nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if c == nil {
+		t.Errorf("expected client named %s", "test")
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	c, _ := NewClient("test", oo.New(), nil, nil)
+	if c.HTTPClient() != nil {
+		t.Error("expected nil client")
+	}
+}
+
+func TestGetCache(t *testing.T) {
+	c, _ := NewClient("test", oo.New(), nil, nil)
+	c.SetCache(nil)
+	if c.Cache() != nil {
+		t.Errorf("expected nil Cache for client named %s", "test")
+	}
+}
+
+func TestClientName(t *testing.T) {
+	c, _ := NewClient("test", oo.New(), nil, nil)
+	if c.Name() != "test" {
+		t.Errorf("expected client named %s", "test")
+	}
+}
+
+func TestConfiguration(t *testing.T) {
+	c, _ := NewClient("test", oo.New(), nil, nil)
+	if c.Configuration() == nil {
+		t.Error("expected non-nil config")
+	}
+}
+
+func TestRouter(t *testing.T) {
+	c, _ := NewClient("test", oo.New(), nil, nil)
+	if c.Router() != nil {
+		t.Error("expected nil router")
+	}
+}
+
+func TestDefaultPathConfigs(t *testing.T) {
+	c := &Client{}
+	dpc := c.DefaultPathConfigs(nil)
+	if len(dpc) != 1 {
+		t.Errorf("expected %d got %d", 1, len(dpc))
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	c := &Client{}
+	m := c.Handlers()
+	if _, ok := m["alb"]; !ok {
+		t.Errorf("expected to find handler named: %s", "alb")
+	}
+}
+
+func TestValidatePools(t *testing.T) {
+
+	c, err := newTestClient("rr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.pool == nil || len(c.pool.members) != 3 {
+		t.Error("expected pool with 3 members")
+	}
+
+	tests := []struct {
+		name    string
+		options *oo.Options
+		errText string
+	}{
+		{"nil options", oo.New(), "nil options"},
+		{"bad mechanism", newTestALBOptions("invalid", "test-1"), "invalid mechanism"},
+		{"empty pool", newTestALBOptions("rr"), "empty pool"},
+		{"self", newTestALBOptions("rr", "test-alb"), "itself"},
+		{"missing member", newTestALBOptions("rr", "missing"), "invalid pool member"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clients := backends.Backends{"test-1": newTestMember("test-1")}
+			c, _ := NewClient("test-alb", test.options, nil, clients)
+			clients["test-alb"] = c
+			err := ValidatePools(clients, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), test.errText) {
+				t.Errorf("expected error containing %s got %v", test.errText, err)
+			}
+		})
+	}
+
+	// bad hash source
+	o := newTestALBOptions("ch", "test-1")
+	o.ALBOptions.HashSource = "invalid"
+	clients := backends.Backends{"test-1": newTestMember("test-1")}
+	c, _ = NewClient("test-alb", o, nil, clients)
+	clients["test-alb"] = c
+	err = ValidatePools(clients, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), ErrInvalidHashSource.Error()) {
+		t.Error("expected error for invalid hash source, got", err)
+	}
+
+	// timeseries merge pools require timeseries members of the same provider
+	o = newTestALBOptions("tsm", "test-1")
+	clients = backends.Backends{"test-1": newTestMember("test-1")}
+	c, _ = NewClient("test-alb", o, nil, clients)
+	clients["test-alb"] = c
+	err = ValidatePools(clients, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "does not support timeseries merging") {
+		t.Error("expected error for non-timeseries member, got", err)
+	}
+
+	o = newTestALBOptions("tsm", "test-1", "test-2")
+	clients = backends.Backends{
+		"test-1": newTestTimeseriesMember("test-1", "prometheus", nil),
+		"test-2": newTestTimeseriesMember("test-2", "influxdb", nil),
+	}
+	c, _ = NewClient("test-alb", o, nil, clients)
+	clients["test-alb"] = c
+	err = ValidatePools(clients, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "mismatched provider") {
+		t.Error("expected error for mismatched provider, got", err)
+	}
+
+	// first good response pools require members with an origin url
+	o = newTestALBOptions("fgr", "test-1")
+	clients = backends.Backends{"test-1": newTestMember("test-1")}
+	c, _ = NewClient("test-alb", o, nil, clients)
+	clients["test-alb"] = c
+	err = ValidatePools(clients, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "has no origin url") {
+		t.Error("expected error for member without origin url, got", err)
+	}
+
+	// members are registered with the health checker
+	hc := healthcheck.New()
+	defer hc.Shutdown()
+	clients = backends.Backends{"test-1": newTestMember("test-1")}
+	c, _ = NewClient("test-alb", newTestALBOptions("rr", "test-1"), nil, clients)
+	clients["test-alb"] = c
+	err = ValidatePools(clients, hc, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if hc.Status("test-1") == nil {
+		t.Error("expected pool member to be registered with the health checker")
+	}
+	if c.pool.members[0].status != hc.Status("test-1") {
+		t.Error("expected pool member status to be the health checker status")
+	}
+}
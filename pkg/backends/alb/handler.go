@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"net/http"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/handlers"
+)
+
+// Handler routes the HTTP request to a pool member selected by the ALB mechanism
+func (c *Client) Handler(w http.ResponseWriter, r *http.Request) {
+
+	// the hop count protects against request loops when a pool member routes back to the ALB
+	currentHops, maxHops := context.Hops(r.Context())
+	if currentHops >= maxHops || c.pool == nil {
+		handlers.HandleBadRequestResponse(w, r)
+		return
+	}
+
+	pm := c.pool.next(r)
+	if pm == nil {
+		handlers.HandleBadGateway(w, r)
+		return
+	}
+
+	ctx := context.WithHops(r.Context(), currentHops+1, maxHops)
+	switch c.pool.mechanism {
+	case TimeseriesMerge:
+		ctx = context.WithBackendGroup(ctx, c.mergeGroup())
+	case FirstGoodResponse:
+		ctx = context.WithBackendGroup(ctx, c.firstResponseGroup())
+	}
+
+	pm.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// cacheKeyPrefix returns the prefix used to cache responses from the ALB's backend groups
+func (c *Client) cacheKeyPrefix() string {
+	if c.options != nil && c.options.CacheKeyPrefix != "" {
+		return c.options.CacheKeyPrefix
+	}
+	return c.name
+}
+
+// mergeGroup returns a TimeseriesMergeGroup comprising the available pool members
+func (c *Client) mergeGroup() *engines.TimeseriesMergeGroup {
+	members := c.pool.available()
+	mg := &engines.TimeseriesMergeGroup{
+		CacheKeyPrefix: c.cacheKeyPrefix(),
+		Members:        make([]backends.TimeseriesClient, 0, len(members)),
+	}
+	for _, pm := range members {
+		if tc, ok := pm.client.(backends.TimeseriesClient); ok {
+			mg.Members = append(mg.Members, tc)
+		}
+	}
+	return mg
+}
+
+// firstResponseGroup returns a FirstResponseGroup comprising the available pool members
+func (c *Client) firstResponseGroup() *engines.FirstResponseGroup {
+	members := c.pool.available()
+	g := &engines.FirstResponseGroup{
+		Name:           c.name,
+		CacheKeyPrefix: c.cacheKeyPrefix(),
+		Members:        make([]backends.Client, len(members)),
+	}
+	for i, pm := range members {
+		g.Members[i] = pm.client
+	}
+	return g
+}
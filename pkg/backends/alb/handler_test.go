@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/backends/reverseproxy"
+	tc "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+)
+
+func TestHandler(t *testing.T) {
+
+	c, err := newTestClient("rr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+		c.Handler(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+		}
+		seen[w.Header().Get("Test-Member")] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected %d members to serve requests, got %d", 3, len(seen))
+	}
+
+	// all members failing should result in a bad gateway
+	for _, pm := range c.pool.members {
+		pm.status = &healthcheck.Status{}
+		pm.status.Set(healthcheck.StatusFailing, "test")
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	c.Handler(w, r)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected %d got %d", http.StatusBadGateway, w.Code)
+	}
+
+	// exceeding the max hops should result in a bad request
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	r = r.WithContext(tc.WithHops(r.Context(), 2, 2))
+	c.Handler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// an alb with no validated pool should result in a bad request
+	c = &Client{}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	c.Handler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandlerTimeseriesMerge(t *testing.T) {
+
+	var mg *engines.TimeseriesMergeGroup
+	clients := backends.Backends{
+		"test-1": newTestTimeseriesMember("test-1", "prometheus", &mg),
+		"test-2": newTestTimeseriesMember("test-2", "prometheus", &mg),
+	}
+	c, _ := NewClient("test-alb", newTestALBOptions("tsm", "test-1", "test-2"), nil, clients)
+	clients["test-alb"] = c
+	if err := ValidatePools(clients, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	c.Handler(w, r)
+	if w.Header().Get("Test-Member") != "test-1" {
+		t.Errorf("expected %s got %s", "test-1", w.Header().Get("Test-Member"))
+	}
+	if mg == nil {
+		t.Fatal("expected non-nil merge group")
+	}
+	if len(mg.Members) != 2 {
+		t.Errorf("expected %d got %d", 2, len(mg.Members))
+	}
+	if mg.CacheKeyPrefix != "test-alb" {
+		t.Errorf("expected %s got %s", "test-alb", mg.CacheKeyPrefix)
+	}
+
+	// unavailable members are excluded from the group, and the next member serves the request
+	c.pool.members[0].status = &healthcheck.Status{}
+	c.pool.members[0].status.Set(healthcheck.StatusFailing, "test")
+	c.options.CacheKeyPrefix = "test-prefix"
+	w = httptest.NewRecorder()
+	c.Handler(w, r)
+	if w.Header().Get("Test-Member") != "test-2" {
+		t.Errorf("expected %s got %s", "test-2", w.Header().Get("Test-Member"))
+	}
+	if len(mg.Members) != 1 || mg.Members[0].Name() != "test-2" {
+		t.Error("expected merge group with only test-2")
+	}
+	if mg.CacheKeyPrefix != "test-prefix" {
+		t.Errorf("expected %s got %s", "test-prefix", mg.CacheKeyPrefix)
+	}
+}
+
+func TestHandlerFirstGoodResponse(t *testing.T) {
+
+	var g *engines.FirstResponseGroup
+	newMember := func(name string) backends.Client {
+		o := oo.New()
+		o.Host = name + ".example.com"
+		c, _ := reverseproxy.NewClient(name, o,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g, _ = tc.BackendGroup(r.Context()).(*engines.FirstResponseGroup)
+				w.Header().Set("Test-Member", name)
+				w.WriteHeader(http.StatusOK)
+			}))
+		return c
+	}
+
+	clients := backends.Backends{
+		"test-1": newMember("test-1"),
+		"test-2": newMember("test-2"),
+	}
+	c, _ := NewClient("test-alb", newTestALBOptions("fgr", "test-1", "test-2"), nil, clients)
+	clients["test-alb"] = c
+	if err := ValidatePools(clients, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	c.Handler(w, r)
+	if w.Header().Get("Test-Member") != "test-1" {
+		t.Errorf("expected %s got %s", "test-1", w.Header().Get("Test-Member"))
+	}
+	if g == nil {
+		t.Fatal("expected non-nil first response group")
+	}
+	if len(g.Members) != 2 {
+		t.Errorf("expected %d got %d", 2, len(g.Members))
+	}
+	if g.Name != "test-alb" || g.CacheKeyPrefix != "test-alb" {
+		t.Errorf("expected %s got %s, %s", "test-alb", g.Name, g.CacheKeyPrefix)
+	}
+
+	// unavailable members are excluded from the group
+	c.pool.members[0].status = &healthcheck.Status{}
+	c.pool.members[0].status.Set(healthcheck.StatusFailing, "test")
+	w = httptest.NewRecorder()
+	c.Handler(w, r)
+	if w.Header().Get("Test-Member") != "test-2" {
+		t.Errorf("expected %s got %s", "test-2", w.Header().Get("Test-Member"))
+	}
+	if len(g.Members) != 1 || g.Members[0].Name() != "test-2" {
+		t.Error("expected first response group with only test-2")
+	}
+}
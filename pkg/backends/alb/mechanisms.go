@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import "strconv"
+
+// Mechanism enumerates the load balancing mechanisms supported by the ALB
+type Mechanism int
+
+const (
+	// RoundRobin distributes requests to each available pool member in turn
+	RoundRobin = Mechanism(iota)
+	// ConsistentHash distributes requests based on the hash of a request property,
+	// so that requests with the same property value are routed to the same pool member
+	ConsistentHash
+	// LeastLatency distributes requests to the available pool member with the lowest
+	// observed response latency
+	LeastLatency
+	// FewestConnections distributes requests to the available pool member with the
+	// fewest open connections
+	FewestConnections
+	// TimeseriesMerge routes timeseries requests to all available pool members and merges
+	// their responses, and routes all other requests to the first available pool member
+	TimeseriesMerge
+	// FirstGoodResponse routes requests to all available pool members and serves the
+	// first response having a 2xx status
+	FirstGoodResponse
+)
+
+// Names is a map of Mechanisms keyed by string name
+var Names = map[string]Mechanism{
+	"rr":                  RoundRobin,
+	"roundrobin":          RoundRobin,
+	"ch":                  ConsistentHash,
+	"consistent_hash":     ConsistentHash,
+	"lat":                 LeastLatency,
+	"least_latency":       LeastLatency,
+	"fc":                  FewestConnections,
+	"fewest_connections":  FewestConnections,
+	"tsm":                 TimeseriesMerge,
+	"tsmerge":             TimeseriesMerge,
+	"fgr":                 FirstGoodResponse,
+	"first_good_response": FirstGoodResponse,
+}
+
+// Values is a map of Mechanisms valued by string name
+var Values = map[Mechanism]string{
+	RoundRobin:        "rr",
+	ConsistentHash:    "ch",
+	LeastLatency:      "lat",
+	FewestConnections: "fc",
+	TimeseriesMerge:   "tsm",
+	FirstGoodResponse: "fgr",
+}
+
+func (m Mechanism) String() string {
+	if v, ok := Values[m]; ok {
+		return v
+	}
+	return strconv.Itoa(int(m))
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import "testing"
+
+func TestMechanismString(t *testing.T) {
+
+	if RoundRobin.String() != "rr" {
+		t.Errorf("expected %s got %s", "rr", RoundRobin.String())
+	}
+
+	if FewestConnections.String() != "fc" {
+		t.Errorf("expected %s got %s", "fc", FewestConnections.String())
+	}
+
+	if FirstGoodResponse.String() != "fgr" {
+		t.Errorf("expected %s got %s", "fgr", FirstGoodResponse.String())
+	}
+
+	var m Mechanism = 30
+	if m.String() != "30" {
+		t.Errorf("expected %s got %s", "30", m.String())
+	}
+}
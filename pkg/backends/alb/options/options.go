@@ -0,0 +1,62 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package options provides options for Application Load Balancer backends
+package options
+
+import "github.com/tricksterproxy/trickster/pkg/config/defaults"
+
+// Options defines the options for an Application Load Balancer
+type Options struct {
+	// MechanismName indicates the name of the load balancing mechanism. Possible options:
+	//  Mechanism   Description
+	//  rr          round robin (default)
+	//  ch          consistent hash of the request property indicated by HashSource
+	//  lat         the available pool member with the lowest observed response latency
+	//  fc          the available pool member with the fewest open connections
+	//  tsm         merges timeseries responses from all available pool members
+	//  fgr         uses the first good response from all available pool members
+	MechanismName string `toml:"mechanism"`
+	// Pool provides the list of Backend names that the ALB distributes requests across
+	Pool []string `toml:"pool"`
+	// HashSource indicates the request property hashed when MechanismName is 'ch'. Possible options:
+	// client_ip (default), url, path, host, header, param
+	HashSource string `toml:"hash_source"`
+	// HashKey provides the target header or param name when HashSource is header or param
+	HashKey string `toml:"hash_key"`
+}
+
+// New returns a new Options reference with default values set
+func New() *Options {
+	return &Options{
+		MechanismName: defaults.DefaultALBMechanismName,
+		HashSource:    defaults.DefaultALBHashSource,
+	}
+}
+
+// Clone returns a perfect copy of the subject *Options
+func (o *Options) Clone() *Options {
+	no := &Options{
+		MechanismName: o.MechanismName,
+		HashSource:    o.HashSource,
+		HashKey:       o.HashKey,
+	}
+	if o.Pool != nil {
+		no.Pool = make([]string, len(o.Pool))
+		copy(no.Pool, o.Pool)
+	}
+	return no
+}
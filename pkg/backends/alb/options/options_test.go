@@ -0,0 +1,39 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	o := New()
+	if o.MechanismName != "rr" {
+		t.Errorf("expected %s got %s", "rr", o.MechanismName)
+	}
+}
+
+func TestClone(t *testing.T) {
+	o := New()
+	o.Pool = []string{"test-1", "test-2"}
+	o2 := o.Clone()
+	if len(o2.Pool) != 2 || o2.MechanismName != o.MechanismName {
+		t.Error("clone mismatch")
+	}
+	o2.Pool[0] = "changed"
+	if o.Pool[0] != "test-1" {
+		t.Error("expected clone to copy the pool")
+	}
+}
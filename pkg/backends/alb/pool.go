@@ -0,0 +1,271 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	"github.com/tricksterproxy/trickster/pkg/util/fnv"
+)
+
+// the number of virtual nodes on the hash ring for each pool member
+const hashReplicas = 64
+
+type hashSourceFunc func(*http.Request, string) string
+
+var hashSourceFuncs = map[string]hashSourceFunc{
+	"client_ip": hashClientIP,
+	"url":       hashURL,
+	"path":      hashPath,
+	"host":      hashHost,
+	"header":    hashHeader,
+	"param":     hashParam,
+}
+
+// ErrInvalidHashSource indicates the configured hash source is not supported
+var ErrInvalidHashSource = errors.New("invalid hash source")
+
+type pool struct {
+	mechanism  Mechanism
+	members    []*poolMember
+	pos        uint64
+	ring       hashRing
+	hashSource hashSourceFunc
+	hashKey    string
+}
+
+type poolMember struct {
+	name    string
+	client  backends.Client
+	handler http.Handler
+	status  *healthcheck.Status
+	// the number of requests currently being served by the member
+	activeConns int64
+	// the exponentially-weighted moving average of response latency, in nanoseconds
+	latency int64
+}
+
+type hashRingEntry struct {
+	hash   uint64
+	member int
+}
+
+type hashRing []hashRingEntry
+
+func (r hashRing) Len() int           { return len(r) }
+func (r hashRing) Less(i, j int) bool { return r[i].hash < r[j].hash }
+func (r hashRing) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+func newPool(m Mechanism, members []*poolMember, hashSource, hashKey string) (*pool, error) {
+	p := &pool{mechanism: m, members: members, hashKey: hashKey}
+	if m == ConsistentHash {
+		f, ok := hashSourceFuncs[hashSource]
+		if !ok {
+			return nil, ErrInvalidHashSource
+		}
+		p.hashSource = f
+		p.ring = make(hashRing, 0, len(members)*hashReplicas)
+		for i, pm := range members {
+			for j := 0; j < hashReplicas; j++ {
+				p.ring = append(p.ring,
+					hashRingEntry{hash: hash(pm.name + "#" + strconv.Itoa(j)), member: i})
+			}
+		}
+		sort.Sort(p.ring)
+	}
+	return p, nil
+}
+
+// next returns the pool member that should serve the request, or nil if
+// no pool members are available
+func (p *pool) next(r *http.Request) *poolMember {
+	if len(p.members) == 0 {
+		return nil
+	}
+	switch p.mechanism {
+	case ConsistentHash:
+		return p.nextConsistentHash(r)
+	case LeastLatency:
+		return p.nextLeastLatency()
+	case FewestConnections:
+		return p.nextFewestConnections()
+	case TimeseriesMerge, FirstGoodResponse:
+		return p.nextAvailable()
+	}
+	return p.nextRoundRobin()
+}
+
+// nextAvailable returns the first available member in pool order
+func (p *pool) nextAvailable() *poolMember {
+	for _, pm := range p.members {
+		if pm.status.IsAvailable() {
+			return pm
+		}
+	}
+	return nil
+}
+
+// available returns all available members in pool order
+func (p *pool) available() []*poolMember {
+	out := make([]*poolMember, 0, len(p.members))
+	for _, pm := range p.members {
+		if pm.status.IsAvailable() {
+			out = append(out, pm)
+		}
+	}
+	return out
+}
+
+func (p *pool) nextRoundRobin() *poolMember {
+	l := uint64(len(p.members))
+	start := atomic.AddUint64(&p.pos, 1)
+	for i := uint64(0); i < l; i++ {
+		pm := p.members[(start+i)%l]
+		if pm.status.IsAvailable() {
+			return pm
+		}
+	}
+	return nil
+}
+
+func (p *pool) nextConsistentHash(r *http.Request) *poolMember {
+	h := hash(p.hashSource(r, p.hashKey))
+	l := len(p.ring)
+	i := sort.Search(l, func(i int) bool { return p.ring[i].hash >= h })
+	// walk the ring from the hashed position until an available member is found
+	for j := 0; j < l; j++ {
+		pm := p.members[p.ring[(i+j)%l].member]
+		if pm.status.IsAvailable() {
+			return pm
+		}
+	}
+	return nil
+}
+
+func (p *pool) nextLeastLatency() *poolMember {
+	var out *poolMember
+	var min int64 = -1
+	for _, pm := range p.members {
+		if !pm.status.IsAvailable() {
+			continue
+		}
+		// a member that has not yet served a request is selected so its latency can be observed
+		l := atomic.LoadInt64(&pm.latency)
+		if l == 0 {
+			return pm
+		}
+		if min == -1 || l < min {
+			min = l
+			out = pm
+		}
+	}
+	return out
+}
+
+func (p *pool) nextFewestConnections() *poolMember {
+	var out *poolMember
+	var min int64 = -1
+	for _, pm := range p.members {
+		if !pm.status.IsAvailable() {
+			continue
+		}
+		c := atomic.LoadInt64(&pm.activeConns)
+		if min == -1 || c < min {
+			min = c
+			out = pm
+		}
+	}
+	return out
+}
+
+// ServeHTTP passes the request to the member's handler, while tracking the number of open
+// connections and the response latency
+func (pm *poolMember) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&pm.activeConns, 1)
+	defer atomic.AddInt64(&pm.activeConns, -1)
+	start := time.Now()
+	pm.handler.ServeHTTP(w, r)
+	pm.observeLatency(time.Since(start))
+}
+
+// observeLatency updates the member's moving average latency with a weight of 1/8
+// for the newest observation
+func (pm *poolMember) observeLatency(d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&pm.latency)
+		nv := int64(d)
+		if old > 0 {
+			nv = old + (int64(d)-old)/8
+		}
+		if nv < 1 {
+			nv = 1
+		}
+		if atomic.CompareAndSwapInt64(&pm.latency, old, nv) {
+			return
+		}
+	}
+}
+
+func hash(s string) uint64 {
+	h := fnv.NewInlineFNV64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func hashClientIP(r *http.Request, unused string) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func hashURL(r *http.Request, unused string) string {
+	if r.URL != nil {
+		return r.URL.String()
+	}
+	return ""
+}
+
+func hashPath(r *http.Request, unused string) string {
+	if r.URL != nil {
+		return r.URL.Path
+	}
+	return ""
+}
+
+func hashHost(r *http.Request, unused string) string {
+	return r.Host
+}
+
+func hashHeader(r *http.Request, headerName string) string {
+	return r.Header.Get(headerName)
+}
+
+func hashParam(r *http.Request, paramName string) string {
+	if r.URL != nil {
+		return r.URL.Query().Get(paramName)
+	}
+	return ""
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+)
+
+func newTestPoolMembers(n int) []*poolMember {
+	members := make([]*poolMember, n)
+	for i := range members {
+		members[i] = &poolMember{
+			name:    string(rune('a' + i)),
+			handler: http.NotFoundHandler(),
+			status:  &healthcheck.Status{},
+		}
+	}
+	return members
+}
+
+func TestNextRoundRobin(t *testing.T) {
+	members := newTestPoolMembers(3)
+	p, _ := newPool(RoundRobin, members, "", "")
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+
+	seen := make(map[string]int)
+	for i := 0; i < 6; i++ {
+		seen[p.next(r).name]++
+	}
+	for _, pm := range members {
+		if seen[pm.name] != 2 {
+			t.Errorf("expected %d got %d for member %s", 2, seen[pm.name], pm.name)
+		}
+	}
+
+	members[1].status.Set(healthcheck.StatusFailing, "test")
+	for i := 0; i < 6; i++ {
+		if p.next(r) == members[1] {
+			t.Error("expected failing member to be skipped")
+		}
+	}
+
+	members[0].status.Set(healthcheck.StatusFailing, "test")
+	members[2].status.Set(healthcheck.StatusFailing, "test")
+	if p.next(r) != nil {
+		t.Error("expected nil member")
+	}
+
+	p, _ = newPool(RoundRobin, nil, "", "")
+	if p.next(r) != nil {
+		t.Error("expected nil member")
+	}
+}
+
+func TestNextConsistentHash(t *testing.T) {
+
+	_, err := newPool(ConsistentHash, newTestPoolMembers(3), "invalid", "")
+	if err != ErrInvalidHashSource {
+		t.Error("expected error for invalid hash source")
+	}
+
+	members := newTestPoolMembers(3)
+	p, err := newPool(ConsistentHash, members, "path", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.ring) != 3*hashReplicas {
+		t.Errorf("expected %d got %d", 3*hashReplicas, len(p.ring))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://0/test/path", nil)
+	pm := p.next(r)
+	for i := 0; i < 10; i++ {
+		if p.next(r) != pm {
+			t.Error("expected the same member for the same hash source value")
+		}
+	}
+
+	// when the hashed member fails, the request moves to another member
+	pm.status.Set(healthcheck.StatusFailing, "test")
+	pm2 := p.next(r)
+	if pm2 == nil || pm2 == pm {
+		t.Error("expected a different member")
+	}
+	pm.status.Set(healthcheck.StatusPassing, "")
+	if p.next(r) != pm {
+		t.Error("expected the original member after recovery")
+	}
+
+	for _, pm := range members {
+		pm.status.Set(healthcheck.StatusFailing, "test")
+	}
+	if p.next(r) != nil {
+		t.Error("expected nil member")
+	}
+}
+
+func TestNextLeastLatency(t *testing.T) {
+	members := newTestPoolMembers(3)
+	p, _ := newPool(LeastLatency, members, "", "")
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+
+	// unmeasured members are selected first
+	if p.next(r) != members[0] {
+		t.Error("expected first member")
+	}
+
+	members[0].observeLatency(30 * time.Millisecond)
+	members[1].observeLatency(10 * time.Millisecond)
+	members[2].observeLatency(20 * time.Millisecond)
+	if p.next(r) != members[1] {
+		t.Error("expected lowest latency member")
+	}
+
+	members[1].status.Set(healthcheck.StatusFailing, "test")
+	if p.next(r) != members[2] {
+		t.Error("expected lowest latency available member")
+	}
+}
+
+func TestNextFewestConnections(t *testing.T) {
+	members := newTestPoolMembers(3)
+	p, _ := newPool(FewestConnections, members, "", "")
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+
+	members[0].activeConns = 3
+	members[1].activeConns = 1
+	members[2].activeConns = 2
+	if p.next(r) != members[1] {
+		t.Error("expected member with fewest connections")
+	}
+
+	members[1].status.Set(healthcheck.StatusFailing, "test")
+	if p.next(r) != members[2] {
+		t.Error("expected available member with fewest connections")
+	}
+}
+
+func TestNextAvailable(t *testing.T) {
+	members := newTestPoolMembers(3)
+	p, _ := newPool(TimeseriesMerge, members, "", "")
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+
+	if p.next(r) != members[0] {
+		t.Error("expected first member")
+	}
+	if len(p.available()) != 3 {
+		t.Errorf("expected %d got %d", 3, len(p.available()))
+	}
+
+	members[0].status.Set(healthcheck.StatusFailing, "test")
+	if p.next(r) != members[1] {
+		t.Error("expected second member")
+	}
+	if len(p.available()) != 2 {
+		t.Errorf("expected %d got %d", 2, len(p.available()))
+	}
+
+	p, _ = newPool(FirstGoodResponse, members, "", "")
+	if p.next(r) != members[1] {
+		t.Error("expected second member")
+	}
+}
+
+func TestPoolMemberServeHTTP(t *testing.T) {
+	pm := &poolMember{handler: http.NotFoundHandler()}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	pm.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d got %d", http.StatusNotFound, w.Code)
+	}
+	if pm.activeConns != 0 {
+		t.Errorf("expected %d got %d", 0, pm.activeConns)
+	}
+	if pm.latency == 0 {
+		t.Error("expected non-zero latency")
+	}
+}
+
+func TestPoolMemberServeHTTPPanic(t *testing.T) {
+	// a panicking handler, such as one aborted with http.ErrAbortHandler,
+	// must not leave the member's connection count elevated
+	pm := &poolMember{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		pm.ServeHTTP(w, r)
+	}()
+	if pm.activeConns != 0 {
+		t.Errorf("expected %d got %d", 0, pm.activeConns)
+	}
+}
+
+func TestObserveLatency(t *testing.T) {
+	pm := &poolMember{}
+	pm.observeLatency(80 * time.Millisecond)
+	if pm.latency != int64(80*time.Millisecond) {
+		t.Errorf("expected %d got %d", int64(80*time.Millisecond), pm.latency)
+	}
+	pm.observeLatency(0)
+	if pm.latency != int64(70*time.Millisecond) {
+		t.Errorf("expected %d got %d", int64(70*time.Millisecond), pm.latency)
+	}
+}
+
+func TestHashSources(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/path?param1=value1", nil)
+	r.RemoteAddr = "127.0.0.1:8080"
+	r.Header.Set("Test-Header", "header-value")
+
+	tests := []struct {
+		source, key, expected string
+	}{
+		{"client_ip", "", "127.0.0.1"},
+		{"url", "", "http://example.com/path?param1=value1"},
+		{"path", "", "/path"},
+		{"host", "", "example.com"},
+		{"header", "Test-Header", "header-value"},
+		{"param", "param1", "value1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			v := hashSourceFuncs[test.source](r, test.key)
+			if v != test.expected {
+				t.Errorf("expected %s got %s", test.expected, v)
+			}
+		})
+	}
+
+	r.RemoteAddr = "invalid"
+	if v := hashClientIP(r, ""); v != "invalid" {
+		t.Errorf("expected %s got %s", "invalid", v)
+	}
+}
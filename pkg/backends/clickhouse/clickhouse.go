@@ -111,6 +111,9 @@ func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuer
 
 	trq, ro, canOPC, err := parse(sqlQuery)
 	if err != nil {
+		if canOPC {
+			return objectQuery(r, sqlQuery), nil, canOPC, err
+		}
 		return nil, nil, canOPC, err
 	}
 
@@ -140,3 +143,14 @@ func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuer
 
 	return trq, ro, canOPC, nil
 }
+
+// objectQuery returns a TimeRangeQuery for a read-only statement that is not a time range
+// query, whose TemplateURL carries the normalized statement for use in cache key derivation
+func objectQuery(r *http.Request, statement string) *timeseries.TimeRangeQuery {
+	trq := &timeseries.TimeRangeQuery{Statement: normalizeStatement(statement),
+		TemplateURL: urls.Clone(r.URL)}
+	qi := trq.TemplateURL.Query()
+	qi.Set(upQuery, trq.Statement)
+	trq.TemplateURL.RawQuery = qi.Encode()
+	return trq
+}
@@ -19,7 +19,6 @@ package clickhouse
 import (
 	"context"
 	"net/http"
-	"net/url"
 
 	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
 	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
@@ -57,17 +56,7 @@ func (c *Client) HealthHandler(w http.ResponseWriter, r *http.Request) {
 func (c *Client) populateHeathCheckRequestValues() {
 
 	oc := c.config
-
-	if oc.HealthCheckUpstreamPath == "-" {
-		oc.HealthCheckUpstreamPath = "/"
-	}
-	if oc.HealthCheckVerb == "-" {
-		oc.HealthCheckVerb = http.MethodGet
-	}
-	if oc.HealthCheckQuery == "-" {
-		q := url.Values{"query": {healthQuery}}
-		oc.HealthCheckQuery = q.Encode()
-	}
+	populateHeathCheckRequestValues(oc)
 
 	c.healthURL = urls.Clone(c.baseUpstreamURL)
 	c.healthURL.Path += oc.HealthCheckUpstreamPath
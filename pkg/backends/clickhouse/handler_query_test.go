@@ -21,8 +21,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/methods"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
 )
@@ -105,3 +109,87 @@ func TestQueryHandler(t *testing.T) {
 	}
 
 }
+
+func TestQueryHandlerNonTimeseries(t *testing.T) {
+
+	// the origin echoes the query it received, so the test can verify the upstream
+	// request was not rewritten by normalization
+	var upstreamHits int
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits++
+		q := r.URL.Query().Get(upQuery)
+		if methods.HasBody(r.Method) {
+			b, _ := ioutil.ReadAll(r.Body)
+			q = string(b)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(q))
+	}))
+	defer es.Close()
+
+	client := &Client{name: "test"}
+	ts, _, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs,
+		200, "{}", nil, "clickhouse", "/", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(es.URL)
+
+	tests := []struct {
+		method, query, expectedStatus string
+		expectedHits                  int
+	}{
+		{http.MethodGet, "SELECT name FROM system.tables", "kmiss", 1},
+		// whitespace, comments and keyword case differences share the same cache key
+		{http.MethodGet, "select  name\n FROM system.tables -- tables", "hit", 1},
+		{http.MethodPost, "SELECT database FROM system.tables", "kmiss", 2},
+		{http.MethodPost, "/* dbs */ SELECT database\tFROM system.tables", "hit", 2},
+		// non-SELECT statements are never cached
+		{http.MethodPost, "INSERT INTO t SELECT name FROM system.tables", "proxy-only", 3},
+		{http.MethodPost, "INSERT INTO t SELECT name FROM system.tables", "proxy-only", 4},
+	}
+
+	for i, test := range tests {
+		var r2 *http.Request
+		if test.method == http.MethodGet {
+			r2 = httptest.NewRequest(test.method, "http://0/?"+
+				url.Values{upQuery: {test.query}}.Encode(), nil)
+		} else {
+			r2 = httptest.NewRequest(test.method, "http://0/", strings.NewReader(test.query))
+		}
+		r2 = r2.WithContext(r.Context())
+		w := httptest.NewRecorder()
+		client.QueryHandler(w, r2)
+		resp := w.Result()
+		if h := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(h, "status="+test.expectedStatus) {
+			t.Errorf("%d: expected status %s got %s", i, test.expectedStatus, h)
+		}
+		if upstreamHits != test.expectedHits {
+			t.Errorf("%d: expected %d upstream hits got %d", i, test.expectedHits, upstreamHits)
+		}
+		b, _ := ioutil.ReadAll(resp.Body)
+		if test.expectedStatus != "hit" && string(b) != test.query {
+			t.Errorf("%d: expected upstream query %s got %s", i, test.query, string(b))
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// a path-level TTL of 0 disables object caching of non-timeseries queries
+	rsc.PathConfig.NonTimeseriesTTL = 0
+	rsc.PathConfig.HasNonTimeseriesTTL = true
+	defer func() { rsc.PathConfig.HasNonTimeseriesTTL = false }()
+	r2 := httptest.NewRequest(http.MethodGet, "http://0/?"+
+		url.Values{upQuery: {"SELECT 1"}}.Encode(), nil).WithContext(r.Context())
+	w := httptest.NewRecorder()
+	client.QueryHandler(w, r2)
+	if h := w.Result().Header.Get(headers.NameTricksterResult); !strings.Contains(h, "status=proxy-only") {
+		t.Errorf("expected status proxy-only got %s", h)
+	}
+
+}
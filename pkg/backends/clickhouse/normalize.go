@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"strings"
+
+	lsql "github.com/tricksterproxy/trickster/pkg/parsing/lex/sql"
+	"github.com/tricksterproxy/trickster/pkg/parsing/token"
+)
+
+// normalizeStatement returns the statement with comments removed, runs of whitespace
+// collapsed to a single space, and keywords lowercased, so that semantically identical
+// statements share a cache key. Identifiers and literals retain their original case.
+// If the statement cannot be lexed, it is returned unmodified
+func normalizeStatement(statement string) string {
+
+	ch := make(chan *token.Token, 64)
+	go lexer.Run(statement, ch)
+
+	tokens := make(token.Tokens, 0, 64)
+	for t := range ch {
+		tokens = append(tokens, t)
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(statement))
+	var needSpace bool
+	for i, t := range tokens {
+		switch {
+		case t.Typ.IsErr():
+			return statement
+		case t.Typ.IsEOF():
+			continue
+		case t.Typ == token.Space || t.Typ == lsql.TokenComment:
+			needSpace = sb.Len() > 0
+			continue
+		}
+		if needSpace {
+			sb.WriteByte(' ')
+			needSpace = false
+		}
+		if lsql.IsKeyword(t.Typ) || token.IsLogicalOperator(t.Typ) {
+			sb.WriteString(t.Val)
+			continue
+		}
+		end := len(statement)
+		if i+1 < len(tokens) {
+			end = tokens[i+1].Pos
+		}
+		sb.WriteString(statement[t.Pos:end])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clickhouse
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNormalizeStatement(t *testing.T) {
+
+	tests := []struct {
+		in, expected string
+	}{
+		{
+			"SELECT  a,\n\tb FROM t WHERE x = 'Some  Value' -- trailing comment",
+			"select a, b from t where x = 'Some  Value'",
+		},
+		{
+			"/* leading */ select a, b from t where x = 'Some  Value'",
+			"select a, b from t where x = 'Some  Value'",
+		},
+		{
+			"select MyCol from MyTable GROUP BY MyCol ORDER BY MyCol // eol\n",
+			"select MyCol from MyTable group by MyCol order by MyCol",
+		},
+		{
+			"select 'unterminated",
+			"select 'unterminated",
+		},
+		{
+			"",
+			"",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := normalizeStatement(test.in)
+			if out != test.expected {
+				t.Errorf("expected %q got %q", test.expected, out)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package clickhouse
 
 import (
 	"net/http"
+	"net/url"
 
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
@@ -42,8 +43,24 @@ func (c *Client) Handlers() map[string]http.Handler {
 	return c.handlers
 }
 
+func populateHeathCheckRequestValues(oc *oo.Options) {
+	if oc.HealthCheckUpstreamPath == "-" {
+		oc.HealthCheckUpstreamPath = "/"
+	}
+	if oc.HealthCheckVerb == "-" {
+		oc.HealthCheckVerb = http.MethodGet
+	}
+	if oc.HealthCheckQuery == "-" {
+		q := url.Values{"query": {healthQuery}}
+		oc.HealthCheckQuery = q.Encode()
+	}
+}
+
 // DefaultPathConfigs returns the default PathConfigs for the given Provider
 func (c *Client) DefaultPathConfigs(oc *oo.Options) map[string]*po.Options {
+
+	populateHeathCheckRequestValues(oc)
+
 	paths := map[string]*po.Options{
 		"/": {
 			Path:           "/",
@@ -17,8 +17,10 @@
 package clickhouse
 
 import (
+	"net/http"
 	"testing"
 
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
 )
@@ -61,3 +63,19 @@ func TestDefaultPathConfigs(t *testing.T) {
 	}
 
 }
+
+func TestDefaultPathConfigsHealthCheck(t *testing.T) {
+	oc := oo.New()
+	c := &Client{}
+	c.DefaultPathConfigs(oc)
+	if oc.HealthCheckUpstreamPath != "/" {
+		t.Errorf("expected %s got %s", "/", oc.HealthCheckUpstreamPath)
+	}
+	if oc.HealthCheckVerb != http.MethodGet {
+		t.Errorf("expected %s got %s", http.MethodGet, oc.HealthCheckVerb)
+	}
+	const expected = "query=SELECT+1+FORMAT+JSON"
+	if oc.HealthCheckQuery != expected {
+		t.Errorf("expected %s got %s", expected, oc.HealthCheckQuery)
+	}
+}
@@ -0,0 +1,26 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import "errors"
+
+// ErrUnsupportedFormat indicates the render request's output format is not supported
+// by the Delta Proxy Cache
+var ErrUnsupportedFormat = errors.New("unsupported render format")
+
+// ErrInvalidTime indicates a from or until value could not be parsed
+var ErrInvalidTime = errors.New("invalid time value")
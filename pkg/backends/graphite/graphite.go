@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package graphite provides the Graphite Backend provider
+package graphite
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/proxy"
+	"github.com/tricksterproxy/trickster/pkg/proxy/errors"
+	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+var _ backends.Client = (*Client)(nil)
+
+// Graphite API
+const (
+	mnRender = "render"
+	mnFind   = "metrics/find"
+)
+
+// Common URL Parameter Names
+const (
+	upTarget        = "target"
+	upFrom          = "from"
+	upUntil         = "until"
+	upFormat        = "format"
+	upTZ            = "tz"
+	upMaxDataPoints = "maxDataPoints"
+	upNoNullPoints  = "noNullPoints"
+	upJSONP         = "jsonp"
+	upQuery         = "query"
+	upWildcards     = "wildcards"
+)
+
+// formatJSON is the only render output format that is supported by the Delta Proxy Cache
+const formatJSON = "json"
+
+// defaultFrom is the default value of the from parameter used by Graphite
+const defaultFrom = "-24h"
+
+// Client Implements Proxy Client Interface
+type Client struct {
+	name               string
+	config             *oo.Options
+	cache              cache.Cache
+	webClient          *http.Client
+	handlers           map[string]http.Handler
+	handlersRegistered bool
+	baseUpstreamURL    *url.URL
+	healthURL          *url.URL
+	healthHeaders      http.Header
+	healthMethod       string
+	router             http.Handler
+	modeler            *timeseries.Modeler
+}
+
+// NewClient returns a new Client Instance
+func NewClient(name string, oc *oo.Options, router http.Handler,
+	cache cache.Cache, modeler *timeseries.Modeler) (backends.Client, error) {
+	c, err := proxy.NewHTTPClient(oc)
+	bur := urls.FromParts(oc.Scheme, oc.Host, oc.PathPrefix, "", "")
+	// explicitly disable Fast Forward for this client, since Graphite does not
+	// provide datapoints at a finer resolution than the step
+	oc.FastForwardDisable = true
+	return &Client{name: name, config: oc, router: router, cache: cache,
+		webClient: c, baseUpstreamURL: bur, modeler: modeler}, err
+}
+
+// SetCache sets the Cache object the client will use for caching origin content
+func (c *Client) SetCache(cc cache.Cache) {
+	c.cache = cc
+}
+
+// Configuration returns the upstream Configuration for this Client
+func (c *Client) Configuration() *oo.Options {
+	return c.config
+}
+
+// HTTPClient returns the HTTP Client for this Backend
+func (c *Client) HTTPClient() *http.Client {
+	return c.webClient
+}
+
+// Name returns the name of the upstream Configuration proxied by the Client
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Cache returns and handle to the Cache instance used by the Client
+func (c *Client) Cache() cache.Cache {
+	return c.cache
+}
+
+// Router returns the http.Handler that handles request routing for this Client
+func (c *Client) Router() http.Handler {
+	return c.router
+}
+
+// ParseTimeRangeQuery parses the key parts of a TimeRangeQuery from the inbound HTTP Request
+func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
+	rlo := &timeseries.RequestOptions{}
+
+	v, _, _ := params.GetRequestValues(r)
+
+	targets := v[upTarget]
+	if len(targets) == 0 {
+		return nil, nil, false, errors.MissingURLParam(upTarget)
+	}
+
+	// only JSON output can be modeled as a timeseries; other formats are just proxied
+	if v.Get(upFormat) != formatJSON || v.Get(upJSONP) != "" {
+		return nil, nil, false, ErrUnsupportedFormat
+	}
+
+	loc, err := parseTimeZone(v.Get(upTZ))
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	now := time.Now()
+	from := v.Get(upFrom)
+	if from == "" {
+		from = defaultFrom
+	}
+	if trq.Extent.Start, err = parseTime(from, now, loc); err != nil {
+		return nil, nil, false, err
+	}
+	if trq.Extent.End, err = parseTime(v.Get(upUntil), now, loc); err != nil {
+		return nil, nil, false, err
+	}
+	if !trq.Extent.End.After(trq.Extent.Start) {
+		return nil, nil, false, timeseries.ErrInvalidExtent
+	}
+
+	trq.Step = renderStep(r)
+
+	// the statement is the sorted list of targets, so that requests for the same
+	// targets in a different order share the same series
+	sorted := make([]string, len(targets))
+	copy(sorted, targets)
+	sort.Strings(sorted)
+	trq.Statement = strings.Join(sorted, "&")
+
+	// the TemplateURL will always have URL Query Params, even for POSTs, and
+	// excludes the time range parameters, since they are set by SetExtent
+	trq.TemplateURL = urls.Clone(r.URL)
+	qt := url.Values(http.Header(v).Clone())
+	qt.Del(upFrom)
+	qt.Del(upUntil)
+	qt.Del(upTZ)
+	qt.Del(upMaxDataPoints)
+	trq.TemplateURL.RawQuery = qt.Encode()
+
+	return trq, rlo, false, nil
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/graphite/model"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	cr "github.com/tricksterproxy/trickster/pkg/cache/registration"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+var testModeler = model.NewModeler()
+
+func TestGraphiteClientInterfacing(t *testing.T) {
+
+	// this test ensures the client will properly conform to the
+	// Client and TimeseriesClient interfaces
+
+	c := &Client{name: "test"}
+	var oc backends.Client = c
+	var tc backends.TimeseriesClient = c
+
+	if oc.Name() != "test" {
+		t.Errorf("expected %s got %s", "test", oc.Name())
+	}
+
+	if tc.Name() != "test" {
+		t.Errorf("expected %s got %s", "test", tc.Name())
+	}
+}
+
+func TestNewClient(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test", []string{"-origin-url", "http://1", "-provider", "test"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := cr.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer cr.CloseCaches(caches)
+	cache, ok := caches["default"]
+	if !ok {
+		t.Errorf("Could not find default configuration")
+	}
+
+	oc := &oo.Options{Provider: "TEST_CLIENT"}
+	c, err := NewClient("default", oc, nil, cache, testModeler)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if c.Name() != "default" {
+		t.Errorf("expected %s got %s", "default", c.Name())
+	}
+
+	if c.Cache().Configuration().Provider != "memory" {
+		t.Errorf("expected %s got %s", "memory", c.Cache().Configuration().Provider)
+	}
+
+	if c.Configuration().Provider != "TEST_CLIENT" {
+		t.Errorf("expected %s got %s", "TEST_CLIENT", c.Configuration().Provider)
+	}
+
+	if !oc.FastForwardDisable {
+		t.Error("expected fast forward to be disabled")
+	}
+}
+
+func TestClientAccessors(t *testing.T) {
+
+	c := &Client{name: "test", webClient: &http.Client{}}
+	c.SetCache(nil)
+	if c.Cache() != nil {
+		t.Error("expected nil cache")
+	}
+	if c.HTTPClient() == nil {
+		t.Error("expected non-nil http client")
+	}
+	if c.Router() != nil {
+		t.Error("expected nil router")
+	}
+}
+
+func TestParseTimeRangeQuery(t *testing.T) {
+
+	client := &Client{name: "test"}
+
+	r := httptest.NewRequest(http.MethodGet, "http://0/render?"+url.Values{
+		upTarget:        {"b.*", "a.*"},
+		upFrom:          {"1577836800"},
+		upUntil:         {"1577840400"},
+		upFormat:        {formatJSON},
+		upMaxDataPoints: {"100"},
+	}.Encode(), nil)
+
+	trq, _, canOPC, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canOPC {
+		t.Error("expected false")
+	}
+	if trq.Statement != "a.*&b.*" {
+		t.Errorf("expected %s got %s", "a.*&b.*", trq.Statement)
+	}
+	if trq.Step != defaultRenderStep {
+		t.Errorf("expected %s got %s", defaultRenderStep, trq.Step)
+	}
+	if trq.Extent.Start.Unix() != 1577836800 || trq.Extent.End.Unix() != 1577840400 {
+		t.Errorf("unexpected extent %s", trq.Extent.String())
+	}
+	qt := trq.TemplateURL.Query()
+	if qt.Get(upFrom) != "" || qt.Get(upUntil) != "" || qt.Get(upMaxDataPoints) != "" {
+		t.Errorf("unexpected template url query %s", trq.TemplateURL.RawQuery)
+	}
+	if len(qt[upTarget]) != 2 {
+		t.Errorf("expected %d got %d", 2, len(qt[upTarget]))
+	}
+
+	// POST with relative times and the default until
+	body := url.Values{upTarget: {"a.*"}, upFrom: {"-1h"}, upFormat: {formatJSON}}.Encode()
+	r = httptest.NewRequest(http.MethodPost, "http://0/render", strings.NewReader(body))
+	r.Header.Set(headers.NameContentType, headers.ValueXFormURLEncoded)
+	trq, _, _, err = client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := trq.Extent.End.Sub(trq.Extent.Start); d != time.Hour {
+		t.Errorf("expected %s got %s", time.Hour, d)
+	}
+	if time.Since(trq.Extent.End) > time.Minute {
+		t.Errorf("expected until to be now, got %s", trq.Extent.End)
+	}
+	if trq.TemplateURL.Query().Get(upTarget) != "a.*" {
+		t.Errorf("expected %s got %s", "a.*", trq.TemplateURL.Query().Get(upTarget))
+	}
+}
+
+func TestParseTimeRangeQueryErrors(t *testing.T) {
+
+	client := &Client{name: "test"}
+
+	tests := []struct {
+		v   url.Values
+		err error
+	}{
+		{url.Values{upFormat: {formatJSON}}, nil},
+		{url.Values{upTarget: {"a"}}, ErrUnsupportedFormat},
+		{url.Values{upTarget: {"a"}, upFormat: {"png"}}, ErrUnsupportedFormat},
+		{url.Values{upTarget: {"a"}, upFormat: {formatJSON}, upJSONP: {"cb"}}, ErrUnsupportedFormat},
+		{url.Values{upTarget: {"a"}, upFormat: {formatJSON}, upFrom: {"invalid"}}, ErrInvalidTime},
+		{url.Values{upTarget: {"a"}, upFormat: {formatJSON}, upUntil: {"-1x"}}, ErrInvalidTime},
+		{url.Values{upTarget: {"a"}, upFormat: {formatJSON}, upFrom: {"-1h"}, upUntil: {"-2h"}},
+			timeseries.ErrInvalidExtent},
+		{url.Values{upTarget: {"a"}, upFormat: {formatJSON}, upTZ: {"Invalid/Zone"}}, nil},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://0/render?"+test.v.Encode(), nil)
+		_, _, canOPC, err := client.ParseTimeRangeQuery(r)
+		if err == nil {
+			t.Errorf("test %d: expected error", i)
+			continue
+		}
+		if test.err != nil && err != test.err {
+			t.Errorf("test %d: expected %s got %s", i, test.err, err)
+		}
+		if canOPC {
+			t.Errorf("test %d: expected false", i)
+		}
+	}
+}
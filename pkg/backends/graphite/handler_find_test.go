@@ -0,0 +1,79 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+func TestFindHandler(t *testing.T) {
+
+	var upstreamQuery url.Values
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamQuery = r.URL.Query()
+		w.Write([]byte("[]"))
+	}))
+	defer es.Close()
+
+	client := &Client{name: "test"}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs,
+		200, "", nil, "graphite", "/metrics/find?query=a.*&from=1577836830&until=-1h", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(es.URL)
+
+	client.FindHandler(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", resp.StatusCode)
+	}
+
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if string(bodyBytes) != "[]" {
+		t.Errorf("expected '[]' got %s.", bodyBytes)
+	}
+
+	if upstreamQuery.Get(upFrom) != "1577836800" {
+		t.Errorf("expected %s got %s", "1577836800", upstreamQuery.Get(upFrom))
+	}
+
+	if upstreamQuery.Get(upUntil) == "-1h" {
+		t.Errorf("expected normalized until got %s", upstreamQuery.Get(upUntil))
+	}
+
+	if upstreamQuery.Get(upQuery) != "a.*" {
+		t.Errorf("expected %s got %s", "a.*", upstreamQuery.Get(upQuery))
+	}
+}
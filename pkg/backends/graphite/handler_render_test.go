@@ -0,0 +1,176 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/graphite/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+// newTestGraphite returns a server that emulates the Graphite render API, returning a
+// datapoint each minute in the requested range, and the list of requests it received
+func newTestGraphite() (*httptest.Server, *[]url.Values) {
+	reqs := make([]url.Values, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		reqs = append(reqs, r.Form)
+		from, _ := strconv.ParseInt(r.Form.Get(upFrom), 10, 64)
+		until, _ := strconv.ParseInt(r.Form.Get(upUntil), 10, 64)
+		sb := strings.Builder{}
+		sep := ""
+		for t := (from/60 + 1) * 60; t <= until; t += 60 {
+			v := "null"
+			if t%300 != 0 {
+				v = strconv.FormatInt(t%7, 10)
+			}
+			sb.WriteString(fmt.Sprintf("%s[%s,%d]", sep, v, t))
+			sep = ","
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"target":"a","tags":{"name":"a"},"datapoints":[%s]}]`, sb.String())
+	}))
+	return ts, &reqs
+}
+
+func renderQuery(from, until time.Time) string {
+	return url.Values{upTarget: {"a"}, upFormat: {formatJSON}, upMaxDataPoints: {"10"},
+		upFrom:  {strconv.FormatInt(from.Unix(), 10)},
+		upUntil: {strconv.FormatInt(until.Unix(), 10)}}.Encode()
+}
+
+func TestRenderHandler(t *testing.T) {
+
+	start := time.Now().Truncate(time.Minute).Add(-time.Hour)
+
+	client := &Client{name: "test", modeler: model.NewModeler()}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs,
+		200, "", nil, "graphite", "/render?"+renderQuery(start, start.Add(10*time.Minute)), "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	gs, reqs := newTestGraphite()
+	defer gs.Close()
+
+	ctx := r.Context()
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(gs.URL)
+
+	client.RenderHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 got %d.", resp.StatusCode)
+	}
+
+	var wfd []model.WFSeries
+	if err := json.NewDecoder(resp.Body).Decode(&wfd); err != nil {
+		t.Fatal(err)
+	}
+	if len(wfd) != 1 || len(wfd[0].Datapoints) != 11 {
+		t.Fatalf("expected 1 series with %d datapoints got %v", 11, wfd)
+	}
+	if len(*reqs) != 1 {
+		t.Fatalf("expected %d upstream requests got %d", 1, len(*reqs))
+	}
+	if (*reqs)[0].Get(upMaxDataPoints) != "" {
+		t.Error("expected maxDataPoints to be removed from the upstream request")
+	}
+	if v := (*reqs)[0].Get(upFrom); v != strconv.FormatInt(start.Unix()-1, 10) {
+		t.Errorf("expected %d got %s", start.Unix()-1, v)
+	}
+
+	// extending the range should only request the missing datapoints
+	r = httptest.NewRequest(http.MethodGet, "http://0/render?"+
+		renderQuery(start, start.Add(15*time.Minute)), nil).WithContext(ctx)
+	w = httptest.NewRecorder()
+	client.RenderHandler(w, r)
+	resp = w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 got %d.", resp.StatusCode)
+	}
+	wfd = nil
+	if err := json.NewDecoder(resp.Body).Decode(&wfd); err != nil {
+		t.Fatal(err)
+	}
+	if len(wfd) != 1 || len(wfd[0].Datapoints) != 16 {
+		t.Fatalf("expected 1 series with %d datapoints got %v", 16, wfd)
+	}
+	if len(*reqs) != 2 {
+		t.Fatalf("expected %d upstream requests got %d", 2, len(*reqs))
+	}
+	from, _ := strconv.ParseInt((*reqs)[1].Get(upFrom), 10, 64)
+	if from < start.Add(10*time.Minute).Unix()-1 {
+		t.Errorf("expected delta request to start after %d got %d",
+			start.Add(10*time.Minute).Unix()-1, from)
+	}
+
+	// null datapoints are preserved
+	var nulls, expected int
+	for _, dp := range wfd[0].Datapoints {
+		if dp[0] == nil {
+			nulls++
+		}
+		if int64(*dp[1])%300 == 0 {
+			expected++
+		}
+	}
+	if nulls != expected {
+		t.Errorf("expected %d null datapoints got %d", expected, nulls)
+	}
+}
+
+func TestRenderHandlerUnsupportedFormat(t *testing.T) {
+
+	client := &Client{name: "test", modeler: model.NewModeler()}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs,
+		200, "png", nil, "graphite", "/render?target=a&format=png", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(ts.URL)
+
+	client.RenderHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", resp.StatusCode)
+	}
+	if w.Body.String() != "png" {
+		t.Errorf("expected %s got %s", "png", w.Body.String())
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package model provides the Graphite render API's wire format modeling
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
+)
+
+// WFSeries is a series in the Wire Format Document returned by the render API
+// when format=json. The Wire Format Document is a list of WFSeries
+type WFSeries struct {
+	Target     string        `json:"target"`
+	Tags       dataset.Tags  `json:"tags,omitempty"`
+	Datapoints [][2]*float64 `json:"datapoints"`
+}
+
+// pointSize is the memory utilization of a Point: 8 bytes for the epoch, 8 bytes for
+// the size, 16 bytes for the value's interface header and 8 bytes for the float64 value
+const pointSize = 40
+
+// NewModeler returns a collection of modeling functions for graphite interoperability
+func NewModeler() *timeseries.Modeler {
+	return &timeseries.Modeler{
+		WireUnmarshalerReader: UnmarshalTimeseriesReader,
+		WireMarshaler:         MarshalTimeseries,
+		WireMarshalWriter:     MarshalTimeseriesWriter,
+		WireUnmarshaler:       UnmarshalTimeseries,
+		CacheMarshaler:        dataset.MarshalDataSet,
+		CacheUnmarshaler:      dataset.UnmarshalDataSet,
+	}
+}
+
+// UnmarshalTimeseries converts a JSON blob into a Timeseries
+func UnmarshalTimeseries(data []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	buf := bytes.NewReader(data)
+	return UnmarshalTimeseriesReader(buf, trq)
+}
+
+// UnmarshalTimeseriesReader converts a JSON blob into a Timeseries via io.Reader
+func UnmarshalTimeseriesReader(reader io.Reader, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if trq == nil {
+		return nil, timeseries.ErrNoTimerangeQuery
+	}
+	var wfd []WFSeries
+	d := json.NewDecoder(reader)
+	err := d.Decode(&wfd)
+	if err != nil {
+		return nil, err
+	}
+	ds := &dataset.DataSet{
+		Results:        []*dataset.Result{{}},
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+	}
+	ds.Results[0].SeriesList = make([]*dataset.Series, len(wfd))
+
+	for i, ws := range wfd {
+		sh := dataset.SeriesHeader{
+			Name:           ws.Target,
+			Tags:           ws.Tags,
+			QueryStatement: trq.Statement,
+			FieldsList: []timeseries.FieldDefinition{
+				{Name: "value", DataType: timeseries.Float64},
+			},
+		}
+		pts := make(dataset.Points, 0, len(ws.Datapoints))
+		for _, dp := range ws.Datapoints {
+			if dp[1] == nil {
+				return nil, timeseries.ErrInvalidBody
+			}
+			pt := dataset.Point{
+				Epoch:  epoch.Epoch(int64(*dp[1]) * 1000000000),
+				Size:   pointSize,
+				Values: []interface{}{nil},
+			}
+			if dp[0] != nil {
+				pt.Values[0] = *dp[0]
+			}
+			pts = append(pts, pt)
+		}
+		sh.CalculateSize()
+		ds.Results[0].SeriesList[i] = &dataset.Series{
+			Header:    sh,
+			Points:    pts,
+			PointSize: int64(16 + pointSize*len(pts)),
+		}
+	}
+	return ds, nil
+}
+
+// MarshalTimeseries converts a Timeseries into a JSON blob
+func MarshalTimeseries(ts timeseries.Timeseries, rlo *timeseries.RequestOptions, status int) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := MarshalTimeseriesWriter(ts, rlo, status, buf)
+	return buf.Bytes(), err
+}
+
+// MarshalTimeseriesWriter converts a Timeseries into a JSON blob via an io.Writer
+func MarshalTimeseriesWriter(ts timeseries.Timeseries, rlo *timeseries.RequestOptions, status int, w io.Writer) error {
+
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok {
+		return timeseries.ErrUnknownFormat
+	}
+	// With Graphite we presume only one Result per Dataset
+	if len(ds.Results) != 1 {
+		return timeseries.ErrUnknownFormat
+	}
+	if rw, ok := w.(http.ResponseWriter); ok {
+		h := rw.Header()
+		h.Set(headers.NameContentType, headers.ValueApplicationJSON)
+		rw.WriteHeader(status)
+	}
+
+	w.Write([]byte("["))
+	seriesSep := ""
+	for _, s := range ds.Results[0].SeriesList {
+		if s == nil {
+			continue
+		}
+		target, _ := json.Marshal(s.Header.Name)
+		w.Write([]byte(seriesSep + `{"target":`))
+		w.Write(target)
+		if len(s.Header.Tags) > 0 {
+			tags, _ := json.Marshal(s.Header.Tags)
+			w.Write([]byte(`,"tags":`))
+			w.Write(tags)
+		}
+		w.Write([]byte(`,"datapoints":[`))
+		sep := ""
+		sort.Sort(s.Points)
+		for _, p := range s.Points {
+			w.Write([]byte(sep + "[" + formatValue(p.Values) + "," +
+				strconv.FormatInt(int64(p.Epoch)/1000000000, 10) + "]"))
+			sep = ","
+		}
+		w.Write([]byte("]}"))
+		seriesSep = ","
+	}
+	w.Write([]byte("]"))
+	return nil
+}
+
+// formatValue returns the JSON representation of a point's value, which is null
+// for missing values, as well as NaN and infinite values that JSON cannot represent
+func formatValue(v []interface{}) string {
+	if len(v) == 0 {
+		return "null"
+	}
+	f, ok := v[0].(float64)
+	if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "null"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
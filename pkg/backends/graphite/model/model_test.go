@@ -0,0 +1,165 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+)
+
+const testDoc = `[{"target":"a.b","tags":{"name":"a.b"},"datapoints":[[1.5,1577836800],[null,1577836860],[3,1577836920]]},` +
+	`{"target":"sumSeries(a.*)","datapoints":[[2,1577836800]]}]`
+
+func testTRQ() *timeseries.TimeRangeQuery {
+	return &timeseries.TimeRangeQuery{
+		Statement: "a.b",
+		Extent:    timeseries.Extent{Start: time.Unix(1577836800, 0), End: time.Unix(1577836920, 0)},
+		Step:      time.Minute,
+	}
+}
+
+func TestNewModeler(t *testing.T) {
+	m := NewModeler()
+	if m.WireUnmarshaler == nil || m.WireMarshaler == nil || m.CacheMarshaler == nil {
+		t.Error("expected non-nil modeler funcs")
+	}
+}
+
+func TestUnmarshalTimeseries(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testDoc), testTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := ts.(*dataset.DataSet)
+	if len(ds.Results) != 1 || len(ds.Results[0].SeriesList) != 2 {
+		t.Fatal("unexpected dataset shape")
+	}
+	s := ds.Results[0].SeriesList[0]
+	if s.Header.Name != "a.b" || s.Header.Tags["name"] != "a.b" {
+		t.Errorf("unexpected header %s", s.Header.String())
+	}
+	if len(s.Points) != 3 {
+		t.Fatalf("expected %d got %d", 3, len(s.Points))
+	}
+	if s.Points[0].Epoch != 1577836800000000000 || s.Points[0].Values[0] != 1.5 {
+		t.Errorf("unexpected point %v", s.Points[0])
+	}
+	if s.Points[1].Values[0] != nil {
+		t.Errorf("expected nil value got %v", s.Points[1].Values[0])
+	}
+	if s.PointSize != 16+3*pointSize {
+		t.Errorf("expected %d got %d", 16+3*pointSize, s.PointSize)
+	}
+	if ds.Extents()[0] != testTRQ().Extent {
+		t.Errorf("unexpected extent %s", ds.Extents()[0].String())
+	}
+
+	if _, err = UnmarshalTimeseries([]byte(testDoc), nil); err != timeseries.ErrNoTimerangeQuery {
+		t.Error("expected error for nil time range query")
+	}
+	if _, err = UnmarshalTimeseries([]byte("{"), testTRQ()); err == nil {
+		t.Error("expected error for invalid document")
+	}
+	if _, err = UnmarshalTimeseries([]byte(`[{"target":"a","datapoints":[[1,null]]}]`),
+		testTRQ()); err != timeseries.ErrInvalidBody {
+		t.Error("expected error for null timestamp")
+	}
+}
+
+func TestMarshalTimeseries(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testDoc), testTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalTimeseries(ts, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != testDoc {
+		t.Errorf("expected %s got %s", testDoc, string(b))
+	}
+
+	w := httptest.NewRecorder()
+	err = MarshalTimeseriesWriter(ts, nil, 200, w)
+	if err != nil {
+		t.Error(err)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected %s got %s", "application/json", w.Header().Get("Content-Type"))
+	}
+
+	if _, err = MarshalTimeseries(nil, nil, 200); err != timeseries.ErrUnknownFormat {
+		t.Error("expected error for unknown format")
+	}
+	if _, err = MarshalTimeseries(&dataset.DataSet{}, nil, 200); err != timeseries.ErrUnknownFormat {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestMarshalCacheRoundTrip(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testDoc), testTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewModeler()
+	b, err := m.CacheMarshaler(ts, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts2, err := m.CacheUnmarshaler(b, testTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err = MarshalTimeseriesWriter(ts2, nil, 200, buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != testDoc {
+		t.Errorf("expected %s got %s", testDoc, buf.String())
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+
+	tests := []struct {
+		v        []interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{[]interface{}{nil}, "null"},
+		{[]interface{}{math.NaN()}, "null"},
+		{[]interface{}{math.Inf(1)}, "null"},
+		{[]interface{}{"1"}, "null"},
+		{[]interface{}{0.25}, "0.25"},
+		{[]interface{}{float64(100000000)}, "100000000"},
+	}
+
+	for i, test := range tests {
+		if s := formatValue(test.v); s != test.expected {
+			t.Errorf("test %d: expected %s got %s", i, test.expected, s)
+		}
+	}
+}
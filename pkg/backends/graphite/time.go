@@ -0,0 +1,179 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// offsetUnits maps the prefixes of Graphite's time offset units to their durations,
+// in the order they must be checked. Graphite treats a month as 30 days and a year as 365
+var offsetUnits = []struct {
+	prefix string
+	d      time.Duration
+}{
+	{"s", time.Second},
+	{"min", time.Minute},
+	{"h", time.Hour},
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"mon", 30 * 24 * time.Hour},
+	{"y", 365 * 24 * time.Hour},
+}
+
+// absoluteLayouts are the absolute time formats accepted by Graphite's from and until parameters
+var absoluteLayouts = []string{"15:04_20060102", "20060102", "01/02/06", "01/02/2006"}
+
+// parseTimeZone returns the location named by the tz parameter, or UTC when it is empty
+func parseTimeZone(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// parseTime converts a Graphite from or until value to a time.Time. It supports
+// epoch seconds, the absolute formats in absoluteLayouts, the now, today, yesterday
+// and tomorrow references, and relative offsets like -24h or now-1d12h.
+// An empty value is treated as now
+func parseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	s = strings.ToLower(strings.Replace(strings.TrimSpace(s), " ", "", -1))
+	if s == "" {
+		return now, nil
+	}
+
+	if isDigits(s) && !isYYYYMMDD(s) {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, ErrInvalidTime
+		}
+		return time.Unix(i, 0), nil
+	}
+
+	ref, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		ref, offset = s[:i], s[i:]
+	}
+
+	var t time.Time
+	switch ref {
+	case "", "now":
+		t = now
+	case "today", "midnight":
+		t = midnight(now, loc)
+	case "yesterday":
+		t = midnight(now, loc).AddDate(0, 0, -1)
+	case "tomorrow":
+		t = midnight(now, loc).AddDate(0, 0, 1)
+	default:
+		var err error
+		if t, err = parseAbsoluteTime(ref, loc); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if offset == "" {
+		return t, nil
+	}
+	d, err := parseOffset(offset)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(d), nil
+}
+
+func parseAbsoluteTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range absoluteLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidTime
+}
+
+// parseOffset converts a signed Graphite time offset, like -1d12h, to a time.Duration
+func parseOffset(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, ErrInvalidTime
+	}
+	sign := time.Duration(1)
+	if s[0] == '-' {
+		sign = -1
+	}
+	s = s[1:]
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, ErrInvalidTime
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, ErrInvalidTime
+		}
+		s = s[i:]
+		j := 0
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		unit := s[:j]
+		s = s[j:]
+		var d time.Duration
+		for _, u := range offsetUnits {
+			if strings.HasPrefix(unit, u.prefix) {
+				d = u.d
+				break
+			}
+		}
+		if d == 0 {
+			return 0, ErrInvalidTime
+		}
+		total += time.Duration(n) * d
+	}
+	return sign * total, nil
+}
+
+func midnight(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isYYYYMMDD returns true if the all-digit string is a date rather than
+// epoch seconds, using the same heuristic as Graphite
+func isYYYYMMDD(s string) bool {
+	if len(s) != 8 {
+		return false
+	}
+	y, _ := strconv.Atoi(s[:4])
+	m, _ := strconv.Atoi(s[4:6])
+	d, _ := strconv.Atoi(s[6:])
+	return y > 1900 && y < 2100 && m < 13 && d < 32
+}
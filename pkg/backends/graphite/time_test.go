@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+
+	now := time.Date(2020, 1, 2, 15, 30, 45, 0, time.UTC)
+	ny, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		input    string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{"", time.UTC, now},
+		{"now", time.UTC, now},
+		{"1577836800", time.UTC, time.Unix(1577836800, 0)},
+		{"-24h", time.UTC, now.Add(-24 * time.Hour)},
+		{"-5min", time.UTC, now.Add(-5 * time.Minute)},
+		{"-30s", time.UTC, now.Add(-30 * time.Second)},
+		{"-1d12h", time.UTC, now.Add(-36 * time.Hour)},
+		{"-2weeks", time.UTC, now.Add(-14 * 24 * time.Hour)},
+		{"-1mon", time.UTC, now.Add(-30 * 24 * time.Hour)},
+		{"-1y", time.UTC, now.Add(-365 * 24 * time.Hour)},
+		{"+1h", time.UTC, now.Add(time.Hour)},
+		{"now-1h", time.UTC, now.Add(-time.Hour)},
+		{"today", time.UTC, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"yesterday", time.UTC, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"tomorrow", time.UTC, time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"midnight+6h", time.UTC, time.Date(2020, 1, 2, 6, 0, 0, 0, time.UTC)},
+		{"20200101", time.UTC, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"04:00_20200101", time.UTC, time.Date(2020, 1, 1, 4, 0, 0, 0, time.UTC)},
+		{"01/01/20", time.UTC, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"01/01/2020", time.UTC, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"04:00_20200101", ny, time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for i, test := range tests {
+		out, err := parseTime(test.input, now, test.loc)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if !out.Equal(test.expected) {
+			t.Errorf("test %d: expected %s got %s", i, test.expected, out)
+		}
+	}
+}
+
+func TestParseTimeFails(t *testing.T) {
+
+	now := time.Now()
+	for _, s := range []string{"a", "-", "-h", "-1", "-1m", "-1x", "2020-01-01", "now-"} {
+		if _, err := parseTime(s, now, time.UTC); err != ErrInvalidTime {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+
+	loc, err := parseTimeZone("")
+	if err != nil || loc != time.UTC {
+		t.Error("expected UTC")
+	}
+
+	loc, err = parseTimeZone("America/New_York")
+	if err != nil || loc.String() != "America/New_York" {
+		t.Error("expected America/New_York")
+	}
+
+	if _, err = parseTimeZone("Invalid/Zone"); err == nil {
+		t.Error("expected error for invalid time zone")
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+// defaultRenderStep is the step used to align cached render data when the path does not
+// configure time_normalization_ms. It should match the finest retention of the queried metrics
+const defaultRenderStep = time.Minute
+
+// defaultFindTimeNormalization is the granularity to which the time parameters of
+// find requests are normalized when the path does not configure time_normalization_ms
+const defaultFindTimeNormalization = time.Minute
+
+// SetExtent will change the upstream request query to use the provided Extent
+func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	v, _, _ := params.GetRequestValues(r)
+	// Graphite excludes datapoints at the from time, so it is moved back by one second
+	// in order to include a datapoint at the start of the extent
+	v.Set(upFrom, strconv.FormatInt(extent.Start.Unix()-1, 10))
+	v.Set(upUntil, strconv.FormatInt(extent.End.Unix(), 10))
+	v.Set(upFormat, formatJSON)
+	v.Del(upTZ)
+	// consolidation to maxDataPoints depends on the requested time range,
+	// so it would differ between the extents that are merged in the cache
+	v.Del(upMaxDataPoints)
+	params.SetRequestValues(r, v)
+}
+
+// timeNormalization returns the granularity to which the request's time parameters
+// should be normalized, using the provided default when the path does not set one
+func timeNormalization(r *http.Request, def time.Duration) time.Duration {
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.PathConfig == nil || !rsc.PathConfig.HasTimeNormalization {
+		return def
+	}
+	return rsc.PathConfig.TimeNormalization
+}
+
+// renderStep returns the step used to align the request's cached render data. Since
+// the step can't be disabled, the default is used when the path's normalization is 0
+func renderStep(r *http.Request) time.Duration {
+	if d := timeNormalization(r, defaultRenderStep); d > 0 {
+		return d
+	}
+	return defaultRenderStep
+}
+
+// normalizeTimeParams converts the named time parameters to epoch seconds, rounded down
+// to the provided granularity, so that requests made within the same window (including
+// those using relative times) share a cache key. Values that cannot be parsed are left unmodified.
+func normalizeTimeParams(qp url.Values, granularity time.Duration, names ...string) {
+	loc, err := parseTimeZone(qp.Get(upTZ))
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, name := range names {
+		p := qp.Get(name)
+		if p == "" {
+			continue
+		}
+		t, err := parseTime(p, now, loc)
+		if err != nil {
+			continue
+		}
+		if granularity > 0 {
+			t = t.Truncate(granularity)
+		}
+		qp.Set(name, strconv.FormatInt(t.Unix(), 10))
+	}
+	qp.Del(upTZ)
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphite
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+func TestSetExtent(t *testing.T) {
+
+	start := time.Unix(1577836800, 0)
+	end := time.Unix(1577840400, 0)
+	e := &timeseries.Extent{Start: start, End: end}
+
+	client := &Client{name: "test"}
+
+	r := httptest.NewRequest(http.MethodGet, "http://0/render?target=a&from=-1h&tz=UTC&maxDataPoints=10&format=json", nil)
+	client.SetExtent(r, nil, e)
+	v := r.URL.Query()
+	if v.Get(upFrom) != "1577836799" {
+		t.Errorf("expected %s got %s", "1577836799", v.Get(upFrom))
+	}
+	if v.Get(upUntil) != "1577840400" {
+		t.Errorf("expected %s got %s", "1577840400", v.Get(upUntil))
+	}
+	if v.Get(upMaxDataPoints) != "" || v.Get(upTZ) != "" {
+		t.Errorf("unexpected query %s", r.URL.RawQuery)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://0/render",
+		strings.NewReader("target=a&from=-1h&format=json"))
+	r.Header.Set(headers.NameContentType, headers.ValueXFormURLEncoded)
+	client.SetExtent(r, nil, e)
+	b, _ := ioutil.ReadAll(r.Body)
+	v, _ = url.ParseQuery(string(b))
+	if v.Get(upFrom) != "1577836799" || v.Get(upTarget) != "a" {
+		t.Errorf("unexpected body %s", string(b))
+	}
+}
+
+func TestRenderStep(t *testing.T) {
+
+	r := httptest.NewRequest(http.MethodGet, "http://0/render", nil)
+	if d := renderStep(r); d != defaultRenderStep {
+		t.Errorf("expected %s got %s", defaultRenderStep, d)
+	}
+
+	pc := po.New()
+	pc.HasTimeNormalization = true
+	pc.TimeNormalization = 10 * time.Second
+	rsc := request.NewResources(nil, pc, nil, nil, nil, nil, nil)
+	r = r.WithContext(tctx.WithResources(context.Background(), rsc))
+	if d := renderStep(r); d != 10*time.Second {
+		t.Errorf("expected %s got %s", 10*time.Second, d)
+	}
+
+	pc.TimeNormalization = 0
+	if d := renderStep(r); d != defaultRenderStep {
+		t.Errorf("expected %s got %s", defaultRenderStep, d)
+	}
+}
+
+func TestNormalizeTimeParams(t *testing.T) {
+
+	qp := url.Values{upFrom: {"1577836830"}, upUntil: {"invalid"}, upQuery: {"a.*"}}
+	normalizeTimeParams(qp, time.Minute, upFrom, upUntil)
+	if qp.Get(upFrom) != "1577836800" {
+		t.Errorf("expected %s got %s", "1577836800", qp.Get(upFrom))
+	}
+	if qp.Get(upUntil) != "invalid" {
+		t.Errorf("expected %s got %s", "invalid", qp.Get(upUntil))
+	}
+
+	qp = url.Values{upFrom: {"-1h"}, upTZ: {"UTC"}}
+	normalizeTimeParams(qp, 0, upFrom)
+	if qp.Get(upFrom) == "-1h" || qp.Get(upTZ) != "" {
+		t.Errorf("unexpected query %s", qp.Encode())
+	}
+
+	qp = url.Values{upFrom: {"-1h"}, upTZ: {"Invalid/Zone"}}
+	normalizeTimeParams(qp, time.Minute, upFrom)
+	if qp.Get(upFrom) != "-1h" {
+		t.Errorf("expected %s got %s", "-1h", qp.Get(upFrom))
+	}
+}
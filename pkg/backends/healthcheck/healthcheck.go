@@ -0,0 +1,111 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package healthcheck provides a background health checker for upstream backends
+package healthcheck
+
+import (
+	"net/http"
+	"sync"
+
+	bo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/util/metrics"
+)
+
+// HealthChecker defines the Health Checker interface
+type HealthChecker interface {
+	// Register begins background health checks of the named backend, and returns its Status
+	Register(name string, o *bo.Options, client *http.Client, logger interface{}) (*Status, error)
+	// Unregister stops health checking the named backend
+	Unregister(name string)
+	// Status returns the current Status of the named backend
+	Status(name string) *Status
+	// Statuses returns the Status of all registered backends
+	Statuses() StatusLookup
+	// Shutdown stops all background health checks
+	Shutdown()
+}
+
+type healthChecker struct {
+	targets  map[string]*target
+	statuses StatusLookup
+	mtx      sync.Mutex
+}
+
+// New returns a new HealthChecker
+func New() HealthChecker {
+	return &healthChecker{
+		targets:  make(map[string]*target),
+		statuses: make(StatusLookup),
+	}
+}
+
+func (hc *healthChecker) Register(name string, o *bo.Options,
+	client *http.Client, logger interface{}) (*Status, error) {
+	hc.mtx.Lock()
+	defer hc.mtx.Unlock()
+	if s, ok := hc.statuses[name]; ok {
+		return s, nil
+	}
+	t, err := newTarget(name, o, client, logger)
+	if err != nil {
+		return nil, err
+	}
+	hc.statuses[name] = t.status
+	if t.isCheckable() {
+		hc.targets[name] = t
+		t.start()
+	}
+	return t.status, nil
+}
+
+func (hc *healthChecker) Unregister(name string) {
+	hc.mtx.Lock()
+	defer hc.mtx.Unlock()
+	if t, ok := hc.targets[name]; ok {
+		t.stop()
+		delete(hc.targets, name)
+	}
+	if s, ok := hc.statuses[name]; ok {
+		metrics.BackendHealthStatus.DeleteLabelValues(name, s.provider)
+		delete(hc.statuses, name)
+	}
+}
+
+func (hc *healthChecker) Status(name string) *Status {
+	hc.mtx.Lock()
+	defer hc.mtx.Unlock()
+	return hc.statuses[name]
+}
+
+func (hc *healthChecker) Statuses() StatusLookup {
+	hc.mtx.Lock()
+	defer hc.mtx.Unlock()
+	out := make(StatusLookup, len(hc.statuses))
+	for k, v := range hc.statuses {
+		out[k] = v
+	}
+	return out
+}
+
+func (hc *healthChecker) Shutdown() {
+	hc.mtx.Lock()
+	defer hc.mtx.Unlock()
+	for _, t := range hc.targets {
+		t.stop()
+	}
+	hc.targets = make(map[string]*target)
+}
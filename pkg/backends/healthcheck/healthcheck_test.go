@@ -0,0 +1,107 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	bo "github.com/tricksterproxy/trickster/pkg/backends/options"
+)
+
+func newTestOptions(ts *httptest.Server) *bo.Options {
+	o := bo.New()
+	u, _ := url.Parse(ts.URL)
+	o.Scheme = u.Scheme
+	o.Host = u.Host
+	o.HealthCheckUpstreamPath = "/health"
+	o.HealthCheckVerb = http.MethodGet
+	o.HealthCheckQuery = "-"
+	o.HealthCheckInterval = 10 * time.Millisecond
+	o.HealthCheckFailureThreshold = 1
+	o.HealthCheckRecoveryThreshold = 1
+	return o
+}
+
+// waitForStatus polls the status until it matches the expected value or times out
+func waitForStatus(s *Status, expected int32) bool {
+	for i := 0; i < 200; i++ {
+		if s.Get() == expected {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestRegister(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	hc := New()
+	defer hc.Shutdown()
+
+	_, err := hc.Register("test", nil, ts.Client(), nil)
+	if err != ErrNoOptions {
+		t.Error("expected error for nil options")
+	}
+
+	s, err := hc.Register("test", newTestOptions(ts), ts.Client(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waitForStatus(s, StatusPassing) {
+		t.Errorf("expected %s got %s", "passing", s.String())
+	}
+
+	// re-registering returns the existing status
+	s2, _ := hc.Register("test", newTestOptions(ts), ts.Client(), nil)
+	if s2 != s {
+		t.Error("expected existing status")
+	}
+
+	if hc.Status("test") != s {
+		t.Error("expected registered status")
+	}
+
+	if len(hc.Statuses()) != 1 {
+		t.Errorf("expected %d got %d", 1, len(hc.Statuses()))
+	}
+
+	hc.Unregister("test")
+	if hc.Status("test") != nil {
+		t.Error("expected nil status")
+	}
+}
+
+func TestRegisterUncheckable(t *testing.T) {
+	hc := New()
+	defer hc.Shutdown()
+	s, err := hc.Register("test", bo.New(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Get() != StatusUnknown || !s.IsAvailable() {
+		t.Error("expected unknown, available status")
+	}
+}
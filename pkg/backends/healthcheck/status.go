@@ -0,0 +1,105 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// StatusFailing indicates the backend has failed its health checks
+	StatusFailing = int32(-1)
+	// StatusUnknown indicates the backend has not yet been checked, or is not checkable
+	StatusUnknown = int32(0)
+	// StatusPassing indicates the backend has passed its health checks
+	StatusPassing = int32(1)
+)
+
+// StatusLookup is a map of Statuses keyed by backend name
+type StatusLookup map[string]*Status
+
+// Status maintains the health check status of a backend
+type Status struct {
+	name         string
+	provider     string
+	status       int32
+	detail       string
+	failingSince time.Time
+	mtx          sync.Mutex
+}
+
+// Name returns the name of the backend the Status represents
+func (s *Status) Name() string {
+	return s.name
+}
+
+// Provider returns the provider of the backend the Status represents
+func (s *Status) Provider() string {
+	return s.provider
+}
+
+// Get returns the current status value
+func (s *Status) Get() int32 {
+	return atomic.LoadInt32(&s.status)
+}
+
+// IsAvailable returns true if the backend is not failing its health checks
+func (s *Status) IsAvailable() bool {
+	return s == nil || s.Get() >= StatusUnknown
+}
+
+// Detail returns the detail message for the most recent health check
+func (s *Status) Detail() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.detail
+}
+
+// FailingSince returns the time the backend began failing, or a zero time if it is not failing
+func (s *Status) FailingSince() time.Time {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.failingSince
+}
+
+// Set updates the status value and detail message
+func (s *Status) Set(status int32, detail string) {
+	s.mtx.Lock()
+	if status == StatusFailing && s.Get() != StatusFailing {
+		s.failingSince = time.Now()
+	} else if status != StatusFailing {
+		s.failingSince = time.Time{}
+	}
+	s.detail = detail
+	atomic.StoreInt32(&s.status, status)
+	s.mtx.Unlock()
+}
+
+func (s *Status) String() string {
+	switch s.Get() {
+	case StatusFailing:
+		return "failing"
+	case StatusPassing:
+		return "passing"
+	case StatusUnknown:
+		return "unknown"
+	}
+	return strconv.Itoa(int(s.Get()))
+}
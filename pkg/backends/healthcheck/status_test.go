@@ -0,0 +1,64 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import "testing"
+
+func TestStatus(t *testing.T) {
+
+	var s *Status
+	if !s.IsAvailable() {
+		t.Error("expected nil status to be available")
+	}
+
+	s = &Status{name: "test"}
+	if s.Name() != "test" {
+		t.Errorf("expected %s got %s", "test", s.Name())
+	}
+	if s.String() != "unknown" || !s.IsAvailable() {
+		t.Error("expected unknown, available status")
+	}
+
+	s.Set(StatusFailing, "test detail")
+	if s.String() != "failing" || s.IsAvailable() {
+		t.Error("expected failing, unavailable status")
+	}
+	if s.Detail() != "test detail" {
+		t.Errorf("expected %s got %s", "test detail", s.Detail())
+	}
+	fs := s.FailingSince()
+	if fs.IsZero() {
+		t.Error("expected non-zero failing since time")
+	}
+	s.Set(StatusFailing, "test detail")
+	if !s.FailingSince().Equal(fs) {
+		t.Error("expected failing since time to be unchanged")
+	}
+
+	s.Set(StatusPassing, "")
+	if s.String() != "passing" || !s.IsAvailable() {
+		t.Error("expected passing, available status")
+	}
+	if !s.FailingSince().IsZero() {
+		t.Error("expected zero failing since time")
+	}
+
+	s.Set(5, "")
+	if s.String() != "5" {
+		t.Errorf("expected %s got %s", "5", s.String())
+	}
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	bo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	d "github.com/tricksterproxy/trickster/pkg/config/defaults"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/util/metrics"
+)
+
+// ErrNoOptions indicates the provided Options were nil
+var ErrNoOptions = errors.New("no options provided")
+
+type target struct {
+	name     string
+	provider string
+	method   string
+	url      *url.URL
+	header   http.Header
+	interval time.Duration
+	timeout  time.Duration
+	client   *http.Client
+	status   *Status
+	logger   interface{}
+	cancel   context.CancelFunc
+
+	// failureThreshold and recoveryThreshold are the number of consecutive failed or
+	// successful probes required to change the status
+	failureThreshold  int
+	recoveryThreshold int
+	// failures and successes count consecutive probe results; they are only
+	// accessed by the probing goroutine
+	failures  int
+	successes int
+}
+
+func newTarget(name string, o *bo.Options, client *http.Client,
+	logger interface{}) (*target, error) {
+
+	if o == nil {
+		return nil, ErrNoOptions
+	}
+
+	t := &target{
+		name:              name,
+		provider:          o.Provider,
+		client:            client,
+		logger:            logger,
+		interval:          o.HealthCheckInterval,
+		timeout:           o.HealthCheckTimeout,
+		failureThreshold:  o.HealthCheckFailureThreshold,
+		recoveryThreshold: o.HealthCheckRecoveryThreshold,
+		status:            &Status{name: name, provider: o.Provider},
+	}
+	if t.interval <= 0 {
+		t.interval = time.Duration(d.DefaultHealthCheckIntervalMS) * time.Millisecond
+	}
+	if t.timeout <= 0 {
+		t.timeout = time.Duration(d.DefaultHealthCheckTimeoutMS) * time.Millisecond
+	}
+	if t.failureThreshold < 1 {
+		t.failureThreshold = 1
+	}
+	if t.recoveryThreshold < 1 {
+		t.recoveryThreshold = 1
+	}
+	metrics.BackendHealthStatus.WithLabelValues(name, o.Provider).Set(float64(StatusUnknown))
+
+	// a path or verb of "-" indicates the backend has no health check configuration
+	if o.HealthCheckUpstreamPath == "-" || o.HealthCheckVerb == "-" ||
+		o.Host == "" || client == nil {
+		return t, nil
+	}
+
+	t.method = o.HealthCheckVerb
+	t.url = &url.URL{
+		Scheme: o.Scheme,
+		Host:   o.Host,
+		Path:   o.PathPrefix + o.HealthCheckUpstreamPath,
+	}
+	if o.HealthCheckQuery != "-" {
+		t.url.RawQuery = o.HealthCheckQuery
+	}
+	t.header = http.Header{}
+	if o.HealthCheckHeaders != nil {
+		headers.UpdateHeaders(t.header, o.HealthCheckHeaders)
+	}
+
+	return t, nil
+}
+
+func (t *target) isCheckable() bool {
+	return t.url != nil
+}
+
+func (t *target) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	go func() {
+		t.probe(ctx)
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.probe(ctx)
+			}
+		}
+	}()
+}
+
+func (t *target) stop() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+func (t *target) probe(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, t.method, t.url.String(), nil)
+	if err != nil {
+		t.fail(err.Error())
+		return
+	}
+	req.Header = t.header.Clone()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// the probe was cancelled because the target was stopped
+		if ctx.Err() == context.Canceled {
+			return
+		}
+		t.fail(err.Error())
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		t.fail("unexpected status code: " + strconv.Itoa(resp.StatusCode))
+		return
+	}
+	t.pass()
+}
+
+// fail records a failed probe, and marks the target as failing once the
+// failure threshold is reached
+func (t *target) fail(detail string) {
+	t.successes = 0
+	t.failures++
+	if t.failures >= t.failureThreshold || t.status.Get() == StatusFailing {
+		t.setStatus(StatusFailing, detail)
+	}
+}
+
+// pass records a successful probe, and marks the target as passing, unless it is
+// failing and has not yet reached the recovery threshold
+func (t *target) pass() {
+	t.failures = 0
+	t.successes++
+	if t.status.Get() != StatusFailing || t.successes >= t.recoveryThreshold {
+		t.setStatus(StatusPassing, "")
+	}
+}
+
+func (t *target) setStatus(status int32, detail string) {
+	prev := t.status.Get()
+	t.status.Set(status, detail)
+	metrics.BackendHealthStatus.WithLabelValues(t.name, t.provider).Set(float64(status))
+	if prev == status {
+		return
+	}
+	switch status {
+	case StatusFailing:
+		tl.Warn(t.logger, "backend health check failing",
+			tl.Pairs{"backendName": t.name, "detail": detail})
+	case StatusPassing:
+		tl.Info(t.logger, "backend health check passing",
+			tl.Pairs{"backendName": t.name})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bo "github.com/tricksterproxy/trickster/pkg/backends/options"
+)
+
+func TestNewTarget(t *testing.T) {
+
+	_, err := newTarget("test", nil, nil, nil)
+	if err != ErrNoOptions {
+		t.Error("expected error for nil options")
+	}
+
+	o := bo.New()
+	o.Host = "0"
+	o.HealthCheckUpstreamPath = "-"
+	tgt, err := newTarget("test", o, http.DefaultClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tgt.isCheckable() {
+		t.Error("expected target to not be checkable")
+	}
+
+	o.Scheme = "http"
+	o.PathPrefix = "/prefix"
+	o.HealthCheckUpstreamPath = "/health"
+	o.HealthCheckVerb = http.MethodHead
+	o.HealthCheckQuery = "q=1"
+	o.HealthCheckHeaders = map[string]string{"Test": "value"}
+	tgt, _ = newTarget("test", o, http.DefaultClient, nil)
+	if !tgt.isCheckable() {
+		t.Fatal("expected target to be checkable")
+	}
+	if tgt.url.String() != "http://0/prefix/health?q=1" {
+		t.Errorf("expected %s got %s", "http://0/prefix/health?q=1", tgt.url.String())
+	}
+	if tgt.header.Get("Test") != "value" {
+		t.Errorf("expected %s got %s", "value", tgt.header.Get("Test"))
+	}
+	if tgt.method != http.MethodHead {
+		t.Errorf("expected %s got %s", http.MethodHead, tgt.method)
+	}
+}
+
+func TestProbe(t *testing.T) {
+
+	code := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(code)
+	}))
+	defer ts.Close()
+
+	tgt, _ := newTarget("test", newTestOptions(ts), ts.Client(), nil)
+
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusPassing {
+		t.Errorf("expected %s got %s", "passing", tgt.status.String())
+	}
+
+	code = http.StatusInternalServerError
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusFailing {
+		t.Errorf("expected %s got %s", "failing", tgt.status.String())
+	}
+	if tgt.status.Detail() != "unexpected status code: 500" {
+		t.Errorf("expected %s got %s", "unexpected status code: 500", tgt.status.Detail())
+	}
+
+	code = http.StatusOK
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusPassing {
+		t.Errorf("expected %s got %s", "passing", tgt.status.String())
+	}
+
+	// an unreachable upstream fails the check
+	ts.Close()
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusFailing {
+		t.Errorf("expected %s got %s", "failing", tgt.status.String())
+	}
+}
+
+func TestProbeThresholds(t *testing.T) {
+
+	code := http.StatusInternalServerError
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer ts.Close()
+
+	o := newTestOptions(ts)
+	o.HealthCheckFailureThreshold = 2
+	o.HealthCheckRecoveryThreshold = 3
+	tgt, _ := newTarget("test", o, ts.Client(), nil)
+
+	// the status is unchanged until the failure threshold is reached
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusUnknown {
+		t.Errorf("expected %s got %s", "unknown", tgt.status.String())
+	}
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusFailing {
+		t.Errorf("expected %s got %s", "failing", tgt.status.String())
+	}
+
+	// a failing target requires consecutive successes to recover
+	code = http.StatusOK
+	tgt.probe(context.Background())
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusFailing {
+		t.Errorf("expected %s got %s", "failing", tgt.status.String())
+	}
+	code = http.StatusInternalServerError
+	tgt.probe(context.Background())
+	code = http.StatusOK
+	tgt.probe(context.Background())
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusFailing {
+		t.Errorf("expected %s got %s", "failing", tgt.status.String())
+	}
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusPassing {
+		t.Errorf("expected %s got %s", "passing", tgt.status.String())
+	}
+
+	// a single failure does not fail a passing target
+	code = http.StatusInternalServerError
+	tgt.probe(context.Background())
+	if tgt.status.Get() != StatusPassing {
+		t.Errorf("expected %s got %s", "passing", tgt.status.String())
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	tgt, _ := newTarget("test", newTestOptions(ts), ts.Client(), nil)
+	tgt.start()
+	if !waitForStatus(tgt.status, StatusFailing) {
+		t.Errorf("expected %s got %s", "failing", tgt.status.String())
+	}
+	tgt.stop()
+}
@@ -0,0 +1,28 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import "errors"
+
+// ErrUnsupportedFluxRange indicates the Flux query's range() call could not be parsed
+var ErrUnsupportedFluxRange = errors.New("unsupported flux range")
+
+// ErrUnsupportedFluxDialect indicates the Flux query requests a CSV dialect that is not supported
+var ErrUnsupportedFluxDialect = errors.New("unsupported flux dialect")
+
+// ErrUnsupportedFluxType indicates the query request type is not Flux
+var ErrUnsupportedFluxType = errors.New("unsupported query type")
@@ -0,0 +1,298 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/influxdb/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/errors"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/util/regexp/matching"
+	"github.com/tricksterproxy/trickster/pkg/util/timeconv"
+)
+
+// This file handles parsing and tokenization of the range in InfluxDB 2.x Flux queries
+// for cache key hashing and delta proxy caching.
+
+// Tokens for String Interpolation
+const (
+	tkFluxStart = "<$FLUX_START$>"
+	tkFluxStop  = "<$FLUX_STOP$>"
+)
+
+// fluxRequest is the JSON body of a Flux query request
+type fluxRequest struct {
+	Query   string       `json:"query"`
+	Type    string       `json:"type,omitempty"`
+	Dialect *fluxDialect `json:"dialect,omitempty"`
+	Now     string       `json:"now,omitempty"`
+}
+
+// fluxDialect describes the requested Annotated CSV output format
+type fluxDialect struct {
+	Header         *bool    `json:"header,omitempty"`
+	Delimiter      string   `json:"delimiter,omitempty"`
+	Annotations    []string `json:"annotations,omitempty"`
+	CommentPrefix  string   `json:"commentPrefix,omitempty"`
+	DateTimeFormat string   `json:"dateTimeFormat,omitempty"`
+}
+
+// upstreamFluxDialect is the dialect requested from the upstream, which includes the
+// annotations required to unmarshal the response
+var upstreamFluxDialect = map[string]interface{}{
+	"header":      true,
+	"delimiter":   ",",
+	"annotations": []string{"group", "datatype", "default"},
+}
+
+var fluxAnnotations = map[string]byte{
+	"datatype": model.FluxAnnotationDatatype,
+	"group":    model.FluxAnnotationGroup,
+	"default":  model.FluxAnnotationDefault,
+}
+
+var reFluxRange, reFluxStep, reFluxTimeSrc, reFluxDuration *regexp.Regexp
+
+func init() {
+
+	// Regexp for extracting the range from a Flux query. searches for something like: range(start: -1h, stop: now())
+	reFluxRange = regexp.MustCompile(`range\s*\(\s*start\s*:\s*(?P<start>[^,)]+?)\s*` +
+		`(,\s*stop\s*:\s*(?P<stop>[^,)]+?(\(\))?)\s*)?\)`)
+
+	// Regexp for extracting the step from a Flux query. searches for something like: aggregateWindow(every: 1m
+	reFluxStep = regexp.MustCompile(`aggregateWindow\s*\(\s*every\s*:\s*(?P<step>[0-9]+(ns|us|µs|ms|s|m|h|d|w))\s*` +
+		`(?P<args>[^)]*)\)`)
+
+	// Regexp for determining if aggregateWindow timestamps are the window start, rather than the default stop
+	reFluxTimeSrc = regexp.MustCompile(`timeSrc\s*:\s*"_start"`)
+
+	reFluxDuration = regexp.MustCompile(`([0-9]+)(ns|us|µs|ms|s|m|h|d|w|y)`)
+}
+
+// isFluxRequest returns true if the URL is for the Flux query endpoint
+func isFluxRequest(u *url.URL) bool {
+	return u != nil && strings.HasSuffix(u.Path, "/"+mnFluxQuery)
+}
+
+// parseFluxTimeRangeQuery parses the key parts of a TimeRangeQuery from an inbound Flux query request
+func parseFluxTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, nil, false, errors.ErrNotTimeRangeQuery
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, false, errors.ParseRequestBody(err)
+	}
+
+	fr := &fluxRequest{}
+	var extra map[string]interface{}
+	ct := r.Header.Get(headers.NameContentType)
+	if strings.HasPrefix(ct, headers.ValueApplicationFlux) {
+		fr.Query = string(b)
+	} else if err = json.Unmarshal(b, fr); err != nil {
+		return nil, nil, false, errors.ParseRequestBody(err)
+	} else {
+		// any other body members, like extern, are retained for the upstream requests. now is
+		// applied to the extent, and the upstream range is absolute, so it is not retained
+		json.Unmarshal(b, &extra)
+		for _, k := range []string{upFluxQuery, "type", "dialect", "now"} {
+			delete(extra, k)
+		}
+	}
+	if fr.Type != "" && fr.Type != "flux" {
+		return nil, nil, false, ErrUnsupportedFluxType
+	}
+	if fr.Query == "" {
+		return nil, nil, false, errors.MissingRequestParam("query")
+	}
+
+	rlo := &timeseries.RequestOptions{}
+	if rlo.OutputFormat, err = parseFluxDialect(fr.Dialect); err != nil {
+		return nil, nil, false, err
+	}
+
+	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
+
+	m := matching.GetNamedMatches(reFluxStep, fr.Query, nil)
+	if _, ok := m["step"]; !ok || strings.Contains(m["args"], "offset") {
+		return nil, nil, false, errors.ErrStepParse
+	}
+	if trq.Step, err = timeconv.ParseDuration(m["step"]); err != nil {
+		return nil, nil, false, errors.ErrStepParse
+	}
+
+	now := time.Now()
+	if fr.Now != "" {
+		if now, err = time.Parse(time.RFC3339Nano, fr.Now); err != nil {
+			return nil, nil, false, errors.ParseRequestBody(err)
+		}
+	}
+
+	// only scripts with a single range() can be delta proxy cached, since the
+	// upstream requests rewrite the range to the missing extents
+	locs := reFluxRange.FindAllStringIndex(fr.Query, -1)
+	if len(locs) != 1 {
+		return nil, nil, false, ErrUnsupportedFluxRange
+	}
+	m = matching.GetNamedMatches(reFluxRange, fr.Query, nil)
+	if trq.Extent.Start, err = parseFluxTime(m["start"], now); err != nil {
+		return nil, nil, false, err
+	}
+	trq.Extent.End = now
+	if stop, ok := m["stop"]; ok {
+		if trq.Extent.End, err = parseFluxTime(stop, now); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	// the extent is inclusive of the timestamps of the first and last windows in the range,
+	// which are the window stops unless the window starts are used as the timestamp source
+	if reFluxTimeSrc.MatchString(fr.Query) {
+		trq.Extent.End = trq.Extent.End.Add(-trq.Step)
+	} else {
+		trq.Extent.Start = trq.Extent.Start.Add(trq.Step)
+	}
+
+	loc := locs[0]
+	trq.Statement = fr.Query[:loc[0]] + "range(start: " + tkFluxStart + ", stop: " + tkFluxStop + ")" +
+		fr.Query[loc[1]:]
+	rlo.ExtractFastForwardDisabled(fr.Query)
+
+	// the tokenized query and any other body members are conveyed in the template url's
+	// query params so that they are included in the cache key and upstream requests
+	trq.TemplateURL = urls.Clone(r.URL)
+	qt := trq.TemplateURL.Query()
+	qt.Set(upFluxQuery, trq.Statement)
+	if len(extra) > 0 {
+		b, _ = json.Marshal(extra)
+		qt.Set(upFluxBody, string(b))
+	}
+	trq.TemplateURL.RawQuery = qt.Encode()
+
+	return trq, rlo, false, nil
+}
+
+// parseFluxDialect returns the OutputFormat representing the requested dialect
+func parseFluxDialect(d *fluxDialect) (byte, error) {
+	if d == nil {
+		return 0, nil
+	}
+	if (d.Delimiter != "" && d.Delimiter != ",") || (d.CommentPrefix != "" && d.CommentPrefix != "#") ||
+		(d.DateTimeFormat != "" && d.DateTimeFormat != "RFC3339") {
+		return 0, ErrUnsupportedFluxDialect
+	}
+	var f byte
+	for _, a := range d.Annotations {
+		b, ok := fluxAnnotations[a]
+		if !ok {
+			return 0, ErrUnsupportedFluxDialect
+		}
+		f |= b
+	}
+	if d.Header != nil && !*d.Header {
+		f |= model.FluxNoHeader
+	}
+	return f, nil
+}
+
+// parseFluxTime parses a range() start or stop value, which may be now(), a relative
+// duration such as -1h30m, an RFC3339 timestamp or a Unix timestamp in seconds
+func parseFluxTime(v string, now time.Time) (time.Time, error) {
+	if v == "now()" {
+		return now, nil
+	}
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(i, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+	neg := strings.HasPrefix(v, "-")
+	d, err := parseFluxDuration(strings.TrimPrefix(v, "-"))
+	if err != nil {
+		return time.Time{}, ErrUnsupportedFluxRange
+	}
+	if neg {
+		d = -d
+	}
+	return now.Add(d), nil
+}
+
+// parseFluxDuration parses a Flux duration literal, which may have multiple units (e.g., 1h30m)
+func parseFluxDuration(v string) (time.Duration, error) {
+	parts := reFluxDuration.FindAllStringSubmatch(v, -1)
+	var l int
+	var d time.Duration
+	for _, p := range parts {
+		l += len(p[0])
+		i, _ := strconv.ParseInt(p[1], 10, 64)
+		pd, err := timeconv.ParseDurationParts(i, p[2])
+		if err != nil {
+			return 0, err
+		}
+		d += pd
+	}
+	if len(parts) == 0 || l != len(v) {
+		return errors.ParseDuration(v)
+	}
+	return d, nil
+}
+
+// interpolateFluxQuery inserts the extent into the tokenized Flux query's range, adjusting
+// the range by the step, based on the timestamp source of the aggregate windows
+func interpolateFluxQuery(template string, extent *timeseries.Extent, step time.Duration) string {
+	start, stop := extent.Start, extent.End
+	if reFluxTimeSrc.MatchString(template) {
+		stop = stop.Add(step)
+	} else {
+		start = start.Add(-step)
+	}
+	return strings.NewReplacer(tkFluxStart, start.UTC().Format(time.RFC3339Nano),
+		tkFluxStop, stop.UTC().Format(time.RFC3339Nano)).Replace(template)
+}
+
+// setFluxExtent will change the upstream Flux request body to use the provided Extent
+func setFluxExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	t := trq.TemplateURL.Query()
+	body := make(map[string]interface{})
+	if v := t.Get(upFluxBody); v != "" {
+		json.Unmarshal([]byte(v), &body)
+	}
+	body[upFluxQuery] = interpolateFluxQuery(t.Get(upFluxQuery), extent, trq.Step)
+	body["type"] = "flux"
+	body["dialect"] = upstreamFluxDialect
+	b, _ := json.Marshal(body)
+	r.Header.Set(headers.NameContentType, headers.ValueApplicationJSON)
+	r.Header.Set(headers.NameAccept, headers.ValueTextCSV)
+	r.ContentLength = int64(len(b))
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/influxdb/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/errors"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+const testFluxQuery = `from(bucket: "test") |> range(start: -6h, stop: now()) ` +
+	`|> filter(fn: (r) => r._measurement == "cpu") |> aggregateWindow(every: 1m, fn: mean)`
+
+func newTestFluxRequest(body, contentType string) *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "http://0/api/v2/query?org=test",
+		bytes.NewBufferString(body))
+	r.Header.Set(headers.NameContentType, contentType)
+	return r
+}
+
+func TestParseFluxTimeRangeQuery(t *testing.T) {
+
+	client := &Client{}
+
+	b, _ := json.Marshal(map[string]interface{}{"query": testFluxQuery, "type": "flux",
+		"dialect": map[string]interface{}{"annotations": []string{"datatype", "group"}},
+		"extern":  map[string]interface{}{"type": "File"}})
+	r := newTestFluxRequest(string(b), headers.ValueApplicationJSON)
+	trq, rlo, canOPC, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canOPC {
+		t.Error("expected false")
+	}
+	if trq.Step != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, trq.Step)
+	}
+	// the first window's timestamp is its stop
+	if d := trq.Extent.End.Sub(trq.Extent.Start); d != 6*time.Hour-time.Minute {
+		t.Errorf("expected %s got %s", 6*time.Hour-time.Minute, d)
+	}
+	if !strings.Contains(trq.Statement, "range(start: "+tkFluxStart+", stop: "+tkFluxStop+")") {
+		t.Errorf("expected tokenized range got %s", trq.Statement)
+	}
+	if rlo.OutputFormat != model.FluxAnnotationDatatype|model.FluxAnnotationGroup {
+		t.Errorf("expected %d got %d", model.FluxAnnotationDatatype|model.FluxAnnotationGroup,
+			rlo.OutputFormat)
+	}
+	qt := trq.TemplateURL.Query()
+	if qt.Get(upFluxQuery) != trq.Statement || qt.Get(upOrg) != "test" {
+		t.Errorf("unexpected template query %s", trq.TemplateURL.RawQuery)
+	}
+	if qt.Get(upFluxBody) != `{"extern":{"type":"File"}}` {
+		t.Errorf("unexpected template body %s", qt.Get(upFluxBody))
+	}
+
+	// requests that differ only by now share a cache key
+	var keys []string
+	for _, now := range []string{"2020-01-01T06:00:00Z", "2020-01-01T06:00:10Z"} {
+		b2, _ := json.Marshal(map[string]interface{}{"query": testFluxQuery, "now": now})
+		trq2, _, _, err := client.ParseTimeRangeQuery(newTestFluxRequest(string(b2),
+			headers.ValueApplicationJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, trq2.TemplateURL.RawQuery)
+	}
+	if keys[0] != keys[1] {
+		t.Errorf("expected %s got %s", keys[0], keys[1])
+	}
+
+	// the request body is still readable after parsing
+	b2, _ := ioutil.ReadAll(r.Body)
+	if !bytes.Equal(b, b2) {
+		t.Errorf("expected %s got %s", string(b), string(b2))
+	}
+
+	// raw flux with an absolute range, and timestamps at the window start
+	r = newTestFluxRequest(`from(bucket: "test") |> range(start: 2020-01-01T00:00:00Z, stop: 1577844000) `+
+		`|> aggregateWindow(every: 5m, fn: mean, timeSrc: "_start")`, headers.ValueApplicationFlux)
+	trq, rlo, _, err = client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trq.Extent.Start.Unix() != 1577836800 || trq.Extent.End.Unix() != 1577843700 {
+		t.Errorf("unexpected extent %s", trq.Extent.String())
+	}
+	if rlo.OutputFormat != 0 {
+		t.Errorf("expected %d got %d", 0, rlo.OutputFormat)
+	}
+
+	tests := []struct {
+		body string
+		err  error
+	}{
+		{`{"query":"` + strings.Replace(testFluxQuery, `"`, `\"`, -1) + `","type":"influxql"}`,
+			ErrUnsupportedFluxType},
+		{`{"query":"` + strings.Replace(testFluxQuery, `"`, `\"`, -1) + `","dialect":{"delimiter":";"}}`,
+			ErrUnsupportedFluxDialect},
+		{`{"query":"` + strings.Replace(testFluxQuery, `"`, `\"`, -1) + `","dialect":{"annotations":["x"]}}`,
+			ErrUnsupportedFluxDialect},
+		{`{"query":"from(bucket: \"test\") |> range(start: -1h)"}`, errors.ErrStepParse},
+		{`{"query":"from(bucket: \"test\") |> range(start: -1h) |> aggregateWindow(every: 1m, offset: 10s, fn: mean)"}`,
+			errors.ErrStepParse},
+		{`{"query":"from(bucket: \"test\") |> range(start: v.timeRangeStart) |> aggregateWindow(every: 1m, fn: mean)"}`,
+			ErrUnsupportedFluxRange},
+		{`{"query":"a = from(bucket: \"a\") |> range(start: -1h) b = from(bucket: \"b\") |> range(start: -1h) ` +
+			`union(tables: [a, b]) |> aggregateWindow(every: 1m, fn: mean)"}`, ErrUnsupportedFluxRange},
+		{`{"query":""}`, errors.MissingRequestParam("query")},
+	}
+	for _, test := range tests {
+		_, _, _, err = client.ParseTimeRangeQuery(newTestFluxRequest(test.body, headers.ValueApplicationJSON))
+		if err == nil || err.Error() != test.err.Error() {
+			t.Errorf("%s: expected %v got %v", test.body, test.err, err)
+		}
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v2/query", nil)
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+
+	r = newTestFluxRequest("{", headers.ValueApplicationJSON)
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err == nil {
+		t.Error("expected error for invalid json")
+	}
+}
+
+func TestParseFluxTime(t *testing.T) {
+	now := time.Unix(1577836800, 0)
+	tests := []struct {
+		v        string
+		expected time.Time
+		err      bool
+	}{
+		{"now()", now, false},
+		{"-1h30m", now.Add(-90 * time.Minute), false},
+		{"1d", now.Add(24 * time.Hour), false},
+		{"1577836000", time.Unix(1577836000, 0), false},
+		{"2020-01-01T01:00:00Z", now.Add(time.Hour), false},
+		{"-1mo", time.Time{}, true},
+		{"-1h30x", time.Time{}, true},
+	}
+	for _, test := range tests {
+		v, err := parseFluxTime(test.v, now)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error %v", test.v, err)
+		}
+		if !v.Equal(test.expected) {
+			t.Errorf("%s: expected %s got %s", test.v, test.expected, v)
+		}
+	}
+}
+
+func TestSetFluxExtent(t *testing.T) {
+
+	client := &Client{}
+	b, _ := json.Marshal(map[string]interface{}{"query": testFluxQuery, "now": "2020-01-01T06:00:00Z"})
+	r := newTestFluxRequest(string(b), headers.ValueApplicationJSON)
+	trq, _, _, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &timeseries.Extent{Start: time.Unix(1577836800, 0), End: time.Unix(1577840400, 0)}
+	client.SetExtent(r, trq, e)
+
+	if r.Header.Get(headers.NameContentType) != headers.ValueApplicationJSON {
+		t.Errorf("expected %s got %s", headers.ValueApplicationJSON, r.Header.Get(headers.NameContentType))
+	}
+	body := make(map[string]interface{})
+	b, _ = ioutil.ReadAll(r.Body)
+	if err = json.Unmarshal(b, &body); err != nil {
+		t.Fatal(err)
+	}
+	// the first window's timestamp is its stop, so the range starts one step earlier
+	if !strings.Contains(body["query"].(string),
+		"range(start: 2019-12-31T23:59:00Z, stop: 2020-01-01T01:00:00Z)") {
+		t.Errorf("unexpected query %s", body["query"])
+	}
+	// now is applied to the extent, and the upstream range is absolute
+	if _, ok := body["now"]; ok {
+		t.Errorf("expected now to be removed got %v", body["now"])
+	}
+	if _, ok := body["dialect"]; !ok {
+		t.Error("expected dialect")
+	}
+	if r.ContentLength != int64(len(b)) {
+		t.Errorf("expected %d got %d", len(b), r.ContentLength)
+	}
+
+	// windows timestamped at their start extend the range stop by one step
+	s := interpolateFluxQuery(`range(start: `+tkFluxStart+`, stop: `+tkFluxStop+
+		`) |> aggregateWindow(every: 1m, fn: mean, timeSrc: "_start")`, e, time.Minute)
+	if !strings.HasPrefix(s, "range(start: 2020-01-01T00:00:00Z, stop: 2020-01-01T01:01:00Z)") {
+		t.Errorf("unexpected query %s", s)
+	}
+}
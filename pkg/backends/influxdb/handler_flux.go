@@ -0,0 +1,34 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"net/http"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+)
+
+// FluxHandler handles InfluxDB 2.x Flux query requests and processes them through the delta proxy cache
+func (c *Client) FluxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		c.ProxyHandler(w, r)
+		return
+	}
+	r.URL = urls.BuildUpstreamURL(r, c.baseUpstreamURL)
+	engines.DeltaProxyCacheRequest(w, r, c.fluxModeler)
+}
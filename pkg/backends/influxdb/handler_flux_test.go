@@ -0,0 +1,136 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/influxdb/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+var reTestFluxRange = regexp.MustCompile(`range\(start: (\S+), stop: (\S+)\)`)
+
+// newTestFluxServer returns a server that responds to Flux queries with one point per minute,
+// timestamped at the stop of each window in the requested range
+func newTestFluxServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]interface{})
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+		q, _ := body["query"].(string)
+		m := reTestFluxRange.FindStringSubmatch(q)
+		if m == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		start, _ := time.Parse(time.RFC3339Nano, m[1])
+		stop, _ := time.Parse(time.RFC3339Nano, m[2])
+		w.Header().Set(headers.NameContentType, headers.ValueTextCSV)
+		w.Write([]byte("#group,false,false,true,true,false,false,true\r\n" +
+			"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string\r\n" +
+			"#default,_result,,,,,,\r\n,result,table,_start,_stop,_time,_value,_field\r\n"))
+		for t := start.Add(time.Minute); !t.After(stop); t = t.Add(time.Minute) {
+			fmt.Fprintf(w, ",,0,%s,%s,%s,%d,usage\r\n", m[1], m[2], t.Format(time.RFC3339), t.Unix())
+		}
+	}))
+}
+
+func TestFluxHandler(t *testing.T) {
+
+	client := &Client{name: "test", fluxModeler: model.NewFluxModeler()}
+	ts, _, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs, 200, "", nil,
+		"influxdb", "/"+mnFluxQuery, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	fs := newTestFluxServer()
+	defer fs.Close()
+
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(fs.URL)
+
+	end := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	q := fmt.Sprintf(`from(bucket: "test") |> range(start: %s, stop: %s) |> aggregateWindow(every: 1m, fn: mean)`,
+		end.Add(-10*time.Minute).UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+
+	for i, status := range []string{"kmiss", "hit"} {
+		w := httptest.NewRecorder()
+		r2 := httptest.NewRequest(http.MethodPost, "http://0/api/v2/query?org=test",
+			bytes.NewBufferString(q)).WithContext(r.Context())
+		r2.Header.Set(headers.NameContentType, headers.ValueApplicationFlux)
+		client.FluxHandler(w, r2)
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%d: expected %d got %d", i, http.StatusOK, resp.StatusCode)
+		}
+		if h := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(h, "status="+status) {
+			t.Errorf("%d: expected status %s got %s", i, status, h)
+		}
+		b, _ := ioutil.ReadAll(resp.Body)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\r\n")
+		// the header row and the 10 windows stopping within the range
+		if len(lines) != 11 || !strings.HasPrefix(lines[1], ",_result,0,") {
+			t.Errorf("%d: unexpected body %s", i, string(b))
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestFluxHandlerProxy(t *testing.T) {
+
+	client := &Client{name: "test", fluxModeler: model.NewFluxModeler()}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs, 200, "{}", nil,
+		"influxdb", "/"+mnFluxQuery, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(ts.URL)
+
+	// non-POST requests are proxied
+	client.FluxHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	if string(b) != "{}" {
+		t.Errorf("expected '{}' got %s", string(b))
+	}
+}
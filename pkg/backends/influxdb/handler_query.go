@@ -70,6 +70,10 @@ var epochToFlag = map[string]byte{
 func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
 	*timeseries.RequestOptions, bool, error) {
 
+	if isFluxRequest(r.URL) {
+		return parseFluxTimeRangeQuery(r)
+	}
+
 	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
 	rlo := &timeseries.RequestOptions{}
 
@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/influxdb/model"
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/proxy"
@@ -50,6 +51,7 @@ type Client struct {
 	healthBody       io.Reader
 	healthHeaderLock *sync.Mutex
 	modeler          *timeseries.Modeler
+	fluxModeler      *timeseries.Modeler
 }
 
 // NewClient returns a new Client Instance
@@ -61,7 +63,7 @@ func NewClient(name string, oc *oo.Options, router http.Handler,
 	oc.FastForwardDisable = true
 	return &Client{name: name, config: oc, router: router, cache: cache,
 		webClient: c, baseUpstreamURL: bur, healthHeaderLock: &sync.Mutex{},
-		modeler: modeler}, err
+		modeler: modeler, fluxModeler: model.NewFluxModeler()}, err
 }
 
 // Configuration returns the upstream Configuration for this Client
@@ -0,0 +1,460 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
+)
+
+// Flux Annotated CSV Dialect options, conveyed to the marshaler via RequestOptions.OutputFormat.
+// An OutputFormat of 0 represents a header row with no annotations
+const (
+	// FluxAnnotationDatatype indicates the #datatype annotation row is included
+	FluxAnnotationDatatype byte = 1 << iota
+	// FluxAnnotationGroup indicates the #group annotation row is included
+	FluxAnnotationGroup
+	// FluxAnnotationDefault indicates the #default annotation row is included
+	FluxAnnotationDefault
+	// FluxNoHeader indicates the column header row is excluded
+	FluxNoHeader
+)
+
+// Flux Annotated CSV column names and datatypes with special handling
+const (
+	fluxColResult    = "result"
+	fluxColTable     = "table"
+	fluxColStart     = "_start"
+	fluxColStop      = "_stop"
+	fluxColTime      = "_time"
+	fluxColError     = "error"
+	fluxTypeString   = "string"
+	fluxTypeLong     = "long"
+	fluxTypeULong    = "unsignedLong"
+	fluxTypeDouble   = "double"
+	fluxTypeBool     = "boolean"
+	fluxTypeDateTime = "dateTime:RFC3339"
+)
+
+// NewFluxModeler returns a collection of modeling functions for InfluxDB 2.x Flux interoperability
+func NewFluxModeler() *timeseries.Modeler {
+	return &timeseries.Modeler{
+		WireUnmarshalerReader: UnmarshalFluxTimeseriesReader,
+		WireMarshaler:         MarshalFluxTimeseries,
+		WireMarshalWriter:     MarshalFluxTimeseriesWriter,
+		WireUnmarshaler:       UnmarshalFluxTimeseries,
+		CacheMarshaler:        dataset.MarshalDataSet,
+		CacheUnmarshaler:      dataset.UnmarshalDataSet,
+	}
+}
+
+// fluxTable describes the columns of an Annotated CSV table
+type fluxTable struct {
+	columns   []string
+	datatypes []string
+	groups    []bool
+	defaults  []string
+}
+
+// UnmarshalFluxTimeseries converts an Annotated CSV document into a Timeseries
+func UnmarshalFluxTimeseries(data []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	return UnmarshalFluxTimeseriesReader(bytes.NewReader(data), trq)
+}
+
+// UnmarshalFluxTimeseriesReader converts an Annotated CSV document into a Timeseries via io.Reader.
+// The document must include the #datatype and #group annotations. Each Flux table is represented
+// as a Series, named for its result, and tagged with its group key columns other than _start and _stop
+func UnmarshalFluxTimeseriesReader(reader io.Reader, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if trq == nil {
+		return nil, timeseries.ErrNoTimerangeQuery
+	}
+
+	cr := csv.NewReader(reader)
+	cr.FieldsPerRecord = -1
+
+	ds := &dataset.DataSet{
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+	}
+	results := make(map[string]*dataset.Result)
+	resultNames := make([]string, 0, 1)
+	lookup := make(map[dataset.Hash]*dataset.Series)
+
+	var tbl *fluxTable
+	var inHeader bool
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 2 {
+			continue
+		}
+		if strings.HasPrefix(rec[0], "#") {
+			// a new table's annotations follow the previous table's data rows
+			if !inHeader {
+				tbl = &fluxTable{}
+				inHeader = true
+			}
+			switch rec[0] {
+			case "#datatype":
+				tbl.datatypes = rec[1:]
+			case "#group":
+				tbl.groups = make([]bool, len(rec)-1)
+				for i, v := range rec[1:] {
+					tbl.groups[i] = v == "true"
+				}
+			case "#default":
+				tbl.defaults = rec[1:]
+			}
+			continue
+		}
+		if tbl == nil || len(tbl.groups) != len(tbl.datatypes) {
+			return nil, timeseries.ErrInvalidBody
+		}
+		if inHeader {
+			tbl.columns = rec[1:]
+			if len(tbl.columns) != len(tbl.datatypes) {
+				return nil, timeseries.ErrInvalidBody
+			}
+			inHeader = false
+			continue
+		}
+		if len(rec)-1 != len(tbl.columns) {
+			return nil, timeseries.ErrInvalidBody
+		}
+		if tbl.columns[0] == fluxColError {
+			ds.Error = rec[1]
+			continue
+		}
+
+		s, pt, err := tbl.seriesPoint(rec[1:], trq.Statement)
+		if err != nil {
+			return nil, err
+		}
+		h := s.Header.CalculateHash()
+		if es, ok := lookup[h]; ok {
+			s = es
+		} else {
+			lookup[h] = s
+			r, ok := results[s.Header.Name]
+			if !ok {
+				r = &dataset.Result{StatementID: len(resultNames)}
+				results[s.Header.Name] = r
+				resultNames = append(resultNames, s.Header.Name)
+			}
+			r.SeriesList = append(r.SeriesList, s)
+		}
+		s.Points = append(s.Points, pt)
+		s.PointSize += int64(pt.Size)
+	}
+
+	ds.Results = make([]*dataset.Result, len(resultNames))
+	for i, n := range resultNames {
+		ds.Results[i] = results[n]
+		for _, s := range ds.Results[i].SeriesList {
+			sort.Sort(s.Points)
+		}
+	}
+	if len(ds.Results) == 0 {
+		ds.Results = []*dataset.Result{{}}
+	}
+	return ds, nil
+}
+
+// seriesPoint returns a Series with the header for the provided row, and the row's Point
+func (tbl *fluxTable) seriesPoint(row []string, stmt string) (*dataset.Series, dataset.Point, error) {
+	sh := dataset.SeriesHeader{Tags: make(dataset.Tags), QueryStatement: stmt}
+	pt := dataset.Point{Size: 12}
+	var timeFound bool
+	for i, c := range tbl.columns {
+		v := row[i]
+		if v == "" && i < len(tbl.defaults) {
+			v = tbl.defaults[i]
+		}
+		switch {
+		case c == fluxColResult:
+			sh.Name = v
+		case c == fluxColTable || c == fluxColStart || c == fluxColStop:
+		case c == fluxColTime:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, pt, timeseries.ErrInvalidTimeFormat
+			}
+			pt.Epoch = epoch.Epoch(t.UnixNano())
+			timeFound = true
+		case tbl.groups[i]:
+			sh.Tags[c] = v
+		default:
+			fd := timeseries.FieldDefinition{Name: c, SDataType: tbl.datatypes[i]}
+			val, err := parseFluxValue(v, &fd)
+			if err != nil {
+				return nil, pt, err
+			}
+			sh.FieldsList = append(sh.FieldsList, fd)
+			pt.Values = append(pt.Values, val)
+			switch t := val.(type) {
+			case string:
+				pt.Size += len(t)
+			case bool:
+				pt.Size++
+			case nil:
+			default:
+				pt.Size += 8
+			}
+		}
+	}
+	if !timeFound {
+		return nil, pt, timeseries.ErrInvalidBody
+	}
+	sh.Size = sh.CalculateSize()
+	return &dataset.Series{Header: sh}, pt, nil
+}
+
+// parseFluxValue parses the value according to the field's Flux datatype, and sets the
+// field's DataType accordingly. Empty values are null and return nil
+func parseFluxValue(v string, fd *timeseries.FieldDefinition) (interface{}, error) {
+	switch fd.SDataType {
+	case fluxTypeDouble:
+		fd.DataType = timeseries.Float64
+		if v == "" {
+			return nil, nil
+		}
+		return strconv.ParseFloat(v, 64)
+	case fluxTypeLong:
+		fd.DataType = timeseries.Int64
+		if v == "" {
+			return nil, nil
+		}
+		return strconv.ParseInt(v, 10, 64)
+	case fluxTypeULong:
+		fd.DataType = timeseries.Int64
+		if v == "" {
+			return nil, nil
+		}
+		return strconv.ParseUint(v, 10, 64)
+	case fluxTypeBool:
+		fd.DataType = timeseries.Bool
+		if v == "" {
+			return nil, nil
+		}
+		return strconv.ParseBool(v)
+	}
+	fd.DataType = timeseries.String
+	if v == "" {
+		return nil, nil
+	}
+	return v, nil
+}
+
+// MarshalFluxTimeseries converts a Timeseries into an Annotated CSV document
+func MarshalFluxTimeseries(ts timeseries.Timeseries, rlo *timeseries.RequestOptions, status int) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := MarshalFluxTimeseriesWriter(ts, rlo, status, buf)
+	return buf.Bytes(), err
+}
+
+// MarshalFluxTimeseriesWriter converts a Timeseries into an Annotated CSV document via an io.Writer,
+// using the dialect provided in the RequestOptions' OutputFormat
+func MarshalFluxTimeseriesWriter(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+	status int, w io.Writer) error {
+	if ts == nil {
+		return timeseries.ErrUnknownFormat
+	}
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok {
+		return timeseries.ErrUnknownFormat
+	}
+	var dialect byte
+	if rlo != nil {
+		dialect = rlo.OutputFormat
+	}
+
+	if rw, ok := w.(http.ResponseWriter); ok {
+		rw.Header().Set(headers.NameContentType, headers.ValueTextCSV+"; charset=utf-8")
+		rw.WriteHeader(status)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	if ds.Error != "" {
+		tbl := &fluxTable{columns: []string{fluxColError, "reference"},
+			datatypes: []string{fluxTypeString, fluxTypeString},
+			groups:    []bool{true, true}, defaults: []string{"", ""}}
+		tbl.writeHeader(cw, dialect)
+		cw.Write([]string{"", ds.Error, ""})
+		cw.Flush()
+		return cw.Error()
+	}
+
+	start, stop := fluxExtent(ds)
+	var prev string
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		var tableID int
+		for _, s := range r.SeriesList {
+			if s == nil || len(s.Points) == 0 {
+				continue
+			}
+			tbl, tags := newFluxTable(s)
+			if sig := tbl.signature(); sig != prev {
+				if prev != "" {
+					cw.Flush()
+					w.Write([]byte("\r\n"))
+				}
+				tbl.writeHeader(cw, dialect)
+				prev = sig
+			}
+			resultName := s.Header.Name
+			if dialect&FluxAnnotationDefault != 0 {
+				resultName = ""
+			}
+			tid := strconv.Itoa(tableID)
+			for _, p := range s.Points {
+				row := make([]string, 0, len(tbl.columns)+1)
+				row = append(row, "", resultName, tid, start, stop,
+					time.Unix(0, int64(p.Epoch)).UTC().Format(time.RFC3339Nano))
+				for i := range s.Header.FieldsList {
+					var v interface{}
+					if i < len(p.Values) {
+						v = p.Values[i]
+					}
+					row = append(row, formatFluxValue(v))
+				}
+				row = append(row, tags...)
+				cw.Write(row)
+			}
+			tableID++
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// newFluxTable returns the table columns and the tag values for the Series
+func newFluxTable(s *dataset.Series) (*fluxTable, []string) {
+	keys := s.Header.Tags.Keys()
+	sort.Strings(keys)
+	l := 5 + len(s.Header.FieldsList) + len(keys)
+	tbl := &fluxTable{
+		columns:   make([]string, 0, l),
+		datatypes: make([]string, 0, l),
+		groups:    make([]bool, 0, l),
+		defaults:  make([]string, l),
+	}
+	tbl.columns = append(tbl.columns, fluxColResult, fluxColTable, fluxColStart, fluxColStop, fluxColTime)
+	tbl.datatypes = append(tbl.datatypes, fluxTypeString, fluxTypeLong, fluxTypeDateTime,
+		fluxTypeDateTime, fluxTypeDateTime)
+	tbl.groups = append(tbl.groups, false, false, true, true, false)
+	tbl.defaults[0] = s.Header.Name
+	for _, fd := range s.Header.FieldsList {
+		tbl.columns = append(tbl.columns, fd.Name)
+		dt := fd.SDataType
+		if dt == "" {
+			dt = fluxTypeString
+		}
+		tbl.datatypes = append(tbl.datatypes, dt)
+		tbl.groups = append(tbl.groups, false)
+	}
+	tags := make([]string, len(keys))
+	for i, k := range keys {
+		tbl.columns = append(tbl.columns, k)
+		tbl.datatypes = append(tbl.datatypes, fluxTypeString)
+		tbl.groups = append(tbl.groups, true)
+		tags[i] = s.Header.Tags[k]
+	}
+	return tbl, tags
+}
+
+func (tbl *fluxTable) signature() string {
+	var sb strings.Builder
+	for i, c := range tbl.columns {
+		sb.WriteString(c + ":" + tbl.datatypes[i] + ":" + strconv.FormatBool(tbl.groups[i]) +
+			":" + tbl.defaults[i] + ",")
+	}
+	return sb.String()
+}
+
+func (tbl *fluxTable) writeHeader(cw *csv.Writer, dialect byte) {
+	if dialect&FluxAnnotationGroup != 0 {
+		row := make([]string, len(tbl.groups)+1)
+		row[0] = "#group"
+		for i, g := range tbl.groups {
+			row[i+1] = strconv.FormatBool(g)
+		}
+		cw.Write(row)
+	}
+	if dialect&FluxAnnotationDatatype != 0 {
+		cw.Write(append([]string{"#datatype"}, tbl.datatypes...))
+	}
+	if dialect&FluxAnnotationDefault != 0 {
+		cw.Write(append([]string{"#default"}, tbl.defaults...))
+	}
+	if dialect&FluxNoHeader == 0 {
+		cw.Write(append([]string{""}, tbl.columns...))
+	}
+}
+
+// fluxExtent returns the formatted _start and _stop values for the DataSet
+func fluxExtent(ds *dataset.DataSet) (string, string) {
+	var e timeseries.Extent
+	if ds.TimeRangeQuery != nil {
+		e = ds.TimeRangeQuery.Extent
+	}
+	if e.Start.IsZero() && len(ds.ExtentList) > 0 {
+		e = timeseries.Extent{Start: ds.ExtentList[0].Start,
+			End: ds.ExtentList[len(ds.ExtentList)-1].End}
+	}
+	return e.Start.UTC().Format(time.RFC3339Nano), e.End.UTC().Format(time.RFC3339Nano)
+}
+
+func formatFluxValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case uint64:
+		return strconv.FormatUint(t, 10)
+	case int:
+		return strconv.Itoa(t)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+)
+
+const testFluxCSV = "#group,false,false,true,true,false,false,true,true,true\r\n" +
+	"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string\r\n" +
+	"#default,_result,,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement,host\r\n" +
+	",,0,2020-01-01T00:00:00Z,2020-01-01T00:03:00Z,2020-01-01T00:02:00Z,2.5,usage,cpu,a\r\n" +
+	",,0,2020-01-01T00:00:00Z,2020-01-01T00:03:00Z,2020-01-01T00:01:00Z,1.5,usage,cpu,a\r\n" +
+	",,1,2020-01-01T00:00:00Z,2020-01-01T00:03:00Z,2020-01-01T00:01:00Z,,usage,cpu,b\r\n" +
+	"\r\n" +
+	"#group,false,false,true,true,false,false,true,true\r\n" +
+	"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,long,string,string\r\n" +
+	"#default,_result,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement\r\n" +
+	",,2,2020-01-01T00:00:00Z,2020-01-01T00:03:00Z,2020-01-01T00:01:00Z,7,count,mem\r\n" +
+	"\r\n"
+
+func testFluxTRQ() *timeseries.TimeRangeQuery {
+	return &timeseries.TimeRangeQuery{
+		Statement: "test",
+		Step:      time.Minute,
+		Extent: timeseries.Extent{Start: time.Unix(1577836860, 0),
+			End: time.Unix(1577836920, 0)},
+	}
+}
+
+func TestUnmarshalFluxTimeseries(t *testing.T) {
+
+	ts, err := UnmarshalFluxTimeseries([]byte(testFluxCSV), testFluxTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := ts.(*dataset.DataSet)
+	if len(ds.Results) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(ds.Results))
+	}
+	sl := ds.Results[0].SeriesList
+	if len(sl) != 3 {
+		t.Fatalf("expected %d got %d", 3, len(sl))
+	}
+	if sl[0].Header.Name != "_result" || sl[0].Header.Tags["host"] != "a" ||
+		sl[0].Header.Tags["_field"] != "usage" {
+		t.Errorf("unexpected header %s", sl[0].Header.String())
+	}
+	if _, ok := sl[0].Header.Tags["_start"]; ok {
+		t.Error("expected _start to be excluded from tags")
+	}
+	if len(sl[0].Points) != 2 || sl[0].Points[0].Values[0].(float64) != 1.5 {
+		t.Errorf("unexpected points %v", sl[0].Points)
+	}
+	if sl[1].Points[0].Values[0] != nil {
+		t.Errorf("expected nil value got %v", sl[1].Points[0].Values[0])
+	}
+	if sl[2].Header.FieldsList[0].DataType != timeseries.Int64 ||
+		sl[2].Points[0].Values[0].(int64) != 7 {
+		t.Errorf("unexpected series %s", sl[2].String())
+	}
+
+	_, err = UnmarshalFluxTimeseries([]byte(testFluxCSV), nil)
+	if err != timeseries.ErrNoTimerangeQuery {
+		t.Errorf("expected %v got %v", timeseries.ErrNoTimerangeQuery, err)
+	}
+
+	// the #group and #datatype annotations are required
+	_, err = UnmarshalFluxTimeseries([]byte(",result,table,_time,_value\r\n,_result,0,"+
+		"2020-01-01T00:01:00Z,1\r\n"), testFluxTRQ())
+	if err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+
+	// tables must have a _time column
+	_, err = UnmarshalFluxTimeseries([]byte("#group,false,false\r\n#datatype,string,long\r\n"+
+		",result,table\r\n,_result,0\r\n"), testFluxTRQ())
+	if err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+
+	_, err = UnmarshalFluxTimeseries([]byte("#group,false,false,false\r\n"+
+		"#datatype,string,long,double\r\n,result,table,_value\r\n,_result,0,x\r\n"), testFluxTRQ())
+	if err == nil {
+		t.Error("expected error for invalid double")
+	}
+}
+
+func TestUnmarshalFluxTimeseriesError(t *testing.T) {
+	const csv = "#datatype,string,string\r\n#group,true,true\r\n#default,,\r\n" +
+		",error,reference\r\n,query failed,\r\n"
+	ts, err := UnmarshalFluxTimeseries([]byte(csv), testFluxTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.(*dataset.DataSet).Error != "query failed" {
+		t.Errorf("expected %s got %s", "query failed", ts.(*dataset.DataSet).Error)
+	}
+	b, err := MarshalFluxTimeseries(ts, &timeseries.RequestOptions{}, 200)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != ",error,reference\r\n,query failed,\r\n" {
+		t.Errorf("unexpected output %s", string(b))
+	}
+}
+
+func TestMarshalFluxTimeseries(t *testing.T) {
+
+	ts, err := UnmarshalFluxTimeseries([]byte(testFluxCSV), testFluxTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rlo := &timeseries.RequestOptions{OutputFormat: FluxAnnotationDatatype |
+		FluxAnnotationGroup | FluxAnnotationDefault}
+	b, err := MarshalFluxTimeseries(ts, rlo, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, "#group,false,false,true,true,false,false,true,true,true\r\n"+
+		"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string\r\n"+
+		"#default,_result,,,,,,,,\r\n"+
+		",result,table,_start,_stop,_time,_value,_field,_measurement,host\r\n"+
+		",,0,2020-01-01T00:01:00Z,2020-01-01T00:02:00Z,2020-01-01T00:01:00Z,1.5,usage,cpu,a\r\n") {
+		t.Errorf("unexpected output %s", s)
+	}
+	if !strings.Contains(s, ",,1,2020-01-01T00:01:00Z,2020-01-01T00:02:00Z,2020-01-01T00:01:00Z,,usage,cpu,b\r\n\r\n") {
+		t.Errorf("expected a new table block for the changed schema: %s", s)
+	}
+
+	// the marshaled output unmarshals to an equivalent timeseries
+	ts2, err := UnmarshalFluxTimeseries(b, testFluxTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts2.SeriesCount() != 3 || ts2.ValueCount() != ts.ValueCount() {
+		t.Errorf("expected %d series and %d values got %d and %d", 3, ts.ValueCount(),
+			ts2.SeriesCount(), ts2.ValueCount())
+	}
+
+	// without annotations, the result name is written to each row
+	w := httptest.NewRecorder()
+	err = MarshalFluxTimeseriesWriter(ts, nil, 200, w)
+	if err != nil {
+		t.Error(err)
+	}
+	if !strings.HasPrefix(w.Body.String(), ",result,table,_start,_stop,_time,_value,_field,_measurement,host\r\n"+
+		",_result,0,") {
+		t.Errorf("unexpected output %s", w.Body.String())
+	}
+	if ct := w.Header().Get(headers.NameContentType); !strings.HasPrefix(ct, headers.ValueTextCSV) {
+		t.Errorf("expected %s got %s", headers.ValueTextCSV, ct)
+	}
+
+	// without a header
+	b, _ = MarshalFluxTimeseries(ts, &timeseries.RequestOptions{OutputFormat: FluxNoHeader}, 200)
+	if !strings.HasPrefix(string(b), ",_result,0,") {
+		t.Errorf("unexpected output %s", string(b))
+	}
+
+	if _, err = MarshalFluxTimeseries(nil, rlo, 200); err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+}
+
+func TestNewFluxModeler(t *testing.T) {
+	m := NewFluxModeler()
+	if m.WireUnmarshaler == nil || m.WireMarshalWriter == nil || m.CacheMarshaler == nil {
+		t.Error("expected non-nil modeler funcs")
+	}
+}
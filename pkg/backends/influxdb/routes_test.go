@@ -29,6 +29,9 @@ func TestRegisterHandlers(t *testing.T) {
 	if _, ok := c.handlers[mnQuery]; !ok {
 		t.Errorf("expected to find handler named: %s", mnQuery)
 	}
+	if _, ok := c.handlers["flux"]; !ok {
+		t.Errorf("expected to find handler named: %s", "flux")
+	}
 }
 
 func TestHandlers(t *testing.T) {
@@ -55,7 +58,11 @@ func TestDefaultPathConfigs(t *testing.T) {
 		t.Errorf("expected to find path named: %s", "/")
 	}
 
-	const expectedLen = 2
+	if _, ok := client.config.Paths["/"+mnFluxQuery]; !ok {
+		t.Errorf("expected to find path named: %s", "/"+mnFluxQuery)
+	}
+
+	const expectedLen = 3
 	if len(client.config.Paths) != expectedLen {
 		t.Errorf("expected ordered length to be: %d", expectedLen)
 	}
@@ -26,7 +26,8 @@ import (
 
 // Upstream Endpoints
 const (
-	mnQuery = "query"
+	mnQuery     = "query"
+	mnFluxQuery = "api/v2/query"
 )
 
 // Common URL Parameter Names
@@ -36,10 +37,20 @@ const (
 	upEpoch   = "epoch"
 	upPretty  = "pretty"
 	upChunked = "chunked"
+
+	// upFluxQuery and upFluxBody convey a Flux query's body in its TemplateURL
+	upFluxQuery = "query"
+	upFluxBody  = "body"
+	upOrg       = "org"
+	upOrgID     = "orgID"
 )
 
 // SetExtent will change the upstream request query to use the provided Extent
 func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	if isFluxRequest(trq.TemplateURL) {
+		setFluxExtent(r, trq, extent)
+		return
+	}
 	v, _, _ := params.GetRequestValues(r)
 	// the TemplateURL in the TimeRangeQuery will always have URL Query Params, even for POSTs
 	// For POST, ParseTimeRangeQuery extracts the params from the original request body and
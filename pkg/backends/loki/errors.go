@@ -0,0 +1,29 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import "errors"
+
+// ErrLogQuery indicates the query is a log query, whose streams results are not
+// supported by the Delta Proxy Cache
+var ErrLogQuery = errors.New("log queries are not time series queries")
+
+// ErrInvalidTime indicates a start, end or time value could not be parsed
+var ErrInvalidTime = errors.New("invalid time value")
+
+// ErrInvalidStep indicates a step value is zero or negative
+var ErrInvalidStep = errors.New("step must be greater than zero")
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package loki
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+func TestLabelsHandler(t *testing.T) {
+
+	client := &Client{name: "test"}
+	ts, w, r, hc, err := tu.NewTestInstance("",
+		client.DefaultPathConfigs, 200, "{}", nil, "loki",
+		"/loki/api/v1/labels?start=100&end=110",
+		"debug")
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(ts.URL)
+	defer ts.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	pc, ok := client.config.Paths[APIPath+mnLabels]
+	if !ok {
+		t.Errorf("could not find path config named %s", mnLabels)
+	}
+	rsc.PathConfig = pc
+
+	client.LabelsHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", resp.StatusCode)
+	}
+	if v := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=kmiss") {
+		t.Errorf("expected kmiss got %s", v)
+	}
+
+	// a request in the same minute should be a hit
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", ts.URL+
+		"/loki/api/v1/labels?start=119&end=118", nil).WithContext(r.Context())
+
+	client.LabelsHandler(w, r)
+	resp = w.Result()
+	if v := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=hit") {
+		t.Errorf("expected hit got %s", v)
+	}
+
+	// with normalization disabled, the same request should be a miss
+	pc = pc.Clone()
+	pc.TimeNormalization = 0
+	rsc.PathConfig = pc
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", ts.URL+
+		"/loki/api/v1/labels?start=119&end=118", nil).WithContext(r.Context())
+
+	client.LabelsHandler(w, r)
+	resp = w.Result()
+	if v := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=kmiss") {
+		t.Errorf("expected kmiss got %s", v)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import (
+	"net/http"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+)
+
+// QueryRangeHandler handles timeseries requests for Loki and processes them through
+// the delta proxy cache. Log queries, which return streams, are object cached by the
+// delta proxy cache over time-normalized windows, so their start and end times are
+// rounded down to the path's normalization granularity before they are proxied
+func (c *Client) QueryRangeHandler(w http.ResponseWriter, r *http.Request) {
+	r.URL = urls.BuildUpstreamURL(r, c.baseUpstreamURL)
+	qp, _, _ := params.GetRequestValues(r)
+	if isLogQuery(qp.Get(upQuery)) {
+		normalizeTimeParams(qp, timeNormalization(r, defaultLogTimeNormalization), upStart, upEnd)
+		params.SetRequestValues(r, qp)
+	}
+	engines.DeltaProxyCacheRequest(w, r, c.modeler)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/loki/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+// newTestLoki returns a server that emulates the Loki query_range API, returning a
+// matrix with a datapoint at each step in the requested range for metric queries and
+// a single stream for log queries, and the list of requests it received
+func newTestLoki() (*httptest.Server, *[]url.Values) {
+	reqs := make([]url.Values, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		reqs = append(reqs, r.Form)
+		w.Header().Set("Content-Type", "application/json")
+		start, _ := parseTime(r.Form.Get(upStart))
+		end, _ := parseTime(r.Form.Get(upEnd))
+		if isLogQuery(r.Form.Get(upQuery)) {
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"streams","result":`+
+				`[{"stream":{"job":"a"},"values":[["%d","line"]]}]}}`, end.UnixNano())
+			return
+		}
+		step, _ := parseDuration(r.Form.Get(upStep))
+		sb := strings.Builder{}
+		sep := ""
+		for t := start; !t.After(end); t = t.Add(step) {
+			sb.WriteString(fmt.Sprintf(`%s[%d,"%d"]`, sep, t.Unix(), t.Unix()%7))
+			sep = ","
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":`+
+			`[{"metric":{"job":"a"},"values":[%s]}],"stats":{}}}`, sb.String())
+	}))
+	return ts, &reqs
+}
+
+func queryRangeQuery(query string, start, end time.Time) string {
+	return url.Values{upQuery: {query}, upStep: {"60"}, upLimit: {"100"},
+		upStart: {strconv.FormatInt(start.UnixNano(), 10)},
+		upEnd:   {strconv.FormatInt(end.UnixNano(), 10)}}.Encode()
+}
+
+func TestQueryRangeHandler(t *testing.T) {
+
+	const query = `sum(rate({job="a"}[1m]))`
+	start := time.Now().Truncate(time.Minute).Add(-time.Hour)
+
+	client := &Client{name: "test", modeler: model.NewModeler()}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs, 200, "", nil, "loki",
+		APIPath+mnQueryRange+"?"+queryRangeQuery(query, start, start.Add(10*time.Minute)), "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	ls, reqs := newTestLoki()
+	defer ls.Close()
+
+	ctx := r.Context()
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(ls.URL)
+
+	client.QueryRangeHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 got %d.", resp.StatusCode)
+	}
+
+	wfd := &model.WFDocument{}
+	if err := json.NewDecoder(resp.Body).Decode(wfd); err != nil {
+		t.Fatal(err)
+	}
+	if len(wfd.Data.Results) != 1 || len(wfd.Data.Results[0].Values) != 11 {
+		t.Fatalf("expected 1 series with %d values got %v", 11, wfd.Data.Results)
+	}
+	if len(*reqs) != 1 {
+		t.Fatalf("expected %d upstream requests got %d", 1, len(*reqs))
+	}
+
+	// extending the range should only request the missing datapoints
+	r = httptest.NewRequest(http.MethodGet, "http://0"+APIPath+mnQueryRange+"?"+
+		queryRangeQuery(query, start, start.Add(15*time.Minute)), nil).WithContext(ctx)
+	w = httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	resp = w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 got %d.", resp.StatusCode)
+	}
+	if v := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=phit") {
+		t.Errorf("expected phit got %s", v)
+	}
+	wfd = &model.WFDocument{}
+	if err := json.NewDecoder(resp.Body).Decode(wfd); err != nil {
+		t.Fatal(err)
+	}
+	if len(wfd.Data.Results) != 1 || len(wfd.Data.Results[0].Values) != 16 {
+		t.Fatalf("expected 1 series with %d values got %v", 16, wfd.Data.Results)
+	}
+	if len(*reqs) != 2 {
+		t.Fatalf("expected %d upstream requests got %d", 2, len(*reqs))
+	}
+	from, _ := parseTime((*reqs)[1].Get(upStart))
+	if from.Before(start.Add(10 * time.Minute)) {
+		t.Errorf("expected delta request to start after %s got %s", start.Add(10*time.Minute), from)
+	}
+}
+
+func TestQueryRangeHandlerLogQuery(t *testing.T) {
+
+	const query = `{job="a"} |= "error"`
+	start := time.Unix(1577836812, 0)
+
+	client := &Client{name: "test", modeler: model.NewModeler()}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs, 200, "", nil, "loki",
+		APIPath+mnQueryRange+"?"+queryRangeQuery(query, start, start.Add(time.Hour)), "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	ls, reqs := newTestLoki()
+	defer ls.Close()
+
+	ctx := r.Context()
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(ls.URL)
+	rsc.PathConfig = client.config.Paths[APIPath+mnQueryRange]
+
+	client.QueryRangeHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 got %d.", resp.StatusCode)
+	}
+	if v := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=kmiss") {
+		t.Errorf("expected kmiss got %s", v)
+	}
+	if len(*reqs) != 1 {
+		t.Fatalf("expected %d upstream requests got %d", 1, len(*reqs))
+	}
+	if v := (*reqs)[0].Get(upStart); v != "1577836800000000000" {
+		t.Errorf("expected %s got %s", "1577836800000000000", v)
+	}
+
+	// a request in the same normalized window should be a hit
+	r = httptest.NewRequest(http.MethodGet, "http://0"+APIPath+mnQueryRange+"?"+
+		queryRangeQuery(query, start.Add(30*time.Second), start.Add(time.Hour+30*time.Second)),
+		nil).WithContext(ctx)
+	w = httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	resp = w.Result()
+	if v := resp.Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=hit") {
+		t.Errorf("expected hit got %s", v)
+	}
+	if len(*reqs) != 1 {
+		t.Errorf("expected %d upstream requests got %d", 1, len(*reqs))
+	}
+	if !strings.Contains(w.Body.String(), `"streams"`) {
+		t.Errorf("expected streams result got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package loki provides the Grafana Loki Backend provider
+package loki
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/proxy"
+	"github.com/tricksterproxy/trickster/pkg/proxy/errors"
+	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	tt "github.com/tricksterproxy/trickster/pkg/util/timeconv"
+)
+
+var _ backends.Client = (*Client)(nil)
+
+// Loki API
+const (
+	APIPath      = "/loki/api/v1/"
+	mnQueryRange = "query_range"
+	mnQuery      = "query"
+	mnLabels     = "labels"
+	mnLabel      = "label"
+	mnSeries     = "series"
+)
+
+// Common URL Parameter Names
+const (
+	upQuery     = "query"
+	upStart     = "start"
+	upEnd       = "end"
+	upStep      = "step"
+	upTime      = "time"
+	upDirection = "direction"
+	upLimit     = "limit"
+	upInterval  = "interval"
+	upMatch     = "match[]"
+)
+
+// defaultQueryRange is the range queried by Loki when a request does not provide a start time
+const defaultQueryRange = time.Hour
+
+// Client Implements Proxy Client Interface
+type Client struct {
+	name               string
+	config             *oo.Options
+	cache              cache.Cache
+	webClient          *http.Client
+	handlers           map[string]http.Handler
+	handlersRegistered bool
+	baseUpstreamURL    *url.URL
+	healthURL          *url.URL
+	healthHeaders      http.Header
+	healthMethod       string
+	router             http.Handler
+	modeler            *timeseries.Modeler
+}
+
+// NewClient returns a new Client Instance
+func NewClient(name string, oc *oo.Options, router http.Handler,
+	cache cache.Cache, modeler *timeseries.Modeler) (backends.Client, error) {
+	c, err := proxy.NewHTTPClient(oc)
+	bur := urls.FromParts(oc.Scheme, oc.Host, oc.PathPrefix, "", "")
+	return &Client{name: name, config: oc, router: router, cache: cache,
+		webClient: c, baseUpstreamURL: bur, modeler: modeler}, err
+}
+
+// SetCache sets the Cache object the client will use for caching origin content
+func (c *Client) SetCache(cc cache.Cache) {
+	c.cache = cc
+}
+
+// Configuration returns the upstream Configuration for this Client
+func (c *Client) Configuration() *oo.Options {
+	return c.config
+}
+
+// HTTPClient returns the HTTP Client for this Backend
+func (c *Client) HTTPClient() *http.Client {
+	return c.webClient
+}
+
+// Name returns the name of the upstream Configuration proxied by the Client
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Cache returns and handle to the Cache instance used by the Client
+func (c *Client) Cache() cache.Cache {
+	return c.cache
+}
+
+// Router returns the http.Handler that handles request routing for this Client
+func (c *Client) Router() http.Handler {
+	return c.router
+}
+
+// parseTime converts a Loki time URL parameter to time.Time. Integer values are
+// nanosecond epochs, unless they have 10 or fewer digits, in which case they are
+// second epochs. Decimal values are second epochs, and RFC3339 values are supported.
+func parseTime(s string) (time.Time, error) {
+	if strings.Contains(s, ".") {
+		if t, err := strconv.ParseFloat(s, 64); err == nil {
+			s, ns := math.Modf(t)
+			ns = math.Round(ns*1000) / 1000
+			return time.Unix(int64(s), int64(ns*float64(time.Second))), nil
+		}
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if len(s) <= 10 {
+			return time.Unix(i, 0), nil
+		}
+		return time.Unix(0, i), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, ErrInvalidTime
+}
+
+// parseDuration parses Loki step parameters, which can be durations like 1m, 5s, etc.,
+// or float64 values in seconds
+func parseDuration(input string) (time.Duration, error) {
+	v, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return tt.ParseDuration(input)
+	}
+	return time.Duration(v * float64(time.Second)), nil
+}
+
+// defaultStep returns the step used by Loki when a range query does not provide one,
+// which targets 250 datapoints across the range, with a minimum of one second
+func defaultStep(e timeseries.Extent) time.Duration {
+	return time.Duration(math.Max(math.Floor(e.End.Sub(e.Start).Seconds()/250), 1)) * time.Second
+}
+
+// formatStep returns the step as a string of seconds
+func formatStep(step time.Duration) string {
+	return strconv.FormatFloat(step.Seconds(), 'f', -1, 64)
+}
+
+// isLogQuery returns true if the LogQL statement is a log query, which begins with a
+// stream selector and returns streams, rather than a metric query, which returns a matrix
+func isLogQuery(statement string) bool {
+	return strings.HasPrefix(strings.TrimSpace(statement), "{")
+}
+
+// ParseTimeRangeQuery parses the key parts of a TimeRangeQuery from the inbound HTTP Request
+func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
+	rlo := &timeseries.RequestOptions{}
+
+	qp, _, _ := params.GetRequestValues(r)
+
+	trq.Statement = qp.Get(upQuery)
+	if trq.Statement == "" {
+		return nil, nil, false, errors.MissingURLParam(upQuery)
+	}
+
+	// log queries return streams, which can't be delta cached, so they are object
+	// cached over time-normalized windows instead
+	if isLogQuery(trq.Statement) {
+		return objectQuery(r, qp), nil, true, ErrLogQuery
+	}
+
+	trq.Extent.End = time.Now()
+	if p := qp.Get(upEnd); p != "" {
+		t, err := parseTime(p)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		trq.Extent.End = t
+	}
+
+	trq.Extent.Start = trq.Extent.End.Add(-defaultQueryRange)
+	if p := qp.Get(upStart); p != "" {
+		t, err := parseTime(p)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		trq.Extent.Start = t
+	}
+
+	if trq.Extent.End.Before(trq.Extent.Start) {
+		return nil, nil, false, timeseries.ErrInvalidExtent
+	}
+
+	if p := qp.Get(upStep); p != "" {
+		step, err := parseDuration(p)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		trq.Step = step
+	} else {
+		trq.Step = defaultStep(trq.Extent)
+	}
+	if trq.Step <= 0 {
+		return nil, nil, false, ErrInvalidStep
+	}
+
+	rlo.ExtractFastForwardDisabled(trq.Statement)
+	trq.ExtractBackfillTolerance(trq.Statement)
+
+	if strings.Contains(trq.Statement, " offset ") {
+		trq.IsOffset = true
+		rlo.FastForwardDisable = true
+	}
+
+	// the TemplateURL will always have URL Query Params, even for POSTs, and excludes
+	// the time range parameters, since they are set by SetExtent. The step is always
+	// included, so that requests relying on Loki's default step share a cache key
+	// only when their steps match
+	trq.TemplateURL = urls.Clone(r.URL)
+	qt := url.Values(http.Header(qp).Clone())
+	qt.Del(upStart)
+	qt.Del(upEnd)
+	qt.Set(upStep, formatStep(trq.Step))
+	trq.TemplateURL.RawQuery = qt.Encode()
+
+	return trq, rlo, false, nil
+}
+
+// objectQuery returns a TimeRangeQuery for a log query, whose TemplateURL carries the
+// query with time-normalized start and end values for use in cache key derivation
+func objectQuery(r *http.Request, qp url.Values) *timeseries.TimeRangeQuery {
+	trq := &timeseries.TimeRangeQuery{Statement: qp.Get(upQuery), TemplateURL: urls.Clone(r.URL)}
+	qt := url.Values(http.Header(qp).Clone())
+	normalizeTimeParams(qt, timeNormalization(r, defaultLogTimeNormalization), upStart, upEnd)
+	trq.TemplateURL.RawQuery = qt.Encode()
+	return trq
+}
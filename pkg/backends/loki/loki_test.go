@@ -0,0 +1,291 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/loki/model"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	cr "github.com/tricksterproxy/trickster/pkg/cache/registration"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+var testModeler = model.NewModeler()
+
+func TestLokiClientInterfacing(t *testing.T) {
+
+	// this test ensures the client will properly conform to the
+	// Client and TimeseriesClient interfaces
+
+	c := &Client{name: "test"}
+	var oc backends.Client = c
+	var tc backends.TimeseriesClient = c
+
+	if oc.Name() != "test" {
+		t.Errorf("expected %s got %s", "test", oc.Name())
+	}
+
+	if tc.Name() != "test" {
+		t.Errorf("expected %s got %s", "test", tc.Name())
+	}
+}
+
+func TestNewClient(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test", []string{"-origin-url", "http://1", "-provider", "test"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := cr.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer cr.CloseCaches(caches)
+	cache, ok := caches["default"]
+	if !ok {
+		t.Errorf("Could not find default configuration")
+	}
+
+	oc := &oo.Options{Provider: "TEST_CLIENT"}
+	c, err := NewClient("default", oc, nil, cache, testModeler)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if c.Name() != "default" {
+		t.Errorf("expected %s got %s", "default", c.Name())
+	}
+
+	if c.Cache().Configuration().Provider != "memory" {
+		t.Errorf("expected %s got %s", "memory", c.Cache().Configuration().Provider)
+	}
+
+	if c.Configuration().Provider != "TEST_CLIENT" {
+		t.Errorf("expected %s got %s", "TEST_CLIENT", c.Configuration().Provider)
+	}
+}
+
+func TestClientAccessors(t *testing.T) {
+
+	c := &Client{name: "test", webClient: &http.Client{}}
+	c.SetCache(nil)
+	if c.Cache() != nil {
+		t.Error("expected nil cache")
+	}
+	if c.HTTPClient() == nil {
+		t.Error("expected non-nil http client")
+	}
+	if c.Router() != nil {
+		t.Error("expected nil router")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+
+	tests := []struct {
+		s        string
+		expected time.Time
+		err      error
+	}{
+		{"1577836800", time.Unix(1577836800, 0), nil},
+		{"1577836800123456789", time.Unix(0, 1577836800123456789), nil},
+		{"1577836800.5", time.Unix(1577836800, 500000000), nil},
+		{"2020-01-01T00:00:00Z", time.Unix(1577836800, 0), nil},
+		{"invalid", time.Time{}, ErrInvalidTime},
+	}
+
+	for i, test := range tests {
+		v, err := parseTime(test.s)
+		if err != test.err {
+			t.Errorf("test %d: expected %v got %v", i, test.err, err)
+		}
+		if !v.Equal(test.expected) {
+			t.Errorf("test %d: expected %s got %s", i, test.expected, v)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+
+	tests := []struct {
+		s        string
+		expected time.Duration
+		hasErr   bool
+	}{
+		{"15", 15 * time.Second, false},
+		{"0.5", 500 * time.Millisecond, false},
+		{"1m", time.Minute, false},
+		{"invalid", 0, true},
+	}
+
+	for i, test := range tests {
+		v, err := parseDuration(test.s)
+		if (err != nil) != test.hasErr {
+			t.Errorf("test %d: unexpected error %v", i, err)
+		}
+		if v != test.expected {
+			t.Errorf("test %d: expected %s got %s", i, test.expected, v)
+		}
+	}
+}
+
+func TestDefaultStep(t *testing.T) {
+	start := time.Unix(1577836800, 0)
+	if v := defaultStep(timeseries.Extent{Start: start, End: start.Add(time.Minute)}); v != time.Second {
+		t.Errorf("expected %s got %s", time.Second, v)
+	}
+	if v := defaultStep(timeseries.Extent{Start: start, End: start.Add(time.Hour)}); v != 14*time.Second {
+		t.Errorf("expected %s got %s", 14*time.Second, v)
+	}
+}
+
+func TestIsLogQuery(t *testing.T) {
+	if !isLogQuery(` {job="a"} |= "error"`) {
+		t.Error("expected true")
+	}
+	if isLogQuery(`sum(rate({job="a"}[1m]))`) {
+		t.Error("expected false")
+	}
+}
+
+func TestParseTimeRangeQuery(t *testing.T) {
+
+	client := &Client{name: "test"}
+
+	r := httptest.NewRequest(http.MethodGet, "http://0/loki/api/v1/query_range?"+url.Values{
+		upQuery: {`rate({job="a"}[1m])`},
+		upStart: {"1577836800000000000"},
+		upEnd:   {"1577840400000000000"},
+		upStep:  {"60"},
+		upLimit: {"100"},
+	}.Encode(), nil)
+
+	trq, rlo, canOPC, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canOPC {
+		t.Error("expected false")
+	}
+	if rlo.FastForwardDisable {
+		t.Error("expected fast forward to be enabled")
+	}
+	if trq.Step != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, trq.Step)
+	}
+	if trq.Extent.Start.Unix() != 1577836800 || trq.Extent.End.Unix() != 1577840400 {
+		t.Errorf("unexpected extent %s", trq.Extent.String())
+	}
+	qt := trq.TemplateURL.Query()
+	if qt.Get(upStart) != "" || qt.Get(upEnd) != "" {
+		t.Errorf("unexpected template url query %s", trq.TemplateURL.RawQuery)
+	}
+	if qt.Get(upLimit) != "100" {
+		t.Errorf("expected %s got %s", "100", qt.Get(upLimit))
+	}
+
+	// POST with an offset, default times and the default step
+	body := url.Values{upQuery: {`rate({job="a"}[1m] offset 1h)`}}.Encode()
+	r = httptest.NewRequest(http.MethodPost, "http://0/loki/api/v1/query_range", strings.NewReader(body))
+	r.Header.Set(headers.NameContentType, headers.ValueXFormURLEncoded)
+	trq, rlo, _, err = client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !trq.IsOffset || !rlo.FastForwardDisable {
+		t.Error("expected offset query with fast forward disabled")
+	}
+	if d := trq.Extent.End.Sub(trq.Extent.Start); d != defaultQueryRange {
+		t.Errorf("expected %s got %s", defaultQueryRange, d)
+	}
+	if trq.Step != 14*time.Second {
+		t.Errorf("expected %s got %s", 14*time.Second, trq.Step)
+	}
+	if v := trq.TemplateURL.Query().Get(upStep); v != "14" {
+		t.Errorf("expected %s got %s", "14", v)
+	}
+}
+
+func TestParseTimeRangeQueryLogQuery(t *testing.T) {
+
+	client := &Client{name: "test"}
+
+	r := httptest.NewRequest(http.MethodGet, "http://0/loki/api/v1/query_range?"+url.Values{
+		upQuery: {`{job="a"} |= "error"`},
+		upStart: {"1577836812000000000"},
+		upEnd:   {"1577840412000000000"},
+	}.Encode(), nil)
+
+	trq, _, canOPC, err := client.ParseTimeRangeQuery(r)
+	if err != ErrLogQuery {
+		t.Errorf("expected %v got %v", ErrLogQuery, err)
+	}
+	if !canOPC {
+		t.Error("expected true")
+	}
+	if trq == nil || trq.TemplateURL == nil {
+		t.Fatal("expected template url")
+	}
+	qt := trq.TemplateURL.Query()
+	if v := qt.Get(upStart); v != "1577836800000000000" {
+		t.Errorf("expected %s got %s", "1577836800000000000", v)
+	}
+	if v := qt.Get(upEnd); v != "1577840400000000000" {
+		t.Errorf("expected %s got %s", "1577840400000000000", v)
+	}
+}
+
+func TestParseTimeRangeQueryErrors(t *testing.T) {
+
+	client := &Client{name: "test"}
+
+	tests := []struct {
+		v   url.Values
+		err error
+	}{
+		{url.Values{upStart: {"1"}}, nil},
+		{url.Values{upQuery: {"rate({a=\"b\"}[1m])"}, upStart: {"invalid"}}, ErrInvalidTime},
+		{url.Values{upQuery: {"rate({a=\"b\"}[1m])"}, upEnd: {"invalid"}}, ErrInvalidTime},
+		{url.Values{upQuery: {"rate({a=\"b\"}[1m])"}, upStart: {"20"}, upEnd: {"10"}},
+			timeseries.ErrInvalidExtent},
+		{url.Values{upQuery: {"rate({a=\"b\"}[1m])"}, upStep: {"invalid"}}, nil},
+		{url.Values{upQuery: {"rate({a=\"b\"}[1m])"}, upStep: {"-1"}}, ErrInvalidStep},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://0/loki/api/v1/query_range?"+test.v.Encode(), nil)
+		_, _, canOPC, err := client.ParseTimeRangeQuery(r)
+		if err == nil {
+			t.Errorf("test %d: expected error", i)
+			continue
+		}
+		if test.err != nil && err != test.err {
+			t.Errorf("test %d: expected %s got %s", i, test.err, err)
+		}
+		if canOPC {
+			t.Errorf("test %d: expected false", i)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package model provides the Loki query API's wire format modeling
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	pm "github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
+)
+
+// Loki query result types
+const (
+	resultTypeMatrix = "matrix"
+	resultTypeVector = "vector"
+)
+
+// ErrUnsupportedResultType indicates the query result type can't be modeled as a
+// timeseries, such as the streams returned by log queries
+var ErrUnsupportedResultType = errors.New("unsupported result type")
+
+// WFDocument the Wire Format Document for the timeseries
+type WFDocument struct {
+	Status string `json:"status"`
+	Data   WFData `json:"data"`
+}
+
+// WFData is the data section of the WFD
+type WFData struct {
+	ResultType string     `json:"resultType"`
+	Results    []WFResult `json:"result"`
+}
+
+// WFResult is the Result section of the WFD
+type WFResult struct {
+	Metric dataset.Tags    `json:"metric"`
+	Values [][]interface{} `json:"values"`
+	Value  []interface{}   `json:"value"`
+}
+
+// NewModeler returns a collection of modeling functions for loki interoperability.
+// Loki's matrix results share the Prometheus wire format, so they are marshaled
+// by the Prometheus model
+func NewModeler() *timeseries.Modeler {
+	return &timeseries.Modeler{
+		WireUnmarshalerReader: UnmarshalTimeseriesReader,
+		WireMarshaler:         pm.MarshalTimeseries,
+		WireMarshalWriter:     pm.MarshalTimeseriesWriter,
+		WireUnmarshaler:       UnmarshalTimeseries,
+		CacheMarshaler:        dataset.MarshalDataSet,
+		CacheUnmarshaler:      dataset.UnmarshalDataSet,
+	}
+}
+
+// UnmarshalTimeseries converts a JSON blob into a Timeseries
+func UnmarshalTimeseries(data []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	buf := bytes.NewReader(data)
+	return UnmarshalTimeseriesReader(buf, trq)
+}
+
+// UnmarshalTimeseriesReader converts a JSON blob into a Timeseries via io.Reader
+func UnmarshalTimeseriesReader(reader io.Reader, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if trq == nil {
+		return nil, timeseries.ErrNoTimerangeQuery
+	}
+	wfd := &WFDocument{}
+	d := json.NewDecoder(reader)
+	err := d.Decode(wfd)
+	if err != nil {
+		return nil, err
+	}
+	if wfd.Data.ResultType != resultTypeMatrix && wfd.Data.ResultType != resultTypeVector {
+		return nil, ErrUnsupportedResultType
+	}
+	ds := &dataset.DataSet{
+		Status:         wfd.Status,
+		Results:        []*dataset.Result{{}},
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+	}
+	ds.Results[0].SeriesList = make([]*dataset.Series, len(wfd.Data.Results))
+
+	for i, lr := range wfd.Data.Results {
+		sh := dataset.SeriesHeader{
+			Tags:           lr.Metric,
+			QueryStatement: trq.Statement,
+			FieldsList: []timeseries.FieldDefinition{
+				{Name: "value", DataType: timeseries.String},
+			},
+		}
+		if n, ok := lr.Metric["__name__"]; ok {
+			sh.Name = n
+		}
+		var pts dataset.Points
+		var ps int64 = 16
+		if wfd.Data.ResultType == resultTypeMatrix {
+			pts = make(dataset.Points, 0, len(lr.Values))
+			for _, v := range lr.Values {
+				pt, err := pointFromValues(v)
+				if err != nil {
+					return nil, err
+				}
+				ps += int64(pt.Size)
+				pts = append(pts, pt)
+			}
+		} else if len(lr.Value) == 2 {
+			pt, err := pointFromValues(lr.Value)
+			if err != nil {
+				return nil, err
+			}
+			pts = dataset.Points{pt}
+			ps = int64(pt.Size)
+			t := time.Unix(0, int64(pt.Epoch))
+			ds.ExtentList = timeseries.ExtentList{timeseries.Extent{Start: t, End: t}}
+		}
+		sh.CalculateSize()
+		ds.Results[0].SeriesList[i] = &dataset.Series{
+			Header:    sh,
+			Points:    pts,
+			PointSize: ps,
+		}
+	}
+	return ds, nil
+}
+
+func pointFromValues(v []interface{}) (dataset.Point, error) {
+	if len(v) != 2 {
+		return dataset.Point{}, timeseries.ErrInvalidBody
+	}
+	var f1 float64
+	var s string
+	var ok bool
+	if f1, ok = v[0].(float64); !ok {
+		return dataset.Point{}, timeseries.ErrInvalidBody
+	}
+	if s, ok = v[1].(string); !ok {
+		return dataset.Point{}, timeseries.ErrInvalidBody
+	}
+	return dataset.Point{
+		Epoch:  epoch.Epoch(math.Round(f1*1000)) * 1000000,
+		Size:   len(s) + 32, // 8 bytes for epoch, 8 bytes for size, 16 bytes for s stringHeader
+		Values: []interface{}{s},
+	}, nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+)
+
+const testDoc = `{"status":"success","data":{"resultType":"matrix","result":[{` +
+	`"metric":{"job":"a","level":"error"},"values"` +
+	`:[[1577836800,"1"],[1577836815,"1.5"],[1577836830,"2"]]},{"metric":` +
+	`{"job":"b","level":"error"},"values":` +
+	`[[1577836800,"0"],[1577836815,"0"],[1577836830,"1"]]}]}}`
+
+func TestNewModeler(t *testing.T) {
+	m := NewModeler()
+	if m.WireUnmarshaler == nil || m.WireMarshaler == nil ||
+		m.WireUnmarshalerReader == nil || m.WireMarshalWriter == nil {
+		t.Error("expected non-nil modeler functions")
+	}
+}
+
+func TestUnmarshalTimeseries(t *testing.T) {
+
+	b := []byte(`{"status":"success","data":{"resultType":"matrix","result":[{` +
+		`"metric":{"job":"a","level":"error"},"values"` +
+		`:[[1577836800,"1"],[1577836815,"1.5"],[1577836830,"2"]]},{"metric":` +
+		`{"job":"b","level":"error"},"values":` +
+		`[[1577836800,"0"],[1577836815,"0"],[1577836830,"1"]]}],` +
+		`"stats":{"summary":{"bytesProcessedPerSecond":1}}}}`)
+	trq := &timeseries.TimeRangeQuery{Statement: `rate({job=~"a|b"}[1m])`}
+	ts, err := UnmarshalTimeseries(b, trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok {
+		t.Fatal(timeseries.ErrUnknownFormat)
+	}
+	if ds.SeriesCount() != 2 {
+		t.Errorf("expected %d got %d", 2, ds.SeriesCount())
+	}
+	if ds.ValueCount() != 6 {
+		t.Errorf("expected %d got %d", 6, ds.ValueCount())
+	}
+
+	m := NewModeler()
+	b, err = m.WireMarshaler(ds, nil, 200)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != testDoc {
+		t.Errorf("expected %s got %s", testDoc, string(b))
+	}
+}
+
+func TestUnmarshalInstantaneous(t *testing.T) {
+	trq := &timeseries.TimeRangeQuery{}
+	b := []byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+		`{"metric":{"job":"a"},"value":[1577836800.5,"3"]}]}}`)
+	ts, err := UnmarshalTimeseries(b, trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := ts.Extents()
+	if len(x) != 1 || x[0].Start.UnixNano() != 1577836800500000000 {
+		t.Errorf("unexpected extents %v", x)
+	}
+}
+
+func TestUnmarshalTimeseriesErrors(t *testing.T) {
+
+	tests := []struct {
+		body string
+		trq  *timeseries.TimeRangeQuery
+		err  error
+	}{
+		{testDoc, nil, timeseries.ErrNoTimerangeQuery},
+		{`{"status":"success","data":{"resultType":"streams","result":` +
+			`[{"stream":{"job":"a"},"values":[["1577836800000000000","line"]]}]}}`,
+			&timeseries.TimeRangeQuery{}, ErrUnsupportedResultType},
+		{`{"status":"success","data":{"resultType":"matrix","result":` +
+			`[{"metric":{"job":"a"},"values":[["1577836800","1"]]}]}}`,
+			&timeseries.TimeRangeQuery{}, timeseries.ErrInvalidBody},
+		{`{"status":"success","data":{"resultType":"matrix","result":` +
+			`[{"metric":{"job":"a"},"values":[[1577836800,1]]}]}}`,
+			&timeseries.TimeRangeQuery{}, timeseries.ErrInvalidBody},
+		{`{"status":"success","data":{"resultType":"vector","result":` +
+			`[{"metric":{"job":"a"},"value":[1577836800]}]}}`,
+			&timeseries.TimeRangeQuery{}, nil},
+		{`{"status":"success","data":{"resultType":"vector","result":` +
+			`[{"metric":{"job":"a"},"value":["x","1"]}]}}`,
+			&timeseries.TimeRangeQuery{}, timeseries.ErrInvalidBody},
+	}
+
+	for i, test := range tests {
+		_, err := UnmarshalTimeseries([]byte(test.body), test.trq)
+		if err != test.err {
+			t.Errorf("test %d: expected %v got %v", i, test.err, err)
+		}
+	}
+
+	if _, err := UnmarshalTimeseries([]byte("{"), &timeseries.TimeRangeQuery{}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+)
+
+func (c *Client) registerHandlers() {
+	c.handlersRegistered = true
+	c.handlers = make(map[string]http.Handler)
+	// This is the registry of handlers that Trickster supports for Loki,
+	// and are able to be referenced by name (map key) in Config Files
+	c.handlers["health"] = http.HandlerFunc(c.HealthHandler)
+	c.handlers[mnQueryRange] = http.HandlerFunc(c.QueryRangeHandler)
+	c.handlers[mnQuery] = http.HandlerFunc(c.QueryHandler)
+	c.handlers[mnSeries] = http.HandlerFunc(c.SeriesHandler)
+	c.handlers[mnLabels] = http.HandlerFunc(c.LabelsHandler)
+	c.handlers["proxy"] = http.HandlerFunc(c.ProxyHandler)
+}
+
+// Handlers returns a map of the HTTP Handlers the client has registered
+func (c *Client) Handlers() map[string]http.Handler {
+	if !c.handlersRegistered {
+		c.registerHandlers()
+	}
+	return c.handlers
+}
+
+func populateHeathCheckRequestValues(oc *oo.Options) {
+	if oc.HealthCheckUpstreamPath == "-" {
+		oc.HealthCheckUpstreamPath = "/ready"
+	}
+	if oc.HealthCheckVerb == "-" {
+		oc.HealthCheckVerb = http.MethodGet
+	}
+	if oc.HealthCheckQuery == "-" {
+		oc.HealthCheckQuery = ""
+	}
+}
+
+// DefaultPathConfigs returns the default PathConfigs for the given Provider
+func (c *Client) DefaultPathConfigs(oc *oo.Options) map[string]*po.Options {
+
+	populateHeathCheckRequestValues(oc)
+
+	var rhts map[string]string
+	if oc != nil {
+		rhts = map[string]string{
+			headers.NameCacheControl: fmt.Sprintf("%s=%d", headers.ValueSharedMaxAge, oc.TimeseriesTTLMS/1000)}
+	}
+	rhinst := map[string]string{
+		headers.NameCacheControl: fmt.Sprintf("%s=%d", headers.ValueSharedMaxAge, 30)}
+
+	paths := map[string]*po.Options{
+
+		// start and end are only included in the cache keys of log queries, since
+		// metric queries are delta cached and exclude them from the key
+		APIPath + mnQueryRange: {
+			Path:            APIPath + mnQueryRange,
+			HandlerName:     mnQueryRange,
+			Methods:         []string{http.MethodGet, http.MethodPost},
+			CacheKeyParams:  []string{upQuery, upStep, upDirection, upLimit, upInterval, upStart, upEnd},
+			CacheKeyHeaders: []string{},
+			ResponseHeaders: rhts,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultLogTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultLogTimeNormalization,
+			HasTimeNormalization: true,
+		},
+
+		APIPath + mnQuery: {
+			Path:            APIPath + mnQuery,
+			HandlerName:     mnQuery,
+			Methods:         []string{http.MethodGet, http.MethodPost},
+			CacheKeyParams:  []string{upQuery, upTime, upDirection, upLimit},
+			CacheKeyHeaders: []string{},
+			ResponseHeaders: rhinst,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultQueryTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultQueryTimeNormalization,
+			HasTimeNormalization: true,
+		},
+
+		APIPath + mnSeries: {
+			Path:            APIPath + mnSeries,
+			HandlerName:     mnSeries,
+			Methods:         []string{http.MethodGet, http.MethodPost},
+			CacheKeyParams:  []string{upMatch, upStart, upEnd},
+			CacheKeyHeaders: []string{},
+			ResponseHeaders: rhinst,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultMetadataTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultMetadataTimeNormalization,
+			HasTimeNormalization: true,
+		},
+
+		APIPath + mnLabels: {
+			Path:            APIPath + mnLabels,
+			HandlerName:     mnLabels,
+			Methods:         []string{http.MethodGet, http.MethodPost},
+			CacheKeyParams:  []string{upQuery, upStart, upEnd},
+			CacheKeyHeaders: []string{},
+			ResponseHeaders: rhinst,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultMetadataTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultMetadataTimeNormalization,
+			HasTimeNormalization: true,
+		},
+
+		APIPath + mnLabel + "/": {
+			Path:            APIPath + mnLabel + "/",
+			HandlerName:     mnLabels,
+			Methods:         []string{http.MethodGet},
+			CacheKeyParams:  []string{upQuery, upStart, upEnd},
+			CacheKeyHeaders: []string{},
+			MatchTypeName:   "prefix",
+			MatchType:       matching.PathMatchTypePrefix,
+			ResponseHeaders: rhinst,
+
+			TimeNormalizationMS:  int(defaultMetadataTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultMetadataTimeNormalization,
+			HasTimeNormalization: true,
+		},
+
+		APIPath: {
+			Path:          APIPath,
+			HandlerName:   "proxy",
+			Methods:       []string{http.MethodGet, http.MethodPost},
+			MatchType:     matching.PathMatchTypePrefix,
+			MatchTypeName: "prefix",
+		},
+
+		"/": {
+			Path:          "/",
+			HandlerName:   "proxy",
+			Methods:       []string{http.MethodGet, http.MethodPost},
+			MatchType:     matching.PathMatchTypePrefix,
+			MatchTypeName: "prefix",
+		},
+	}
+
+	oc.FastForwardPath = paths[APIPath+mnQuery].Clone()
+
+	return paths
+
+}
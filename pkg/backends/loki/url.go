@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+// default granularities to which time parameters are normalized when the path
+// does not configure time_normalization_ms
+const (
+	defaultQueryTimeNormalization    = 15 * time.Second
+	defaultLogTimeNormalization      = time.Minute
+	defaultMetadataTimeNormalization = time.Minute
+)
+
+// SetExtent will change the upstream request query to use the provided Extent
+func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	v, _, _ := params.GetRequestValues(r)
+	v.Set(upStart, strconv.FormatInt(extent.Start.UnixNano(), 10))
+	v.Set(upEnd, strconv.FormatInt(extent.End.UnixNano(), 10))
+	v.Set(upStep, formatStep(trq.Step))
+	params.SetRequestValues(r, v)
+}
+
+// FastForwardRequest returns an *http.Request crafted to collect Fast Forward
+// data from the Origin, based on the provided HTTP Request
+func (c *Client) FastForwardRequest(r *http.Request) (*http.Request, error) {
+	nr := r.Clone(context.Background())
+	if strings.HasSuffix(nr.URL.Path, "/query_range") {
+		nr.URL.Path = nr.URL.Path[0 : len(nr.URL.Path)-6]
+	}
+	v, _, _ := params.GetRequestValues(nr)
+	v.Del(upStart)
+	v.Del(upEnd)
+	v.Del(upStep)
+	v.Del(upInterval)
+	params.SetRequestValues(nr, v)
+	return nr, nil
+}
+
+// timeNormalization returns the granularity to which the request's time parameters
+// should be normalized, using the provided default when the path does not set one
+func timeNormalization(r *http.Request, def time.Duration) time.Duration {
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.PathConfig == nil || !rsc.PathConfig.HasTimeNormalization {
+		return def
+	}
+	return rsc.PathConfig.TimeNormalization
+}
+
+// normalizeTimeParams rounds the named time parameters down to the provided
+// granularity, so that requests made within the same window share a cache key.
+// Normalized values are nanosecond epochs, and values that cannot be parsed as
+// a timestamp are left unmodified.
+func normalizeTimeParams(qp url.Values, granularity time.Duration, names ...string) {
+	if granularity <= 0 {
+		return
+	}
+	for _, name := range names {
+		p := qp.Get(name)
+		if p == "" {
+			continue
+		}
+		t, err := parseTime(p)
+		if err != nil {
+			continue
+		}
+		qp.Set(name, strconv.FormatInt(t.Truncate(granularity).UnixNano(), 10))
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loki
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+func TestSetExtent(t *testing.T) {
+
+	start := time.Unix(1577836800, 0)
+	end := start.Add(time.Hour)
+
+	client := &Client{}
+	trq := &timeseries.TimeRangeQuery{Step: 15 * time.Second}
+	e := &timeseries.Extent{Start: start, End: end}
+
+	r, _ := http.NewRequest(http.MethodGet, "http://0/loki/api/v1/query_range?query=up", nil)
+	client.SetExtent(r, trq, e)
+
+	expected := "end=" + strconv.FormatInt(end.UnixNano(), 10) + "&query=up&start=" +
+		strconv.FormatInt(start.UnixNano(), 10) + "&step=15"
+	if r.URL.RawQuery != expected {
+		t.Errorf("\nexpected [%s]\ngot [%s]", expected, r.URL.RawQuery)
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "http://0/loki/api/v1/query_range",
+		bytes.NewBufferString("query=up"))
+	r.Header.Set(headers.NameContentType, headers.ValueXFormURLEncoded)
+	client.SetExtent(r, trq, e)
+	if r.ContentLength != int64(len(expected)) {
+		t.Errorf("expected %d got %d", len(expected), r.ContentLength)
+	}
+}
+
+func TestFastForwardRequest(t *testing.T) {
+
+	client := &Client{}
+	r, _ := http.NewRequest(http.MethodGet,
+		"http://0/loki/api/v1/query_range?query=up&start=1&end=1&step=1&interval=1", nil)
+
+	r2, err := client.FastForwardRequest(r)
+	if err != nil {
+		t.Error(err)
+	}
+	if r2.URL.Path != APIPath+mnQuery {
+		t.Errorf("expected %s got %s", APIPath+mnQuery, r2.URL.Path)
+	}
+	if r2.URL.RawQuery != "query=up" {
+		t.Errorf("expected %s got %s", "query=up", r2.URL.RawQuery)
+	}
+}
+
+func TestNormalizeTimeParams(t *testing.T) {
+
+	qp := url.Values{
+		upStart: {"1577836812345678901"},
+		upEnd:   {"2020-01-01T00:01:30Z"},
+		upTime:  {"1577836812.345"},
+		upStep:  {"not-a-time"},
+	}
+
+	normalizeTimeParams(qp, 0, upStart)
+	if v := qp.Get(upStart); v != "1577836812345678901" {
+		t.Errorf("expected %s got %s", "1577836812345678901", v)
+	}
+
+	normalizeTimeParams(qp, time.Minute, upStart, upEnd, upStep, upQuery)
+	if v := qp.Get(upStart); v != "1577836800000000000" {
+		t.Errorf("expected %s got %s", "1577836800000000000", v)
+	}
+	if v := qp.Get(upEnd); v != "1577836860000000000" {
+		t.Errorf("expected %s got %s", "1577836860000000000", v)
+	}
+	if v := qp.Get(upStep); v != "not-a-time" {
+		t.Errorf("expected %s got %s", "not-a-time", v)
+	}
+
+	normalizeTimeParams(qp, 100*time.Millisecond, upTime)
+	if v := qp.Get(upTime); v != "1577836812300000000" {
+		t.Errorf("expected %s got %s", "1577836812300000000", v)
+	}
+}
+
+func TestTimeNormalization(t *testing.T) {
+
+	r, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/", nil)
+	if d := timeNormalization(r, time.Minute); d != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, d)
+	}
+
+	pc := po.New()
+	r = request.SetResources(r, request.NewResources(nil, pc, nil, nil, nil, nil, nil))
+	if d := timeNormalization(r, time.Minute); d != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, d)
+	}
+
+	pc.HasTimeNormalization = true
+	if d := timeNormalization(r, time.Minute); d != 0 {
+		t.Errorf("expected %s got %s", time.Duration(0), d)
+	}
+}
@@ -25,13 +25,17 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	ao "github.com/tricksterproxy/trickster/pkg/backends/alb/options"
 	ro "github.com/tricksterproxy/trickster/pkg/backends/rule/options"
 	"github.com/tricksterproxy/trickster/pkg/cache/evictionmethods"
 	"github.com/tricksterproxy/trickster/pkg/cache/negative"
 	co "github.com/tricksterproxy/trickster/pkg/cache/options"
 	d "github.com/tricksterproxy/trickster/pkg/config/defaults"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter"
+	rlo "github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter"
 	to "github.com/tricksterproxy/trickster/pkg/proxy/tls/options"
 
@@ -65,6 +69,13 @@ type Options struct {
 	CacheName string `toml:"cache_name"`
 	// CacheKeyPrefix defines the cache key prefix the backend will use when writing objects to the cache
 	CacheKeyPrefix string `toml:"cache_key_prefix"`
+	// TenantHeader, when set, names the request header (e.g., X-Scope-OrgID) that identifies the
+	// tenant of a multi-tenant backend. Requests without a valid tenant are rejected, and each
+	// tenant's objects are cached under a tenant-specific cache key prefix
+	TenantHeader string `toml:"tenant_header"`
+	// TenantCacheKeyPrefixes maps a tenant identifier to the cache key prefix used for its objects,
+	// overriding the default of CacheKeyPrefix + ".tenant." + the tenant identifier
+	TenantCacheKeyPrefixes map[string]string `toml:"tenant_cache_key_prefixes"`
 	// HealthCheckUpstreamPath provides the URL path for the upstream health check
 	HealthCheckUpstreamPath string `toml:"health_check_upstream_path"`
 	// HealthCheckVerb provides the HTTP verb to use when making an upstream health check
@@ -73,6 +84,17 @@ type Options struct {
 	HealthCheckQuery string `toml:"health_check_query"`
 	// HealthCheckHeaders provides the HTTP Headers to apply when making an upstream health check
 	HealthCheckHeaders map[string]string `toml:"health_check_headers"`
+	// HealthCheckIntervalMS is the interval between background upstream health checks
+	HealthCheckIntervalMS int64 `toml:"health_check_interval_ms"`
+	// HealthCheckTimeoutMS defines how long a background upstream health check will wait
+	// for a response before it is considered failed
+	HealthCheckTimeoutMS int64 `toml:"health_check_timeout_ms"`
+	// HealthCheckFailureThreshold is the number of consecutive failed background upstream
+	// health checks required before the backend is considered to be failing
+	HealthCheckFailureThreshold int `toml:"health_check_failure_threshold"`
+	// HealthCheckRecoveryThreshold is the number of consecutive successful background upstream
+	// health checks required before a failing backend is considered to be passing
+	HealthCheckRecoveryThreshold int `toml:"health_check_recovery_threshold"`
 	// Object Proxy Cache and Delta Proxy Cache Configurations
 	// TimeseriesRetentionFactor limits the maximum the number of chronological
 	// timestamps worth of data to store in cache for each query
@@ -84,6 +106,18 @@ type Options struct {
 	// number of seconds from being cached this allows propagation of upstream backfill operations
 	// that modify recently-served data
 	BackfillToleranceMS int64 `toml:"backfill_tolerance_ms"`
+	// TimeseriesChunkFactor, when > 0, stores each cached timeseries in fixed-width time chunks of
+	// this many steps, each under its own cache key, rather than as a single cache object
+	TimeseriesChunkFactor int `toml:"timeseries_chunk_factor"`
+	// MaxUpstreamConcurrency limits the number of concurrent upstream requests made by the
+	// Delta Proxy Cache to fill the gaps of a single client request; 0 is unlimited
+	MaxUpstreamConcurrency int `toml:"max_upstream_concurrency"`
+	// MaxExtentSteps limits the number of timestamps requested by any single Delta Proxy Cache
+	// upstream request, with larger gaps split into several requests; 0 is unlimited
+	MaxExtentSteps int `toml:"max_extent_steps"`
+	// MaxExtentDurationMS limits the time range requested by any single Delta Proxy Cache
+	// upstream request, with larger gaps split into several requests; 0 is unlimited
+	MaxExtentDurationMS int `toml:"max_extent_duration_ms"`
 	// PathList is a list of Path Options that control the behavior of the given paths when requested
 	Paths map[string]*po.Options `toml:"paths"`
 	// NegativeCacheName provides the name of the Negative Cache Config to be used by this Backend
@@ -92,8 +126,17 @@ type Options struct {
 	TimeseriesTTLMS int `toml:"timeseries_ttl_ms"`
 	// TimeseriesTTLMS specifies the cache TTL of fast forward data
 	FastForwardTTLMS int `toml:"fastforward_ttl_ms"`
+	// NonTimeseriesTTLMS specifies the cache TTL of read-only queries to a time series backend
+	// that are not time range queries; a value of 0 disables caching of those queries
+	NonTimeseriesTTLMS int `toml:"non_timeseries_ttl_ms"`
 	// MaxTTLMS specifies the maximum allowed TTL for any cache object
 	MaxTTLMS int `toml:"max_ttl_ms"`
+	// StaleWhileRevalidateMS specifies the default stale-while-revalidate window for cached objects
+	// whose upstream response does not include the Cache-Control directive
+	StaleWhileRevalidateMS int `toml:"stale_while_revalidate_ms"`
+	// StaleIfErrorMS specifies the default stale-if-error window for cached objects
+	// whose upstream response does not include the Cache-Control directive
+	StaleIfErrorMS int `toml:"stale_if_error_ms"`
 	// RevalidationFactor specifies how many times to multiply the object freshness lifetime
 	// by to calculate an absolute cache TTL
 	RevalidationFactor float64 `toml:"revalidation_factor"`
@@ -110,7 +153,16 @@ type Options struct {
 	// ReqRewriterName is the name of a configured Rewriter that will modify the request prior to
 	// processing by the backend client
 	ReqRewriterName string `toml:"req_rewriter_name"`
-
+	// AuthenticatorName is the name of a configured Authenticator that clients must pass before
+	// their requests are processed by the backend client
+	AuthenticatorName string `toml:"authenticator_name"`
+	// RateLimiterName is the name of a configured Rate Limiter that is applied to all requests
+	// to the backend
+	RateLimiterName string `toml:"rate_limiter_name"`
+
+	// ALBOptions holds the load balancing options for the Backend.
+	// This is only effective if the Backend provider is 'alb'
+	ALBOptions *ao.Options `toml:"alb"`
 	// TLS is the TLS Configuration for the Frontend and Backend
 	TLS *to.Options `toml:"tls"`
 
@@ -121,6 +173,10 @@ type Options struct {
 	IsDefault bool `toml:"is_default"`
 	// FastForwardDisable indicates whether the FastForward feature should be disabled for this backend
 	FastForwardDisable bool `toml:"fast_forward_disable"`
+	// InstantQueriesFromRangeCache, when true, allows instant queries to be answered from the Delta
+	// Proxy Cache object of a range query for the same expression, when it covers the requested time.
+	// This is only effective if the Backend provider is 'prometheus'
+	InstantQueriesFromRangeCache bool `toml:"instant_queries_from_range_cache"`
 	// PathRoutingDisabled, when true, will bypass /backendName/path route registrations
 	PathRoutingDisabled bool `toml:"path_routing_disabled"`
 	// RequireTLS, when true, indicates this Backend Config's paths must only be registered with the TLS Router
@@ -143,6 +199,10 @@ type Options struct {
 	Router *mux.Router `toml:"-"`
 	// Timeout is the time.Duration representation of TimeoutMS
 	Timeout time.Duration `toml:"-"`
+	// HealthCheckInterval is the time.Duration representation of HealthCheckIntervalMS
+	HealthCheckInterval time.Duration `toml:"-"`
+	// HealthCheckTimeout is the time.Duration representation of HealthCheckTimeoutMS
+	HealthCheckTimeout time.Duration `toml:"-"`
 	// BackfillTolerance is the time.Duration representation of BackfillToleranceMS
 	BackfillTolerance time.Duration `toml:"-"`
 	// ValueRetention is the time.Duration representation of ValueRetentionSecs
@@ -168,8 +228,16 @@ type Options struct {
 	FastForwardTTL time.Duration `toml:"-"`
 	// FastForwardPath is the paths.Options to use for upstream Fast Forward Requests
 	FastForwardPath *po.Options `toml:"-"`
+	// NonTimeseriesTTL is the parsed value of NonTimeseriesTTLMS
+	NonTimeseriesTTL time.Duration `toml:"-"`
 	// MaxTTL is the parsed value of MaxTTLMS
 	MaxTTL time.Duration `toml:"-"`
+	// StaleWhileRevalidate is the parsed value of StaleWhileRevalidateMS
+	StaleWhileRevalidate time.Duration `toml:"-"`
+	// StaleIfError is the parsed value of StaleIfErrorMS
+	StaleIfError time.Duration `toml:"-"`
+	// MaxExtentDuration is the parsed value of MaxExtentDurationMS
+	MaxExtentDuration time.Duration `toml:"-"`
 	// HTTPClient is the Client used by Trickster to communicate with the origin
 	HTTPClient *http.Client `toml:"-"`
 	// CompressableTypes is the map version of CompressableTypeList for fast lookup
@@ -178,6 +246,10 @@ type Options struct {
 	RuleOptions *ro.Options `toml:"-"`
 	// ReqRewriter is the rewriter handler as indicated by RuleName
 	ReqRewriter rewriter.RewriteInstructions
+	// Authenticator is the frontend authenticator as indicated by AuthenticatorName
+	Authenticator authenticator.Authenticator `toml:"-"`
+	// RateLimiter is the backend's instance of the rate limiter indicated by RateLimiterName
+	RateLimiter *ratelimiter.Limiter `toml:"-"`
 }
 
 // New will return a pointer to an BackendOptions with the default configuration settings
@@ -191,9 +263,15 @@ func New() *Options {
 		FastForwardTTL:               d.DefaultFastForwardTTLMS * time.Millisecond,
 		FastForwardTTLMS:             d.DefaultFastForwardTTLMS,
 		ForwardedHeaders:             d.DefaultForwardedHeaders,
+		HealthCheckFailureThreshold:  d.DefaultHealthCheckFailureThreshold,
 		HealthCheckHeaders:           make(map[string]string),
+		HealthCheckInterval:          d.DefaultHealthCheckIntervalMS * time.Millisecond,
+		HealthCheckIntervalMS:        d.DefaultHealthCheckIntervalMS,
 		HealthCheckQuery:             d.DefaultHealthCheckQuery,
 		HealthCheckUpstreamPath:      d.DefaultHealthCheckPath,
+		HealthCheckRecoveryThreshold: d.DefaultHealthCheckRecoveryThreshold,
+		HealthCheckTimeout:           d.DefaultHealthCheckTimeoutMS * time.Millisecond,
+		HealthCheckTimeoutMS:         d.DefaultHealthCheckTimeoutMS,
 		HealthCheckVerb:              d.DefaultHealthCheckVerb,
 		KeepAliveTimeoutMS:           d.DefaultKeepAliveTimeoutMS,
 		MaxIdleConns:                 d.DefaultMaxIdleConns,
@@ -202,6 +280,8 @@ func New() *Options {
 		MaxTTLMS:                     d.DefaultMaxTTLMS,
 		NegativeCache:                make(map[int]time.Duration),
 		NegativeCacheName:            d.DefaultBackendNegativeCacheName,
+		NonTimeseriesTTL:             d.DefaultNonTimeseriesTTLMS * time.Millisecond,
+		NonTimeseriesTTLMS:           d.DefaultNonTimeseriesTTLMS,
 		Paths:                        make(map[string]*po.Options),
 		RevalidationFactor:           d.DefaultRevalidationFactor,
 		TLS:                          &to.Options{},
@@ -226,13 +306,23 @@ func (oc *Options) Clone() *Options {
 	o.BackfillToleranceMS = oc.BackfillToleranceMS
 	o.CacheName = oc.CacheName
 	o.CacheKeyPrefix = oc.CacheKeyPrefix
+	o.TenantHeader = oc.TenantHeader
 	o.FastForwardDisable = oc.FastForwardDisable
+	o.InstantQueriesFromRangeCache = oc.InstantQueriesFromRangeCache
 	o.FastForwardTTL = oc.FastForwardTTL
 	o.FastForwardTTLMS = oc.FastForwardTTLMS
+	o.NonTimeseriesTTL = oc.NonTimeseriesTTL
+	o.NonTimeseriesTTLMS = oc.NonTimeseriesTTLMS
 	o.ForwardedHeaders = oc.ForwardedHeaders
 	o.HealthCheckUpstreamPath = oc.HealthCheckUpstreamPath
 	o.HealthCheckVerb = oc.HealthCheckVerb
 	o.HealthCheckQuery = oc.HealthCheckQuery
+	o.HealthCheckInterval = oc.HealthCheckInterval
+	o.HealthCheckIntervalMS = oc.HealthCheckIntervalMS
+	o.HealthCheckTimeout = oc.HealthCheckTimeout
+	o.HealthCheckTimeoutMS = oc.HealthCheckTimeoutMS
+	o.HealthCheckFailureThreshold = oc.HealthCheckFailureThreshold
+	o.HealthCheckRecoveryThreshold = oc.HealthCheckRecoveryThreshold
 	o.Host = oc.Host
 	o.Name = oc.Name
 	o.IsDefault = oc.IsDefault
@@ -241,18 +331,31 @@ func (oc *Options) Clone() *Options {
 	o.MaxTTLMS = oc.MaxTTLMS
 	o.MaxTTL = oc.MaxTTL
 	o.MaxObjectSizeBytes = oc.MaxObjectSizeBytes
+	o.MaxExtentDuration = oc.MaxExtentDuration
+	o.MaxExtentDurationMS = oc.MaxExtentDurationMS
+	o.MaxExtentSteps = oc.MaxExtentSteps
+	o.MaxUpstreamConcurrency = oc.MaxUpstreamConcurrency
 	o.MultipartRangesDisabled = oc.MultipartRangesDisabled
 	o.Provider = oc.Provider
 	o.OriginURL = oc.OriginURL
 	o.PathPrefix = oc.PathPrefix
 	o.ReqRewriterName = oc.ReqRewriterName
+	o.AuthenticatorName = oc.AuthenticatorName
+	o.Authenticator = oc.Authenticator
+	o.RateLimiterName = oc.RateLimiterName
+	o.RateLimiter = oc.RateLimiter
 	o.RevalidationFactor = oc.RevalidationFactor
 	o.RuleName = oc.RuleName
 	o.Scheme = oc.Scheme
+	o.StaleIfError = oc.StaleIfError
+	o.StaleIfErrorMS = oc.StaleIfErrorMS
+	o.StaleWhileRevalidate = oc.StaleWhileRevalidate
+	o.StaleWhileRevalidateMS = oc.StaleWhileRevalidateMS
 	o.Timeout = oc.Timeout
 	o.TimeoutMS = oc.TimeoutMS
 	o.TimeseriesRetention = oc.TimeseriesRetention
 	o.TimeseriesRetentionFactor = oc.TimeseriesRetentionFactor
+	o.TimeseriesChunkFactor = oc.TimeseriesChunkFactor
 	o.TimeseriesEvictionMethodName = oc.TimeseriesEvictionMethodName
 	o.TimeseriesEvictionMethod = oc.TimeseriesEvictionMethod
 	o.TimeseriesTTL = oc.TimeseriesTTL
@@ -287,6 +390,13 @@ func (oc *Options) Clone() *Options {
 		o.HealthCheckHeaders = headers.Lookup(oc.HealthCheckHeaders).Clone()
 	}
 
+	if oc.TenantCacheKeyPrefixes != nil {
+		o.TenantCacheKeyPrefixes = make(map[string]string, len(oc.TenantCacheKeyPrefixes))
+		for k, v := range oc.TenantCacheKeyPrefixes {
+			o.TenantCacheKeyPrefixes[k] = v
+		}
+	}
+
 	o.Paths = make(map[string]*po.Options)
 	for l, p := range oc.Paths {
 		o.Paths[l] = p.Clone()
@@ -301,6 +411,10 @@ func (oc *Options) Clone() *Options {
 		o.NegativeCache = m
 	}
 
+	if oc.ALBOptions != nil {
+		o.ALBOptions = oc.ALBOptions.Clone()
+	}
+
 	if oc.TLS != nil {
 		o.TLS = oc.TLS.Clone()
 	}
@@ -338,11 +452,17 @@ func (l Lookup) Validate(ncl negative.Lookups) error {
 		o.Host = url.Host
 		o.PathPrefix = url.Path
 		o.Timeout = time.Duration(o.TimeoutMS) * time.Millisecond
+		o.HealthCheckInterval = time.Duration(o.HealthCheckIntervalMS) * time.Millisecond
+		o.HealthCheckTimeout = time.Duration(o.HealthCheckTimeoutMS) * time.Millisecond
 		o.BackfillTolerance = time.Duration(o.BackfillToleranceMS) * time.Millisecond
 		o.TimeseriesRetention = time.Duration(o.TimeseriesRetentionFactor)
 		o.TimeseriesTTL = time.Duration(o.TimeseriesTTLMS) * time.Millisecond
 		o.FastForwardTTL = time.Duration(o.FastForwardTTLMS) * time.Millisecond
+		o.NonTimeseriesTTL = time.Duration(o.NonTimeseriesTTLMS) * time.Millisecond
 		o.MaxTTL = time.Duration(o.MaxTTLMS) * time.Millisecond
+		o.StaleWhileRevalidate = time.Duration(o.StaleWhileRevalidateMS) * time.Millisecond
+		o.StaleIfError = time.Duration(o.StaleIfErrorMS) * time.Millisecond
+		o.MaxExtentDuration = time.Duration(o.MaxExtentDurationMS) * time.Millisecond
 		if o.CompressableTypeList != nil {
 			o.CompressableTypes = make(map[string]bool)
 			for _, v := range o.CompressableTypeList {
@@ -352,6 +472,11 @@ func (l Lookup) Validate(ncl negative.Lookups) error {
 		if o.CacheKeyPrefix == "" {
 			o.CacheKeyPrefix = o.Host
 		}
+		for t := range o.TenantCacheKeyPrefixes {
+			if err := ValidateTenantID(t); err != nil {
+				return fmt.Errorf(`invalid tenant "%s" in tenant_cache_key_prefixes for backend "%s"`, t, k)
+			}
+		}
 
 		nc := ncl.Get(o.NegativeCacheName)
 		if nc == nil {
@@ -370,8 +495,13 @@ func (l Lookup) Validate(ncl negative.Lookups) error {
 			o.FastForwardTTLMS = o.MaxTTLMS
 			o.FastForwardTTL = o.MaxTTL
 		}
+
+		if o.NonTimeseriesTTLMS > o.MaxTTLMS {
+			o.NonTimeseriesTTLMS = o.MaxTTLMS
+			o.NonTimeseriesTTL = o.MaxTTL
+		}
 	}
-	return nil
+	return l.validateTenantCacheKeyPrefixes()
 }
 
 // ValidateBackendName ensures the backend name is permitted against the dictionary of
@@ -400,6 +530,10 @@ func (l Lookup) ValidateConfigMappings(rules ro.Lookup, caches co.Lookup) error
 			r.Name = oc.RuleName
 			oc.RuleOptions = r
 		case "alb":
+			// ALB Type Validations
+			if oc.ALBOptions == nil || len(oc.ALBOptions.Pool) == 0 {
+				return fmt.Errorf("no alb pool provided in backend options [%s]", k)
+			}
 		default:
 			if _, ok := caches[oc.CacheName]; !ok {
 				return fmt.Errorf("invalid cache name [%s] provided in backend options [%s]", oc.CacheName, k)
@@ -432,6 +566,8 @@ func ProcessTOML(
 	options *Options,
 	metadata *toml.MetaData,
 	crw map[string]rewriter.RewriteInstructions,
+	authenticators authenticator.Lookup,
+	rateLimiters rlo.Lookup,
 	backends Lookup,
 	activeCaches map[string]bool,
 ) (*Options, error) {
@@ -453,6 +589,26 @@ func ProcessTOML(
 		oc.ReqRewriter = ri
 	}
 
+	if metadata.IsDefined("backends", name, "authenticator_name") && options.AuthenticatorName != "" {
+		oc.AuthenticatorName = options.AuthenticatorName
+		a, ok := authenticators[oc.AuthenticatorName]
+		if !ok {
+			return nil, fmt.Errorf("invalid authenticator name %s in backend options %s",
+				oc.AuthenticatorName, name)
+		}
+		oc.Authenticator = a
+	}
+
+	if metadata.IsDefined("backends", name, "rate_limiter_name") && options.RateLimiterName != "" {
+		oc.RateLimiterName = options.RateLimiterName
+		rl, ok := rateLimiters[oc.RateLimiterName]
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limiter name %s in backend options %s",
+				oc.RateLimiterName, name)
+		}
+		oc.RateLimiter = ratelimiter.New(rl)
+	}
+
 	if metadata.IsDefined("backends", name, "provider") {
 		oc.Provider = options.Provider
 	}
@@ -495,6 +651,14 @@ func ProcessTOML(
 		oc.CacheKeyPrefix = options.CacheKeyPrefix
 	}
 
+	if metadata.IsDefined("backends", name, "tenant_header") {
+		oc.TenantHeader = options.TenantHeader
+	}
+
+	if metadata.IsDefined("backends", name, "tenant_cache_key_prefixes") {
+		oc.TenantCacheKeyPrefixes = options.TenantCacheKeyPrefixes
+	}
+
 	if metadata.IsDefined("backends", name, "origin_url") {
 		oc.OriginURL = options.OriginURL
 	}
@@ -538,19 +702,52 @@ func ProcessTOML(
 		oc.FastForwardTTLMS = options.FastForwardTTLMS
 	}
 
+	if metadata.IsDefined("backends", name, "non_timeseries_ttl_ms") {
+		oc.NonTimeseriesTTLMS = options.NonTimeseriesTTLMS
+	}
+
+	if metadata.IsDefined("backends", name, "stale_while_revalidate_ms") {
+		oc.StaleWhileRevalidateMS = options.StaleWhileRevalidateMS
+	}
+
+	if metadata.IsDefined("backends", name, "stale_if_error_ms") {
+		oc.StaleIfErrorMS = options.StaleIfErrorMS
+	}
+
 	if metadata.IsDefined("backends", name, "fast_forward_disable") {
 		oc.FastForwardDisable = options.FastForwardDisable
 	}
 
+	if metadata.IsDefined("backends", name, "instant_queries_from_range_cache") {
+		oc.InstantQueriesFromRangeCache = options.InstantQueriesFromRangeCache
+	}
+
 	if metadata.IsDefined("backends", name, "backfill_tolerance_ms") {
 		oc.BackfillToleranceMS = options.BackfillToleranceMS
 	}
 
+	if metadata.IsDefined("backends", name, "timeseries_chunk_factor") {
+		oc.TimeseriesChunkFactor = options.TimeseriesChunkFactor
+	}
+
+	if metadata.IsDefined("backends", name, "max_upstream_concurrency") {
+		oc.MaxUpstreamConcurrency = options.MaxUpstreamConcurrency
+	}
+
+	if metadata.IsDefined("backends", name, "max_extent_steps") {
+		oc.MaxExtentSteps = options.MaxExtentSteps
+	}
+
+	if metadata.IsDefined("backends", name, "max_extent_duration_ms") {
+		oc.MaxExtentDurationMS = options.MaxExtentDurationMS
+	}
+
 	if metadata.IsDefined("backends", name, "paths") {
-		err := po.ProcessTOML(name, metadata, options.Paths, crw)
+		err := po.ProcessTOML(name, metadata, options.Paths, crw, rateLimiters)
 		if err != nil {
 			return nil, err
 		}
+		oc.Paths = options.Paths
 	}
 
 	if metadata.IsDefined("backends", name, "negative_cache_name") {
@@ -577,6 +774,22 @@ func ProcessTOML(
 		oc.HealthCheckHeaders = options.HealthCheckHeaders
 	}
 
+	if metadata.IsDefined("backends", name, "health_check_interval_ms") {
+		oc.HealthCheckIntervalMS = options.HealthCheckIntervalMS
+	}
+
+	if metadata.IsDefined("backends", name, "health_check_timeout_ms") {
+		oc.HealthCheckTimeoutMS = options.HealthCheckTimeoutMS
+	}
+
+	if metadata.IsDefined("backends", name, "health_check_failure_threshold") {
+		oc.HealthCheckFailureThreshold = options.HealthCheckFailureThreshold
+	}
+
+	if metadata.IsDefined("backends", name, "health_check_recovery_threshold") {
+		oc.HealthCheckRecoveryThreshold = options.HealthCheckRecoveryThreshold
+	}
+
 	if metadata.IsDefined("backends", name, "max_object_size_bytes") {
 		oc.MaxObjectSizeBytes = options.MaxObjectSizeBytes
 	}
@@ -593,6 +806,23 @@ func ProcessTOML(
 		oc.DearticulateUpstreamRanges = options.DearticulateUpstreamRanges
 	}
 
+	if metadata.IsDefined("backends", name, "alb") && options.ALBOptions != nil {
+		oc.ALBOptions = ao.New()
+		if metadata.IsDefined("backends", name, "alb", "mechanism") {
+			oc.ALBOptions.MechanismName = options.ALBOptions.MechanismName
+		}
+		if metadata.IsDefined("backends", name, "alb", "pool") {
+			oc.ALBOptions.Pool = make([]string, len(options.ALBOptions.Pool))
+			copy(oc.ALBOptions.Pool, options.ALBOptions.Pool)
+		}
+		if metadata.IsDefined("backends", name, "alb", "hash_source") {
+			oc.ALBOptions.HashSource = options.ALBOptions.HashSource
+		}
+		if metadata.IsDefined("backends", name, "alb", "hash_key") {
+			oc.ALBOptions.HashKey = options.ALBOptions.HashKey
+		}
+	}
+
 	if metadata.IsDefined("backends", name, "tls") {
 		oc.TLS = &to.Options{
 			InsecureSkipVerify:        options.TLS.InsecureSkipVerify,
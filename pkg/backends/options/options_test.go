@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	ao "github.com/tricksterproxy/trickster/pkg/backends/alb/options"
 	ro "github.com/tricksterproxy/trickster/pkg/backends/rule/options"
 	co "github.com/tricksterproxy/trickster/pkg/cache/options"
 	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
@@ -44,10 +45,27 @@ func TestClone(t *testing.T) {
 	o.NegativeCache = map[int]time.Duration{1: 1}
 	o.FastForwardPath = p
 	o.RuleOptions = &ro.Options{}
+	o.ALBOptions = &ao.Options{Pool: []string{"test"}}
+	o.TenantHeader = "X-Scope-OrgID"
+	o.TenantCacheKeyPrefixes = map[string]string{"test": "test"}
+	o.AuthenticatorName = "test"
+	o.RateLimiterName = "test"
 	o2 := o.Clone()
 	if o2.CacheName != "test" {
 		t.Error("clone failed")
 	}
+	if o2.TenantHeader != "X-Scope-OrgID" || o2.TenantCacheKeyPrefixes["test"] != "test" {
+		t.Error("clone failed")
+	}
+	if o2.ALBOptions == nil || len(o2.ALBOptions.Pool) != 1 {
+		t.Error("clone failed")
+	}
+	if o2.AuthenticatorName != "test" {
+		t.Error("clone failed")
+	}
+	if o2.RateLimiterName != "test" {
+		t.Error("clone failed")
+	}
 
 }
 
@@ -94,6 +112,19 @@ func TestValidateConfigMappings(t *testing.T) {
 		t.Error("expected error for invalid backend name")
 	}
 
+	delete(ol, "frontend")
+	oc.Provider = "alb"
+	err = ol.ValidateConfigMappings(ro.Lookup{}, co.Lookup{})
+	if err == nil {
+		t.Error("expected error for missing alb pool")
+	}
+
+	oc.ALBOptions = &ao.Options{Pool: []string{"test2"}}
+	err = ol.ValidateConfigMappings(ro.Lookup{}, co.Lookup{})
+	if err != nil {
+		t.Error(err)
+	}
+
 	// delete(oc, "frontend")
 	// oc.Provider = "rule"
 	// oc.RuleName = "invalid"
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxTenantIDLength is the maximum length of each tenant identifier in a tenant header
+const MaxTenantIDLength = 150
+
+// ErrMissingTenant is returned when a request to a multi-tenant backend does not include
+// the tenant header
+var ErrMissingTenant = errors.New("missing tenant identifier")
+
+// ErrInvalidTenant is returned when a request to a multi-tenant backend includes a tenant
+// identifier that is not permitted
+var ErrInvalidTenant = errors.New("invalid tenant identifier")
+
+// IsMultiTenant returns true if the backend is configured with a tenant header
+func (oc *Options) IsMultiTenant() bool {
+	return oc != nil && oc.TenantHeader != ""
+}
+
+// Tenant returns the validated tenant identifier from the provided request headers. An empty
+// string and nil error are returned when the backend is not multi-tenant
+func (oc *Options) Tenant(h http.Header) (string, error) {
+	if !oc.IsMultiTenant() {
+		return "", nil
+	}
+	return TenantID(oc.TenantHeader, h)
+}
+
+// TenantID returns the validated tenant identifier from the named header. Multiple tenants
+// may be provided in the header's value separated by '|', as with federated queries
+func TenantID(name string, h http.Header) (string, error) {
+	if h == nil {
+		return "", ErrMissingTenant
+	}
+	t := strings.TrimSpace(h.Get(name))
+	if t == "" {
+		return "", ErrMissingTenant
+	}
+	if err := ValidateTenantID(t); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+// ValidateTenantID returns an error if the provided tenant identifier includes characters
+// or tenant names that are not permitted
+func ValidateTenantID(t string) error {
+	for _, id := range strings.Split(t, "|") {
+		if id == "" || id == "." || id == ".." || len(id) > MaxTenantIDLength {
+			return ErrInvalidTenant
+		}
+		for _, r := range id {
+			if !isTenantIDRune(r) {
+				return ErrInvalidTenant
+			}
+		}
+	}
+	return nil
+}
+
+func isTenantIDRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!-_.*'()", r)
+}
+
+// TenantCacheKeyPrefix returns the cache key prefix for the provided tenant's objects, using
+// prefix as the base. A prefix configured in TenantCacheKeyPrefixes takes precedence when the
+// base is the backend's own CacheKeyPrefix
+func (oc *Options) TenantCacheKeyPrefix(prefix, tenant string) string {
+	if tenant == "" {
+		return prefix
+	}
+	if prefix == oc.CacheKeyPrefix {
+		if p, ok := oc.TenantCacheKeyPrefixes[tenant]; ok && p != "" {
+			return p
+		}
+	}
+	// the '.' separator is escaped so that no tenant's prefix is a prefix of another's
+	return prefix + ".tenant." + strings.Replace(url.QueryEscape(tenant), ".", "%2E", -1)
+}
+
+// validateTenantCacheKeyPrefixes ensures that no tenant's cache key prefix override overlaps
+// the cache key prefix of any backend or the override of any other tenant, so that purging
+// the objects of one tenant or backend never removes those of another
+func (l Lookup) validateTenantCacheKeyPrefixes() error {
+	for k, o := range l {
+		for t, p := range o.TenantCacheKeyPrefixes {
+			if p == "" {
+				continue
+			}
+			for k2, o2 := range l {
+				if prefixesOverlap(p, o2.CacheKeyPrefix) {
+					return fmt.Errorf(`tenant_cache_key_prefixes value "%s" for tenant "%s" of backend "%s" overlaps the cache_key_prefix of backend "%s"`,
+						p, t, k, k2)
+				}
+				for t2, p2 := range o2.TenantCacheKeyPrefixes {
+					if (k == k2 && t == t2) || p2 == "" {
+						continue
+					}
+					if prefixesOverlap(p, p2) {
+						return fmt.Errorf(`tenant_cache_key_prefixes value "%s" for tenant "%s" of backend "%s" overlaps that of tenant "%s" of backend "%s"`,
+							p, t, k, t2, k2)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// prefixesOverlap returns true if the objects stored under one of the provided cache key
+// prefixes would be removed by a purge of the other
+func prefixesOverlap(p1, p2 string) bool {
+	return p1 == p2 || strings.HasPrefix(p1, p2+".") || strings.HasPrefix(p2, p1+".")
+}
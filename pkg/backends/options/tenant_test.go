@@ -0,0 +1,139 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/cache/negative"
+)
+
+func TestTenant(t *testing.T) {
+
+	o := New()
+	h := http.Header{}
+	if tenant, err := o.Tenant(h); tenant != "" || err != nil {
+		t.Errorf("expected empty tenant and nil error got %s %v", tenant, err)
+	}
+
+	o.TenantHeader = "X-Scope-OrgID"
+	if !o.IsMultiTenant() {
+		t.Error("expected multi-tenant backend")
+	}
+
+	tests := []struct {
+		value, expected string
+		err             error
+	}{
+		{"", "", ErrMissingTenant},
+		{"team-a", "team-a", nil},
+		{" team-a ", "team-a", nil},
+		{"team-a|team-b", "team-a|team-b", nil},
+		{"team-a|", "", ErrInvalidTenant},
+		{"..", "", ErrInvalidTenant},
+		{"team/a", "", ErrInvalidTenant},
+		{strings.Repeat("a", MaxTenantIDLength+1), "", ErrInvalidTenant},
+	}
+
+	for _, test := range tests {
+		h.Set(o.TenantHeader, test.value)
+		tenant, err := o.Tenant(h)
+		if err != test.err {
+			t.Errorf("%s: expected %v got %v", test.value, test.err, err)
+		}
+		if tenant != test.expected {
+			t.Errorf("expected %s got %s", test.expected, tenant)
+		}
+	}
+
+	if _, err := TenantID(o.TenantHeader, nil); err != ErrMissingTenant {
+		t.Errorf("expected %v got %v", ErrMissingTenant, err)
+	}
+}
+
+func TestTenantCacheKeyPrefix(t *testing.T) {
+
+	o := New()
+	o.CacheKeyPrefix = "test"
+	o.TenantHeader = "X-Scope-OrgID"
+	o.TenantCacheKeyPrefixes = map[string]string{"team-b": "team-b-prefix"}
+
+	tests := []struct {
+		prefix, tenant, expected string
+	}{
+		{"test", "", "test"},
+		{"test", "team-a", "test.tenant.team-a"},
+		{"test", "team.a", "test.tenant.team%2Ea"},
+		{"test", "team-a|team-c", "test.tenant.team-a%7Cteam-c"},
+		{"test", "team-b", "team-b-prefix"},
+		{"group", "team-b", "group.tenant.team-b"},
+	}
+
+	for _, test := range tests {
+		if p := o.TenantCacheKeyPrefix(test.prefix, test.tenant); p != test.expected {
+			t.Errorf("expected %s got %s", test.expected, p)
+		}
+	}
+}
+
+func TestValidateTenantCacheKeyPrefixes(t *testing.T) {
+
+	o := New()
+	o.Provider = "test"
+	o.OriginURL = "http://1"
+	o.TenantHeader = "X-Scope-OrgID"
+	o.TenantCacheKeyPrefixes = map[string]string{"team/a": "test"}
+
+	ncl := negative.Lookups{"default": negative.Lookup{}}
+	l := Lookup{"test": o}
+	if err := l.Validate(ncl); err == nil {
+		t.Error("expected error for invalid tenant")
+	}
+
+	o.TenantCacheKeyPrefixes = map[string]string{"team-a": "test"}
+	if err := l.Validate(ncl); err != nil {
+		t.Error(err)
+	}
+
+	o2 := New()
+	o2.Provider = "test"
+	o2.OriginURL = "http://2"
+	o2.CacheKeyPrefix = "other"
+	l["other"] = o2
+
+	tests := []struct {
+		prefixes map[string]string
+		valid    bool
+	}{
+		{map[string]string{"team-a": "test-a", "team-b": "test-b"}, true},
+		{map[string]string{"team-a": "test", "team-b": ""}, true},
+		{map[string]string{"team-a": "1"}, false},
+		{map[string]string{"team-a": "1.tenant.team-b"}, false},
+		{map[string]string{"team-a": "other"}, false},
+		{map[string]string{"team-a": "other.team-a"}, false},
+		{map[string]string{"team-a": "test", "team-b": "test"}, false},
+		{map[string]string{"team-a": "test", "team-b": "test.b"}, false},
+	}
+	for _, test := range tests {
+		o.TenantCacheKeyPrefixes = test.prefixes
+		if err := l.Validate(ncl); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %t got %v", test.prefixes, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"net/http"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+)
+
+// LabelsHandler proxies requests for paths /labels and /label/<name>/values to the origin by way of the object proxy cache
+func (c *Client) LabelsHandler(w http.ResponseWriter, r *http.Request) {
+
+	u := urls.BuildUpstreamURL(r, c.baseUpstreamURL)
+	qp, _, _ := params.GetRequestValues(r)
+
+	// Round Start and End times down to the path's normalization granularity for cacheability
+	normalizeTimeParams(qp, timeNormalization(r, defaultMetadataTimeNormalization), upStart, upEnd)
+
+	r.URL = u
+	params.SetRequestValues(r, qp)
+
+	engines.ObjectProxyCacheRequest(w, r)
+}
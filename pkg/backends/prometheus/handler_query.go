@@ -17,12 +17,20 @@
 package prometheus
 
 import (
+	"bytes"
 	"net/http"
+	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
+	"github.com/tricksterproxy/trickster/pkg/parsing/promql"
 	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
 )
 
@@ -30,14 +38,165 @@ import (
 func (c *Client) QueryHandler(w http.ResponseWriter, r *http.Request) {
 	u := urls.BuildUpstreamURL(r, c.baseUpstreamURL)
 	qp, _, _ := params.GetRequestValues(r)
-	// Round time param down to the nearest 15 seconds if it exists
-	if p := qp.Get(upTime); p != "" {
-		if i, err := strconv.ParseInt(p, 10, 64); err == nil {
-			qp.Set(upTime, strconv.FormatInt(time.Unix(i, 0).Truncate(time.Second*time.Duration(15)).Unix(), 10))
-		}
-	}
+	// Round time param down to the path's normalization granularity if it exists
+	normalizeTimeParams(qp, timeNormalization(r, defaultQueryTimeNormalization), upTime)
 	r.URL = u
 	params.SetRequestValues(r, qp)
 
+	if c.config != nil && c.config.InstantQueriesFromRangeCache && c.queryFromRangeCache(w, r, qp) {
+		return
+	}
+
 	engines.ObjectProxyCacheRequest(w, r)
 }
+
+// queryFromRangeCache responds to the instant query with the values of a cached range query
+// for the same expression, and returns false without responding when no cached range query
+// covers the requested time. The requested time must be on a boundary of the range query's
+// step, or within the instant query path's time normalization of one, so that the cached
+// values are no older than those the instant query path would serve
+func (c *Client) queryFromRangeCache(w http.ResponseWriter, r *http.Request, qp url.Values) bool {
+
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.BackendOptions == nil || engines.GetRequestCachingPolicy(r.Header).NoCache {
+		return false
+	}
+	pc := rangeQueryPathConfig(rsc.BackendOptions.Paths, r.Method)
+	if pc == nil {
+		return false
+	}
+
+	expr, err := promql.Parse(qp.Get(upQuery))
+	if err != nil || promql.Type(expr) != promql.ValueTypeVector {
+		return false
+	}
+	steps := c.rangeSteps.get(expr.String())
+	if len(steps) == 0 {
+		return false
+	}
+
+	now := time.Now()
+	t := now
+	if p := qp.Get(upTime); p != "" {
+		if t, err = parseTime(p); err != nil {
+			return false
+		}
+	}
+	ts := strconv.FormatInt(t.Unix(), 10)
+	tn := timeNormalization(r, defaultQueryTimeNormalization)
+
+	rsc2 := rsc.Clone()
+	rsc2.PathConfig = pc
+	u := urls.Clone(r.URL)
+	u.Path = u.Path + "_range"
+
+	for _, step := range steps {
+		if !withinStep(t, step, tn) {
+			continue
+		}
+		v := url.Values(http.Header(qp).Clone())
+		v.Del(upTime)
+		v.Set(upStart, ts)
+		v.Set(upEnd, ts)
+		v.Set(upStep, strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+		rr := request.SetResources(r.Clone(r.Context()), rsc2)
+		rr.URL = urls.Clone(u)
+		params.SetRequestValues(rr, v)
+
+		cts, trq, err := engines.CachedTimeseries(rr, c.modeler)
+		if err != nil || !trq.Extent.Start.Equal(trq.Extent.End) ||
+			len(cts.Extents().CalculateDeltas(trq.Extent, trq.Step)) > 0 {
+			continue
+		}
+		b, err := model.MarshalVector(cts, trq.Extent.Start, t)
+		if err != nil {
+			continue
+		}
+
+		h := http.Header{}
+		h.Set(headers.NameContentType, headers.ValueApplicationJSON+"; charset=UTF-8")
+		if rsc.PathConfig != nil {
+			headers.UpdateHeaders(h, rsc.PathConfig.ResponseHeaders)
+		}
+		engines.RecordCachedTimeseriesResult(r, http.StatusOK, time.Since(now), h)
+		engines.Respond(w, http.StatusOK, h, bytes.NewReader(b))
+		return true
+	}
+	return false
+}
+
+// withinStep returns true if t is less than the tolerance after a boundary of the step,
+// or is on the boundary
+func withinStep(t time.Time, step, tolerance time.Duration) bool {
+	d := t.Sub(t.Truncate(step))
+	return d == 0 || d < tolerance
+}
+
+// rangeQueryPathConfig returns the path config of the range query handler that supports the method
+func rangeQueryPathConfig(paths map[string]*po.Options, method string) *po.Options {
+	for _, pc := range paths {
+		if pc == nil || pc.HandlerName != mnQueryRange {
+			continue
+		}
+		for _, m := range pc.Methods {
+			if m == method {
+				return pc
+			}
+		}
+	}
+	return nil
+}
+
+// the limits of the stepIndex, beyond which the least recently added entries are discarded
+const (
+	maxIndexedStatements = 10000
+	maxStepsPerStatement = 4
+)
+
+// stepIndex records the steps of recent range queries for each canonical statement, so that
+// instant queries for the same statement can locate the range queries' cached timeseries
+type stepIndex struct {
+	mtx   sync.Mutex
+	steps map[string][]time.Duration
+	order []string
+}
+
+func newStepIndex() *stepIndex {
+	return &stepIndex{steps: make(map[string][]time.Duration)}
+}
+
+// add records the step of a range query for the statement as its most recent step
+func (si *stepIndex) add(statement string, step time.Duration) {
+	if si == nil || step <= 0 {
+		return
+	}
+	si.mtx.Lock()
+	defer si.mtx.Unlock()
+	steps, ok := si.steps[statement]
+	if !ok {
+		if len(si.order) >= maxIndexedStatements {
+			delete(si.steps, si.order[0])
+			si.order = si.order[1:]
+		}
+		si.order = append(si.order, statement)
+	}
+	l := make([]time.Duration, 1, maxStepsPerStatement)
+	l[0] = step
+	for _, s := range steps {
+		if s != step && len(l) < maxStepsPerStatement {
+			l = append(l, s)
+		}
+	}
+	si.steps[statement] = l
+}
+
+// get returns the steps recorded for the statement, the most recent first
+func (si *stepIndex) get(statement string) []time.Duration {
+	if si == nil {
+		return nil
+	}
+	si.mtx.Lock()
+	defer si.mtx.Unlock()
+	return si.steps[statement]
+}
@@ -27,5 +27,11 @@ import (
 // Prometheus and processes them through the delta proxy cache
 func (c *Client) QueryRangeHandler(w http.ResponseWriter, r *http.Request) {
 	r.URL = urls.BuildUpstreamURL(r, c.baseUpstreamURL)
+	if c.config != nil && c.config.InstantQueriesFromRangeCache {
+		// the step is recorded so that instant queries can locate the cached timeseries
+		if trq, _, _, err := c.ParseTimeRangeQuery(r); err == nil {
+			c.rangeSteps.add(trq.Statement, trq.Step)
+		}
+	}
 	engines.DeltaProxyCacheRequest(w, r, c.modeler)
 }
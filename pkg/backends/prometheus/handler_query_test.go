@@ -17,12 +17,24 @@
 package prometheus
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/util/metrics"
 	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestQueryHandler(t *testing.T) {
@@ -64,3 +76,155 @@ func TestQueryHandler(t *testing.T) {
 		t.Errorf("expected '{}' got %s.", bodyBytes)
 	}
 }
+
+func TestQueryHandlerFromRangeCache(t *testing.T) {
+
+	client := &Client{name: "test", modeler: model.NewModeler(), rangeSteps: newStepIndex()}
+	ts, w, r, hc, err := tu.NewTestInstance("",
+		client.DefaultPathConfigs, 200, "", nil, "promsim", APIPath+mnQueryRange, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.config.FastForwardDisable = true
+	client.config.InstantQueriesFromRangeCache = true
+	client.baseUpstreamURL, _ = url.Parse(ts.URL + "/prometheus")
+
+	const query = `up{series_count="2",latency_ms="0",range_latency_ms="0"}`
+	step := time.Minute
+	end := time.Now().Add(-time.Hour).Truncate(step)
+	start := end.Add(-time.Hour)
+
+	r.URL.RawQuery = url.Values{upQuery: {query}, upStep: {"60"},
+		upStart: {strconv.FormatInt(start.Unix(), 10)},
+		upEnd:   {strconv.FormatInt(end.Unix(), 10)}}.Encode()
+	client.QueryRangeHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	// Give time for the object to be written to cache in a separate goroutine from response
+	time.Sleep(time.Millisecond * 10)
+
+	instant := func(q string, tm time.Time) *httptest.ResponseRecorder {
+		rsc2 := rsc.Clone()
+		rsc2.PathConfig = client.config.Paths[APIPath+mnQuery]
+		r2 := httptest.NewRequest(http.MethodGet, ts.URL+APIPath+mnQuery+"?"+url.Values{
+			upQuery: {q}, upTime: {strconv.FormatInt(tm.Unix(), 10)}}.Encode(), nil)
+		r2 = request.SetResources(r2, rsc2)
+		w2 := httptest.NewRecorder()
+		client.QueryHandler(w2, r2)
+		return w2
+	}
+
+	hits := func() float64 {
+		m := &dto.Metric{}
+		metrics.ProxyRequestStatus.WithLabelValues(client.config.Name, client.config.Provider,
+			http.MethodGet, "hit", "200", "/prometheus"+APIPath+mnQuery, "").Write(m)
+		return m.GetCounter().GetValue()
+	}
+	before := hits()
+
+	// an equivalent query for a time within the cached range, on a boundary of the step
+	tm := start.Add(30 * time.Minute)
+	w2 := instant(`up{range_latency_ms="0",latency_ms="0",series_count="2"}`, tm)
+	if w2.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w2.Code)
+	}
+	if v := hits() - before; v != 1 {
+		t.Errorf("expected %d got %f", 1, v)
+	}
+	if h := w2.Header().Get(headers.NameTricksterResult); !strings.Contains(h, "engine=DeltaProxyCache") ||
+		!strings.Contains(h, "status=hit") {
+		t.Errorf("unexpected result header: %s", h)
+	}
+	body := w2.Body.String()
+	expected := `"value":[` + strconv.FormatInt(tm.Unix(), 10) + `,"`
+	if !strings.HasPrefix(body, `{"status":"success","data":{"resultType":"vector","result":[{"metric":`) ||
+		strings.Count(body, expected) != 2 {
+		t.Errorf("unexpected body: %s", body)
+	}
+
+	// the values must match those of the origin at the aligned time
+	client.config.InstantQueriesFromRangeCache = false
+	w3 := instant(query, tm)
+	var d1, d2 model.WFDocument
+	json.Unmarshal(w3.Body.Bytes(), &d1)
+	json.Unmarshal([]byte(body), &d2)
+	if len(d1.Data.Results) != 2 || !reflect.DeepEqual(d1, d2) {
+		t.Errorf("expected %s got %s", w3.Body.String(), body)
+	}
+	client.config.InstantQueriesFromRangeCache = true
+
+	// times outside of the cached range or off the step, and scalar queries, are not served
+	// from the range cache
+	for _, test := range []struct {
+		q  string
+		tm time.Time
+	}{
+		{query, end.Add(time.Hour)},
+		{query, tm.Add(30 * time.Second)},
+		{`scalar(` + query + `)`, tm},
+		{`up{series_count="3"}`, tm},
+	} {
+		w2 = instant(test.q, test.tm)
+		if h := w2.Header().Get(headers.NameTricksterResult); !strings.Contains(h, "engine=ObjectProxyCache") {
+			t.Errorf("unexpected result header: %s", h)
+		}
+	}
+}
+
+func TestWithinStep(t *testing.T) {
+	tm := time.Unix(1577836800, 0)
+	tests := []struct {
+		t         time.Time
+		step, tol time.Duration
+		expected  bool
+	}{
+		{tm, time.Minute, 0, true},
+		{tm.Add(10 * time.Second), time.Minute, 0, false},
+		{tm.Add(10 * time.Second), time.Minute, 15 * time.Second, true},
+		{tm.Add(15 * time.Second), time.Minute, 15 * time.Second, false},
+		{tm.Add(45 * time.Second), time.Minute, 15 * time.Second, false},
+	}
+	for i, test := range tests {
+		if v := withinStep(test.t, test.step, test.tol); v != test.expected {
+			t.Errorf("%d: expected %t got %t", i, test.expected, v)
+		}
+	}
+}
+
+func TestStepIndex(t *testing.T) {
+
+	si := newStepIndex()
+	si.add("a", time.Minute)
+	si.add("a", time.Second)
+	si.add("a", time.Minute)
+	si.add("a", 0)
+	if steps := si.get("a"); len(steps) != 2 || steps[0] != time.Minute || steps[1] != time.Second {
+		t.Errorf("unexpected steps: %v", steps)
+	}
+	for i := 1; i <= maxStepsPerStatement+1; i++ {
+		si.add("b", time.Duration(i)*time.Second)
+	}
+	if steps := si.get("b"); len(steps) != maxStepsPerStatement ||
+		steps[0] != time.Duration(maxStepsPerStatement+1)*time.Second {
+		t.Errorf("unexpected steps: %v", steps)
+	}
+	for i := 0; i < maxIndexedStatements; i++ {
+		si.add(strconv.Itoa(i), time.Second)
+	}
+	if si.get("a") != nil || si.get("b") != nil || len(si.steps) != maxIndexedStatements {
+		t.Error("expected the oldest statements to be discarded")
+	}
+
+	var nsi *stepIndex
+	nsi.add("a", time.Second)
+	if nsi.get("a") != nil {
+		t.Error("expected nil steps")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/util/md5"
+)
+
+var remoteReadModeler = model.NewRemoteReadModeler()
+
+// ReadHandler handles Prometheus Remote Read requests and processes them through the
+// delta proxy cache, which caches the raw samples of the requested series
+func (c *Client) ReadHandler(w http.ResponseWriter, r *http.Request) {
+	r.URL = urls.BuildUpstreamURL(r, c.baseUpstreamURL)
+	engines.DeltaProxyCacheRequest(w, r, remoteReadModeler)
+}
+
+// readHandlerParseTimeRangeQuery parses the TimeRangeQuery from a Remote Read request.
+// Requests having multiple queries, or that do not accept a sampled response, are not
+// cacheable and will be proxied
+func readHandlerParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	rr, err := model.DecodeReadRequest(request.GetBody(r))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(rr.Queries) != 1 || !rr.AcceptsSamples() {
+		return nil, nil, false, model.ErrUnsupportedReadRequest
+	}
+	q := rr.Queries[0]
+
+	trq := &timeseries.TimeRangeQuery{
+		Statement: q.Statement(),
+		Extent: timeseries.Extent{
+			Start: time.Unix(0, q.StartTimestampMs*int64(time.Millisecond)),
+			End:   time.Unix(0, q.EndTimestampMs*int64(time.Millisecond)),
+		},
+		Step:        timeNormalization(r, defaultQueryTimeNormalization),
+		TemplateURL: urls.Clone(r.URL),
+		ParsedQuery: rr,
+	}
+	if trq.Step <= 0 {
+		trq.Step = defaultQueryTimeNormalization
+	}
+	if trq.Extent.End.Before(trq.Extent.Start) {
+		return nil, nil, false, timeseries.ErrInvalidExtent
+	}
+
+	// Fast Forward is served by the instantaneous query endpoint, which returns
+	// evaluated values rather than raw samples, so it is never used for Remote Read
+	rlo := &timeseries.RequestOptions{
+		FastForwardDisable: true,
+		OutputExtent:       &timeseries.Extent{Start: trq.Extent.Start, End: trq.Extent.End},
+	}
+	return trq, rlo, false, nil
+}
+
+// readHandlerSetExtent rewrites the Remote Read request body to query the provided Extent.
+// Since the samples of a series are not aligned to the step, each Extent fetches the
+// samples in (extent.Start-step, extent.End], so that adjacent Extents leave no gaps
+func readHandlerSetExtent(r *http.Request, rr *model.ReadRequest, step time.Duration,
+	extent *timeseries.Extent) {
+	rr = rr.Clone()
+	q := rr.Queries[0]
+	q.StartTimestampMs = extent.Start.Add(-step).UnixNano()/int64(time.Millisecond) + 1
+	q.EndTimestampMs = extent.End.UnixNano() / int64(time.Millisecond)
+	if q.Hints != nil {
+		q.Hints.StartMs = q.StartTimestampMs
+		q.Hints.EndMs = q.EndTimestampMs
+	}
+	rr.AcceptedResponseTypes = []model.ReadResponseType{model.ReadResponseTypeSamples}
+	request.SetBody(r, rr.Encode())
+}
+
+// readHandlerDeriveCacheKey derives the cache key for a Remote Read request from its
+// query's matchers and hints, excluding the query's time range
+func readHandlerDeriveCacheKey(path string, params url.Values,
+	h http.Header, body io.ReadCloser, extra string) (string, io.ReadCloser) {
+	var sb strings.Builder
+	sb.WriteString(path)
+	if body == nil {
+		body = ioutil.NopCloser(bytes.NewReader([]byte{}))
+	}
+	if b, err := ioutil.ReadAll(body); err == nil {
+		body = ioutil.NopCloser(bytes.NewReader(b))
+		if rr, err := model.DecodeReadRequest(b); err == nil {
+			for _, q := range rr.Queries {
+				sb.WriteString(q.Statement())
+			}
+		}
+	}
+	if v := h.Get(headers.NameAuthorization); v != "" {
+		sb.WriteString(headers.NameAuthorization + "." + v)
+	}
+	sb.WriteString(extra)
+	return md5.Checksum(sb.String()), body
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+const testReadInterval = 5000 // ms between samples returned by the test server
+
+// newTestRemoteRead returns a server that emulates the Prometheus Remote Read API,
+// returning one series per query with a sample at each testReadInterval in the
+// requested range, and the list of requests it received
+func newTestRemoteRead() (*httptest.Server, *[]*model.ReadRequest) {
+	reqs := make([]*model.ReadRequest, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		rr, err := model.DecodeReadRequest(b)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reqs = append(reqs, rr)
+		resp := &model.ReadResponse{}
+		for _, q := range rr.Queries {
+			s := &model.TimeSeries{Labels: []model.Label{{Name: "__name__", Value: "up"},
+				{Name: "job", Value: "a"}}}
+			for t := (q.StartTimestampMs + testReadInterval - 1) / testReadInterval *
+				testReadInterval; t <= q.EndTimestampMs; t += testReadInterval {
+				s.Samples = append(s.Samples, model.Sample{Value: float64(t % 7), Timestamp: t})
+			}
+			resp.Results = append(resp.Results, &model.QueryResult{Timeseries: []*model.TimeSeries{s}})
+		}
+		w.Header().Set(headers.NameContentType, headers.ValueApplicationProtobuf)
+		w.Header().Set(headers.NameContentEncoding, headers.ValueSnappy)
+		w.Write(resp.Encode())
+	}))
+	return ts, &reqs
+}
+
+func testReadRequest(start, end time.Time, queries int) *model.ReadRequest {
+	rr := &model.ReadRequest{}
+	for i := 0; i < queries; i++ {
+		rr.Queries = append(rr.Queries, &model.ReadQuery{
+			StartTimestampMs: start.UnixNano() / int64(time.Millisecond),
+			EndTimestampMs:   end.UnixNano() / int64(time.Millisecond),
+			Matchers: []*model.LabelMatcher{{Type: model.MatchEqual, Name: "__name__", Value: "up"},
+				{Type: model.MatchRegexp, Name: "job", Value: "a|b"}},
+		})
+	}
+	return rr
+}
+
+func newTestReadClient(t *testing.T) (*Client, *httptest.ResponseRecorder, *http.Request,
+	*[]*model.ReadRequest, func()) {
+	client := &Client{name: "test"}
+	ts, w, r, hc, err := tu.NewTestInstance("", client.DefaultPathConfigs, 200, "", nil, "prometheus",
+		APIPath+mnRead, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, reqs := newTestRemoteRead()
+	rsc := request.GetResources(r)
+	rsc.BackendClient = client
+	client.config = rsc.BackendOptions
+	client.webClient = hc
+	client.config.HTTPClient = hc
+	client.baseUpstreamURL, _ = url.Parse(rs.URL)
+	rsc.PathConfig = client.config.Paths[APIPath+mnRead]
+	return client, w, r, reqs, func() { ts.Close(); rs.Close() }
+}
+
+func readResponse(t *testing.T, w *httptest.ResponseRecorder) *model.ReadResponse {
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 got %d.", resp.StatusCode)
+	}
+	if v := resp.Header.Get(headers.NameContentEncoding); v != headers.ValueSnappy {
+		t.Errorf("expected %s got %s", headers.ValueSnappy, v)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	rr, err := model.DecodeReadResponse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rr
+}
+
+func TestReadHandler(t *testing.T) {
+
+	start := time.Now().Truncate(time.Minute).Add(-time.Hour)
+
+	client, w, r, reqs, closer := newTestReadClient(t)
+	defer closer()
+	ctx := r.Context()
+
+	r = httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead,
+		bytes.NewReader(testReadRequest(start, start.Add(10*time.Minute), 1).Encode())).WithContext(ctx)
+	client.ReadHandler(w, r)
+	rr := readResponse(t, w)
+	if len(rr.Results) != 1 || len(rr.Results[0].Timeseries) != 1 ||
+		len(rr.Results[0].Timeseries[0].Samples) != 121 {
+		t.Fatalf("expected 1 series with %d samples got %v", 121, rr.Results)
+	}
+	if len(*reqs) != 1 {
+		t.Fatalf("expected %d upstream requests got %d", 1, len(*reqs))
+	}
+
+	// extending the range should only request the missing samples
+	r = httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead,
+		bytes.NewReader(testReadRequest(start, start.Add(15*time.Minute), 1).Encode())).WithContext(ctx)
+	w = httptest.NewRecorder()
+	client.ReadHandler(w, r)
+	if v := w.Result().Header.Get(headers.NameTricksterResult); !strings.Contains(v, "status=phit") {
+		t.Errorf("expected phit got %s", v)
+	}
+	rr = readResponse(t, w)
+	if len(rr.Results) != 1 || len(rr.Results[0].Timeseries) != 1 {
+		t.Fatalf("expected 1 series got %v", rr.Results)
+	}
+	samples := rr.Results[0].Timeseries[0].Samples
+	if len(samples) != 181 {
+		t.Fatalf("expected %d samples got %d", 181, len(samples))
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Timestamp-samples[i-1].Timestamp != testReadInterval {
+			t.Fatalf("expected contiguous samples got %d after %d",
+				samples[i].Timestamp, samples[i-1].Timestamp)
+		}
+	}
+	if len(*reqs) != 2 {
+		t.Fatalf("expected %d upstream requests got %d", 2, len(*reqs))
+	}
+	expected := start.Add(10*time.Minute).UnixNano()/int64(time.Millisecond) + 1
+	if v := (*reqs)[1].Queries[0].StartTimestampMs; v != expected {
+		t.Errorf("expected delta request to start at %d got %d", expected, v)
+	}
+}
+
+func TestReadHandlerMultipleQueries(t *testing.T) {
+
+	start := time.Now().Truncate(time.Minute).Add(-time.Hour)
+
+	client, w, r, reqs, closer := newTestReadClient(t)
+	defer closer()
+
+	r = httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead,
+		bytes.NewReader(testReadRequest(start, start.Add(10*time.Minute), 2).Encode())).
+		WithContext(r.Context())
+	client.ReadHandler(w, r)
+	rr := readResponse(t, w)
+	if len(rr.Results) != 2 {
+		t.Errorf("expected %d results got %d", 2, len(rr.Results))
+	}
+	if len(*reqs) != 1 || len((*reqs)[0].Queries) != 2 {
+		t.Errorf("expected the request to be proxied unmodified")
+	}
+}
+
+func TestReadHandlerParseTimeRangeQuery(t *testing.T) {
+
+	start := time.Unix(1577836800, 0)
+	rr := testReadRequest(start, start.Add(time.Hour), 1)
+
+	r := httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead, bytes.NewReader(rr.Encode()))
+	trq, rlo, canOPC, err := readHandlerParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canOPC {
+		t.Error("expected false")
+	}
+	if !rlo.FastForwardDisable {
+		t.Error("expected fast forward to be disabled")
+	}
+	if rlo.OutputExtent == nil || !rlo.OutputExtent.Start.Equal(start) {
+		t.Errorf("expected output extent to start at %s", start)
+	}
+	if !trq.Extent.Start.Equal(start) || !trq.Extent.End.Equal(start.Add(time.Hour)) {
+		t.Errorf("unexpected extent %s", trq.Extent.String())
+	}
+	if trq.Step != defaultQueryTimeNormalization {
+		t.Errorf("expected %s got %s", defaultQueryTimeNormalization, trq.Step)
+	}
+	const expected = `{__name__="up",job=~"a|b"}`
+	if trq.Statement != expected {
+		t.Errorf("expected %s got %s", expected, trq.Statement)
+	}
+	if _, ok := trq.ParsedQuery.(*model.ReadRequest); !ok {
+		t.Errorf("expected *model.ReadRequest got %T", trq.ParsedQuery)
+	}
+
+	rr.AcceptedResponseTypes = []model.ReadResponseType{model.ReadResponseTypeStreamedXORChunks}
+	r = httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead, bytes.NewReader(rr.Encode()))
+	_, _, _, err = readHandlerParseTimeRangeQuery(r)
+	if err != model.ErrUnsupportedReadRequest {
+		t.Errorf("expected %v got %v", model.ErrUnsupportedReadRequest, err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead,
+		bytes.NewReader(testReadRequest(start, start.Add(-time.Hour), 1).Encode()))
+	_, _, _, err = readHandlerParseTimeRangeQuery(r)
+	if err != timeseries.ErrInvalidExtent {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidExtent, err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead, strings.NewReader("invalid"))
+	_, _, _, err = readHandlerParseTimeRangeQuery(r)
+	if err == nil {
+		t.Error("expected error for invalid body")
+	}
+}
+
+func TestReadHandlerSetExtent(t *testing.T) {
+
+	start := time.Unix(1577836800, 0)
+	rr := testReadRequest(start, start.Add(time.Hour), 1)
+	rr.Queries[0].Hints = &model.ReadHints{StepMs: 15000}
+	trq := &timeseries.TimeRangeQuery{Step: 15 * time.Second, ParsedQuery: rr}
+
+	r := httptest.NewRequest(http.MethodPost, "http://0"+APIPath+mnRead, bytes.NewReader(rr.Encode()))
+	client := &Client{}
+	e := &timeseries.Extent{Start: start.Add(time.Minute), End: start.Add(2 * time.Minute)}
+	client.SetExtent(r, trq, e)
+
+	b, _ := ioutil.ReadAll(r.Body)
+	if r.ContentLength != int64(len(b)) {
+		t.Errorf("expected %d got %d", len(b), r.ContentLength)
+	}
+	rr2, err := model.DecodeReadRequest(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := rr2.Queries[0]
+	if q.StartTimestampMs != 1577836845001 || q.EndTimestampMs != 1577836920000 {
+		t.Errorf("unexpected range %d-%d", q.StartTimestampMs, q.EndTimestampMs)
+	}
+	if q.Hints.StartMs != q.StartTimestampMs || q.Hints.EndMs != q.EndTimestampMs {
+		t.Errorf("unexpected hints range %d-%d", q.Hints.StartMs, q.Hints.EndMs)
+	}
+	// the parsed query must not be modified
+	if rr.Queries[0].StartTimestampMs != 1577836800000 {
+		t.Errorf("expected %d got %d", 1577836800000, rr.Queries[0].StartTimestampMs)
+	}
+}
+
+func TestReadHandlerDeriveCacheKey(t *testing.T) {
+
+	start := time.Unix(1577836800, 0)
+	b1 := testReadRequest(start, start.Add(time.Hour), 1).Encode()
+	b2 := testReadRequest(start.Add(time.Minute), start.Add(2*time.Hour), 1).Encode()
+
+	k1, body := readHandlerDeriveCacheKey(APIPath+mnRead, nil, http.Header{},
+		ioutil.NopCloser(bytes.NewReader(b1)), "")
+	k2, _ := readHandlerDeriveCacheKey(APIPath+mnRead, nil, http.Header{},
+		ioutil.NopCloser(bytes.NewReader(b2)), "")
+	if k1 != k2 {
+		t.Errorf("expected %s got %s", k1, k2)
+	}
+	if b, _ := ioutil.ReadAll(body); !bytes.Equal(b, b1) {
+		t.Error("expected body to be restored")
+	}
+
+	k3, _ := readHandlerDeriveCacheKey(APIPath+mnRead, nil,
+		http.Header{headers.NameAuthorization: {"Basic dGVzdA=="}},
+		ioutil.NopCloser(bytes.NewReader(b1)), "")
+	if k1 == k3 {
+		t.Error("expected keys to differ by authorization")
+	}
+
+	k4, _ := readHandlerDeriveCacheKey(APIPath+mnRead, nil, http.Header{}, nil, "")
+	if k4 == k1 {
+		t.Error("expected keys to differ")
+	}
+}
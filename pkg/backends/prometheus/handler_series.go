@@ -18,8 +18,6 @@ package prometheus
 
 import (
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
 	"github.com/tricksterproxy/trickster/pkg/proxy/params"
@@ -32,18 +30,8 @@ func (c *Client) SeriesHandler(w http.ResponseWriter, r *http.Request) {
 	u := urls.BuildUpstreamURL(r, c.baseUpstreamURL)
 	qp, _, _ := params.GetRequestValues(r)
 
-	// Round Start and End times down to top of most recent minute for cacheability
-	if p := qp.Get(upStart); p != "" {
-		if i, err := strconv.ParseInt(p, 10, 64); err == nil {
-			qp.Set(upStart, strconv.FormatInt(time.Unix(i, 0).Truncate(time.Second*time.Duration(60)).Unix(), 10))
-		}
-	}
-
-	if p := qp.Get(upEnd); p != "" {
-		if i, err := strconv.ParseInt(p, 10, 64); err == nil {
-			qp.Set(upEnd, strconv.FormatInt(time.Unix(i, 0).Truncate(time.Second*time.Duration(60)).Unix(), 10))
-		}
-	}
+	// Round Start and End times down to the path's normalization granularity for cacheability
+	normalizeTimeParams(qp, timeNormalization(r, defaultMetadataTimeNormalization), upStart, upEnd)
 
 	r.URL = u
 	params.SetRequestValues(r, qp)
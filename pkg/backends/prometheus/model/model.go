@@ -216,3 +216,58 @@ func MarshalTimeseriesWriter(ts timeseries.Timeseries, rlo *timeseries.RequestOp
 	w.Write([]byte("]}}"))
 	return nil
 }
+
+// MarshalVector converts the values of a Timeseries at the sample time into an
+// instant vector JSON blob, reporting each value at the evaluation time t
+func MarshalVector(ts timeseries.Timeseries, sample, t time.Time) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := MarshalVectorWriter(ts, sample, t, buf)
+	return buf.Bytes(), err
+}
+
+// MarshalVectorWriter converts the values of a Timeseries at the sample time into an
+// instant vector JSON blob via an io.Writer, reporting each value at the evaluation time t.
+// Series without a value at the sample time are omitted
+func MarshalVectorWriter(ts timeseries.Timeseries, sample, t time.Time, w io.Writer) error {
+
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok {
+		return timeseries.ErrUnknownFormat
+	}
+	// With Prometheus we presume only one Result per Dataset
+	if len(ds.Results) != 1 {
+		return timeseries.ErrUnknownFormat
+	}
+
+	e := epoch.Epoch(sample.UnixNano())
+	ets := strconv.FormatFloat(float64(t.UnixNano())/1000000000, 'f', -1, 64)
+
+	w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[`))
+
+	seriesSep := ""
+	for _, s := range ds.Results[0].SeriesList {
+		if s == nil {
+			continue
+		}
+		var v interface{}
+		for _, p := range s.Points {
+			if p.Epoch == e && len(p.Values) > 0 {
+				v = p.Values[0]
+				break
+			}
+		}
+		if v == nil {
+			continue
+		}
+		w.Write([]byte(seriesSep + `{"metric":{`))
+		sep := ""
+		for _, k := range s.Header.Tags.Keys() {
+			w.Write([]byte(fmt.Sprintf(`%s"%s":"%s"`, sep, k, s.Header.Tags[k])))
+			sep = ","
+		}
+		w.Write([]byte(fmt.Sprintf(`},"value":[%s,"%s"]}`, ets, v)))
+		seriesSep = ","
+	}
+	w.Write([]byte("]}}"))
+	return nil
+}
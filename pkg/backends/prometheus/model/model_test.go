@@ -18,6 +18,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
@@ -64,3 +65,66 @@ func TestUnmarshalInstantaneous(t *testing.T) {
 		return
 	}
 }
+
+func TestMarshalVector(t *testing.T) {
+	trq := &timeseries.TimeRangeQuery{}
+	ts, err := UnmarshalTimeseries([]byte(testDoc), trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := ts.(*dataset.DataSet)
+	// remove the second series' value at 1435781445
+	pts := make(dataset.Points, 0, 2)
+	for _, p := range ds.Results[0].SeriesList[1].Points {
+		if p.Epoch != 1435781445000000000 {
+			pts = append(pts, p)
+		}
+	}
+	ds.Results[0].SeriesList[1].Points = pts
+
+	tests := []struct {
+		t        int64
+		expected string
+	}{
+		{1435781460, `{"status":"success","data":{"resultType":"vector","result":[{` +
+			`"metric":{"__name__":"up","instance":"localhost:9090","job":"prometheus"},` +
+			`"value":[1435781460,"1"]},{"metric":{"__name__":"up","instance":"localhost:9091",` +
+			`"job":"node"},"value":[1435781460,"1"]}]}}`},
+		{1435781445, `{"status":"success","data":{"resultType":"vector","result":[{` +
+			`"metric":{"__name__":"up","instance":"localhost:9090","job":"prometheus"},` +
+			`"value":[1435781445,"1"]}]}}`},
+		{1435781400, `{"status":"success","data":{"resultType":"vector","result":[]}}`},
+	}
+
+	for _, test := range tests {
+		b, err := MarshalVector(ds, time.Unix(test.t, 0), time.Unix(test.t, 0))
+		if err != nil {
+			t.Error(err)
+		}
+		if string(b) != test.expected {
+			t.Errorf("expected %s got %s", test.expected, string(b))
+		}
+	}
+
+	// values are reported at the evaluation time, rather than the sample time
+	b, err := MarshalVector(ds, time.Unix(1435781445, 0), time.Unix(1435781450, 0))
+	if err != nil {
+		t.Error(err)
+	}
+	const expected = `{"status":"success","data":{"resultType":"vector","result":[{` +
+		`"metric":{"__name__":"up","instance":"localhost:9090","job":"prometheus"},` +
+		`"value":[1435781450,"1"]}]}}`
+	if string(b) != expected {
+		t.Errorf("expected %s got %s", expected, string(b))
+	}
+
+	_, err = MarshalVector(nil, time.Unix(0, 0), time.Unix(0, 0))
+	if err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+	ds.Results = nil
+	_, err = MarshalVector(ds, time.Unix(0, 0), time.Unix(0, 0))
+	if err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+}
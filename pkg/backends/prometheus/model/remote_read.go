@@ -0,0 +1,621 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// This file models the messages of the Prometheus Remote Read protocol, as defined in
+// https://github.com/prometheus/prometheus/blob/master/prompb/remote.proto, which are
+// exchanged as snappy-compressed protocol buffers
+
+// ErrInvalidWireType indicates a protocol buffer field is not encoded with the expected wire type
+var ErrInvalidWireType = errors.New("invalid protocol buffer wire type")
+
+// ErrUnsupportedReadRequest indicates a ReadRequest cannot be served from the cache,
+// because it has multiple queries or does not accept a sampled response
+var ErrUnsupportedReadRequest = errors.New("unsupported remote read request")
+
+// ReadResponseType enumerates the response types a Remote Read client can accept
+type ReadResponseType int32
+
+const (
+	// ReadResponseTypeSamples is a ReadResponse containing the raw samples of each series
+	ReadResponseTypeSamples = ReadResponseType(iota)
+	// ReadResponseTypeStreamedXORChunks is a stream of ChunkedReadResponses
+	ReadResponseTypeStreamedXORChunks
+)
+
+// MatchType enumerates the types of Label Matchers
+type MatchType int32
+
+const (
+	// MatchEqual matches labels equal to the value
+	MatchEqual = MatchType(iota)
+	// MatchNotEqual matches labels not equal to the value
+	MatchNotEqual
+	// MatchRegexp matches labels matching the value's regular expression
+	MatchRegexp
+	// MatchNotRegexp matches labels not matching the value's regular expression
+	MatchNotRegexp
+)
+
+var matchTypeOperators = map[MatchType]string{
+	MatchEqual:     "=",
+	MatchNotEqual:  "!=",
+	MatchRegexp:    "=~",
+	MatchNotRegexp: "!~",
+}
+
+func (t MatchType) String() string {
+	if v, ok := matchTypeOperators[t]; ok {
+		return v
+	}
+	return strconv.Itoa(int(t))
+}
+
+// ReadRequest is a Remote Read request, containing one or more queries
+type ReadRequest struct {
+	Queries               []*ReadQuery
+	AcceptedResponseTypes []ReadResponseType
+}
+
+// ReadQuery is a query for the raw samples of the series matching the Matchers
+// between the start and end timestamps (in milliseconds), inclusive
+type ReadQuery struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []*LabelMatcher
+	Hints            *ReadHints
+}
+
+// LabelMatcher matches series by the value of a label
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+// ReadHints are the optional hints provided to the Remote Read server about the query
+type ReadHints struct {
+	StepMs   int64
+	Func     string
+	StartMs  int64
+	EndMs    int64
+	Grouping []string
+	By       bool
+	RangeMs  int64
+}
+
+// AcceptsSamples returns true if the client accepts a ReadResponse of raw samples, which
+// is the case when the request does not specify any accepted response types
+func (rr *ReadRequest) AcceptsSamples() bool {
+	if len(rr.AcceptedResponseTypes) == 0 {
+		return true
+	}
+	for _, t := range rr.AcceptedResponseTypes {
+		if t == ReadResponseTypeSamples {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy of the ReadRequest
+func (rr *ReadRequest) Clone() *ReadRequest {
+	rr2 := &ReadRequest{Queries: make([]*ReadQuery, len(rr.Queries))}
+	for i, q := range rr.Queries {
+		rr2.Queries[i] = q.Clone()
+	}
+	if rr.AcceptedResponseTypes != nil {
+		rr2.AcceptedResponseTypes = make([]ReadResponseType, len(rr.AcceptedResponseTypes))
+		copy(rr2.AcceptedResponseTypes, rr.AcceptedResponseTypes)
+	}
+	return rr2
+}
+
+// Clone returns a deep copy of the ReadQuery
+func (q *ReadQuery) Clone() *ReadQuery {
+	q2 := &ReadQuery{
+		StartTimestampMs: q.StartTimestampMs,
+		EndTimestampMs:   q.EndTimestampMs,
+		Matchers:         make([]*LabelMatcher, len(q.Matchers)),
+	}
+	for i, m := range q.Matchers {
+		q2.Matchers[i] = &LabelMatcher{Type: m.Type, Name: m.Name, Value: m.Value}
+	}
+	if q.Hints != nil {
+		h := *q.Hints
+		if q.Hints.Grouping != nil {
+			h.Grouping = make([]string, len(q.Hints.Grouping))
+			copy(h.Grouping, q.Hints.Grouping)
+		}
+		q2.Hints = &h
+	}
+	return q2
+}
+
+// Statement returns the query's matchers and hints as a PromQL-like selector string, like
+// {__name__="up",job=~"api.*"}, excluding its time range. Matchers are sorted, so that
+// equivalent queries provide the same Statement
+func (q *ReadQuery) Statement() string {
+	ms := make([]string, len(q.Matchers))
+	for i, m := range q.Matchers {
+		ms[i] = m.Name + m.Type.String() + strconv.Quote(m.Value)
+	}
+	sort.Strings(ms)
+	s := "{" + strings.Join(ms, ",") + "}"
+	if h := q.Hints; h != nil {
+		s += " hints(step_ms=" + strconv.FormatInt(h.StepMs, 10) +
+			",func=" + strconv.Quote(h.Func) +
+			",range_ms=" + strconv.FormatInt(h.RangeMs, 10) +
+			",by=" + strconv.FormatBool(h.By) +
+			",grouping=[" + strings.Join(h.Grouping, ",") + "])"
+	}
+	return s
+}
+
+// DecodeReadRequest decodes a snappy-compressed ReadRequest
+func DecodeReadRequest(b []byte) (*ReadRequest, error) {
+	d, err := snappy.Decode(nil, b)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalReadRequest(d)
+}
+
+// Encode returns the snappy-compressed protocol buffer encoding of the ReadRequest
+func (rr *ReadRequest) Encode() []byte {
+	return snappy.Encode(nil, rr.Marshal())
+}
+
+// UnmarshalReadRequest decodes the protocol buffer encoding of a ReadRequest
+func UnmarshalReadRequest(b []byte) (*ReadRequest, error) {
+	rr := &ReadRequest{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			q, err := unmarshalReadQuery(v)
+			if err != nil {
+				return err
+			}
+			rr.Queries = append(rr.Queries, q)
+		case 2:
+			// repeated enums are packed, but unpacked values must also be accepted
+			switch typ {
+			case protowire.VarintType:
+				rr.AcceptedResponseTypes = append(rr.AcceptedResponseTypes, ReadResponseType(x))
+			case protowire.BytesType:
+				for len(v) > 0 {
+					t, n := protowire.ConsumeVarint(v)
+					if n < 0 {
+						return protowire.ParseError(n)
+					}
+					rr.AcceptedResponseTypes = append(rr.AcceptedResponseTypes, ReadResponseType(t))
+					v = v[n:]
+				}
+			default:
+				return ErrInvalidWireType
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+func unmarshalReadQuery(b []byte) (*ReadQuery, error) {
+	q := &ReadQuery{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1, 2:
+			if typ != protowire.VarintType {
+				return ErrInvalidWireType
+			}
+			if num == 1 {
+				q.StartTimestampMs = int64(x)
+			} else {
+				q.EndTimestampMs = int64(x)
+			}
+		case 3:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			m, err := unmarshalLabelMatcher(v)
+			if err != nil {
+				return err
+			}
+			q.Matchers = append(q.Matchers, m)
+		case 4:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			h, err := unmarshalReadHints(v)
+			if err != nil {
+				return err
+			}
+			q.Hints = h
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func unmarshalLabelMatcher(b []byte) (*LabelMatcher, error) {
+	m := &LabelMatcher{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			if typ != protowire.VarintType {
+				return ErrInvalidWireType
+			}
+			m.Type = MatchType(x)
+		case 2, 3:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			if num == 2 {
+				m.Name = string(v)
+			} else {
+				m.Value = string(v)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func unmarshalReadHints(b []byte) (*ReadHints, error) {
+	h := &ReadHints{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1, 3, 4, 6, 7:
+			if typ != protowire.VarintType {
+				return ErrInvalidWireType
+			}
+			switch num {
+			case 1:
+				h.StepMs = int64(x)
+			case 3:
+				h.StartMs = int64(x)
+			case 4:
+				h.EndMs = int64(x)
+			case 6:
+				h.By = protowire.DecodeBool(x)
+			case 7:
+				h.RangeMs = int64(x)
+			}
+		case 2, 5:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			if num == 2 {
+				h.Func = string(v)
+			} else {
+				h.Grouping = append(h.Grouping, string(v))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Marshal returns the protocol buffer encoding of the ReadRequest
+func (rr *ReadRequest) Marshal() []byte {
+	var b []byte
+	for _, q := range rr.Queries {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, q.marshal())
+	}
+	if len(rr.AcceptedResponseTypes) > 0 {
+		var p []byte
+		for _, t := range rr.AcceptedResponseTypes {
+			p = protowire.AppendVarint(p, uint64(t))
+		}
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, p)
+	}
+	return b
+}
+
+func (q *ReadQuery) marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, uint64(q.StartTimestampMs))
+	b = appendVarintField(b, 2, uint64(q.EndTimestampMs))
+	for _, m := range q.Matchers {
+		var mb []byte
+		mb = appendVarintField(mb, 1, uint64(m.Type))
+		mb = appendStringField(mb, 2, m.Name)
+		mb = appendStringField(mb, 3, m.Value)
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, mb)
+	}
+	if h := q.Hints; h != nil {
+		var hb []byte
+		hb = appendVarintField(hb, 1, uint64(h.StepMs))
+		hb = appendStringField(hb, 2, h.Func)
+		hb = appendVarintField(hb, 3, uint64(h.StartMs))
+		hb = appendVarintField(hb, 4, uint64(h.EndMs))
+		for _, g := range h.Grouping {
+			hb = protowire.AppendTag(hb, 5, protowire.BytesType)
+			hb = protowire.AppendString(hb, g)
+		}
+		hb = appendVarintField(hb, 6, protowire.EncodeBool(h.By))
+		hb = appendVarintField(hb, 7, uint64(h.RangeMs))
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, hb)
+	}
+	return b
+}
+
+// ReadResponse is a Remote Read response, containing one QueryResult per query in the request
+type ReadResponse struct {
+	Results []*QueryResult
+}
+
+// QueryResult contains the series matching a ReadQuery
+type QueryResult struct {
+	Timeseries []*TimeSeries
+}
+
+// TimeSeries is a series of samples identified by its labels
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// Label is a name/value pair identifying a series
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value recorded at a timestamp (in milliseconds)
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// DecodeReadResponse decodes a snappy-compressed ReadResponse
+func DecodeReadResponse(b []byte) (*ReadResponse, error) {
+	d, err := snappy.Decode(nil, b)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalReadResponse(d)
+}
+
+// Encode returns the snappy-compressed protocol buffer encoding of the ReadResponse
+func (rr *ReadResponse) Encode() []byte {
+	return snappy.Encode(nil, rr.Marshal())
+}
+
+// UnmarshalReadResponse decodes the protocol buffer encoding of a ReadResponse
+func UnmarshalReadResponse(b []byte) (*ReadResponse, error) {
+	rr := &ReadResponse{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		if num != 1 {
+			return nil
+		}
+		if typ != protowire.BytesType {
+			return ErrInvalidWireType
+		}
+		qr := &QueryResult{}
+		err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+			if num != 1 {
+				return nil
+			}
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			ts, err := unmarshalTimeSeries(v)
+			if err != nil {
+				return err
+			}
+			qr.Timeseries = append(qr.Timeseries, ts)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		rr.Results = append(rr.Results, qr)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+func unmarshalTimeSeries(b []byte) (*TimeSeries, error) {
+	ts := &TimeSeries{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			l := Label{}
+			err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+				if num != 1 && num != 2 {
+					return nil
+				}
+				if typ != protowire.BytesType {
+					return ErrInvalidWireType
+				}
+				if num == 1 {
+					l.Name = string(v)
+				} else {
+					l.Value = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			if typ != protowire.BytesType {
+				return ErrInvalidWireType
+			}
+			s := Sample{}
+			err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+				switch num {
+				case 1:
+					if typ != protowire.Fixed64Type {
+						return ErrInvalidWireType
+					}
+					s.Value = math.Float64frombits(x)
+				case 2:
+					if typ != protowire.VarintType {
+						return ErrInvalidWireType
+					}
+					s.Timestamp = int64(x)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Marshal returns the protocol buffer encoding of the ReadResponse
+func (rr *ReadResponse) Marshal() []byte {
+	var b []byte
+	for _, qr := range rr.Results {
+		var qb []byte
+		for _, ts := range qr.Timeseries {
+			qb = protowire.AppendTag(qb, 1, protowire.BytesType)
+			qb = protowire.AppendBytes(qb, ts.marshal())
+		}
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, qb)
+	}
+	return b
+}
+
+func (ts *TimeSeries) marshal() []byte {
+	var b []byte
+	for _, l := range ts.Labels {
+		var lb []byte
+		lb = appendStringField(lb, 1, l.Name)
+		lb = appendStringField(lb, 2, l.Value)
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+	for _, s := range ts.Samples {
+		var sb []byte
+		sb = appendDoubleField(sb, 1, s.Value)
+		sb = appendVarintField(sb, 2, uint64(s.Timestamp))
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, sb)
+	}
+	return b
+}
+
+// appendVarintField appends the varint field to b, unless it is the zero value,
+// which is omitted in proto3
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendStringField appends the string field to b, unless it is empty,
+// which is omitted in proto3
+func appendStringField(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendDoubleField appends the double field to b, unless it is zero,
+// which is omitted in proto3
+func appendDoubleField(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 && !math.Signbit(v) {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+// consumeFields calls fn with each field of the protocol buffer message b, providing the
+// field's number and wire type, along with its value, which is v for length-delimited
+// fields and x for varint and fixed64 fields. Fields of other wire types are skipped
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		var x uint64
+		switch typ {
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			x, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, typ, v, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
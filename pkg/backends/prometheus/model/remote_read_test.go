@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func testReadRequest() *ReadRequest {
+	return &ReadRequest{
+		Queries: []*ReadQuery{{
+			StartTimestampMs: 1577836800000,
+			EndTimestampMs:   1577840400000,
+			Matchers: []*LabelMatcher{
+				{Type: MatchRegexp, Name: "job", Value: "api.*"},
+				{Type: MatchEqual, Name: "__name__", Value: "up"},
+				{Type: MatchNotEqual, Name: "env", Value: "dev"},
+				{Type: MatchNotRegexp, Name: "instance", Value: `"a"`},
+			},
+			Hints: &ReadHints{StepMs: 15000, Func: "rate", StartMs: 1577836800000,
+				EndMs: 1577840400000, Grouping: []string{"job"}, By: true, RangeMs: 60000},
+		}},
+		AcceptedResponseTypes: []ReadResponseType{ReadResponseTypeSamples},
+	}
+}
+
+func TestReadRequestEncodeDecode(t *testing.T) {
+	rr := testReadRequest()
+	rr2, err := DecodeReadRequest(rr.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rr, rr2) {
+		t.Errorf("expected %v got %v", rr, rr2)
+	}
+	_, err = DecodeReadRequest([]byte("invalid"))
+	if err == nil {
+		t.Error("expected error for invalid snappy encoding")
+	}
+}
+
+func TestUnmarshalReadRequest(t *testing.T) {
+
+	// unpacked response types and unknown fields
+	var b []byte
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(ReadResponseTypeStreamedXORChunks))
+	b = protowire.AppendTag(b, 9, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, 1)
+	rr, err := UnmarshalReadRequest(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.AcceptedResponseTypes) != 1 ||
+		rr.AcceptedResponseTypes[0] != ReadResponseTypeStreamedXORChunks {
+		t.Errorf("unexpected response types %v", rr.AcceptedResponseTypes)
+	}
+
+	// invalid wire type for queries
+	b = protowire.AppendTag(nil, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	if _, err = UnmarshalReadRequest(b); err != ErrInvalidWireType {
+		t.Errorf("expected %v got %v", ErrInvalidWireType, err)
+	}
+
+	// truncated message
+	if _, err = UnmarshalReadRequest(testReadRequest().Marshal()[:10]); err == nil {
+		t.Error("expected error for truncated message")
+	}
+}
+
+func TestReadRequestAcceptsSamples(t *testing.T) {
+	rr := &ReadRequest{}
+	if !rr.AcceptsSamples() {
+		t.Error("expected true")
+	}
+	rr.AcceptedResponseTypes = []ReadResponseType{ReadResponseTypeStreamedXORChunks}
+	if rr.AcceptsSamples() {
+		t.Error("expected false")
+	}
+	rr.AcceptedResponseTypes = append(rr.AcceptedResponseTypes, ReadResponseTypeSamples)
+	if !rr.AcceptsSamples() {
+		t.Error("expected true")
+	}
+}
+
+func TestReadRequestClone(t *testing.T) {
+	rr := testReadRequest()
+	rr2 := rr.Clone()
+	if !reflect.DeepEqual(rr, rr2) {
+		t.Errorf("expected %v got %v", rr, rr2)
+	}
+	rr2.Queries[0].StartTimestampMs = 0
+	rr2.Queries[0].Matchers[0].Value = "x"
+	rr2.Queries[0].Hints.Grouping[0] = "x"
+	if rr.Queries[0].StartTimestampMs == 0 || rr.Queries[0].Matchers[0].Value == "x" ||
+		rr.Queries[0].Hints.Grouping[0] == "x" {
+		t.Error("expected clone to be independent")
+	}
+}
+
+func TestReadQueryStatement(t *testing.T) {
+	q := testReadRequest().Queries[0]
+	const expected = `{__name__="up",env!="dev",instance!~"\"a\"",job=~"api.*"}` +
+		` hints(step_ms=15000,func="rate",range_ms=60000,by=true,grouping=[job])`
+	if s := q.Statement(); s != expected {
+		t.Errorf("expected %s got %s", expected, s)
+	}
+	// the statement excludes the time range
+	q.Hints.StartMs = 0
+	q.StartTimestampMs = 0
+	if s := q.Statement(); s != expected {
+		t.Errorf("expected %s got %s", expected, s)
+	}
+}
+
+func TestMatchTypeString(t *testing.T) {
+	if s := MatchNotRegexp.String(); s != "!~" {
+		t.Errorf("expected %s got %s", "!~", s)
+	}
+	if s := MatchType(9).String(); s != "9" {
+		t.Errorf("expected %s got %s", "9", s)
+	}
+}
+
+func TestReadResponseEncodeDecode(t *testing.T) {
+	rr := &ReadResponse{Results: []*QueryResult{{Timeseries: []*TimeSeries{{
+		Labels:  []Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "a"}},
+		Samples: []Sample{{Value: 1.5, Timestamp: 1577836800000}, {Value: 0, Timestamp: 1577836815000}},
+	}}}}}
+	rr2, err := DecodeReadResponse(rr.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rr, rr2) {
+		t.Errorf("expected %v got %v", rr, rr2)
+	}
+	_, err = DecodeReadResponse([]byte("invalid"))
+	if err == nil {
+		t.Error("expected error for invalid snappy encoding")
+	}
+
+	// invalid wire type for a sample value
+	var sb []byte
+	sb = protowire.AppendTag(sb, 1, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, 1)
+	var tb []byte
+	tb = protowire.AppendTag(tb, 2, protowire.BytesType)
+	tb = protowire.AppendBytes(tb, sb)
+	var qb []byte
+	qb = protowire.AppendTag(qb, 1, protowire.BytesType)
+	qb = protowire.AppendBytes(qb, tb)
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, qb)
+	if _, err = UnmarshalReadResponse(b); err != ErrInvalidWireType {
+		t.Errorf("expected %v got %v", ErrInvalidWireType, err)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
+)
+
+// NameRemoteReadVersion is the name of the HTTP Header indicating the Remote Read protocol version
+const NameRemoteReadVersion = "X-Prometheus-Remote-Read-Version"
+
+// RemoteReadVersion is the version of the Remote Read protocol supported by Trickster
+const RemoteReadVersion = "0.1.0"
+
+// NewRemoteReadModeler returns a collection of modeling functions for interoperability with
+// the Prometheus Remote Read protocol, whose raw samples are cached as a DataSet
+func NewRemoteReadModeler() *timeseries.Modeler {
+	return &timeseries.Modeler{
+		WireUnmarshalerReader: UnmarshalRemoteReadTimeseriesReader,
+		WireMarshaler:         MarshalRemoteReadTimeseries,
+		WireMarshalWriter:     MarshalRemoteReadTimeseriesWriter,
+		WireUnmarshaler:       UnmarshalRemoteReadTimeseries,
+		CacheMarshaler:        dataset.MarshalDataSet,
+		CacheUnmarshaler:      dataset.UnmarshalDataSet,
+	}
+}
+
+// UnmarshalRemoteReadTimeseries converts a snappy-compressed ReadResponse into a Timeseries
+func UnmarshalRemoteReadTimeseries(data []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if trq == nil {
+		return nil, timeseries.ErrNoTimerangeQuery
+	}
+	rr, err := DecodeReadResponse(data)
+	if err != nil {
+		return nil, err
+	}
+	// A Remote Read request is only accelerated when it contains a single query
+	if len(rr.Results) > 1 {
+		return nil, timeseries.ErrInvalidBody
+	}
+	ds := &dataset.DataSet{
+		Status:         "success",
+		Results:        []*dataset.Result{{}},
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+	}
+	if len(rr.Results) == 0 {
+		return ds, nil
+	}
+	ds.Results[0].SeriesList = make([]*dataset.Series, 0, len(rr.Results[0].Timeseries))
+	for _, ts := range rr.Results[0].Timeseries {
+		sh := dataset.SeriesHeader{
+			Tags:           make(dataset.Tags, len(ts.Labels)),
+			QueryStatement: trq.Statement,
+			FieldsList: []timeseries.FieldDefinition{{
+				Name:     "value",
+				DataType: timeseries.Float64,
+			}},
+		}
+		for _, l := range ts.Labels {
+			sh.Tags[l.Name] = l.Value
+		}
+		sh.Name = sh.Tags["__name__"]
+		sh.Size = sh.CalculateSize()
+		pts := make(dataset.Points, len(ts.Samples))
+		for i, s := range ts.Samples {
+			pts[i] = dataset.Point{
+				Epoch:  epoch.Epoch(s.Timestamp * int64(time.Millisecond)),
+				Size:   40, // 8 bytes for epoch, 8 bytes for size, 16 for the interface, 8 for the float64
+				Values: []interface{}{s.Value},
+			}
+		}
+		sort.Sort(pts)
+		ds.Results[0].SeriesList = append(ds.Results[0].SeriesList,
+			&dataset.Series{Header: sh, Points: pts, PointSize: pts.Size()})
+	}
+	return ds, nil
+}
+
+// UnmarshalRemoteReadTimeseriesReader converts a snappy-compressed ReadResponse
+// into a Timeseries via io.Reader
+func UnmarshalRemoteReadTimeseriesReader(reader io.Reader,
+	trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalRemoteReadTimeseries(b, trq)
+}
+
+// MarshalRemoteReadTimeseries converts a Timeseries into a snappy-compressed ReadResponse
+func MarshalRemoteReadTimeseries(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+	status int) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := MarshalRemoteReadTimeseriesWriter(ts, rlo, status, buf)
+	return buf.Bytes(), err
+}
+
+// MarshalRemoteReadTimeseriesWriter converts a Timeseries into a snappy-compressed
+// ReadResponse via an io.Writer
+func MarshalRemoteReadTimeseriesWriter(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+	status int, w io.Writer) error {
+
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok {
+		return timeseries.ErrUnknownFormat
+	}
+	// As with Prometheus queries, we presume only one Result per Dataset
+	if len(ds.Results) != 1 {
+		return timeseries.ErrUnknownFormat
+	}
+
+	// samples are cached in step-aligned extents, so the output is cropped to the
+	// exact range requested by the client when it is provided
+	var start, end epoch.Epoch
+	if rlo != nil && rlo.OutputExtent != nil {
+		start = epoch.Epoch(rlo.OutputExtent.Start.UnixNano())
+		end = epoch.Epoch(rlo.OutputExtent.End.UnixNano())
+	}
+
+	qr := &QueryResult{Timeseries: make([]*TimeSeries, 0, len(ds.Results[0].SeriesList))}
+	for _, s := range ds.Results[0].SeriesList {
+		if s == nil || len(s.Points) == 0 {
+			continue
+		}
+		rts := &TimeSeries{
+			Labels:  make([]Label, 0, len(s.Header.Tags)),
+			Samples: make([]Sample, 0, len(s.Points)),
+		}
+		for _, k := range s.Header.Tags.Keys() {
+			rts.Labels = append(rts.Labels, Label{Name: k, Value: s.Header.Tags[k]})
+		}
+		for _, p := range s.Points {
+			if len(p.Values) == 0 || (end > 0 && (p.Epoch < start || p.Epoch > end)) {
+				continue
+			}
+			v, ok := p.Values[0].(float64)
+			if !ok {
+				return timeseries.ErrInvalidBody
+			}
+			rts.Samples = append(rts.Samples,
+				Sample{Value: v, Timestamp: int64(p.Epoch) / int64(time.Millisecond)})
+		}
+		if len(rts.Samples) == 0 {
+			continue
+		}
+		qr.Timeseries = append(qr.Timeseries, rts)
+	}
+
+	if rw, ok := w.(http.ResponseWriter); ok {
+		h := rw.Header()
+		h.Del(headers.NameContentLength)
+		h.Set(headers.NameContentType, headers.ValueApplicationProtobuf)
+		h.Set(headers.NameContentEncoding, headers.ValueSnappy)
+		h.Set(NameRemoteReadVersion, RemoteReadVersion)
+		rw.WriteHeader(status)
+	}
+
+	_, err := w.Write((&ReadResponse{Results: []*QueryResult{qr}}).Encode())
+	return err
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+)
+
+func testReadResponse() *ReadResponse {
+	return &ReadResponse{Results: []*QueryResult{{Timeseries: []*TimeSeries{
+		{
+			Labels: []Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "a"}},
+			Samples: []Sample{{Value: 2, Timestamp: 1577836815000},
+				{Value: 1, Timestamp: 1577836800000}, {Value: 3, Timestamp: 1577836830000}},
+		},
+		{
+			Labels: []Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "b"}},
+		},
+	}}}}
+}
+
+func TestNewRemoteReadModeler(t *testing.T) {
+	m := NewRemoteReadModeler()
+	if m.WireUnmarshaler == nil || m.WireMarshalWriter == nil {
+		t.Error("expected non-nil functions")
+	}
+}
+
+func TestUnmarshalRemoteReadTimeseries(t *testing.T) {
+
+	trq := &timeseries.TimeRangeQuery{Statement: `{__name__="up"}`,
+		Extent: timeseries.Extent{Start: time.Unix(1577836800, 0), End: time.Unix(1577836830, 0)}}
+
+	ts, err := UnmarshalRemoteReadTimeseries(testReadResponse().Encode(), trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := ts.(*dataset.DataSet)
+	if len(ds.Results) != 1 || len(ds.Results[0].SeriesList) != 2 {
+		t.Fatalf("unexpected results %v", ds.Results)
+	}
+	s := ds.Results[0].SeriesList[0]
+	if s.Header.Name != "up" || s.Header.Tags["job"] != "a" {
+		t.Errorf("unexpected header %s", s.Header.String())
+	}
+	if len(s.Points) != 3 || s.Points[0].Epoch != 1577836800000000000 || s.Points[0].Values[0] != 1.0 {
+		t.Errorf("unexpected points %v", s.Points)
+	}
+
+	_, err = UnmarshalRemoteReadTimeseries(nil, nil)
+	if err != timeseries.ErrNoTimerangeQuery {
+		t.Errorf("expected %v got %v", timeseries.ErrNoTimerangeQuery, err)
+	}
+
+	_, err = UnmarshalRemoteReadTimeseries([]byte("invalid"), trq)
+	if err == nil {
+		t.Error("expected error for invalid body")
+	}
+
+	rr := testReadResponse()
+	rr.Results = append(rr.Results, rr.Results[0])
+	_, err = UnmarshalRemoteReadTimeseries(rr.Encode(), trq)
+	if err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+
+	ts, err = UnmarshalRemoteReadTimeseriesReader(bytes.NewReader((&ReadResponse{}).Encode()), trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.SeriesCount() != 0 {
+		t.Errorf("expected %d got %d", 0, ts.SeriesCount())
+	}
+}
+
+func TestMarshalRemoteReadTimeseries(t *testing.T) {
+
+	trq := &timeseries.TimeRangeQuery{Statement: `{__name__="up"}`,
+		Extent: timeseries.Extent{Start: time.Unix(1577836800, 0), End: time.Unix(1577836830, 0)}}
+	ts, err := UnmarshalRemoteReadTimeseries(testReadResponse().Encode(), trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := MarshalRemoteReadTimeseries(ts, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := DecodeReadResponse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// empty series are omitted and samples are sorted
+	if len(rr.Results) != 1 || len(rr.Results[0].Timeseries) != 1 {
+		t.Fatalf("unexpected results %v", rr.Results)
+	}
+	samples := rr.Results[0].Timeseries[0].Samples
+	if len(samples) != 3 || samples[0].Timestamp != 1577836800000 || samples[2].Value != 3 {
+		t.Errorf("unexpected samples %v", samples)
+	}
+
+	// the output is cropped to the requested extent
+	w := httptest.NewRecorder()
+	rlo := &timeseries.RequestOptions{OutputExtent: &timeseries.Extent{
+		Start: time.Unix(1577836801, 0), End: time.Unix(1577836815, 0)}}
+	err = MarshalRemoteReadTimeseriesWriter(ts, rlo, 200, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := w.Header().Get(headers.NameContentType); v != headers.ValueApplicationProtobuf {
+		t.Errorf("expected %s got %s", headers.ValueApplicationProtobuf, v)
+	}
+	if v := w.Header().Get(NameRemoteReadVersion); v != RemoteReadVersion {
+		t.Errorf("expected %s got %s", RemoteReadVersion, v)
+	}
+	rr, err = DecodeReadResponse(w.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples = rr.Results[0].Timeseries[0].Samples
+	if len(samples) != 1 || samples[0].Timestamp != 1577836815000 {
+		t.Errorf("unexpected samples %v", samples)
+	}
+
+	_, err = MarshalRemoteReadTimeseries(nil, nil, 200)
+	if err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+
+	_, err = MarshalRemoteReadTimeseries(&dataset.DataSet{}, nil, 200)
+	if err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+
+	ds := ts.(*dataset.DataSet)
+	ds.Results[0].SeriesList[0].Points[0].Values[0] = "1"
+	_, err = MarshalRemoteReadTimeseries(ds, nil, 200)
+	if err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+}
@@ -29,9 +29,11 @@ import (
 	"github.com/tricksterproxy/trickster/pkg/backends"
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/parsing/promql"
 	"github.com/tricksterproxy/trickster/pkg/proxy"
 	"github.com/tricksterproxy/trickster/pkg/proxy/errors"
 	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	tt "github.com/tricksterproxy/trickster/pkg/util/timeconv"
@@ -53,6 +55,7 @@ const (
 	mnAlerts        = "alerts"
 	mnAlertManagers = "alertmanagers"
 	mnStatus        = "status"
+	mnRead          = "read"
 )
 
 // Common URL Parameter Names
@@ -79,6 +82,7 @@ type Client struct {
 	healthMethod       string
 	router             http.Handler
 	modeler            *timeseries.Modeler
+	rangeSteps         *stepIndex
 }
 
 // NewClient returns a new Client Instance
@@ -87,7 +91,7 @@ func NewClient(name string, oc *oo.Options, router http.Handler,
 	c, err := proxy.NewHTTPClient(oc)
 	bur := urls.FromParts(oc.Scheme, oc.Host, oc.PathPrefix, "", "")
 	return &Client{name: name, config: oc, router: router, cache: cache,
-		webClient: c, baseUpstreamURL: bur, modeler: modeler}, err
+		webClient: c, baseUpstreamURL: bur, modeler: modeler, rangeSteps: newStepIndex()}, err
 }
 
 // SetCache sets the Cache object the client will use for caching origin content
@@ -149,16 +153,29 @@ func parseDuration(input string) (time.Duration, error) {
 func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
 	*timeseries.RequestOptions, bool, error) {
 
+	if rsc := request.GetResources(r); rsc != nil && rsc.PathConfig != nil &&
+		rsc.PathConfig.HandlerName == mnRead {
+		return readHandlerParseTimeRangeQuery(r)
+	}
+
 	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
 	rlo := &timeseries.RequestOptions{}
 
 	qp, _, _ := params.GetRequestValues(r)
 
-	trq.Statement = qp.Get(upQuery)
-	if trq.Statement == "" {
+	query := qp.Get(upQuery)
+	if query == "" {
 		return nil, nil, false, errors.MissingURLParam(upQuery)
 	}
 
+	// the query is parsed so that equivalent queries share a canonical statement and
+	// cache key. queries that fail to parse are proxied, so the origin can report the error
+	expr, err := promql.Parse(query)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	trq.Statement = expr.String()
+
 	if p := qp.Get(upStart); p != "" {
 		t, err := parseTime(p)
 		if err != nil {
@@ -189,26 +206,57 @@ func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuer
 		return nil, nil, false, errors.MissingURLParam(upStep)
 	}
 
-	if strings.Contains(trq.Statement, " offset ") {
-		trq.IsOffset = true
-		rlo.FastForwardDisable = true
-	}
-
-	rlo.ExtractFastForwardDisabled(trq.Statement)
-	trq.ExtractBackfillTolerance(trq.Statement)
+	// per-query instructions are conveyed in comments, which the canonical statement omits
+	rlo.ExtractFastForwardDisabled(query)
+	trq.ExtractBackfillTolerance(query)
 
-	if x := strings.Index(trq.Statement, timeseries.BackfillToleranceFlag); x > 1 {
+	if x := strings.Index(query, timeseries.BackfillToleranceFlag); x > 1 {
 		x += 29
 		y := x
-		for ; y < len(trq.Statement); y++ {
-			if trq.Statement[y] < 48 || trq.Statement[y] > 57 {
+		for ; y < len(query); y++ {
+			if query[y] < 48 || query[y] > 57 {
 				break
 			}
 		}
-		if i, err := strconv.Atoi(trq.Statement[x:y]); err == nil {
+		if i, err := strconv.Atoi(query[x:y]); err == nil {
 			trq.BackfillTolerance = time.Second * time.Duration(i)
 		}
 	}
 
+	var atStartEnd bool
+	promql.Inspect(expr, func(e promql.Expr) bool {
+		var offset time.Duration
+		var at *promql.AtModifier
+		switch n := e.(type) {
+		case *promql.VectorSelector:
+			offset, at = n.Offset, n.At
+		case *promql.SubqueryExpr:
+			offset, at = n.Offset, n.At
+		}
+		if offset != 0 || at != nil {
+			trq.IsOffset = true
+		}
+		if at != nil && at.Preprocessor != "" {
+			atStartEnd = true
+		}
+		return true
+	})
+	if atStartEnd {
+		return nil, nil, false, errors.ErrRangeDependentQuery
+	}
+	if trq.IsOffset {
+		rlo.FastForwardDisable = true
+	}
+
+	// the TemplateURL carries the canonical statement and step for use in cache key
+	// derivation, and excludes the time range parameters, since they are set by SetExtent
+	trq.TemplateURL = urls.Clone(r.URL)
+	qt := url.Values(http.Header(qp).Clone())
+	qt.Del(upStart)
+	qt.Del(upEnd)
+	qt.Set(upQuery, trq.Statement)
+	qt.Set(upStep, strconv.FormatFloat(trq.Step.Seconds(), 'f', -1, 64))
+	trq.TemplateURL.RawQuery = qt.Encode()
+
 	return trq, rlo, true, nil
 }
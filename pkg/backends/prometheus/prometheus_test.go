@@ -18,6 +18,7 @@ package prometheus
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -31,6 +32,7 @@ import (
 	cr "github.com/tricksterproxy/trickster/pkg/cache/registration"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/parsing/promql"
 	pe "github.com/tricksterproxy/trickster/pkg/proxy/errors"
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 )
@@ -260,6 +262,27 @@ func TestParseTimeRangeBadStartTime(t *testing.T) {
 	}
 }
 
+func TestParseTimeRangeEmptySelector(t *testing.T) {
+	req := &http.Request{URL: &url.URL{
+		Scheme: "https",
+		Host:   "blah.com",
+		Path:   "/",
+		RawQuery: url.Values(map[string][]string{
+			"query": {`{}`},
+			"start": {strconv.Itoa(int(time.Now().Add(time.Duration(-6) * time.Hour).Unix()))},
+			"end":   {strconv.Itoa(int(time.Now().Unix()))},
+			"step":  {"15"}}).Encode(),
+	}}
+	client := &Client{}
+	_, _, canOPC, err := client.ParseTimeRangeQuery(req)
+	if !errors.Is(err, promql.ErrEmptySelector) {
+		t.Errorf("expected %v got %v", promql.ErrEmptySelector, err)
+	}
+	if canOPC {
+		t.Error("expected canOPC to be false")
+	}
+}
+
 func TestParseTimeRangeBadEndTime(t *testing.T) {
 	const color = "blue"
 	expected := fmt.Errorf(`cannot parse "%s" to a valid timestamp`, color)
@@ -383,28 +406,97 @@ func TestParseTimeRangeQueryNoStep(t *testing.T) {
 }
 
 func TestParseTimeRangeQueryWithOffset(t *testing.T) {
-	req := &http.Request{URL: &url.URL{
-		Scheme: "https",
-		Host:   "blah.com",
-		Path:   "/",
-		RawQuery: url.Values(map[string][]string{
-			"query": {`up and has offset `},
-			"start": {strconv.Itoa(int(time.Now().Add(time.Duration(-6) * time.Hour).Unix()))},
-			"end":   {strconv.Itoa(int(time.Now().Unix()))},
-			"step":  {"15"},
-		}).Encode(),
-	}}
-	client := &Client{}
-	res, _, _, err := client.ParseTimeRangeQuery(req)
-	if err != nil {
-		t.Error(err)
-		return
+
+	tests := []struct {
+		query    string
+		isOffset bool
+		err      error
+	}{
+		{`up`, false, nil},
+		{`sum(rate(offset[5m])) by (offset)`, false, nil},
+		{`up offset 5m`, true, nil},
+		{`rate(up[5m] OFFSET -1h)`, true, nil},
+		{`max_over_time(rate(up[5m])[1h:] offset 1d)`, true, nil},
+		{`up @ 1609746000`, true, nil},
+		{`up and has offset `, false, promql.ErrUnexpectedEOF},
+		{`rate(up[5m] @ start())`, false, pe.ErrRangeDependentQuery},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			req := &http.Request{URL: &url.URL{
+				Scheme: "https",
+				Host:   "blah.com",
+				Path:   "/",
+				RawQuery: url.Values(map[string][]string{
+					"query": {test.query},
+					"start": {strconv.Itoa(int(time.Now().Add(time.Duration(-6) * time.Hour).Unix()))},
+					"end":   {strconv.Itoa(int(time.Now().Unix()))},
+					"step":  {"15"},
+				}).Encode(),
+			}}
+			client := &Client{}
+			res, rlo, canOPC, err := client.ParseTimeRangeQuery(req)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v got %v", test.err, err)
+			}
+			if err != nil {
+				if canOPC {
+					t.Error("expected false")
+				}
+				return
+			}
+			if res.IsOffset != test.isOffset {
+				t.Errorf("expected %t got %t", test.isOffset, res.IsOffset)
+			}
+			if rlo.FastForwardDisable != test.isOffset {
+				t.Errorf("expected %t got %t", test.isOffset, rlo.FastForwardDisable)
+			}
+		})
 	}
+}
 
-	if !res.IsOffset {
-		t.Errorf("expected true got %t", res.IsOffset)
+func TestParseTimeRangeQueryCanonical(t *testing.T) {
+
+	queries := []string{
+		`sum(rate(http_requests_total{job="api",code=~"5.."}[5m])) by (instance)`,
+		"sum by(instance) (\n  rate(http_requests_total{code=~'5..', job=\"api\"}[300s]) # errors\n)",
+		`SUM BY (instance) ((rate(http_requests_total{code=~"5..",job="api",job="api"}[5m])))`,
 	}
+	const expected = `sum by (instance) (rate(http_requests_total{code=~"5..",job="api"}[5m]))`
 
+	client := &Client{}
+	var key string
+	for _, q := range queries {
+		qp := url.Values(map[string][]string{
+			"query":   {q},
+			"start":   {"1609459200"},
+			"end":     {"1609466400"},
+			"step":    {"1m"},
+			"timeout": {"30s"},
+		})
+		req := &http.Request{URL: &url.URL{Scheme: "https", Host: "blah.com",
+			Path: "/api/v1/query_range", RawQuery: qp.Encode()}}
+		res, _, _, err := client.ParseTimeRangeQuery(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Statement != expected {
+			t.Errorf("expected %s got %s", expected, res.Statement)
+		}
+		tq := res.TemplateURL.Query()
+		if tq.Get(upStart) != "" || tq.Get(upEnd) != "" {
+			t.Error("expected time range parameters to be excluded")
+		}
+		if tq.Get(upStep) != "60" || tq.Get("timeout") != "30s" {
+			t.Errorf("unexpected template parameters: %s", res.TemplateURL.RawQuery)
+		}
+		if key == "" {
+			key = res.TemplateURL.RawQuery
+		} else if res.TemplateURL.RawQuery != key {
+			t.Errorf("expected %s got %s", key, res.TemplateURL.RawQuery)
+		}
+	}
 }
 
 func TestSetCache(t *testing.T) {
@@ -19,8 +19,10 @@ package prometheus
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache/key"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
 	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
@@ -35,6 +37,8 @@ func (c *Client) registerHandlers() {
 	c.handlers["query_range"] = http.HandlerFunc(c.QueryRangeHandler)
 	c.handlers["query"] = http.HandlerFunc(c.QueryHandler)
 	c.handlers["series"] = http.HandlerFunc(c.SeriesHandler)
+	c.handlers["labels"] = http.HandlerFunc(c.LabelsHandler)
+	c.handlers["read"] = http.HandlerFunc(c.ReadHandler)
 	c.handlers["proxycache"] = http.HandlerFunc(c.ObjectProxyCacheHandler)
 	c.handlers["proxy"] = http.HandlerFunc(c.ProxyHandler)
 }
@@ -85,6 +89,22 @@ func (c *Client) DefaultPathConfigs(oc *oo.Options) map[string]*po.Options {
 			MatchType:       matching.PathMatchTypeExact,
 		},
 
+		APIPath + mnRead: {
+			Path:            APIPath + mnRead,
+			HandlerName:     mnRead,
+			KeyHasher:       []key.HasherFunc{readHandlerDeriveCacheKey},
+			Methods:         []string{http.MethodPost},
+			CacheKeyParams:  []string{},
+			CacheKeyHeaders: []string{},
+			ResponseHeaders: rhts,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultQueryTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultQueryTimeNormalization,
+			HasTimeNormalization: true,
+		},
+
 		APIPath + mnQuery: {
 			Path:            APIPath + mnQuery,
 			HandlerName:     mnQuery,
@@ -94,6 +114,10 @@ func (c *Client) DefaultPathConfigs(oc *oo.Options) map[string]*po.Options {
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultQueryTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultQueryTimeNormalization,
+			HasTimeNormalization: true,
 		},
 
 		APIPath + mnSeries: {
@@ -105,28 +129,40 @@ func (c *Client) DefaultPathConfigs(oc *oo.Options) map[string]*po.Options {
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultMetadataTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultMetadataTimeNormalization,
+			HasTimeNormalization: true,
 		},
 
 		APIPath + mnLabels: {
 			Path:            APIPath + mnLabels,
-			HandlerName:     "proxycache",
+			HandlerName:     mnLabels,
 			Methods:         []string{http.MethodGet, http.MethodPost},
-			CacheKeyParams:  []string{},
+			CacheKeyParams:  []string{upMatch, upStart, upEnd},
 			CacheKeyHeaders: []string{},
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
+
+			TimeNormalizationMS:  int(defaultMetadataTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultMetadataTimeNormalization,
+			HasTimeNormalization: true,
 		},
 
 		APIPath + mnLabel + "/": {
 			Path:            APIPath + mnLabel + "/",
-			HandlerName:     "proxycache",
+			HandlerName:     mnLabels,
 			Methods:         []string{http.MethodGet},
-			CacheKeyParams:  []string{},
+			CacheKeyParams:  []string{upMatch, upStart, upEnd},
 			CacheKeyHeaders: []string{},
 			MatchTypeName:   "prefix",
 			MatchType:       matching.PathMatchTypePrefix,
 			ResponseHeaders: rhinst,
+
+			TimeNormalizationMS:  int(defaultMetadataTimeNormalization / time.Millisecond),
+			TimeNormalization:    defaultMetadataTimeNormalization,
+			HasTimeNormalization: true,
 		},
 
 		APIPath + mnTargets: {
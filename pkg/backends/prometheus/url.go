@@ -19,15 +19,32 @@ package prometheus
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
 	"github.com/tricksterproxy/trickster/pkg/proxy/params"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 )
 
+// default granularities to which time parameters are normalized when the path
+// does not configure time_normalization_ms
+const (
+	defaultQueryTimeNormalization    = 15 * time.Second
+	defaultMetadataTimeNormalization = time.Minute
+)
+
 // SetExtent will change the upstream request query to use the provided Extent
 func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	if trq != nil {
+		if rr, ok := trq.ParsedQuery.(*model.ReadRequest); ok {
+			readHandlerSetExtent(r, rr, trq.Step, extent)
+			return
+		}
+	}
 	v, _, _ := params.GetRequestValues(r)
 	v.Set(upStart, strconv.FormatInt(extent.Start.Unix(), 10))
 	v.Set(upEnd, strconv.FormatInt(extent.End.Unix(), 10))
@@ -48,3 +65,38 @@ func (c *Client) FastForwardRequest(r *http.Request) (*http.Request, error) {
 	params.SetRequestValues(nr, v)
 	return nr, nil
 }
+
+// timeNormalization returns the granularity to which the request's time parameters
+// should be normalized, using the provided default when the path does not set one
+func timeNormalization(r *http.Request, def time.Duration) time.Duration {
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.PathConfig == nil || !rsc.PathConfig.HasTimeNormalization {
+		return def
+	}
+	return rsc.PathConfig.TimeNormalization
+}
+
+// normalizeTimeParams rounds the named time parameters down to the provided
+// granularity, so that requests made within the same window share a cache key.
+// Values that cannot be parsed as a timestamp are left unmodified.
+func normalizeTimeParams(qp url.Values, granularity time.Duration, names ...string) {
+	if granularity <= 0 {
+		return
+	}
+	for _, name := range names {
+		p := qp.Get(name)
+		if p == "" {
+			continue
+		}
+		t, err := parseTime(p)
+		if err != nil {
+			continue
+		}
+		t = t.Truncate(granularity)
+		if t.Nanosecond() == 0 {
+			qp.Set(name, strconv.FormatInt(t.Unix(), 10))
+			continue
+		}
+		qp.Set(name, strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64))
+	}
+}
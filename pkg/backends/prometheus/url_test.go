@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/tricksterproxy/trickster/pkg/config"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 )
@@ -106,3 +108,55 @@ func TestFastForwardURL(t *testing.T) {
 	}
 
 }
+
+func TestNormalizeTimeParams(t *testing.T) {
+
+	qp := url.Values{
+		upStart: {"119"},
+		upEnd:   {"2020-01-01T00:01:30Z"},
+		upTime:  {"1577836812.345"},
+		upStep:  {"not-a-time"},
+	}
+
+	normalizeTimeParams(qp, 0, upStart)
+	if v := qp.Get(upStart); v != "119" {
+		t.Errorf("expected %s got %s", "119", v)
+	}
+
+	normalizeTimeParams(qp, time.Minute, upStart, upEnd, upStep, upQuery)
+	if v := qp.Get(upStart); v != "60" {
+		t.Errorf("expected %s got %s", "60", v)
+	}
+	if v := qp.Get(upEnd); v != "1577836860" {
+		t.Errorf("expected %s got %s", "1577836860", v)
+	}
+	if v := qp.Get(upStep); v != "not-a-time" {
+		t.Errorf("expected %s got %s", "not-a-time", v)
+	}
+
+	normalizeTimeParams(qp, 100*time.Millisecond, upTime)
+	if v := qp.Get(upTime); v != "1577836812.300" {
+		t.Errorf("expected %s got %s", "1577836812.300", v)
+	}
+
+}
+
+func TestTimeNormalization(t *testing.T) {
+
+	r, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/", nil)
+	if d := timeNormalization(r, time.Minute); d != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, d)
+	}
+
+	pc := po.New()
+	r = request.SetResources(r, request.NewResources(nil, pc, nil, nil, nil, nil, nil))
+	if d := timeNormalization(r, time.Minute); d != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, d)
+	}
+
+	pc.HasTimeNormalization = true
+	if d := timeNormalization(r, time.Minute); d != 0 {
+		t.Errorf("expected %s got %s", time.Duration(0), d)
+	}
+
+}
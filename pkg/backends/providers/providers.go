@@ -36,17 +36,26 @@ const (
 	IronDB
 	// ClickHouse represents the ClickHouse backend provider
 	ClickHouse
+	// ALB represents the Application Load Balancer backend provider
+	ALB
+	// Graphite represents the Graphite backend provider
+	Graphite
+	// Loki represents the Grafana Loki backend provider
+	Loki
 )
 
 // Names is a map of Providers keyed by string name
 var Names = map[string]Provider{
 	"rule":              Rule,
+	"alb":               ALB,
 	"reverseproxycache": RPC,
 	"rpc":               RPC,
 	"prometheus":        Prometheus,
 	"influxdb":          InfluxDB,
 	"irondb":            IronDB,
 	"clickhouse":        ClickHouse,
+	"graphite":          Graphite,
+	"loki":              Loki,
 	"proxy":             RP,
 	"reverseproxy":      RP,
 	"rp":                RP,
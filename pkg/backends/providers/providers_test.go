@@ -53,6 +53,9 @@ func TestIsValidProvider(t *testing.T) {
 		{"invalid", false},
 		{"influxdb", true},
 		{"irondb", true},
+		{"alb", true},
+		{"graphite", true},
+		{"loki", true},
 	}
 
 	for i, test := range tests {
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
 	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
 )
 
@@ -27,17 +28,19 @@ type inputType string
 type extractionFunc func(*http.Request, string) string
 
 var sourceExtractionFuncs = map[inputType]extractionFunc{
-	"method":        extractMethodFromSource,
-	"url":           extractURLFromSource,
-	"url_no_params": extractURLNoParamsFromSource,
-	"scheme":        extractSchemeFromSource,
-	"host":          extracHostFromSource,
-	"hostname":      extractHostnameFromSource,
-	"port":          extractPortFromSource,
-	"path":          extractPathFromSource,
-	"params":        extractParamsFromSource,
-	"param":         extractParamFromSource,
-	"header":        extractHeaderFromSource,
+	"method":         extractMethodFromSource,
+	"url":            extractURLFromSource,
+	"url_no_params":  extractURLNoParamsFromSource,
+	"scheme":         extractSchemeFromSource,
+	"host":           extracHostFromSource,
+	"hostname":       extractHostnameFromSource,
+	"port":           extractPortFromSource,
+	"path":           extractPathFromSource,
+	"params":         extractParamsFromSource,
+	"param":          extractParamFromSource,
+	"header":         extractHeaderFromSource,
+	"identity":       extractIdentityFromSource,
+	"identity_claim": extractIdentityClaimFromSource,
 }
 
 // IsValidSourceName returns true only if the provided source name is supported by the Rules engine
@@ -125,6 +128,17 @@ func extractHeaderFromSource(r *http.Request, headerName string) string {
 	return ""
 }
 
+func extractIdentityFromSource(r *http.Request, unused string) string {
+	if id := authenticator.GetIdentity(r); id != nil {
+		return id.Username
+	}
+	return ""
+}
+
+func extractIdentityClaimFromSource(r *http.Request, claimName string) string {
+	return authenticator.GetIdentity(r).Claim(claimName)
+}
+
 // assumes delimiter is not empty string, and part is >= 0
 func extractSourcePart(input, delimiter string, part int) string {
 	if input == "" || len(delimiter) > len(input) {
@@ -20,6 +20,9 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
 )
 
 func TestExtractions(t *testing.T) {
@@ -39,6 +42,8 @@ func TestExtractions(t *testing.T) {
 
 	r, _ := http.NewRequest("GET", testURL, nil)
 	r.Header = http.Header{testHeaderName: []string{testHeaderVal}}
+	r = r.WithContext(tctx.WithIdentity(r.Context(), &authenticator.Identity{Username: "alice",
+		Claims: map[string]interface{}{"groups": []interface{}{"dev", "ops"}}}))
 
 	tests := []struct {
 		source   string
@@ -57,6 +62,9 @@ func TestExtractions(t *testing.T) {
 		{"params", "", params, r},
 		{"param", "param1", "value", r},
 		{"header", "Authorization", testHeaderVal, r},
+		{"identity", "", "alice", r},
+		{"identity_claim", "groups", "dev,ops", r},
+		{"identity_claim", "missing", "", r},
 		{"method", "", "", nil},
 		{"url", "", "", nil},
 		{"url_no_params", "", "", nil},
@@ -68,6 +76,8 @@ func TestExtractions(t *testing.T) {
 		{"params", "", "", nil},
 		{"param", "param1", "", nil},
 		{"header", "Authorization", "", nil},
+		{"identity", "", "", nil},
+		{"identity_claim", "groups", "", nil},
 	}
 	for i, test := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
@@ -46,10 +46,13 @@ type Options struct {
 	//  params           ?param1=value
 	//  param            [must be used with InputKey as described below]
 	//  header           [must be used with InputKey as described below]
+	//  identity         alice (the authenticated user, when the backend has an authenticator)
+	//  identity_claim   [must be used with InputKey as described below]
 	InputSource string `toml:"input_source"`
 	//
 	// InputKey is optional and provides extra information for locating the data source
-	// when the InputSource is header or param, the input key must be the target header or param name
+	// when the InputSource is header or param, the input key must be the target header or param name;
+	// when the InputSource is identity_claim, the input key must be the target claim name
 	InputKey string `toml:"input_key"`
 	// InputType is optional, defaulting to string, and indicates the type of input:
 	// string, num (treated internally as float64), or bool
@@ -31,7 +31,11 @@ type TimeseriesClient interface {
 	Handlers() map[string]http.Handler
 	// DefaultPathConfigs returns the default PathConfigs for the given Provider
 	DefaultPathConfigs(*oo.Options) map[string]*po.Options
-	// ParseTimeRangeQuery returns a timeseries.TimeRangeQuery based on the provided HTTP Request
+	// ParseTimeRangeQuery returns a timeseries.TimeRangeQuery based on the provided HTTP Request.
+	// When the request is not a time range query, but is a read-only query that can be cached
+	// by the Object Proxy Cache, the bool return value is true and an error is returned; the
+	// TimeRangeQuery may also be non-nil, in which case its TemplateURL is used to derive the
+	// Object Proxy Cache key in lieu of the request's own query parameters
 	ParseTimeRangeQuery(*http.Request) (*timeseries.TimeRangeQuery, *timeseries.RequestOptions, bool, error)
 	// Configuration returns the configuration for the Proxy Client
 	Configuration() *oo.Options
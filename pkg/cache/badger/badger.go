@@ -136,6 +136,29 @@ func (c *Cache) BulkRemove(cacheKeys []string) {
 	})
 }
 
+// RemovePrefix removes all objects with keys beginning with the provided prefix
+func (c *Cache) RemovePrefix(prefix string) (int, error) {
+	keys := make([]string, 0)
+	err := c.dbh.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		p := []byte(prefix)
+		for it.Seek(p); it.ValidForPrefix(p); it.Next() {
+			keys = append(keys, string(it.Item().KeyCopy(nil)))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) > 0 {
+		c.BulkRemove(keys)
+	}
+	return len(keys), nil
+}
+
 // Close closes the Badger Cache
 func (c *Cache) Close() error {
 	return c.dbh.Close()
@@ -177,6 +177,40 @@ func TestBadgerCache_BulkRemove(t *testing.T) {
 
 }
 
+func TestBadgerCache_RemovePrefix(t *testing.T) {
+	cacheConfig := newCacheConfig(t)
+	defer os.RemoveAll(cacheConfig.Badger.Directory)
+	bc := Cache{Config: cacheConfig, Logger: tl.ConsoleLogger("error")}
+
+	if err := bc.Connect(); err != nil {
+		t.Error(err)
+	}
+	defer bc.Close()
+
+	// it should store values
+	for _, key := range []string{"test.a.1", "test.a.2", "test.b.1"} {
+		if err := bc.Store(key, []byte("data"), time.Duration(60)*time.Second); err != nil {
+			t.Error(err)
+		}
+	}
+
+	n, err := bc.RemovePrefix("test.a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 2 {
+		t.Errorf("expected %d got %d", 2, n)
+	}
+
+	// removed keys should be a cache miss, and others should remain
+	if _, ls, _ := bc.Retrieve("test.a.1", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+	if _, ls, _ := bc.Retrieve("test.b.1", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+}
+
 func TestBadgerCache_Retrieve(t *testing.T) {
 	cacheConfig := newCacheConfig(t)
 	defer os.RemoveAll(cacheConfig.Badger.Directory)
@@ -228,6 +228,14 @@ func (c *Cache) BulkRemove(cacheKeys []string) {
 	wg.Wait()
 }
 
+// RemovePrefix removes all objects with keys beginning with the provided prefix
+func (c *Cache) RemovePrefix(prefix string) (int, error) {
+	keys := c.Index.Keys(prefix)
+	c.BulkRemove(keys)
+	c.Index.RemoveObjects(keys, false)
+	return len(keys), nil
+}
+
 // Close closes the Cache
 func (c *Cache) Close() error {
 	if c.Index != nil {
@@ -434,6 +434,46 @@ func TestBboltCache_BulkRemove(t *testing.T) {
 
 }
 
+func TestBboltCache_RemovePrefix(t *testing.T) {
+	cacheConfig := newCacheConfig()
+	bc := Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: locks.NewNamedLocker()}
+	defer os.RemoveAll(cacheConfig.BBolt.Filename)
+
+	err := bc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	defer bc.Close()
+
+	// it should store values
+	for _, key := range []string{"test.a.1", "test.a.2", "test.b.1"} {
+		if err := bc.Store(key, []byte("data"), time.Duration(60)*time.Second); err != nil {
+			t.Error(err)
+		}
+	}
+
+	n, err := bc.RemovePrefix("test.a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 2 {
+		t.Errorf("expected %d got %d", 2, n)
+	}
+
+	// removed keys should be a cache miss, and others should remain
+	if _, ls, _ := bc.Retrieve("test.a.1", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+	if _, ls, _ := bc.Retrieve("test.b.1", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+
+	// removed keys should no longer be in the index
+	if keys := bc.Index.Keys("test."); len(keys) != 1 || keys[0] != "test.b.1" {
+		t.Errorf("unexpected index keys %v", keys)
+	}
+}
+
 func BenchmarkCache_BulkRemove(b *testing.B) {
 	bc := storeBenchmark(b)
 	defer bc.Close()
@@ -62,6 +62,14 @@ type MemoryCache interface {
 	SetLocker(locks.NamedLocker)
 }
 
+// PrefixRemover is implemented by caches that can enumerate their objects' keys, and is
+// used to remove all objects with keys beginning with a prefix, such as a backend's CacheKeyPrefix
+type PrefixRemover interface {
+	// RemovePrefix removes all objects with keys beginning with the provided prefix,
+	// and returns the number of objects removed
+	RemovePrefix(prefix string) (int, error)
+}
+
 // ReferenceObject defines an interface for a cache object possessing the ability to report
 // the approximate comprehensive byte size of its members, to assist with cache size management
 type ReferenceObject interface {
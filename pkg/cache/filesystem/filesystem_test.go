@@ -590,6 +590,46 @@ func TestFilesystemCache_BulkRemove(t *testing.T) {
 	}
 }
 
+func TestFilesystemCache_RemovePrefix(t *testing.T) {
+	cacheConfig := newCacheConfig(t)
+	defer os.RemoveAll(cacheConfig.Filesystem.CachePath)
+	fc := Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: locks.NewNamedLocker()}
+
+	err := fc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	defer fc.Close()
+
+	// it should store values
+	for _, key := range []string{"test.a.1", "test.a.2", "test.b.1"} {
+		if err := fc.Store(key, []byte("data"), time.Duration(60)*time.Second); err != nil {
+			t.Error(err)
+		}
+	}
+
+	n, err := fc.RemovePrefix("test.a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 2 {
+		t.Errorf("expected %d got %d", 2, n)
+	}
+
+	// removed keys should be a cache miss, and others should remain
+	if _, ls, _ := fc.Retrieve("test.a.1", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+	if _, ls, _ := fc.Retrieve("test.b.1", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+
+	// removed keys should no longer be in the index
+	if keys := fc.Index.Keys("test."); len(keys) != 1 || keys[0] != "test.b.1" {
+		t.Errorf("unexpected index keys %v", keys)
+	}
+}
+
 func BenchmarkCache_BulkRemove(b *testing.B) {
 	fc := storeBenchmark(b)
 	defer fc.Close()
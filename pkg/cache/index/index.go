@@ -19,6 +19,7 @@ package index
 
 import (
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -52,7 +53,7 @@ type Index struct {
 	flushFunc      func(cacheKey string, data []byte) `msg:"-"`
 	lastWrite      time.Time                          `msg:"-"`
 
-	isClosing     bool
+	isClosing     int32
 	flusherExited bool
 	reaperExited  bool
 
@@ -61,7 +62,7 @@ type Index struct {
 
 // Close is called to signal the index to shut down any subroutines
 func (idx *Index) Close() {
-	idx.isClosing = true
+	atomic.StoreInt32(&idx.isClosing, 1)
 }
 
 // ToBytes returns a serialized byte slice representing the Index
@@ -240,6 +241,19 @@ func (idx *Index) RemoveObjects(keys []string, noLock bool) {
 	}
 }
 
+// Keys returns the keys of all Objects in the Index beginning with the provided prefix
+func (idx *Index) Keys(prefix string) []string {
+	idx.mtx.Lock()
+	keys := make([]string, 0, len(idx.Objects))
+	for key := range idx.Objects {
+		if key != IndexKey && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	idx.mtx.Unlock()
+	return keys
+}
+
 // GetExpiration returns the cache index's expiration for the object of the given key
 func (idx *Index) GetExpiration(cacheKey string) time.Time {
 	idx.mtx.Lock()
@@ -254,7 +268,7 @@ func (idx *Index) GetExpiration(cacheKey string) time.Time {
 // flusher periodically calls the cache's index flush func that writes the cache index to disk
 func (idx *Index) flusher(logger interface{}) {
 	var lastFlush time.Time
-	for !idx.isClosing {
+	for atomic.LoadInt32(&idx.isClosing) == 0 {
 		time.Sleep(idx.options.FlushInterval)
 		if idx.lastWrite.Before(lastFlush) {
 			continue
@@ -279,7 +293,7 @@ func (idx *Index) flushOnce(logger interface{}) {
 
 // reaper continually iterates through the cache to find expired elements and removes them
 func (idx *Index) reaper(logger interface{}) {
-	for !idx.isClosing {
+	for atomic.LoadInt32(&idx.isClosing) == 0 {
 		idx.reap(logger)
 		time.Sleep(idx.options.ReapInterval)
 	}
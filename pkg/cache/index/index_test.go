@@ -333,3 +333,24 @@ func TestRemoveObjects(t *testing.T) {
 		t.Error("key should not be in map")
 	}
 }
+
+func TestKeys(t *testing.T) {
+	cacheConfig := &co.Options{Provider: "test",
+		Index: &io.Options{ReapInterval: time.Second * time.Duration(10),
+			FlushInterval: time.Second * time.Duration(10)}}
+	idx := NewIndex("test", "test", nil, cacheConfig.Index, testBulkRemoveFunc, fakeFlusherFunc, testLogger)
+	idx.UpdateObject(&Object{Key: "test.a.1", Value: []byte("test_value")})
+	idx.UpdateObject(&Object{Key: "test.b.1", Value: []byte("test_value")})
+	idx.UpdateObject(&Object{Key: IndexKey, Value: []byte("test_value")})
+
+	keys := idx.Keys("test.a.")
+	if len(keys) != 1 || keys[0] != "test.a.1" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	// the index's own key is never included
+	keys = idx.Keys("")
+	if len(keys) != 2 {
+		t.Errorf("expected %d got %d", 2, len(keys))
+	}
+}
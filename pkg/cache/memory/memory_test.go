@@ -361,6 +361,45 @@ func TestCache_BulkRemove(t *testing.T) {
 
 }
 
+func TestCache_RemovePrefix(t *testing.T) {
+	cacheConfig := newCacheConfig(t)
+	mc := Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: testLocker}
+
+	err := mc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mc.Close()
+
+	// it should store values
+	for _, key := range []string{"test.a.1", "test.a.2", "test.b.1"} {
+		if err := mc.Store(key, []byte("data"), time.Duration(60)*time.Second); err != nil {
+			t.Error(err)
+		}
+	}
+
+	n, err := mc.RemovePrefix("test.a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 2 {
+		t.Errorf("expected %d got %d", 2, n)
+	}
+
+	// removed keys should be a cache miss, and others should remain
+	if _, ls, _ := mc.Retrieve("test.a.1", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+	if _, ls, _ := mc.Retrieve("test.b.1", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+
+	// removed keys should no longer be in the index
+	if keys := mc.Index.Keys("test."); len(keys) != 1 || keys[0] != "test.b.1" {
+		t.Errorf("unexpected index keys %v", keys)
+	}
+}
+
 func BenchmarkCache_BulkRemove(b *testing.B) {
 	var keyArray []string
 	for n := 0; n < b.N; n++ {
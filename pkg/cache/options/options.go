@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	badger "github.com/tricksterproxy/trickster/pkg/cache/badger/options"
@@ -50,12 +51,24 @@ type Options struct {
 	BBolt *bbolt.Options `toml:"bbolt"`
 	// Badger provides options for BadgerDB caching
 	Badger *badger.Options `toml:"badger"`
+	// LockProvider is the provider of the named locks used to coordinate cache fills:
+	// "local" (in-process only) or "redis" (shared by all Trickster instances using the cache)
+	LockProvider string `toml:"lock_provider"`
+	// LockLeaseTTLMS is the lease duration of a distributed lock, after which it expires
+	// if the holder has not renewed it (e.g., because the holder has crashed)
+	LockLeaseTTLMS int `toml:"lock_lease_ttl_ms"`
+	// LockRetryIntervalMS is the interval at which a contended distributed lock is retried
+	LockRetryIntervalMS int `toml:"lock_retry_interval_ms"`
 
 	//  Synthetic Values
 
 	// ID represents the internal constant for the provided  string
 	// and is automatically populated at startup
 	ProviderID providers.Provider `toml:"-"`
+	// LockLeaseTTL is the parsed value of LockLeaseTTLMS
+	LockLeaseTTL time.Duration `toml:"-"`
+	// LockRetryInterval is the parsed value of LockRetryIntervalMS
+	LockRetryInterval time.Duration `toml:"-"`
 }
 
 // New will return a pointer to a cache Options with the default configuration settings
@@ -68,6 +81,10 @@ func New() *Options {
 		BBolt:      bbolt.New(),
 		Badger:     badger.New(),
 		Index:      index.New(),
+
+		LockProvider:        d.DefaultCacheLockProvider,
+		LockLeaseTTLMS:      d.DefaultCacheLockLeaseTTLMS,
+		LockRetryIntervalMS: d.DefaultCacheLockRetryIntervalMS,
 	}
 }
 
@@ -78,6 +95,11 @@ func (cc *Options) Clone() *Options {
 	c.Name = cc.Name
 	c.Provider = cc.Provider
 	c.ProviderID = cc.ProviderID
+	c.LockProvider = cc.LockProvider
+	c.LockLeaseTTLMS = cc.LockLeaseTTLMS
+	c.LockLeaseTTL = cc.LockLeaseTTL
+	c.LockRetryIntervalMS = cc.LockRetryIntervalMS
+	c.LockRetryInterval = cc.LockRetryInterval
 
 	c.Index.FlushInterval = cc.Index.FlushInterval
 	c.Index.FlushIntervalMS = cc.Index.FlushIntervalMS
@@ -130,7 +152,10 @@ func (cc *Options) Equal(cc2 *Options) bool {
 
 	return cc.Name == cc2.Name &&
 		cc.Provider == cc2.Provider &&
-		cc.ProviderID == cc2.ProviderID
+		cc.ProviderID == cc2.ProviderID &&
+		cc.LockProvider == cc2.LockProvider &&
+		cc.LockLeaseTTLMS == cc2.LockLeaseTTLMS &&
+		cc.LockRetryIntervalMS == cc2.LockRetryIntervalMS
 
 }
 
@@ -159,6 +184,28 @@ func (l Lookup) ProcessTOML(metadata *toml.MetaData, activeCaches map[string]boo
 			}
 		}
 
+		if metadata.IsDefined("caches", k, "lock_provider") {
+			cc.LockProvider = strings.ToLower(v.LockProvider)
+		}
+
+		switch cc.LockProvider {
+		case "local":
+		case "redis":
+			if cc.ProviderID != providers.Redis {
+				return nil, fmt.Errorf("cache %s: lock_provider 'redis' requires the 'redis' cache provider", k)
+			}
+		default:
+			return nil, fmt.Errorf("cache %s: invalid lock_provider: %s", k, cc.LockProvider)
+		}
+
+		if metadata.IsDefined("caches", k, "lock_lease_ttl_ms") {
+			cc.LockLeaseTTLMS = v.LockLeaseTTLMS
+		}
+
+		if metadata.IsDefined("caches", k, "lock_retry_interval_ms") {
+			cc.LockRetryIntervalMS = v.LockRetryIntervalMS
+		}
+
 		if metadata.IsDefined("caches", k, "index", "reap_interval_ms") {
 			cc.Index.ReapIntervalMS = v.Index.ReapIntervalMS
 		}
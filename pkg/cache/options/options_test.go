@@ -16,7 +16,12 @@
 
 package options
 
-import "testing"
+import (
+	"strconv"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
 
 func TestNew(t *testing.T) {
 	o := New()
@@ -39,3 +44,42 @@ func TestCloneAndEqual(t *testing.T) {
 	}
 
 }
+
+func TestProcessTOMLLockProvider(t *testing.T) {
+
+	tests := []struct {
+		conf      string
+		expectErr bool
+	}{
+		{ // 0 - default lock provider
+			conf: "[caches.test]\nprovider = 'memory'\n",
+		},
+		{ // 1 - redis lock provider with redis cache
+			conf: "[caches.test]\nprovider = 'redis'\nlock_provider = 'redis'\n[caches.test.redis]\nendpoint = 'redis:6379'\n",
+		},
+		{ // 2 - redis lock provider with memory cache
+			conf:      "[caches.test]\nprovider = 'memory'\nlock_provider = 'redis'\n",
+			expectErr: true,
+		},
+		{ // 3 - invalid lock provider
+			conf:      "[caches.test]\nprovider = 'memory'\nlock_provider = 'invalid'\n",
+			expectErr: true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := struct {
+				Caches Lookup `toml:"caches"`
+			}{}
+			md, err := toml.Decode(test.conf, &c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.Caches.ProcessTOML(&md, map[string]bool{"test": true})
+			if (err != nil) != test.expectErr {
+				t.Errorf("expected error %t got %v", test.expectErr, err)
+			}
+		})
+	}
+}
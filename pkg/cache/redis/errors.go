@@ -26,3 +26,7 @@ var ErrInvalidEndpointsConfig = errors.New("invalid 'endpoints' config")
 
 // ErrInvalidSentinalMasterConfig indicates an invalid sentinel_master config
 var ErrInvalidSentinalMasterConfig = errors.New("invalid 'sentinel_master' config")
+
+// ErrLeaseLost indicates a cache write was rejected because the writer's distributed lock
+// lease had expired and the lock was since acquired by another
+var ErrLeaseLost = errors.New("distributed lock lease lost; cache write rejected")
@@ -0,0 +1,334 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/cache/metrics"
+	"github.com/tricksterproxy/trickster/pkg/locks"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	// lockKeyPrefix is prepended to the name of each distributed lock to form its Redis key
+	lockKeyPrefix = "trickster.lock."
+	// fenceKeySuffix is appended to a lock's Redis key to form the key of its fencing counter
+	fenceKeySuffix = ".fence"
+	// fenceTTLFactor is the multiple of the lease TTL for which an idle fencing counter is retained
+	fenceTTLFactor = 10
+
+	defaultLockLeaseTTL      = 30 * time.Second
+	defaultLockRetryInterval = 10 * time.Millisecond
+)
+
+// acquireScript sets the lock key to the next fencing token, only if the lock is not
+// currently leased, and returns the token (or 0 if the lock is held by another)
+var acquireScript = redis.NewScript(`
+if redis.call("exists", KEYS[1]) == 0 then
+	local token = redis.call("incr", KEYS[2])
+	redis.call("pexpire", KEYS[2], ARGV[2])
+	redis.call("set", KEYS[1], token, "PX", ARGV[1])
+	return token
+end
+return 0`)
+
+// renewScript extends the lease on the lock key, only if it is still held by the provided token
+var renewScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript deletes the lock key, only if it is still held by the provided token, so
+// that a holder whose lease has expired cannot release a lock since acquired by another
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// storeScript sets the cache object, only if the fencing counter of its lock has not advanced
+// beyond the provided token, so that a writer whose lease has expired cannot overwrite an
+// object once the lock has been acquired by another
+var storeScript = redis.NewScript(`
+local fence = tonumber(redis.call("get", KEYS[2]) or "0")
+if fence > tonumber(ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("set", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("set", KEYS[1], ARGV[2])
+end
+return 1`)
+
+// distributedLocker is a NamedLocker that coordinates write locks across all Trickster
+// instances sharing a Redis cache. Locks are first acquired from the in-process locker,
+// and write locks then obtain a leased, fenced lock key in Redis. Read locks are
+// in-process only, since only cache fills must be coordinated across the fleet.
+type distributedLocker struct {
+	local         locks.NamedLocker
+	client        redis.Cmdable
+	leaseTTL      time.Duration
+	retryInterval time.Duration
+	cacheName     string
+	logger        interface{}
+
+	// leases maps the name of each lock with a lease held by this locker to its fencing token
+	leases   map[string]int64
+	leaseMtx sync.Mutex
+}
+
+func newDistributedLocker(client redis.Cmdable, local locks.NamedLocker,
+	leaseTTL, retryInterval time.Duration, cacheName string,
+	logger interface{}) *distributedLocker {
+	if local == nil {
+		local = locks.NewNamedLocker()
+	}
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLockLeaseTTL
+	}
+	if retryInterval <= 0 {
+		retryInterval = defaultLockRetryInterval
+	}
+	return &distributedLocker{
+		local:         local,
+		client:        client,
+		leaseTTL:      leaseTTL,
+		retryInterval: retryInterval,
+		cacheName:     cacheName,
+		logger:        logger,
+		leases:        make(map[string]int64),
+	}
+}
+
+// Acquire locks the named lock for writing, and blocks until both the in-process lock
+// and the distributed lease are acquired
+func (lk *distributedLocker) Acquire(lockName string) (locks.NamedLock, error) {
+	nl, err := lk.local.Acquire(lockName)
+	if err != nil {
+		return nil, err
+	}
+	dl := lk.newLock(lockName, nl)
+	dl.fence = lk.fence(dl.key)
+	dl.acquireLease()
+	return dl, nil
+}
+
+// RAcquire locks the named lock for reading, and blocks until the rlock is acquired
+func (lk *distributedLocker) RAcquire(lockName string) (locks.NamedLock, error) {
+	nl, err := lk.local.RAcquire(lockName)
+	if err != nil {
+		return nil, err
+	}
+	return lk.newLock(lockName, nl), nil
+}
+
+func (lk *distributedLocker) newLock(lockName string, nl locks.NamedLock) *distributedLock {
+	// the hash tag ensures the lock and fence keys map to the same Redis Cluster slot
+	return &distributedLock{
+		NamedLock: nl,
+		locker:    lk,
+		name:      lockName,
+		key:       lockKey(lockName),
+	}
+}
+
+func lockKey(lockName string) string {
+	return lockKeyPrefix + "{" + lockName + "}"
+}
+
+// fence returns the current value of the fencing counter for the provided lock key,
+// which is the number of times the distributed lock has been acquired
+func (lk *distributedLocker) fence(key string) int64 {
+	i, err := lk.client.Get(key + fenceKeySuffix).Int64()
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// leaseToken returns the name of the held lock that covers the provided cache key, and
+// the fencing token of its lease. A lock covers its own key and the key's secondary keys
+// (e.g., "<key>.chunk.<n>"). If no held lock covers the key, the token is 0.
+func (lk *distributedLocker) leaseToken(cacheKey string) (string, int64) {
+	lk.leaseMtx.Lock()
+	defer lk.leaseMtx.Unlock()
+	if len(lk.leases) == 0 {
+		return "", 0
+	}
+	for name := cacheKey; ; {
+		if token, ok := lk.leases[name]; ok {
+			return name, token
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 1 {
+			return "", 0
+		}
+		name = name[:i]
+	}
+}
+
+// store writes the cache object. When the object is covered by a lease held by this
+// locker, the write is rejected with ErrLeaseLost if the lock has since been acquired
+// by another holder, as indicated by its fencing counter
+func (lk *distributedLocker) store(cacheKey string, data []byte, ttl time.Duration) error {
+	name, token := lk.leaseToken(cacheKey)
+	if token == 0 {
+		return lk.client.Set(cacheKey, data, ttl).Err()
+	}
+	fenceKey := lockKey(name) + fenceKeySuffix
+	// in Redis Cluster, the fencing counter and a secondary key may map to different slots,
+	// and cannot be accessed by the same script, so the counter is checked before the write
+	if _, ok := lk.client.(*redis.ClusterClient); ok && name != cacheKey {
+		if lk.fence(lockKey(name)) > token {
+			return ErrLeaseLost
+		}
+		return lk.client.Set(cacheKey, data, ttl).Err()
+	}
+	i, err := storeScript.Run(lk.client, []string{cacheKey, fenceKey}, token, data,
+		ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if i == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// fallback logs and counts the use of only the in-process lock when the distributed
+// lease cannot be acquired
+func (lk *distributedLocker) fallback(lockName, reason string, detail tl.Pairs) {
+	detail["lockName"] = lockName
+	tl.Warn(lk.logger, "distributed lock not acquired, proceeding with in-process lock", detail)
+	metrics.ObserveCacheEvent(lk.cacheName, Redis, "lock_fallback", reason)
+}
+
+// distributedLock wraps an in-process NamedLock with a distributed lease
+type distributedLock struct {
+	locks.NamedLock
+	locker *distributedLocker
+	name   string
+	key    string
+	// token is the fencing token of the currently-held lease, or 0 if no lease is held
+	token int64
+	// fence is the last-observed value of the fencing counter while no lease was held
+	fence int64
+	stop  chan bool
+}
+
+// Release releases the distributed lease and the write lock on the subject Named Lock
+func (dl *distributedLock) Release() error {
+	dl.releaseLease()
+	return dl.NamedLock.Release()
+}
+
+// Upgrade will upgrade the current read-lock to a write lock, and then acquire the
+// distributed lease
+func (dl *distributedLock) Upgrade() (locks.NamedLock, error) {
+	if _, err := dl.NamedLock.Upgrade(); err != nil {
+		return nil, err
+	}
+	dl.acquireLease()
+	return dl, nil
+}
+
+// WriteLockCounter returns the number of write locks acquired on the named lock, both
+// in-process and by other Trickster instances, such that an increase of more than 1
+// across an Upgrade indicates another writer held the lock in the interim
+func (dl *distributedLock) WriteLockCounter() int {
+	n := dl.NamedLock.WriteLockCounter()
+	if dl.token > 0 {
+		// the lease acquired by this lock is already counted in-process
+		return n + int(dl.token-1)
+	}
+	dl.fence = dl.locker.fence(dl.key)
+	return n + int(dl.fence)
+}
+
+// acquireLease blocks until the distributed lease is acquired. If the lease cannot be
+// acquired within one lease TTL (e.g., the holder's fill is still running), or if Redis
+// is unavailable, the caller proceeds with only the in-process lock.
+func (dl *distributedLock) acquireLease() {
+	lk := dl.locker
+	keys := []string{dl.key, dl.key + fenceKeySuffix}
+	deadline := time.Now().Add(lk.leaseTTL)
+	for {
+		token, err := acquireScript.Run(lk.client, keys, lk.leaseTTL.Milliseconds(),
+			(lk.leaseTTL * fenceTTLFactor).Milliseconds()).Int64()
+		if err != nil {
+			lk.fallback(dl.name, "error", tl.Pairs{"reason": err.Error()})
+			return
+		}
+		if token > 0 {
+			dl.token = token
+			dl.stop = make(chan bool)
+			lk.leaseMtx.Lock()
+			lk.leases[dl.name] = token
+			lk.leaseMtx.Unlock()
+			go dl.renewLease(token, dl.stop)
+			return
+		}
+		if !time.Now().Before(deadline) {
+			lk.fallback(dl.name, "timeout", tl.Pairs{"leaseTTL": lk.leaseTTL.String()})
+			return
+		}
+		time.Sleep(lk.retryInterval)
+	}
+}
+
+// renewLease periodically extends the lease on the lock key until stopped,
+// or until the lease is found to be lost
+func (dl *distributedLock) renewLease(token int64, stop chan bool) {
+	lk := dl.locker
+	ticker := time.NewTicker(lk.leaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			i, err := renewScript.Run(lk.client, []string{dl.key}, token,
+				lk.leaseTTL.Milliseconds()).Int64()
+			if err != nil || i == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (dl *distributedLock) releaseLease() {
+	if dl.token == 0 {
+		return
+	}
+	close(dl.stop)
+	lk := dl.locker
+	lk.leaseMtx.Lock()
+	if lk.leases[dl.name] == dl.token {
+		delete(lk.leases, dl.name)
+	}
+	lk.leaseMtx.Unlock()
+	releaseScript.Run(lk.client, []string{dl.key}, dl.token)
+	dl.fence = dl.token
+	dl.token = 0
+}
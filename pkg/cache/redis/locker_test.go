@@ -0,0 +1,261 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/locks"
+	"github.com/tricksterproxy/trickster/pkg/util/metrics"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func setupDistributedLockers(t *testing.T) (*distributedLocker, *distributedLocker,
+	*miniredis.Miniredis) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	// each locker simulates a separate Trickster instance, with its own in-process locker
+	lk1 := newDistributedLocker(client, locks.NewNamedLocker(), time.Second, time.Millisecond,
+		"test", nil)
+	lk2 := newDistributedLocker(client, nil, 0, 0, "test", nil)
+	lk2.retryInterval = time.Millisecond
+	return lk1, lk2, s
+}
+
+func TestNewDistributedLocker(t *testing.T) {
+	lk := newDistributedLocker(nil, nil, 0, 0, "test", nil)
+	if lk.local == nil {
+		t.Error("expected non-nil local locker")
+	}
+	if lk.leaseTTL != defaultLockLeaseTTL {
+		t.Errorf("expected %s got %s", defaultLockLeaseTTL, lk.leaseTTL)
+	}
+	if lk.retryInterval != defaultLockRetryInterval {
+		t.Errorf("expected %s got %s", defaultLockRetryInterval, lk.retryInterval)
+	}
+}
+
+func TestDistributedLockerAcquire(t *testing.T) {
+
+	lk1, lk2, s := setupDistributedLockers(t)
+	defer s.Close()
+
+	nl1, err := lk1.Acquire("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.Get("trickster.lock.{test}"); v != "1" {
+		t.Errorf("expected %s got %s", "1", v)
+	}
+
+	acquired := make(chan locks.NamedLock)
+	go func() {
+		nl2, _ := lk2.Acquire("test")
+		acquired <- nl2
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected lock to be held by another instance")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	nl1.Release()
+
+	select {
+	case nl2 := <-acquired:
+		if v, _ := s.Get("trickster.lock.{test}"); v != "2" {
+			t.Errorf("expected %s got %s", "2", v)
+		}
+		nl2.Release()
+	case <-time.After(time.Second):
+		t.Fatal("expected lock to be acquired after release")
+	}
+
+	if s.Exists("trickster.lock.{test}") {
+		t.Error("expected lock key to be removed")
+	}
+
+	_, err = lk1.Acquire("")
+	if err == nil {
+		t.Error("expected error for invalid lock name")
+	}
+	_, err = lk1.RAcquire("")
+	if err == nil {
+		t.Error("expected error for invalid lock name")
+	}
+}
+
+func TestDistributedLockerUpgrade(t *testing.T) {
+
+	lk1, lk2, s := setupDistributedLockers(t)
+	defer s.Close()
+
+	// an uncontended upgrade should increase the write lock counter by exactly 1
+	nl, _ := lk1.RAcquire("test")
+	cwc := nl.WriteLockCounter()
+	nl, _ = nl.Upgrade()
+	if i := nl.WriteLockCounter() - cwc; i != 1 {
+		t.Errorf("expected %d got %d", 1, i)
+	}
+	nl.Release()
+
+	// an upgrade following a write by another instance should increase the counter by more than 1
+	nl, _ = lk1.RAcquire("test")
+	cwc = nl.WriteLockCounter()
+	nl2, _ := lk2.Acquire("test")
+	nl2.Release()
+	nl, _ = nl.Upgrade()
+	if i := nl.WriteLockCounter() - cwc; i != 2 {
+		t.Errorf("expected %d got %d", 2, i)
+	}
+	nl.Release()
+
+	nl, _ = lk1.RAcquire("test")
+	if err := nl.RRelease(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDistributedLockerExpiredLease(t *testing.T) {
+
+	lk1, lk2, s := setupDistributedLockers(t)
+	defer s.Close()
+
+	nl1, _ := lk1.Acquire("test")
+
+	// expire the first instance's lease, as if it had crashed
+	s.FastForward(2 * time.Second)
+
+	nl2, _ := lk2.Acquire("test")
+	if v, _ := s.Get("trickster.lock.{test}"); v != "2" {
+		t.Errorf("expected %s got %s", "2", v)
+	}
+
+	// the first instance's stale token must not release the second instance's lease
+	nl1.Release()
+	if v, _ := s.Get("trickster.lock.{test}"); v != "2" {
+		t.Errorf("expected %s got %s", "2", v)
+	}
+	nl2.Release()
+}
+
+func TestDistributedLockerRedisUnavailable(t *testing.T) {
+
+	lk1, _, s := setupDistributedLockers(t)
+	s.Close()
+
+	before := lockFallbacks(t, "error")
+
+	// the lock should still be acquired in-process when Redis is unavailable
+	nl, err := lk1.Acquire("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nl.WriteLockCounter() != 1 {
+		t.Errorf("expected %d got %d", 1, nl.WriteLockCounter())
+	}
+	nl.Release()
+
+	if i := lockFallbacks(t, "error") - before; i != 1 {
+		t.Errorf("expected %d got %d", 1, i)
+	}
+}
+
+func TestDistributedLockerLeaseTimeout(t *testing.T) {
+
+	lk1, lk2, s := setupDistributedLockers(t)
+	defer s.Close()
+	lk2.leaseTTL = 20 * time.Millisecond
+
+	before := lockFallbacks(t, "timeout")
+
+	nl1, _ := lk1.Acquire("test")
+	// the lease is held by the first instance, so the second falls back after one lease TTL
+	nl2, err := lk2.Acquire("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := lockFallbacks(t, "timeout") - before; i != 1 {
+		t.Errorf("expected %d got %d", 1, i)
+	}
+	nl2.Release()
+	nl1.Release()
+}
+
+func TestDistributedLockerStore(t *testing.T) {
+
+	lk1, lk2, s := setupDistributedLockers(t)
+	defer s.Close()
+
+	// objects not covered by a held lease are written unconditionally
+	if err := lk1.store("other", []byte("value"), time.Minute); err != nil {
+		t.Error(err)
+	}
+
+	nl1, _ := lk1.Acquire("test")
+	if err := lk1.store("test", []byte("one"), time.Minute); err != nil {
+		t.Error(err)
+	}
+	if v, _ := s.Get("test"); v != "one" {
+		t.Errorf("expected %s got %s", "one", v)
+	}
+
+	// expire the first instance's lease, as if its fill had stalled, and acquire it elsewhere
+	s.FastForward(2 * time.Second)
+	nl2, _ := lk2.Acquire("test")
+
+	// the first instance's writes, including to secondary keys, must now be rejected
+	if err := lk1.store("test", []byte("stale"), time.Minute); err != ErrLeaseLost {
+		t.Errorf("expected %v got %v", ErrLeaseLost, err)
+	}
+	if err := lk1.store("test.chunk.1", []byte("stale"), 0); err != ErrLeaseLost {
+		t.Errorf("expected %v got %v", ErrLeaseLost, err)
+	}
+	if v, _ := s.Get("test"); v != "one" {
+		t.Errorf("expected %s got %s", "one", v)
+	}
+
+	if err := lk2.store("test", []byte("two"), time.Minute); err != nil {
+		t.Error(err)
+	}
+	if v, _ := s.Get("test"); v != "two" {
+		t.Errorf("expected %s got %s", "two", v)
+	}
+	nl2.Release()
+	nl1.Release()
+
+	if name, token := lk1.leaseToken("test"); name != "" || token != 0 {
+		t.Errorf("expected no lease got %s %d", name, token)
+	}
+}
+
+func lockFallbacks(t *testing.T, reason string) int {
+	m := &dto.Metric{}
+	if err := metrics.CacheEvents.WithLabelValues("test", Redis, "lock_fallback",
+		reason).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return int(m.GetCounter().GetValue())
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/tricksterproxy/trickster/pkg/cache/metrics"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+
+	"github.com/go-redis/redis"
+)
+
+// scanCount is the number of keys requested from Redis in each iteration of a SCAN
+const scanCount = 1000
+
+// globEscaper escapes the characters that have special meaning in Redis glob-style patterns
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// RemovePrefix removes all objects with keys beginning with the provided prefix. The keys
+// are enumerated with SCAN, so that Redis is not blocked while they are walked. In cluster
+// mode, the keys of each master node are walked separately
+func (c *Cache) RemovePrefix(prefix string) (int, error) {
+	match := globEscaper.Replace(prefix) + "*"
+	if cc, ok := c.client.(*redis.ClusterClient); ok {
+		var n int64
+		err := cc.ForEachMaster(func(client *redis.Client) error {
+			i, err := c.removeScanned(client, match)
+			atomic.AddInt64(&n, int64(i))
+			return err
+		})
+		return int(n), err
+	}
+	return c.removeScanned(c.client, match)
+}
+
+// removeScanned removes each batch of keys matching the pattern as they are scanned
+func (c *Cache) removeScanned(client redis.Cmdable, match string) (int, error) {
+	var cursor uint64
+	var n int
+	for {
+		keys, next, err := client.Scan(cursor, match, scanCount).Result()
+		if err != nil {
+			return n, err
+		}
+		if len(keys) > 0 {
+			// keys are deleted individually, since a cluster node rejects multi-key
+			// commands for keys in different hash slots
+			pipe := client.Pipeline()
+			for _, key := range keys {
+				pipe.Del(key)
+			}
+			if _, err = pipe.Exec(); err != nil {
+				return n, err
+			}
+			tl.Debug(c.Logger, "redis cache prefix remove", tl.Pairs{"keys": len(keys)})
+			metrics.ObserveCacheDel(c.Name, c.Config.Provider, float64(len(keys)))
+			n += len(keys)
+		}
+		if next == 0 {
+			return n, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/cache/status"
+)
+
+func TestRemovePrefix(t *testing.T) {
+
+	rc, close := setupRedisCache(clientTypeStandard)
+	defer close()
+
+	err := rc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	defer rc.Close()
+
+	// enough keys are stored to require multiple scan iterations
+	for i := 0; i < scanCount+10; i++ {
+		if err = rc.Store("test.a."+strconv.Itoa(i), []byte("data"), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// glob characters in the prefix are matched literally
+	if err = rc.Store("test.a*b.1", []byte("data"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err = rc.Store("test.b.1", []byte("data"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := rc.RemovePrefix("test.a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != scanCount+10 {
+		t.Errorf("expected %d got %d", scanCount+10, n)
+	}
+
+	if _, ls, _ := rc.Retrieve("test.a.1", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+
+	n, err = rc.RemovePrefix("test.a*")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 1 {
+		t.Errorf("expected %d got %d", 1, n)
+	}
+
+	if _, ls, _ := rc.Retrieve("test.b.1", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+}
@@ -91,13 +91,23 @@ func (c *Cache) Connect() error {
 		c.closer = client.Close
 		c.client = client
 	}
-	return c.client.Ping().Err()
+	if err := c.client.Ping().Err(); err != nil {
+		return err
+	}
+	if c.Config.LockProvider == "redis" {
+		c.locker = newDistributedLocker(c.client, c.locker, c.Config.LockLeaseTTL,
+			c.Config.LockRetryInterval, c.Name, c.Logger)
+	}
+	return nil
 }
 
 // Store places the the data into the Redis Cache using the provided Key and TTL
 func (c *Cache) Store(cacheKey string, data []byte, ttl time.Duration) error {
 	metrics.ObserveCacheOperation(c.Name, c.Config.Provider, "set", "none", float64(len(data)))
 	tl.Debug(c.Logger, "redis cache store", tl.Pairs{"key": cacheKey})
+	if lk, ok := c.locker.(*distributedLocker); ok {
+		return lk.store(cacheKey, data, ttl)
+	}
 	return c.client.Set(cacheKey, data, ttl).Err()
 }
 
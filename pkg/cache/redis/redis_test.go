@@ -297,6 +297,19 @@ func TestRedisCache_Connect(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+	if _, ok := rc.Locker().(*distributedLocker); ok {
+		t.Error("expected in-process locker")
+	}
+
+	// it should use a distributed locker when configured
+	rc.Config.LockProvider = "redis"
+	err = rc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	if _, ok := rc.Locker().(*distributedLocker); !ok {
+		t.Error("expected distributed locker")
+	}
 }
 
 func TestRedisCache_Store(t *testing.T) {
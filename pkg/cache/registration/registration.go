@@ -71,6 +71,13 @@ func CloseCaches(caches map[string]cache.Cache) error {
 
 // NewCache returns a Cache object based on the provided config.CachingConfig
 func NewCache(cacheName string, cfg *options.Options, logger interface{}) cache.Cache {
+	c, _ := ConnectCache(cacheName, cfg, logger)
+	return c
+}
+
+// ConnectCache returns a connected Cache object based on the provided config.CachingConfig,
+// along with any error encountered while connecting
+func ConnectCache(cacheName string, cfg *options.Options, logger interface{}) (cache.Cache, error) {
 
 	var c cache.Cache
 
@@ -89,6 +96,5 @@ func NewCache(cacheName string, cfg *options.Options, logger interface{}) cache.
 	}
 
 	c.SetLocker(locks.NewNamedLocker())
-	c.Connect()
-	return c
+	return c, c.Connect()
 }
@@ -115,3 +115,25 @@ func newCacheConfig(t *testing.T, cacheProvider string) *co.Options {
 		},
 	}
 }
+
+func TestConnectCache(t *testing.T) {
+
+	logger := tl.ConsoleLogger("error")
+
+	c, err := ConnectCache("test", newCacheConfig(t, "memory"), logger)
+	if err != nil {
+		t.Error(err)
+	}
+	if c == nil {
+		t.Fatal("expected non-nil cache")
+	}
+	c.Close()
+
+	cfg := newCacheConfig(t, "redis")
+	cfg.Redis.Endpoint = "127.0.0.1:1"
+	_, err = ConnectCache("test", cfg, logger)
+	if err == nil {
+		t.Error("expected error")
+	}
+
+}
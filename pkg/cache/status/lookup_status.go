@@ -49,6 +49,9 @@ const (
 	LookupStatusError
 	// LookupStatusProxyHit indicates that the request joined an existing proxy download of the same object
 	LookupStatusProxyHit
+	// LookupStatusStaleHit indicates that an expired object was served from cache, either while
+	// it was revalidated in the background, or in place of an upstream error response
+	LookupStatusStaleHit
 )
 
 var cacheLookupStatusNames = map[string]LookupStatus{
@@ -62,6 +65,7 @@ var cacheLookupStatusNames = map[string]LookupStatus{
 	"proxy-only":  LookupStatusProxyOnly,
 	"nchit":       LookupStatusNegativeCacheHit,
 	"proxy-hit":   LookupStatusProxyHit,
+	"stale-hit":   LookupStatusStaleHit,
 	"error":       LookupStatusError,
 }
 
@@ -76,6 +80,7 @@ var cacheLookupStatusValues = map[LookupStatus]string{
 	LookupStatusProxyOnly:        "proxy-only",
 	LookupStatusNegativeCacheHit: "nchit",
 	LookupStatusProxyHit:         "proxy-hit",
+	LookupStatusStaleHit:         "stale-hit",
 	LookupStatusError:            "error",
 }
 
@@ -33,6 +33,10 @@ func TestLookupStatusString(t *testing.T) {
 		t.Errorf("expected %s got %s", "kmiss", t2.String())
 	}
 
+	if LookupStatusStaleHit.String() != "stale-hit" {
+		t.Errorf("expected %s got %s", "stale-hit", LookupStatusStaleHit.String())
+	}
+
 	if t3.String() != "99" {
 		t.Errorf("expected %s got %s", "99", t3.String())
 	}
@@ -22,6 +22,7 @@ package config
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"sync"
@@ -33,7 +34,10 @@ import (
 	cache "github.com/tricksterproxy/trickster/pkg/cache/options"
 	d "github.com/tricksterproxy/trickster/pkg/config/defaults"
 	reload "github.com/tricksterproxy/trickster/pkg/config/reload/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	authopts "github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	rlo "github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter/options"
 	rewriter "github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter"
 	rwopts "github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter/options"
 	tracing "github.com/tricksterproxy/trickster/pkg/tracing/options"
@@ -63,11 +67,17 @@ type Config struct {
 	Rules map[string]*rule.Options `toml:"rules"`
 	// RequestRewriters is a map of the Rewriters
 	RequestRewriters map[string]*rwopts.Options `toml:"request_rewriters"`
+	// Authenticators is a map of the frontend Authenticators
+	Authenticators map[string]*authopts.Options `toml:"authenticators"`
+	// RateLimiters is a map of the frontend Rate Limiters
+	RateLimiters map[string]*rlo.Options `toml:"rate_limiters"`
 	// ReloadConfig provides configurations for in-process config reloading
 	ReloadConfig *reload.Options `toml:"reloading"`
 
 	// Resources holds runtime resources uses by the Config
 	Resources *Resources `toml:"-"`
+	// CompiledAuthenticators is the map of Authenticators built from Authenticators
+	CompiledAuthenticators authenticator.Lookup `toml:"-"`
 
 	CompiledRewriters map[string]rewriter.RewriteInstructions `toml:"-"`
 	activeCaches      map[string]bool
@@ -89,6 +99,14 @@ type MainConfig struct {
 	ReloadHandlerPath string `toml:"reload_handler_path"`
 	// HeatlHandlerPath provides the base Health Check Handler path
 	HealthHandlerPath string `toml:"health_handler_path"`
+	// PurgeHandlerPath provides the path to register the Cache Purge Handler
+	PurgeHandlerPath string `toml:"purge_handler_path"`
+	// PurgeAuthToken provides the Bearer token that must accompany Cache Purge requests
+	// The Cache Purge Handler is not registered when no token is provided
+	PurgeAuthToken string `toml:"purge_auth_token"`
+	// AdminAuthenticatorName provides the name of the Authenticator that clients must pass to
+	// access the config, reload and purge handlers
+	AdminAuthenticatorName string `toml:"admin_authenticator_name"`
 	// PprofServer provides the name of the http listener that will host the pprof debugging routes
 	// Options are: "metrics", "reload", "both", or "off"; default is both
 	PprofServer string `toml:"pprof_server"`
@@ -161,6 +179,7 @@ func NewConfig() *Config {
 			PingHandlerPath:   d.DefaultPingHandlerPath,
 			ReloadHandlerPath: d.DefaultReloadHandlerPath,
 			HealthHandlerPath: d.DefaultHealthHandlerPath,
+			PurgeHandlerPath:  d.DefaultPurgeHandlerPath,
 			PprofServer:       d.DefaultPprofServerName,
 			ServerName:        hn,
 		},
@@ -197,6 +216,9 @@ func (c *Config) loadFile(flags *Flags) error {
 		c.setDefaults(&toml.MetaData{})
 		return err
 	}
+	if IsYAMLPath(flags.ConfigPath) {
+		return c.loadYAMLConfig(b, flags)
+	}
 	return c.loadTOMLConfig(string(b), flags)
 }
 
@@ -243,12 +265,42 @@ func (c *Config) setDefaults(metadata *toml.MetaData) error {
 		}
 	}
 
+	if c.Authenticators != nil {
+		if c.CompiledAuthenticators, err = authenticator.ProcessConfigs(c.Authenticators); err != nil {
+			return err
+		}
+	}
+
+	if c.Main.AdminAuthenticatorName != "" {
+		a, ok := c.CompiledAuthenticators[c.Main.AdminAuthenticatorName]
+		if !ok {
+			return fmt.Errorf("invalid admin authenticator name %s", c.Main.AdminAuthenticatorName)
+		}
+		// the admin endpoints are not served over TLS
+		if a.Options().Provider == "clientcert" {
+			return fmt.Errorf("admin authenticator %s cannot use the clientcert provider",
+				c.Main.AdminAuthenticatorName)
+		}
+	}
+
+	if err = rlo.Lookup(c.RateLimiters).Validate(); err != nil {
+		return err
+	}
+
 	c.activeCaches = make(map[string]bool)
 	for k, v := range c.Backends {
-		w, err := bo.ProcessTOML(k, v, metadata, c.CompiledRewriters, c.Backends, c.activeCaches)
+		w, err := bo.ProcessTOML(k, v, metadata, c.CompiledRewriters,
+			c.CompiledAuthenticators, c.RateLimiters, c.Backends, c.activeCaches)
 		if err != nil {
 			return err
 		}
+		if w.Authenticator != nil && w.TLS != nil {
+			w.Authenticator, err = authenticator.WithCertificateAuthorities(w.Authenticator,
+				w.TLS.CertificateAuthorityPaths)
+			if err != nil {
+				return err
+			}
+		}
 		c.Backends[k] = w
 	}
 
@@ -303,6 +355,9 @@ func (c *Config) Clone() *Config {
 	nc.Main.PingHandlerPath = c.Main.PingHandlerPath
 	nc.Main.ReloadHandlerPath = c.Main.ReloadHandlerPath
 	nc.Main.HealthHandlerPath = c.Main.HealthHandlerPath
+	nc.Main.PurgeHandlerPath = c.Main.PurgeHandlerPath
+	nc.Main.PurgeAuthToken = c.Main.PurgeAuthToken
+	nc.Main.AdminAuthenticatorName = c.Main.AdminAuthenticatorName
 	nc.Main.PprofServer = c.Main.PprofServer
 	nc.Main.ServerName = c.Main.ServerName
 
@@ -357,6 +412,27 @@ func (c *Config) Clone() *Config {
 		}
 	}
 
+	if c.Authenticators != nil && len(c.Authenticators) > 0 {
+		nc.Authenticators = make(map[string]*authopts.Options)
+		for k, v := range c.Authenticators {
+			nc.Authenticators[k] = v.Clone()
+		}
+	}
+
+	if c.RateLimiters != nil && len(c.RateLimiters) > 0 {
+		nc.RateLimiters = make(map[string]*rlo.Options)
+		for k, v := range c.RateLimiters {
+			nc.RateLimiters[k] = v.Clone()
+		}
+	}
+
+	if c.CompiledAuthenticators != nil {
+		nc.CompiledAuthenticators = make(authenticator.Lookup, len(c.CompiledAuthenticators))
+		for k, v := range c.CompiledAuthenticators {
+			nc.CompiledAuthenticators[k] = v
+		}
+	}
+
 	return nc
 }
 
@@ -410,6 +486,11 @@ func (c *Config) String() string {
 		}
 	}
 
+	// strip Purge token
+	if cp.Main.PurgeAuthToken != "" {
+		cp.Main.PurgeAuthToken = "*****"
+	}
+
 	// strip Redis password
 	for k, v := range cp.Caches {
 		if v != nil && cp.Caches[k].Redis.Password != "" {
@@ -88,11 +88,18 @@ func TestString(t *testing.T) {
 	c1.Backends["default"].Paths["test"] = &po.Options{}
 
 	c1.Caches["default"].Redis.Password = "plaintext-password"
+	c1.Main.PurgeAuthToken = "plaintext-token"
 
 	s := c1.String()
 	if !strings.Contains(s, `password = "*****"`) {
 		t.Errorf("missing password mask: %s", "*****")
 	}
+	if !strings.Contains(s, `purge_auth_token = "*****"`) {
+		t.Errorf("missing purge token mask: %s", "*****")
+	}
+	if c1.Main.PurgeAuthToken != "plaintext-token" {
+		t.Errorf("expected %s got %s", "plaintext-token", c1.Main.PurgeAuthToken)
+	}
 }
 
 func TestHideAuthorizationCredentials(t *testing.T) {
@@ -263,6 +270,103 @@ func TestProcessBackendOptionss(t *testing.T) {
 
 }
 
+const testAuthenticator = `
+ [authenticators]
+   [authenticators.example]
+   provider = 'basic'
+   users_file = '../../testdata/test.htpasswd'
+ `
+
+func TestProcessAuthenticators(t *testing.T) {
+
+	c, _ := emptyTestConfig()
+	c.Backends["test"].AuthenticatorName = "invalid"
+	toml := c.String() + testAuthenticator
+	err := c.loadTOMLConfig(toml, &Flags{})
+	if err == nil || !strings.Contains(err.Error(), "invalid authenticator name") {
+		t.Error("expected error for invalid authenticator name", err)
+	}
+
+	toml = strings.Replace(toml, `authenticator_name = "invalid"`,
+		`authenticator_name = "example"`, -1)
+	err = c.loadTOMLConfig(toml, &Flags{})
+	if err != nil {
+		t.Error(err)
+	}
+	if c.Backends["test"].Authenticator == nil {
+		t.Error("expected non-nil authenticator")
+	}
+
+	nc := c.Clone()
+	if nc.Authenticators["example"] == nil || nc.CompiledAuthenticators["example"] == nil {
+		t.Error("expected cloned authenticators")
+	}
+
+	err = c.loadTOMLConfig(strings.Replace(toml, "'basic'", "'invalid'", -1), &Flags{})
+	if err == nil {
+		t.Error("expected error for invalid authenticator provider")
+	}
+
+	toml = strings.Replace(toml, `admin_authenticator_name = ""`,
+		`admin_authenticator_name = "invalid"`, -1)
+	err = c.loadTOMLConfig(toml, &Flags{})
+	if err == nil || !strings.Contains(err.Error(), "invalid admin authenticator name") {
+		t.Error("expected error for invalid admin authenticator name", err)
+	}
+
+	toml = strings.Replace(toml, `admin_authenticator_name = "invalid"`,
+		`admin_authenticator_name = "example"`, -1)
+	err = c.loadTOMLConfig(toml, &Flags{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = c.loadTOMLConfig(strings.Replace(toml, "'basic'", "'clientcert'", -1), &Flags{})
+	if err == nil || !strings.Contains(err.Error(), "cannot use the clientcert provider") {
+		t.Error("expected error for clientcert admin authenticator", err)
+	}
+}
+
+const testRateLimiter = `
+ [rate_limiters]
+   [rate_limiters.example]
+   requests_per_second = 10.0
+ `
+
+func TestProcessRateLimiters(t *testing.T) {
+
+	c, _ := emptyTestConfig()
+	c.Backends["test"].RateLimiterName = "invalid"
+	toml := c.String() + testRateLimiter
+	err := c.loadTOMLConfig(toml, &Flags{})
+	if err == nil || !strings.Contains(err.Error(), "invalid rate limiter name") {
+		t.Error("expected error for invalid rate limiter name", err)
+	}
+
+	toml = strings.Replace(toml, `rate_limiter_name = "invalid"`,
+		`rate_limiter_name = "example"`, -1)
+	err = c.loadTOMLConfig(toml, &Flags{})
+	if err != nil {
+		t.Error(err)
+	}
+	if c.Backends["test"].RateLimiter == nil {
+		t.Error("expected non-nil rate limiter")
+	} else if c.Backends["test"].RateLimiter.Options().Burst != 10 {
+		t.Errorf("expected %d got %d", 10, c.Backends["test"].RateLimiter.Options().Burst)
+	}
+
+	nc := c.Clone()
+	if nc.RateLimiters["example"] == nil {
+		t.Error("expected cloned rate limiters")
+	}
+
+	err = c.loadTOMLConfig(strings.Replace(toml, "requests_per_second = 10.0",
+		"requests_per_second = 0.0", -1), &Flags{})
+	if err == nil {
+		t.Error("expected error for invalid requests_per_second")
+	}
+}
+
 func TestLoadTOMLConfig(t *testing.T) {
 
 	c := NewConfig()
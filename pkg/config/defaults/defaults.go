@@ -71,10 +71,19 @@ const (
 	DefaultTimeseriesTTLMS = 21600000
 	// DefaultFastForwardTTLMS is the default Cache TTL for Time Series Fast Forward Objects
 	DefaultFastForwardTTLMS = 15000
+	// DefaultNonTimeseriesTTLMS is the default Cache TTL for read-only queries to a Time Series
+	// backend that are not time range queries, and are cached by the Object Proxy Cache
+	DefaultNonTimeseriesTTLMS = 30000
 	// DefaultMaxTTLMS is the default Maximum TTL of any cache object
 	DefaultMaxTTLMS = 86400000
 	// DefaultRevalidationFactor is the default Cache Object Freshness Lifetime to TTL multiplier
 	DefaultRevalidationFactor = 2
+	// DefaultCacheLockProvider is the default provider of the named locks used to coordinate cache fills
+	DefaultCacheLockProvider = "local"
+	// DefaultCacheLockLeaseTTLMS is the default lease duration of a distributed cache lock
+	DefaultCacheLockLeaseTTLMS = 30000
+	// DefaultCacheLockRetryIntervalMS is the default polling interval for a contended distributed cache lock
+	DefaultCacheLockRetryIntervalMS = 10
 	// DefaultRedisClientType is the default Redis Client Type
 	DefaultRedisClientType = "standard"
 	// DefaultRedisProtocol is the default Redis Client protocol
@@ -125,6 +134,16 @@ const (
 	DefaultHealthCheckQuery = "-"
 	// DefaultHealthCheckVerb is the default value (noop) for Backends' Health Check Verb
 	DefaultHealthCheckVerb = "-"
+	// DefaultHealthCheckIntervalMS is the default interval between background upstream health checks
+	DefaultHealthCheckIntervalMS = 5000
+	// DefaultHealthCheckTimeoutMS is the default time to wait for a background upstream health check response
+	DefaultHealthCheckTimeoutMS = 3000
+	// DefaultHealthCheckFailureThreshold is the default number of consecutive failed background
+	// upstream health checks before a backend is considered to be failing
+	DefaultHealthCheckFailureThreshold = 3
+	// DefaultHealthCheckRecoveryThreshold is the default number of consecutive successful background
+	// upstream health checks before a failing backend is considered to be passing
+	DefaultHealthCheckRecoveryThreshold = 3
 	// DefaultConfigHandlerPath is the default value for the Trickster Config Printout Handler path
 	DefaultConfigHandlerPath = "/trickster/config"
 	// DefaultPingHandlerPath is the default value for the Trickster Config Ping Handler path
@@ -133,8 +152,18 @@ const (
 	DefaultReloadHandlerPath = "/trickster/config/reload"
 	// DefaultHealthHandlerPath defines the default path for the Health Handler
 	DefaultHealthHandlerPath = "/trickster/health"
+	// DefaultPurgeHandlerPath defines the default path for the Cache Purge Handler
+	DefaultPurgeHandlerPath = "/trickster/purge"
 	// DefaultMaxRuleExecutions is the default value for the number of allowed Rule executions per Request
 	DefaultMaxRuleExecutions = 16
+	// DefaultALBMechanismName is the default load balancing mechanism for ALB backends
+	DefaultALBMechanismName = "rr"
+	// DefaultALBHashSource is the default request property hashed by ALBs using consistent hashing
+	DefaultALBHashSource = "client_ip"
+	// DefaultRateLimiterKeySource is the default request property by which rate limits are applied
+	DefaultRateLimiterKeySource = "client_ip"
+	// DefaultRateLimiterMaxKeys is the default maximum number of keys tracked by a rate limiter
+	DefaultRateLimiterMaxKeys = 10000
 	// DefaultPprofServerName defines the default Pprof Server Name
 	DefaultPprofServerName = "both"
 	// DefaultForwardedHeaders defines which class of 'Forwarded' headers are attached to upstream requests
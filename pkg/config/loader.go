@@ -88,6 +88,8 @@ func Load(applicationName string, applicationVersion string, arguments []string)
 	for _, c := range c.Caches {
 		c.Index.FlushInterval = time.Duration(c.Index.FlushIntervalMS) * time.Millisecond
 		c.Index.ReapInterval = time.Duration(c.Index.ReapIntervalMS) * time.Millisecond
+		c.LockLeaseTTL = time.Duration(c.LockLeaseTTLMS) * time.Millisecond
+		c.LockRetryInterval = time.Duration(c.LockRetryIntervalMS) * time.Millisecond
 	}
 
 	return c, flags, nil
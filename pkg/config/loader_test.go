@@ -205,6 +205,10 @@ func TestFullLoadConfiguration(t *testing.T) {
 		t.Errorf("expected fast_forward_disable true, got %t", o.FastForwardDisable)
 	}
 
+	if !o.InstantQueriesFromRangeCache {
+		t.Errorf("expected instant_queries_from_range_cache true, got %t", o.InstantQueriesFromRangeCache)
+	}
+
 	if o.BackfillToleranceMS != 301000 {
 		t.Errorf("expected 301000, got %d", o.BackfillToleranceMS)
 	}
@@ -213,6 +217,22 @@ func TestFullLoadConfiguration(t *testing.T) {
 		t.Errorf("expected 37000, got %d", o.TimeoutMS)
 	}
 
+	if o.HealthCheckInterval != 2500*time.Millisecond {
+		t.Errorf("expected %s got %s", 2500*time.Millisecond, o.HealthCheckInterval)
+	}
+
+	if o.HealthCheckTimeout != 1500*time.Millisecond {
+		t.Errorf("expected %s got %s", 1500*time.Millisecond, o.HealthCheckTimeout)
+	}
+
+	if o.HealthCheckFailureThreshold != 4 {
+		t.Errorf("expected %d got %d", 4, o.HealthCheckFailureThreshold)
+	}
+
+	if o.HealthCheckRecoveryThreshold != 2 {
+		t.Errorf("expected %d got %d", 2, o.HealthCheckRecoveryThreshold)
+	}
+
 	if o.IsDefault != true {
 		t.Errorf("expected true got %t", o.IsDefault)
 	}
@@ -235,6 +255,96 @@ func TestFullLoadConfiguration(t *testing.T) {
 		t.Errorf("expected 300000, got %d", o.FastForwardTTLMS)
 	}
 
+	if o.StaleWhileRevalidate != 30*time.Second {
+		t.Errorf("expected %s got %s", 30*time.Second, o.StaleWhileRevalidate)
+	}
+
+	if o.StaleIfError != 10*time.Minute {
+		t.Errorf("expected %s got %s", 10*time.Minute, o.StaleIfError)
+	}
+
+	if o.TimeseriesChunkFactor != 60 {
+		t.Errorf("expected %d got %d", 60, o.TimeseriesChunkFactor)
+	}
+
+	if o.MaxUpstreamConcurrency != 4 {
+		t.Errorf("expected %d got %d", 4, o.MaxUpstreamConcurrency)
+	}
+
+	if o.TenantHeader != "X-Scope-OrgID" {
+		t.Errorf("expected %s got %s", "X-Scope-OrgID", o.TenantHeader)
+	}
+
+	if o.TenantCacheKeyPrefixes["team-a"] != "test-prefix-team-a" {
+		t.Errorf("expected %s got %s", "test-prefix-team-a", o.TenantCacheKeyPrefixes["team-a"])
+	}
+
+	if o.AuthenticatorName != "test" {
+		t.Errorf("expected %s got %s", "test", o.AuthenticatorName)
+	}
+
+	if o.Authenticator == nil {
+		t.Error("expected non-nil authenticator")
+	} else if o.Authenticator.Challenge() != `Basic realm="test-realm"` {
+		t.Errorf("expected %s got %s", `Basic realm="test-realm"`, o.Authenticator.Challenge())
+	}
+
+	ao, ok := conf.Authenticators["test"]
+	if !ok {
+		t.Errorf("unable to find authenticator config: %s", "test")
+		return
+	}
+
+	if ao.Provider != "basic" {
+		t.Errorf("expected %s got %s", "basic", ao.Provider)
+	}
+
+	if ao.IdentityHeader != "X-Trickster-User" {
+		t.Errorf("expected %s got %s", "X-Trickster-User", ao.IdentityHeader)
+	}
+
+	if o.RateLimiterName != "test" {
+		t.Errorf("expected %s got %s", "test", o.RateLimiterName)
+	}
+
+	if o.RateLimiter == nil {
+		t.Error("expected non-nil rate limiter")
+	} else if o.RateLimiter.Options().Burst != 50 {
+		t.Errorf("expected %d got %d", 50, o.RateLimiter.Options().Burst)
+	}
+
+	rl, ok := conf.RateLimiters["test"]
+	if !ok {
+		t.Errorf("unable to find rate limiter config: %s", "test")
+		return
+	}
+
+	if rl.RequestsPerSecond != 25 {
+		t.Errorf("expected %f got %f", 25.0, rl.RequestsPerSecond)
+	}
+
+	if rl.KeySource != "header" || rl.KeyName != "X-API-Key" {
+		t.Errorf("expected %s got %s", "header", rl.KeySource)
+	}
+
+	if rl.MaxKeys != 500 {
+		t.Errorf("expected %d got %d", 500, rl.MaxKeys)
+	}
+
+	if p, ok := o.Paths["/series-GET-HEAD"]; !ok || p.RateLimiter == nil {
+		t.Error("expected non-nil path rate limiter")
+	} else if p.RateLimiter.Options().Burst != 1 {
+		t.Errorf("expected %d got %d", 1, p.RateLimiter.Options().Burst)
+	}
+
+	if o.MaxExtentSteps != 5000 {
+		t.Errorf("expected %d got %d", 5000, o.MaxExtentSteps)
+	}
+
+	if o.MaxExtentDuration != 24*time.Hour {
+		t.Errorf("expected %s got %s", 24*time.Hour, o.MaxExtentDuration)
+	}
+
 	if o.TLS == nil {
 		t.Errorf("expected tls config for backend %s, got nil", "test")
 	}
@@ -271,6 +381,18 @@ func TestFullLoadConfiguration(t *testing.T) {
 		t.Errorf("expected redis, got %s", c.Provider)
 	}
 
+	if c.LockProvider != "redis" {
+		t.Errorf("expected redis, got %s", c.LockProvider)
+	}
+
+	if c.LockLeaseTTL != 15*time.Second {
+		t.Errorf("expected %s, got %s", 15*time.Second, c.LockLeaseTTL)
+	}
+
+	if c.LockRetryInterval != 20*time.Millisecond {
+		t.Errorf("expected %s, got %s", 20*time.Millisecond, c.LockRetryInterval)
+	}
+
 	if c.Index.ReapIntervalMS != 4000 {
 		t.Errorf("expected 4000, got %d", c.Index.ReapIntervalMS)
 	}
@@ -495,6 +617,14 @@ func TestEmptyLoadConfiguration(t *testing.T) {
 		t.Errorf("expected %s, got %s", d.DefaultCacheProvider, c.Provider)
 	}
 
+	if c.LockProvider != d.DefaultCacheLockProvider {
+		t.Errorf("expected %s, got %s", d.DefaultCacheLockProvider, c.LockProvider)
+	}
+
+	if c.LockLeaseTTLMS != d.DefaultCacheLockLeaseTTLMS {
+		t.Errorf("expected %d, got %d", d.DefaultCacheLockLeaseTTLMS, c.LockLeaseTTLMS)
+	}
+
 	if c.Index.ReapIntervalMS != d.DefaultCacheIndexReap {
 		t.Errorf("expected %d, got %d", d.DefaultCacheIndexReap, c.Index.ReapIntervalMS)
 	}
@@ -20,7 +20,6 @@ package reload
 import (
 	"sync"
 
-	"github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
 )
@@ -28,4 +27,4 @@ import (
 // ReloaderFunc describes a function that loads and applies a Trickster config at startup,
 // or gracefully over an existing running Config
 type ReloaderFunc func(oldConf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
-	caches map[string]cache.Cache, args []string, errorsFatal bool) error
+	args []string, errorsFatal bool) error
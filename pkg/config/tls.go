@@ -43,6 +43,15 @@ func (c *Config) TLSCertConfig() (*tls.Config, error) {
 
 	tlsConfig := &tls.Config{Certificates: make([]tls.Certificate, l), NextProtos: []string{"h2"}}
 
+	// client certificates are requested, but verified by any clientcert authenticators
+	// at the time of the request, since each backend may trust different CAs
+	for _, a := range c.CompiledAuthenticators {
+		if a.Options().Provider == "clientcert" {
+			tlsConfig.ClientAuth = tls.RequestClientCert
+			break
+		}
+	}
+
 	for i, tc := range to {
 		tlsConfig.Certificates[i], err = tls.LoadX509KeyPair(tc.TLS.FullChainCertPath, tc.TLS.PrivateKeyPath)
 		if err != nil {
@@ -17,8 +17,11 @@
 package config
 
 import (
+	"crypto/tls"
 	"testing"
 
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	authopts "github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/tls/options"
 	tlstest "github.com/tricksterproxy/trickster/pkg/util/testing/tls"
 )
@@ -57,10 +60,27 @@ func TestTLSCertConfig(t *testing.T) {
 
 	// test good config
 	config.Backends["default"].TLS = tls01
-	_, err = config.TLSCertConfig()
+	n, err = config.TLSCertConfig()
 	if err != nil {
 		t.Error(err)
 	}
+	if n.ClientAuth != tls.NoClientCert {
+		t.Errorf("expected %v got %v", tls.NoClientCert, n.ClientAuth)
+	}
+
+	// test good config with a client certificate authenticator
+	a, err := authenticator.New("test", &authopts.Options{Provider: "clientcert"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.CompiledAuthenticators = authenticator.Lookup{"test": a}
+	n, err = config.TLSCertConfig()
+	if err != nil {
+		t.Error(err)
+	}
+	if n.ClientAuth != tls.RequestClientCert {
+		t.Errorf("expected %v got %v", tls.RequestClientCert, n.ClientAuth)
+	}
 
 	// test config with key file that has invalid key data
 	expectedErr := "tls: failed to find any PEM data in key input"
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidYAMLDocument indicates the YAML config document is not a mapping of config sections
+var ErrInvalidYAMLDocument = errors.New("yaml config document must be a mapping")
+
+// IsYAMLPath returns true if the file path has a YAML file extension (.yaml or .yml)
+func IsYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// loadYAMLConfig loads application configuration from a YAML-formatted byte slice. YAML configs
+// use the same schema as TOML configs, and are converted to TOML before loading, so that the
+// TOML metadata reports which keys were set in the YAML document
+func (c *Config) loadYAMLConfig(yml []byte, flags *Flags) error {
+	tml, err := yamlToTOML(yml)
+	if err != nil {
+		c.setDefaults(&toml.MetaData{})
+		return err
+	}
+	return c.loadTOMLConfig(tml, flags)
+}
+
+// yamlToTOML converts a YAML document into an equivalent TOML document
+func yamlToTOML(yml []byte) (string, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(yml, &doc); err != nil {
+		return "", err
+	}
+	if doc == nil {
+		return "", nil
+	}
+	m, ok := coerceYAML(normalizeYAML(doc), reflect.TypeOf(Config{})).(map[string]interface{})
+	if !ok {
+		return "", ErrInvalidYAMLDocument
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// normalizeYAML converts decoded YAML values into types that can be encoded as TOML:
+// mapping keys are converted to strings, and null values are removed, since TOML has no null
+func normalizeYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, x := range t {
+			if x == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = normalizeYAML(x)
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, x := range t {
+			if x == nil {
+				continue
+			}
+			m[fmt.Sprint(k)] = normalizeYAML(x)
+		}
+		return m
+	case []interface{}:
+		for i := range t {
+			t[i] = normalizeYAML(t[i])
+		}
+	case int:
+		return int64(t)
+	}
+	return v
+}
+
+// coerceYAML walks the normalized YAML value alongside the config type it will be decoded
+// into, converting integers to floats where the target field is a float, since YAML has no
+// way to distinguish 1 from 1.0, but TOML will not decode an integer into a float field
+func coerceYAML(v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, e := range x {
+				x[k] = coerceYAML(e, t.Elem())
+			}
+		case reflect.Struct:
+			for k, e := range x {
+				if f, ok := tomlField(t, k); ok {
+					x[k] = coerceYAML(e, f.Type)
+				}
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i := range x {
+				x[i] = coerceYAML(x[i], t.Elem())
+			}
+		}
+	case int64:
+		if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 {
+			return float64(x)
+		}
+	}
+	return v
+}
+
+// tomlField returns the struct field that the provided toml key decodes into
+func tomlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// YAMLString returns the YAML representation of the running configuration,
+// with the same credential masking as String()
+func (c *Config) YAMLString() string {
+	m := make(map[string]interface{})
+	if _, err := toml.Decode(c.String(), &m); err != nil {
+		return ""
+	}
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// tomlFileToYAML converts a TOML test config file into YAML and writes it to dir
+func tomlFileToYAML(t *testing.T, path, dir string) string {
+	t.Helper()
+	m := make(map[string]interface{})
+	if _, err := toml.DecodeFile(path, &m); err != nil {
+		t.Fatal(err)
+	}
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), ".conf")+".yaml")
+	if err := ioutil.WriteFile(out, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestIsYAMLPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/etc/trickster/trickster.yaml", true},
+		{"/etc/trickster/trickster.YML", true},
+		{"/etc/trickster/trickster.conf", false},
+		{"", false},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if v := IsYAMLPath(test.path); v != test.expected {
+				t.Errorf("expected %t got %t", test.expected, v)
+			}
+		})
+	}
+}
+
+func TestLoadYAMLConfiguration(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "trickster-yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"../../testdata/test.multiple_backends.conf",
+		"../../testdata/test.routing.rules.conf",
+		"../../testdata/test.redis-cluster.conf",
+		"../../testdata/test.custom_health.conf",
+	}
+
+	for i, file := range files {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tc, _, err := Load("trickster-test", "0", []string{"-config", file})
+			if err != nil {
+				t.Fatal(err)
+			}
+			yc, _, err := Load("trickster-test", "0",
+				[]string{"-config", tomlFileToYAML(t, file, dir)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.String() != yc.String() {
+				t.Errorf("yaml config does not match toml config:\n%s\n---\n%s",
+					tc.String(), yc.String())
+			}
+		})
+	}
+}
+
+func TestLoadYAMLConfigDefaults(t *testing.T) {
+
+	const yml = `
+backends:
+  default:
+    provider: prometheus
+    origin_url: http://1
+  test:
+    provider: prometheus
+    origin_url: http://2
+    cache_name: explicit
+    revalidation_factor: 2
+caches:
+  default:
+    provider: memory
+  explicit:
+    provider: memory
+    index:
+      max_size_bytes: 0
+      max_size_backoff_bytes: 0
+`
+	c := NewConfig()
+	if err := c.loadYAMLConfig([]byte(yml), &Flags{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Caches["default"].Index.MaxSizeBytes == 0 {
+		t.Error("expected default max_size_bytes for unset key")
+	}
+
+	if c.Caches["explicit"].Index.MaxSizeBytes != 0 {
+		t.Errorf("expected 0 got %d", c.Caches["explicit"].Index.MaxSizeBytes)
+	}
+
+	if c.Backends["test"].RevalidationFactor != 2 {
+		t.Errorf("expected 2 got %f", c.Backends["test"].RevalidationFactor)
+	}
+
+}
+
+func TestLoadYAMLConfigErrors(t *testing.T) {
+
+	tests := []struct {
+		yml      string
+		expected error
+	}{
+		{"- a\n- b\n", ErrInvalidYAMLDocument},
+		{"frontend: [\n", nil},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := NewConfig()
+			err := c.loadYAMLConfig([]byte(test.yml), &Flags{})
+			if err == nil {
+				t.Error("expected error")
+			} else if test.expected != nil && err != test.expected {
+				t.Errorf("expected %v got %v", test.expected, err)
+			}
+		})
+	}
+
+}
+
+func TestYAMLToTOMLEmpty(t *testing.T) {
+	s, err := yamlToTOML([]byte("# nothing here\n"))
+	if err != nil {
+		t.Error(err)
+	}
+	if s != "" {
+		t.Errorf("expected empty string got %s", s)
+	}
+}
+
+func TestNormalizeYAML(t *testing.T) {
+	v := normalizeYAML(map[interface{}]interface{}{
+		1:    "a",
+		"b":  nil,
+		"c":  []interface{}{2, map[string]interface{}{"d": nil}},
+		true: int(3),
+	})
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{} got %T", v)
+	}
+	if m["1"] != "a" {
+		t.Errorf("expected a got %v", m["1"])
+	}
+	if _, ok := m["b"]; ok {
+		t.Error("expected nil value to be removed")
+	}
+	if m["true"] != int64(3) {
+		t.Errorf("expected int64 3 got %v", m["true"])
+	}
+	l := m["c"].([]interface{})
+	if l[0] != int64(2) {
+		t.Errorf("expected int64 2 got %v", l[0])
+	}
+	if len(l[1].(map[string]interface{})) != 0 {
+		t.Error("expected nested nil value to be removed")
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	c, _, err := Load("trickster-test", "0",
+		[]string{"-config", "../../testdata/test.redis-cluster.conf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.YAMLString()
+	if !strings.Contains(s, "backends:") {
+		t.Errorf("expected yaml output got %s", s)
+	}
+	// the yaml output should load back into an equivalent config
+	c2 := NewConfig()
+	if err := c2.loadYAMLConfig([]byte(s), &Flags{}); err != nil {
+		t.Fatal(err)
+	}
+	if c2.Caches["test"].Redis.ClientType != c.Caches["test"].Redis.ClientType {
+		t.Errorf("expected %s got %s", c.Caches["test"].Redis.ClientType,
+			c2.Caches["test"].Redis.ClientType)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package promql provides a lexer for the Prometheus Query Language
+package promql
+
+import (
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/parsing/lex"
+	"github.com/tricksterproxy/trickster/pkg/parsing/token"
+)
+
+// promqllexer holds the state of the scanner
+type promqllexer struct{}
+
+// NewLexer returns a new PromQL Lexer reference. Since PromQL keywords depend upon their
+// context (e.g., a label may be named 'by'), all words are emitted as token.Identifier,
+// and keywords are left to the parser to identify
+func NewLexer(lo *lex.Options) lex.Lexer {
+	return &promqllexer{}
+}
+
+// Run runs the lexer against the provided string and returns tokens on the
+// provided channel. Run will end when the state is EOF or Error.
+func (l *promqllexer) Run(input string, ch chan *token.Token) {
+	rs := &lex.RunState{
+		Input: input,
+		// PromQL is case-sensitive, so the input is scanned without lowering it
+		InputLowered: input,
+		InputWidth:   len(input),
+		Tokens:       ch,
+	}
+	for state := lexText; state != nil; {
+		state = state(l, rs)
+	}
+	close(ch)
+}
+
+var singleRuneTokens = map[rune]token.Typ{
+	lex.RuneLeftParen:  token.LeftParen,
+	lex.RuneRightParen: token.RightParen,
+	lex.RuneComma:      token.Comma,
+	lex.RunePlus:       token.Plus,
+	lex.RuneMinus:      token.Minus,
+	lex.RuneAsterisk:   token.Multiply,
+	lex.RuneSlash:      token.Divide,
+	lex.RunePercent:    token.Modulo,
+	runeCaret:          TokenPower,
+	runeAt:             TokenAt,
+	runeColon:          TokenColon,
+	runeLeftBrace:      TokenLeftBrace,
+	runeRightBrace:     TokenRightBrace,
+	runeLeftBracket:    TokenLeftBracket,
+	runeRightBracket:   TokenRightBracket,
+}
+
+// lexText scans for the next token in the input
+func lexText(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	r := rs.Next()
+	if t, ok := singleRuneTokens[r]; ok {
+		rs.Emit(t)
+		return lexText
+	}
+	switch {
+	case r == lex.EOF:
+		rs.Emit(token.EOF)
+		return nil
+	case lex.IsWhiteSpace(r):
+		for lex.IsWhiteSpace(rs.Peek()) {
+			rs.Next()
+		}
+		rs.Ignore()
+		return lexText
+	case r == runeHash:
+		return lexComment
+	case r == lex.RuneDoubleQuote || r == lex.RuneSingleQuote || r == runeBacktick:
+		return lexString
+	case isDigit(r) || (r == lex.RunePeriod && isDigit(rs.Peek())):
+		rs.Backup()
+		return lexNumber
+	case isIdentifierStart(r):
+		return lexIdentifier
+	case r == lex.RuneEqual:
+		return lexOperator(rs, token.Equals, map[rune]token.Typ{
+			lex.RuneEqual: TokenEqualEqual, runeTilde: TokenRegexMatch})
+	case r == lex.RuneExclamation:
+		return lexOperator(rs, token.Error, map[rune]token.Typ{
+			lex.RuneEqual: token.NotEqualOperator, runeTilde: TokenRegexNoMatch})
+	case r == lex.RuneLessThan:
+		return lexOperator(rs, token.LessThan, map[rune]token.Typ{
+			lex.RuneEqual: token.LessThanOrEqual})
+	case r == lex.RuneGreaterThan:
+		return lexOperator(rs, token.GreaterThan, map[rune]token.Typ{
+			lex.RuneEqual: token.GreaterThanOrEqual})
+	}
+	return rs.EmitToken(rs.Errorf("unexpected character: %#U", r))
+}
+
+// lexOperator emits the token for an operator that may be followed by a second rune,
+// such as '=' and '=~'. A def of token.Error indicates the second rune is required
+func lexOperator(rs *lex.RunState, def token.Typ, second map[rune]token.Typ) lex.StateFn {
+	if t, ok := second[rs.Peek()]; ok {
+		rs.Next()
+		rs.Emit(t)
+		return lexText
+	}
+	if def == token.Error {
+		return rs.EmitToken(rs.Errorf("unexpected character after '%s'", rs.Input[rs.Start:rs.Pos]))
+	}
+	rs.Emit(def)
+	return lexText
+}
+
+// lexComment scans a comment, which runs to the end of the line. The '#' is known to be present
+func lexComment(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	i := strings.IndexAny(rs.Input[rs.Pos:], "\r\n")
+	if i < 0 {
+		rs.Pos = rs.InputWidth
+	} else {
+		rs.Pos += i
+	}
+	rs.Emit(TokenComment)
+	return lexText
+}
+
+// lexString scans a quoted string. The opening quote is known to be present
+func lexString(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	q := rune(rs.Input[rs.Start])
+	for {
+		switch r := rs.Next(); {
+		case r == lex.EOF:
+			return rs.EmitToken(rs.Errorf("unterminated quoted string"))
+		case r == runeBackslash && q != runeBacktick:
+			if rs.Next() == lex.EOF {
+				return rs.EmitToken(rs.Errorf("unterminated quoted string"))
+			}
+		case r == q:
+			rs.Emit(token.String)
+			return lexText
+		}
+	}
+}
+
+const (
+	decimalDigits = "0123456789"
+	hexDigits     = "0123456789abcdefABCDEF"
+	durationUnits = "smhdwy"
+)
+
+// lexNumber scans a number, or a duration when the digits are followed by a duration unit
+func lexNumber(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	if rs.Accept("0") && rs.Accept("xX") {
+		rs.AcceptRun(hexDigits)
+		return emitNumber(rs, token.Number)
+	}
+	rs.AcceptRun(decimalDigits)
+	if strings.ContainsRune(durationUnits, rs.Peek()) {
+		return lexDuration
+	}
+	if rs.Accept(".") {
+		rs.AcceptRun(decimalDigits)
+	}
+	if rs.Accept("eE") {
+		rs.Accept("+-")
+		if !rs.AcceptRun(decimalDigits) {
+			return rs.EmitToken(rs.Errorf("bad number syntax: %q", rs.Input[rs.Start:rs.Pos]))
+		}
+	}
+	return emitNumber(rs, token.Number)
+}
+
+// lexDuration scans a duration like 1h30m, starting with the first unit
+func lexDuration(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	for {
+		if rs.Accept("m") {
+			rs.Accept("s")
+		} else if !rs.Accept(durationUnits) {
+			return rs.EmitToken(rs.Errorf("bad duration syntax: %q", rs.Input[rs.Start:rs.Pos]))
+		}
+		if !rs.AcceptRun(decimalDigits) {
+			break
+		}
+	}
+	return emitNumber(rs, TokenDuration)
+}
+
+// emitNumber emits a number or duration, which must not be immediately followed by an identifier
+func emitNumber(rs *lex.RunState, t token.Typ) lex.StateFn {
+	if r := rs.Peek(); (isIdentifierStart(r) && r != runeColon) || isDigit(r) {
+		rs.Next()
+		return rs.EmitToken(rs.Errorf("bad number syntax: %q", rs.Input[rs.Start:rs.Pos]))
+	}
+	rs.Emit(t)
+	return lexText
+}
+
+// lexIdentifier scans a metric name, label name, function name or keyword.
+// The first character is known to be present
+func lexIdentifier(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	for r := rs.Next(); isIdentifierStart(r) || isDigit(r); r = rs.Next() {
+	}
+	rs.Backup()
+	rs.Emit(token.Identifier)
+	return lexText
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isIdentifierStart(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == lex.RuneUnderscore || r == runeColon
+}
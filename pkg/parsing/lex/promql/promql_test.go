@@ -0,0 +1,136 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package promql
+
+import (
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/parsing/lex"
+	"github.com/tricksterproxy/trickster/pkg/parsing/token"
+)
+
+func lexAll(input string) []*token.Token {
+	ch := make(chan *token.Token, 256)
+	NewLexer(&lex.Options{}).Run(input, ch)
+	out := make([]*token.Token, 0, len(ch))
+	for t := range ch {
+		out = append(out, t)
+	}
+	return out
+}
+
+func TestLexer(t *testing.T) {
+
+	tests := []struct {
+		in       string
+		expected []token.Typ
+		vals     []string
+		err      string
+	}{
+		{
+			in: `sum by (job) (rate(Metric_1{a="b",c!~'d.*'}[1h30m] offset -5m @ 1609746000))`,
+			expected: []token.Typ{token.Identifier, token.Identifier, token.LeftParen,
+				token.Identifier, token.RightParen, token.LeftParen, token.Identifier,
+				token.LeftParen, token.Identifier, TokenLeftBrace, token.Identifier,
+				token.Equals, token.String, token.Comma, token.Identifier, TokenRegexNoMatch,
+				token.String, TokenRightBrace, TokenLeftBracket, TokenDuration,
+				TokenRightBracket, token.Identifier, token.Minus, TokenDuration, TokenAt,
+				token.Number, token.RightParen, token.RightParen, token.EOF},
+			vals: []string{"sum", "by", "(", "job", ")", "(", "rate", "(", "Metric_1", "{",
+				"a", "=", `"b"`, ",", "c", "!~", `'d.*'`, "}", "[", "1h30m", "]", "offset",
+				"-", "5m", "@", "1609746000", ")", ")", ""},
+		},
+		{
+			in: "a == 0x1F != .5e-3 <= 1 >= 2 < 3 > 4 + 5 - 6 * 7 / 8 % 9 ^ 10 =~ `x\\`",
+			expected: []token.Typ{token.Identifier, TokenEqualEqual, token.Number,
+				token.NotEqualOperator, token.Number, token.LessThanOrEqual, token.Number,
+				token.GreaterThanOrEqual, token.Number, token.LessThan, token.Number,
+				token.GreaterThan, token.Number, token.Plus, token.Number, token.Minus,
+				token.Number, token.Multiply, token.Number, token.Divide, token.Number,
+				token.Modulo, token.Number, TokenPower, token.Number, TokenRegexMatch,
+				token.String, token.EOF},
+		},
+		{
+			in:       "x[5m:1ms] # comment\n+ y",
+			expected: []token.Typ{token.Identifier, TokenLeftBracket, TokenDuration, TokenColon, TokenDuration, TokenRightBracket, TokenComment, token.Plus, token.Identifier, token.EOF},
+			vals:     []string{"x", "[", "5m", ":", "1ms", "]", "# comment", "+", "y", ""},
+		},
+		{
+			in:       `x{a="b\"c"}`,
+			expected: []token.Typ{token.Identifier, TokenLeftBrace, token.Identifier, token.Equals, token.String, TokenRightBrace, token.EOF},
+		},
+		{
+			in:  `x{a="b}`,
+			err: "unterminated quoted string",
+		},
+		{
+			in:  `x{a="b\`,
+			err: "unterminated quoted string",
+		},
+		{
+			in:  "x ! y",
+			err: "unexpected character after '!'",
+		},
+		{
+			in:  "x $ y",
+			err: "unexpected character: U+0024 '$'",
+		},
+		{
+			in:  "x[5mo]",
+			err: `bad number syntax: "5mo"`,
+		},
+		{
+			in:  "x[5q]",
+			err: `bad number syntax: "5q"`,
+		},
+		{
+			in:  "1e+",
+			err: `bad number syntax: "1e+"`,
+		},
+		{
+			in:  "x[5m3]",
+			err: `bad duration syntax: "5m3"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			toks := lexAll(test.in)
+			last := toks[len(toks)-1]
+			if test.err != "" {
+				if last.Typ != token.Error {
+					t.Fatalf("expected error token, got %d", last.Typ)
+				}
+				if last.Val != test.err {
+					t.Errorf("expected %s got %s", test.err, last.Val)
+				}
+				return
+			}
+			if len(toks) != len(test.expected) {
+				t.Fatalf("expected %d tokens got %d: %v", len(test.expected), len(toks), toks)
+			}
+			for i, tok := range toks {
+				if tok.Typ != test.expected[i] {
+					t.Errorf("token %d: expected type %d got %d (%s)", i, test.expected[i], tok.Typ, tok.Val)
+				}
+				if test.vals != nil && tok.Val != test.vals[i] {
+					t.Errorf("token %d: expected value %s got %s", i, test.vals[i], tok.Val)
+				}
+			}
+		})
+	}
+}
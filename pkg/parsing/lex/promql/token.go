@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package promql
+
+import "github.com/tricksterproxy/trickster/pkg/parsing/token"
+
+// Runes used by PromQL that are not provided by the lex package
+const (
+	runeHash         = '#'
+	runeBacktick     = '`'
+	runeBackslash    = '\\'
+	runeTilde        = '~'
+	runeCaret        = '^'
+	runeAt           = '@'
+	runeColon        = ':'
+	runeLeftBrace    = '{'
+	runeRightBrace   = '}'
+	runeLeftBracket  = '['
+	runeRightBracket = ']'
+)
+
+const (
+	// TokenPromQLNonKeyword is the lower bound value for PromQL tokens, and must not be
+	// assigned to a Token.Typ
+	TokenPromQLNonKeyword token.Typ = iota + (token.CustomNonKeyword + 512)
+	// TokenComment is a Comment Token Type, which runs from a '#' to the end of the line
+	TokenComment
+	// TokenDuration represents a duration like 5m or 1h30m
+	TokenDuration
+	// TokenLeftBrace represents a token of '{'
+	TokenLeftBrace
+	// TokenRightBrace represents a token of '}'
+	TokenRightBrace
+	// TokenLeftBracket represents a token of '['
+	TokenLeftBracket
+	// TokenRightBracket represents a token of ']'
+	TokenRightBracket
+	// TokenColon represents a token of ':'
+	TokenColon
+	// TokenAt represents a token of '@'
+	TokenAt
+	// TokenEqualEqual represents a token of '=='
+	TokenEqualEqual
+	// TokenRegexMatch represents a token of '=~'
+	TokenRegexMatch
+	// TokenRegexNoMatch represents a token of '!~'
+	TokenRegexNoMatch
+	// TokenPower represents a token of '^'
+	TokenPower
+	// TokenPromQLNonKeywordEnd is the upper bound value for PromQL tokens, and must not be
+	// assigned to a Token.Typ
+	TokenPromQLNonKeywordEnd
+)
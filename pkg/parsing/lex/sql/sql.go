@@ -92,8 +92,8 @@ func (l *sqllexer) Run(input string, ch chan *token.Token) {
 
 // state functions
 
-// lexEOLComment scans a // comment that terminates at the end of the line
-// it assumes you have already identified '//' and are positioned on the first slash
+// lexEOLComment scans a // or -- comment that terminates at the end of the line
+// it assumes you have already identified '//' or '--' and are positioned on the first character
 func lexEOLComment(li lex.Lexer, rs *lex.RunState) lex.StateFn {
 	rs.Pos += 2
 	i := strings.Index(rs.InputLowered[rs.Pos:], "\n")
@@ -210,6 +210,10 @@ func emitPlus(li lex.Lexer, rs *lex.RunState) lex.StateFn {
 }
 
 func emitMinus(li lex.Lexer, rs *lex.RunState) lex.StateFn {
+	if rs.Peek() == lex.RuneMinus {
+		rs.Backup()
+		return lexEOLComment(li, rs)
+	}
 	rs.Emit(token.Minus)
 	return lexText
 }
@@ -85,6 +85,11 @@ func TestLexer(t *testing.T) {
 			lo:       defaultLO,
 			expected: "",
 		},
+		{
+			in:       "test --EOL COMMENT\nx",
+			lo:       defaultLO,
+			expected: "",
+		},
 		{
 			in:       "test (unexpected right paren ))",
 			lo:       defaultLO,
@@ -266,3 +271,29 @@ func TestSpacedKeywords(t *testing.T) {
 		t.Errorf(`expected "group":3`)
 	}
 }
+
+func TestLexDashComment(t *testing.T) {
+
+	tests := []struct {
+		in       string
+		expected []token.Typ
+	}{
+		{"1 -- comment\n-2", []token.Typ{token.Number, token.Space, TokenComment,
+			token.Space, token.Minus, token.Number, token.EOF}},
+		{"1 --", []token.Typ{token.Number, token.Space, TokenComment, token.EOF}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			ch := make(chan *token.Token, 16)
+			NewLexer(nil).Run(test.in, ch)
+			typs := make([]token.Typ, 0, len(test.expected))
+			for tk := range ch {
+				typs = append(typs, tk.Typ)
+			}
+			if fmt.Sprint(typs) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v got %v", test.expected, typs)
+			}
+		})
+	}
+}
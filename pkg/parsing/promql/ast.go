@@ -0,0 +1,532 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package promql parses Prometheus Query Language expressions into an abstract syntax
+// tree, whose String() methods render a canonical form of the expression that is
+// suitable for use in cache keys
+package promql
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is a node in a parsed PromQL expression
+type Expr interface {
+	// String returns the canonical representation of the expression
+	String() string
+}
+
+// NumberLiteral represents a scalar number
+type NumberLiteral struct {
+	Val float64
+}
+
+// StringLiteral represents a string
+type StringLiteral struct {
+	Val string
+}
+
+// MatchType enumerates the types of Label Matchers
+type MatchType string
+
+const (
+	// MatchEqual matches labels that are equal to the value
+	MatchEqual MatchType = "="
+	// MatchNotEqual matches labels that are not equal to the value
+	MatchNotEqual MatchType = "!="
+	// MatchRegexp matches labels that match the regular expression value
+	MatchRegexp MatchType = "=~"
+	// MatchNotRegexp matches labels that do not match the regular expression value
+	MatchNotRegexp MatchType = "!~"
+)
+
+// LabelMatcher represents a single label matcher in a vector selector
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+// AtModifier represents the @ modifier on a selector or subquery. When Preprocessor
+// is "start" or "end", the timestamp is resolved from the query's time range
+type AtModifier struct {
+	Timestamp    float64
+	Preprocessor string
+}
+
+// VectorSelector represents an instant vector selector like metric{label="value"}
+type VectorSelector struct {
+	Name     string
+	Matchers []*LabelMatcher
+	Offset   time.Duration
+	At       *AtModifier
+}
+
+// MatrixSelector represents a range vector selector like metric[5m]
+type MatrixSelector struct {
+	VectorSelector *VectorSelector
+	Range          time.Duration
+}
+
+// SubqueryExpr represents a subquery like rate(metric[5m])[1h:1m]
+type SubqueryExpr struct {
+	Expr   Expr
+	Range  time.Duration
+	Step   time.Duration
+	Offset time.Duration
+	At     *AtModifier
+}
+
+// ParenExpr represents a parenthesized expression. Since the canonical form
+// only includes parentheses where they are required by operator precedence,
+// it renders the same as the wrapped expression
+type ParenExpr struct {
+	Expr Expr
+}
+
+// UnaryExpr represents a unary negation of an expression
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+// VectorMatchCardinality enumerates the cardinality of a binary operation's vector matching
+type VectorMatchCardinality int
+
+const (
+	// CardOneToOne is the default one-to-one matching of binary operations
+	CardOneToOne VectorMatchCardinality = iota
+	// CardManyToOne is the matching cardinality of group_left
+	CardManyToOne
+	// CardOneToMany is the matching cardinality of group_right
+	CardOneToMany
+	// CardManyToMany is the matching cardinality of the set operators
+	CardManyToMany
+)
+
+// VectorMatching describes how the sides of a binary operation are matched
+type VectorMatching struct {
+	Card VectorMatchCardinality
+	// On is true when MatchingLabels are from on(), and false when from ignoring()
+	On             bool
+	MatchingLabels []string
+	// Include are the labels provided to group_left or group_right
+	Include []string
+}
+
+// BinaryExpr represents a binary operation like a + b
+type BinaryExpr struct {
+	Op             string
+	LHS            Expr
+	RHS            Expr
+	ReturnBool     bool
+	VectorMatching *VectorMatching
+}
+
+// AggregateExpr represents an aggregation like sum by (job) (metric)
+type AggregateExpr struct {
+	Op       string
+	Expr     Expr
+	Param    Expr
+	Grouping []string
+	Without  bool
+}
+
+// Call represents a function call like rate(metric[5m])
+type Call struct {
+	Func string
+	Args []Expr
+}
+
+func (n *NumberLiteral) String() string {
+	switch {
+	case math.IsNaN(n.Val):
+		return "NaN"
+	case math.IsInf(n.Val, 1):
+		return "+Inf"
+	case math.IsInf(n.Val, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(n.Val, 'g', -1, 64)
+}
+
+func (n *StringLiteral) String() string {
+	return strconv.Quote(n.Val)
+}
+
+func (m *LabelMatcher) String() string {
+	return m.Name + string(m.Type) + strconv.Quote(m.Value)
+}
+
+func (a *AtModifier) String() string {
+	if a.Preprocessor != "" {
+		return "@ " + a.Preprocessor + "()"
+	}
+	return "@ " + strconv.FormatFloat(a.Timestamp, 'f', -1, 64)
+}
+
+// modifiers returns the rendered @ and offset modifiers
+func modifiers(at *AtModifier, offset time.Duration) string {
+	var s string
+	if at != nil {
+		s = " " + at.String()
+	}
+	if offset != 0 {
+		s += " offset " + formatDuration(offset)
+	}
+	return s
+}
+
+// selector returns the name and sorted, de-duplicated matchers of the vector selector
+func (n *VectorSelector) selector() string {
+	l := make([]string, 0, len(n.Matchers))
+	seen := make(map[string]bool, len(n.Matchers))
+	for _, m := range n.Matchers {
+		s := m.String()
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		l = append(l, s)
+	}
+	if len(l) == 0 {
+		return n.Name
+	}
+	sort.Strings(l)
+	return n.Name + "{" + strings.Join(l, ",") + "}"
+}
+
+func (n *VectorSelector) String() string {
+	return n.selector() + modifiers(n.At, n.Offset)
+}
+
+func (n *MatrixSelector) String() string {
+	return n.VectorSelector.selector() + "[" + formatDuration(n.Range) + "]" +
+		modifiers(n.VectorSelector.At, n.VectorSelector.Offset)
+}
+
+func (n *SubqueryExpr) String() string {
+	var step string
+	if n.Step != 0 {
+		step = formatDuration(n.Step)
+	}
+	s := n.Expr.String()
+	if precedence(n.Expr) != maxPrecedence {
+		s = "(" + s + ")"
+	}
+	return s + "[" + formatDuration(n.Range) + ":" + step + "]" + modifiers(n.At, n.Offset)
+}
+
+func (n *ParenExpr) String() string {
+	return n.Expr.String()
+}
+
+func (n *UnaryExpr) String() string {
+	s := n.Expr.String()
+	if precedence(n.Expr) < precedencePower {
+		s = "(" + s + ")"
+	}
+	return n.Op + s
+}
+
+func (vm *VectorMatching) String() string {
+	var s string
+	if vm.On || len(vm.MatchingLabels) > 0 ||
+		(vm.Card != CardOneToOne && vm.Card != CardManyToMany) {
+		if vm.On {
+			s = " on"
+		} else {
+			s = " ignoring"
+		}
+		s += labelList(vm.MatchingLabels)
+	}
+	switch vm.Card {
+	case CardManyToOne:
+		s += " group_left" + labelList(vm.Include)
+	case CardOneToMany:
+		s += " group_right" + labelList(vm.Include)
+	}
+	return s
+}
+
+func (n *BinaryExpr) String() string {
+	p := binaryPrecedence[n.Op]
+	rightAssoc := n.Op == "^"
+	lhs, rhs := n.LHS.String(), n.RHS.String()
+	if lp := operandPrecedence(n.LHS, false); lp < p || (lp == p && rightAssoc) {
+		lhs = "(" + lhs + ")"
+	}
+	if rp := operandPrecedence(n.RHS, true); rp < p || (rp == p && !rightAssoc) {
+		rhs = "(" + rhs + ")"
+	}
+	s := lhs + " " + n.Op
+	if n.ReturnBool {
+		s += " bool"
+	}
+	if n.VectorMatching != nil {
+		s += n.VectorMatching.String()
+	}
+	return s + " " + rhs
+}
+
+func (n *AggregateExpr) String() string {
+	s := n.Op
+	if n.Without {
+		s += " without " + labelList(n.Grouping) + " "
+	} else if len(n.Grouping) > 0 {
+		s += " by " + labelList(n.Grouping) + " "
+	}
+	if n.Param != nil {
+		return s + "(" + n.Param.String() + ", " + n.Expr.String() + ")"
+	}
+	return s + "(" + n.Expr.String() + ")"
+}
+
+func (n *Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = a.String()
+	}
+	return n.Func + "(" + strings.Join(args, ", ") + ")"
+}
+
+// labelList returns the sorted, de-duplicated labels as a parenthesized list
+func labelList(labels []string) string {
+	l := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, s := range labels {
+		if !seen[s] {
+			seen[s] = true
+			l = append(l, s)
+		}
+	}
+	sort.Strings(l)
+	return "(" + strings.Join(l, ", ") + ")"
+}
+
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceComparison
+	precedenceAdd
+	precedenceMultiply
+	precedencePower
+	maxPrecedence = 100
+)
+
+var binaryPrecedence = map[string]int{
+	"or":     precedenceOr,
+	"and":    precedenceAnd,
+	"unless": precedenceAnd,
+	"==":     precedenceComparison,
+	"!=":     precedenceComparison,
+	"<":      precedenceComparison,
+	"<=":     precedenceComparison,
+	">":      precedenceComparison,
+	">=":     precedenceComparison,
+	"+":      precedenceAdd,
+	"-":      precedenceAdd,
+	"*":      precedenceMultiply,
+	"/":      precedenceMultiply,
+	"%":      precedenceMultiply,
+	"atan2":  precedenceMultiply,
+	"^":      precedencePower,
+}
+
+// precedence returns the binding strength of the expression when rendered
+func precedence(e Expr) int {
+	switch n := e.(type) {
+	case *ParenExpr:
+		return precedence(n.Expr)
+	case *BinaryExpr:
+		return binaryPrecedence[n.Op]
+	case *UnaryExpr:
+		return precedenceMultiply
+	}
+	return maxPrecedence
+}
+
+// operandPrecedence returns the binding strength of a binary operand. Since a unary
+// operator is a prefix, it never requires parentheses on the right-hand side
+func operandPrecedence(e Expr, rhs bool) int {
+	p := precedence(e)
+	if rhs && p == precedenceMultiply {
+		if _, ok := unwrap(e).(*UnaryExpr); ok {
+			return maxPrecedence
+		}
+	}
+	return p
+}
+
+// unwrap returns the expression inside of any parentheses
+func unwrap(e Expr) Expr {
+	for {
+		p, ok := e.(*ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.Expr
+	}
+}
+
+// ValueType enumerates the types of values to which an expression may evaluate
+type ValueType string
+
+const (
+	// ValueTypeScalar is the type of a scalar expression like 1 + time()
+	ValueTypeScalar ValueType = "scalar"
+	// ValueTypeVector is the type of an instant vector expression like rate(metric[5m])
+	ValueTypeVector ValueType = "vector"
+	// ValueTypeMatrix is the type of a range vector expression like metric[5m]
+	ValueTypeMatrix ValueType = "matrix"
+	// ValueTypeString is the type of a string expression
+	ValueTypeString ValueType = "string"
+)
+
+// scalarFuncs are the functions that return a scalar
+var scalarFuncs = map[string]bool{"pi": true, "scalar": true, "time": true}
+
+// Type returns the type of value to which the expression evaluates
+func Type(e Expr) ValueType {
+	switch n := e.(type) {
+	case *NumberLiteral:
+		return ValueTypeScalar
+	case *StringLiteral:
+		return ValueTypeString
+	case *MatrixSelector, *SubqueryExpr:
+		return ValueTypeMatrix
+	case *ParenExpr:
+		return Type(n.Expr)
+	case *UnaryExpr:
+		return Type(n.Expr)
+	case *BinaryExpr:
+		if Type(n.LHS) == ValueTypeScalar && Type(n.RHS) == ValueTypeScalar {
+			return ValueTypeScalar
+		}
+	case *Call:
+		if scalarFuncs[n.Func] {
+			return ValueTypeScalar
+		}
+	}
+	return ValueTypeVector
+}
+
+// Inspect traverses the expression depth-first, calling f for each node. When f
+// returns false, the node's children are not traversed
+func Inspect(e Expr, f func(Expr) bool) {
+	if e == nil || !f(e) {
+		return
+	}
+	switch n := e.(type) {
+	case *MatrixSelector:
+		Inspect(n.VectorSelector, f)
+	case *SubqueryExpr:
+		Inspect(n.Expr, f)
+	case *ParenExpr:
+		Inspect(n.Expr, f)
+	case *UnaryExpr:
+		Inspect(n.Expr, f)
+	case *BinaryExpr:
+		Inspect(n.LHS, f)
+		Inspect(n.RHS, f)
+	case *AggregateExpr:
+		Inspect(n.Param, f)
+		Inspect(n.Expr, f)
+	case *Call:
+		for _, a := range n.Args {
+			Inspect(a, f)
+		}
+	}
+}
+
+var durationUnits = []struct {
+	unit string
+	d    time.Duration
+}{
+	{"y", 365 * 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+}
+
+// parseDuration parses a PromQL duration like 1h30m
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	last := -1
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, ErrInvalidDuration
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, ErrInvalidDuration
+		}
+		s = s[i:]
+		j := 0
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		u := -1
+		for k, du := range durationUnits {
+			if du.unit == s[:j] {
+				u = k
+				break
+			}
+		}
+		// units must be provided in descending order with no repeats
+		if u <= last {
+			return 0, ErrInvalidDuration
+		}
+		last = u
+		d += time.Duration(n) * durationUnits[u].d
+		s = s[j:]
+	}
+	return d, nil
+}
+
+// formatDuration renders a duration using the largest PromQL units possible
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var s string
+	if d < 0 {
+		s = "-"
+		d = -d
+	}
+	for _, du := range durationUnits {
+		if n := d / du.d; n > 0 {
+			s += strconv.FormatInt(int64(n), 10) + du.unit
+			d -= n * du.d
+		}
+	}
+	// sub-millisecond precision is not supported by PromQL and is truncated
+	if s == "" || s == "-" {
+		return "0s"
+	}
+	return s
+}
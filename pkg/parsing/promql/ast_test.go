@@ -0,0 +1,144 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package promql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+
+	tests := []struct {
+		in       string
+		expected time.Duration
+		err      error
+	}{
+		{"5m", 5 * time.Minute, nil},
+		{"1h30m", 90 * time.Minute, nil},
+		{"1y2w3d", (365 + 14 + 3) * 24 * time.Hour, nil},
+		{"1s500ms", 1500 * time.Millisecond, nil},
+		{"m", 0, ErrInvalidDuration},
+		{"5", 0, ErrInvalidDuration},
+		{"5q", 0, ErrInvalidDuration},
+		{"5m1h", 0, ErrInvalidDuration},
+		{"99999999999999999999s", 0, ErrInvalidDuration},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			d, err := parseDuration(test.in)
+			if err != test.err {
+				t.Errorf("expected %v got %v", test.err, err)
+			}
+			if d != test.expected {
+				t.Errorf("expected %d got %d", test.expected, d)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+
+	tests := []struct {
+		in       time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{time.Microsecond, "0s"},
+		{90 * time.Minute, "1h30m"},
+		{-7 * 24 * time.Hour, "-1w"},
+		{(365+8)*24*time.Hour + 1500*time.Millisecond, "1y1w1d1s500ms"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			if s := formatDuration(test.in); s != test.expected {
+				t.Errorf("expected %s got %s", test.expected, s)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+
+	e, err := Parse(`topk(3, -(sum by (a) (rate(x[5m] offset 1h)) + y @ 5)[1h:] / (z))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offsets, ats, nodes int
+	Inspect(e, func(n Expr) bool {
+		nodes++
+		if vs, ok := n.(*VectorSelector); ok {
+			if vs.Offset != 0 {
+				offsets++
+			}
+			if vs.At != nil {
+				ats++
+			}
+		}
+		return true
+	})
+	if offsets != 1 || ats != 1 {
+		t.Errorf("expected 1 offset and 1 at, got %d and %d", offsets, ats)
+	}
+	if nodes != 14 {
+		t.Errorf("expected %d got %d", 14, nodes)
+	}
+
+	nodes = 0
+	Inspect(e, func(n Expr) bool {
+		nodes++
+		_, ok := n.(*AggregateExpr)
+		return !ok
+	})
+	if nodes != 1 {
+		t.Errorf("expected %d got %d", 1, nodes)
+	}
+}
+
+func TestType(t *testing.T) {
+
+	tests := []struct {
+		in       string
+		expected ValueType
+	}{
+		{`1`, ValueTypeScalar},
+		{`-(1 + time())`, ValueTypeScalar},
+		{`scalar(up) * pi()`, ValueTypeScalar},
+		{`"a"`, ValueTypeString},
+		{`up`, ValueTypeVector},
+		{`up * 2`, ValueTypeVector},
+		{`sum(up)`, ValueTypeVector},
+		{`vector(1)`, ValueTypeVector},
+		{`(up[5m])`, ValueTypeMatrix},
+		{`rate(up[5m])[1h:]`, ValueTypeMatrix},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			e, err := Parse(test.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := Type(e); v != test.expected {
+				t.Errorf("expected %s got %s", test.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package promql
+
+import "errors"
+
+// ErrUnexpectedEOF is an error for when the query ends before an expression is complete
+var ErrUnexpectedEOF = errors.New("unexpected end of input")
+
+// ErrInvalidNumber is an error for when a number literal cannot be parsed
+var ErrInvalidNumber = errors.New("invalid number")
+
+// ErrInvalidString is an error for when a string literal cannot be unquoted
+var ErrInvalidString = errors.New("invalid string")
+
+// ErrInvalidDuration is an error for when a duration cannot be parsed
+var ErrInvalidDuration = errors.New("invalid duration")
+
+// ErrInvalidMatcher is an error for when a label matcher is malformed
+var ErrInvalidMatcher = errors.New("invalid label matcher")
+
+// ErrEmptySelector is an error for when a vector selector has no metric name and
+// no label matcher that excludes the empty string
+var ErrEmptySelector = errors.New("vector selector must contain at least one non-empty matcher")
+
+// ErrInvalidRange is an error for when a range is applied to something other than
+// an instant vector selector
+var ErrInvalidRange = errors.New("ranges only allowed for vector selectors")
+
+// ErrInvalidModifier is an error for when an offset or @ modifier is misplaced or duplicated
+var ErrInvalidModifier = errors.New("invalid offset or @ modifier")
+
+// ErrInvalidVectorMatching is an error for when a binary operation's vector matching
+// clause is malformed or used with an operator that does not support it
+var ErrInvalidVectorMatching = errors.New("invalid vector matching")
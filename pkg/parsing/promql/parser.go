@@ -0,0 +1,675 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package promql
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/parsing"
+	"github.com/tricksterproxy/trickster/pkg/parsing/lex"
+	lp "github.com/tricksterproxy/trickster/pkg/parsing/lex/promql"
+	"github.com/tricksterproxy/trickster/pkg/parsing/token"
+)
+
+var lexer = lp.NewLexer(&lex.Options{})
+
+// aggregators are the aggregation operators, mapped to whether they take a parameter
+var aggregators = map[string]bool{
+	"sum":          false,
+	"avg":          false,
+	"count":        false,
+	"min":          false,
+	"max":          false,
+	"group":        false,
+	"stddev":       false,
+	"stdvar":       false,
+	"topk":         true,
+	"bottomk":      true,
+	"count_values": true,
+	"quantile":     true,
+}
+
+var comparisonTokens = map[token.Typ]string{
+	lp.TokenEqualEqual:       "==",
+	token.NotEqualOperator:   "!=",
+	token.LessThan:           "<",
+	token.LessThanOrEqual:    "<=",
+	token.GreaterThan:        ">",
+	token.GreaterThanOrEqual: ">=",
+}
+
+var arithmeticTokens = map[token.Typ]string{
+	token.Plus:     "+",
+	token.Minus:    "-",
+	token.Multiply: "*",
+	token.Divide:   "/",
+	token.Modulo:   "%",
+	lp.TokenPower:  "^",
+}
+
+var matchTokens = map[token.Typ]MatchType{
+	token.Equals:           MatchEqual,
+	token.NotEqualOperator: MatchNotEqual,
+	lp.TokenRegexMatch:     MatchRegexp,
+	lp.TokenRegexNoMatch:   MatchNotRegexp,
+}
+
+type parser struct {
+	tokens token.Tokens
+	pos    int
+}
+
+// Parse parses the PromQL query into an expression tree. Comments are discarded
+func Parse(query string) (Expr, error) {
+	ch := make(chan *token.Token, 64)
+	go lexer.Run(query, ch)
+	p := &parser{tokens: make(token.Tokens, 0, 64)}
+	for t := range ch {
+		if t.Typ == lp.TokenComment {
+			continue
+		}
+		p.tokens = append(p.tokens, t)
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Typ != token.EOF {
+		return nil, parsing.ParserError(parsing.ErrUnexpectedToken, t)
+	}
+	return e, nil
+}
+
+// peek returns the next token without consuming it. The lexer always
+// ends with an EOF or Error token, which is returned indefinitely
+func (p *parser) peek() *token.Token {
+	if p.pos >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the next token
+func (p *parser) next() *token.Token {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// unexpected returns an error describing the unexpected token
+func (p *parser) unexpected(t *token.Token) error {
+	switch t.Typ {
+	case token.Error:
+		return parsing.ParserError(errors.New(t.Val), t)
+	case token.EOF:
+		return parsing.ParserError(ErrUnexpectedEOF, t)
+	}
+	return parsing.ParserError(parsing.ErrUnexpectedToken, t)
+}
+
+// expect consumes the next token, returning an error if it is not of the provided type
+func (p *parser) expect(typ token.Typ) (*token.Token, error) {
+	t := p.next()
+	if t.Typ != typ {
+		return nil, p.unexpected(t)
+	}
+	return t, nil
+}
+
+// isKeyword returns true if the next token is the provided (case-insensitive) keyword
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.Typ == token.Identifier && strings.ToLower(t.Val) == kw
+}
+
+// binaryOperator returns the operator represented by the next token, if any
+func (p *parser) binaryOperator() (string, bool) {
+	t := p.peek()
+	if op, ok := comparisonTokens[t.Typ]; ok {
+		return op, true
+	}
+	if op, ok := arithmeticTokens[t.Typ]; ok {
+		return op, true
+	}
+	if t.Typ == token.Identifier {
+		switch op := strings.ToLower(t.Val); op {
+		case "and", "or", "unless", "atan2":
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseBinary(precedenceOr)
+}
+
+// parseBinary parses binary operations whose operators have a precedence of at least min
+func (p *parser) parseBinary(min int) (Expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.binaryOperator()
+		if !ok || binaryPrecedence[op] < min {
+			return lhs, nil
+		}
+		opToken := p.next()
+		be := &BinaryExpr{Op: op, LHS: lhs}
+		if err = p.parseBinaryModifiers(be, opToken); err != nil {
+			return nil, err
+		}
+		next := binaryPrecedence[op] + 1
+		if op == "^" {
+			// exponentiation is right-associative
+			next = binaryPrecedence[op]
+		}
+		if be.RHS, err = p.parseBinary(next); err != nil {
+			return nil, err
+		}
+		lhs = be
+	}
+}
+
+// parseBinaryModifiers parses the bool, on / ignoring and group_left / group_right
+// modifiers that may follow a binary operator
+func (p *parser) parseBinaryModifiers(be *BinaryExpr, opToken *token.Token) error {
+	isSet := be.Op == "and" || be.Op == "or" || be.Op == "unless"
+	if p.isKeyword("bool") {
+		if binaryPrecedence[be.Op] != precedenceComparison {
+			return parsing.ParserError(ErrInvalidVectorMatching, p.peek())
+		}
+		p.next()
+		be.ReturnBool = true
+	}
+	vm := &VectorMatching{}
+	if isSet {
+		vm.Card = CardManyToMany
+	}
+	if p.isKeyword("on") || p.isKeyword("ignoring") {
+		vm.On = p.isKeyword("on")
+		p.next()
+		labels, err := p.parseLabelList()
+		if err != nil {
+			return err
+		}
+		vm.MatchingLabels = labels
+		if p.isKeyword("group_left") || p.isKeyword("group_right") {
+			if isSet {
+				return parsing.ParserError(ErrInvalidVectorMatching, p.peek())
+			}
+			if p.isKeyword("group_left") {
+				vm.Card = CardManyToOne
+			} else {
+				vm.Card = CardOneToMany
+			}
+			p.next()
+			if p.peek().Typ == token.LeftParen {
+				if vm.Include, err = p.parseLabelList(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	be.VectorMatching = vm
+	return nil
+}
+
+// parseLabelList parses a parenthesized, comma-separated list of label names
+func (p *parser) parseLabelList() ([]string, error) {
+	if _, err := p.expect(token.LeftParen); err != nil {
+		return nil, err
+	}
+	labels := make([]string, 0, 4)
+	for {
+		t := p.next()
+		if t.Typ == token.RightParen {
+			return labels, nil
+		}
+		if t.Typ != token.Identifier {
+			return nil, p.unexpected(t)
+		}
+		labels = append(labels, t.Val)
+		t = p.next()
+		if t.Typ == token.RightParen {
+			return labels, nil
+		}
+		if t.Typ != token.Comma {
+			return nil, p.unexpected(t)
+		}
+	}
+}
+
+// parseUnary parses an optionally-negated expression. The unary operators bind
+// more tightly than all binary operators other than exponentiation
+func (p *parser) parseUnary() (Expr, error) {
+	t := p.peek()
+	if t.Typ != token.Plus && t.Typ != token.Minus {
+		return p.parsePostfix()
+	}
+	p.next()
+	e, err := p.parseBinary(precedencePower)
+	if err != nil {
+		return nil, err
+	}
+	if t.Typ == token.Plus {
+		return e, nil
+	}
+	return &UnaryExpr{Op: "-", Expr: e}, nil
+}
+
+// parsePostfix parses a primary expression followed by any range, subquery,
+// offset or @ modifiers
+func (p *parser) parsePostfix() (Expr, error) {
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.Typ == lp.TokenLeftBracket:
+			e, err = p.parseRange(e)
+		case t.Typ == lp.TokenAt:
+			err = p.parseAt(e)
+		case p.isKeyword("offset"):
+			err = p.parseOffset(e)
+		default:
+			return e, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseRange parses a range [5m] or subquery [5m:1m] applied to the expression
+func (p *parser) parseRange(e Expr) (Expr, error) {
+	open := p.next()
+	rng, err := p.parseDurationToken()
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.Typ == lp.TokenColon {
+		sq := &SubqueryExpr{Expr: e, Range: rng}
+		if p.peek().Typ == lp.TokenDuration {
+			if sq.Step, err = p.parseDurationToken(); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = p.expect(lp.TokenRightBracket); err != nil {
+			return nil, err
+		}
+		return sq, nil
+	}
+	if t.Typ != lp.TokenRightBracket {
+		return nil, p.unexpected(t)
+	}
+	vs, ok := e.(*VectorSelector)
+	if !ok || vs.Offset != 0 || vs.At != nil {
+		return nil, parsing.ParserError(ErrInvalidRange, open)
+	}
+	return &MatrixSelector{VectorSelector: vs, Range: rng}, nil
+}
+
+// modifiable returns pointers to the offset and @ modifiers of the expression, if it
+// supports them
+func modifiable(e Expr) (*time.Duration, **AtModifier, bool) {
+	switch n := e.(type) {
+	case *VectorSelector:
+		return &n.Offset, &n.At, true
+	case *MatrixSelector:
+		return &n.VectorSelector.Offset, &n.VectorSelector.At, true
+	case *SubqueryExpr:
+		return &n.Offset, &n.At, true
+	}
+	return nil, nil, false
+}
+
+// parseOffset parses an offset modifier applied to the expression
+func (p *parser) parseOffset(e Expr) error {
+	t := p.next()
+	offset, _, ok := modifiable(e)
+	if !ok || *offset != 0 {
+		return parsing.ParserError(ErrInvalidModifier, t)
+	}
+	neg := p.peek().Typ == token.Minus
+	if neg {
+		p.next()
+	}
+	d, err := p.parseDurationToken()
+	if err != nil {
+		return err
+	}
+	if neg {
+		d = -d
+	}
+	*offset = d
+	return nil
+}
+
+// parseAt parses an @ modifier applied to the expression
+func (p *parser) parseAt(e Expr) error {
+	t := p.next()
+	_, at, ok := modifiable(e)
+	if !ok || *at != nil {
+		return parsing.ParserError(ErrInvalidModifier, t)
+	}
+	if p.isKeyword("start") || p.isKeyword("end") {
+		pp := strings.ToLower(p.next().Val)
+		if _, err := p.expect(token.LeftParen); err != nil {
+			return err
+		}
+		if _, err := p.expect(token.RightParen); err != nil {
+			return err
+		}
+		*at = &AtModifier{Preprocessor: pp}
+		return nil
+	}
+	neg := p.peek().Typ == token.Minus
+	if neg || p.peek().Typ == token.Plus {
+		p.next()
+	}
+	t = p.next()
+	if t.Typ != token.Number {
+		return p.unexpected(t)
+	}
+	v, err := parseNumber(t)
+	if err != nil {
+		return err
+	}
+	if neg {
+		v = -v
+	}
+	*at = &AtModifier{Timestamp: v}
+	return nil
+}
+
+// parseDurationToken consumes a duration token and returns its value
+func (p *parser) parseDurationToken() (time.Duration, error) {
+	t, err := p.expect(lp.TokenDuration)
+	if err != nil {
+		return 0, err
+	}
+	d, err := parseDuration(t.Val)
+	if err != nil {
+		return 0, parsing.ParserError(err, t)
+	}
+	return d, nil
+}
+
+// parsePrimary parses a literal, selector, aggregation, function call or parenthesized expression
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.Typ {
+	case token.Number:
+		p.next()
+		v, err := parseNumber(t)
+		if err != nil {
+			return nil, err
+		}
+		return &NumberLiteral{Val: v}, nil
+	case token.String:
+		p.next()
+		s, err := unquote(t)
+		if err != nil {
+			return nil, err
+		}
+		return &StringLiteral{Val: s}, nil
+	case token.LeftParen:
+		p.next()
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = p.expect(token.RightParen); err != nil {
+			return nil, err
+		}
+		return &ParenExpr{Expr: e}, nil
+	case lp.TokenLeftBrace:
+		return p.parseVectorSelector("")
+	case token.Identifier:
+		p.next()
+		lower := strings.ToLower(t.Val)
+		if lower == "inf" || lower == "nan" {
+			v, _ := strconv.ParseFloat(lower, 64)
+			return &NumberLiteral{Val: v}, nil
+		}
+		if hasParam, ok := aggregators[lower]; ok &&
+			(p.peek().Typ == token.LeftParen || p.isKeyword("by") || p.isKeyword("without")) {
+			return p.parseAggregate(lower, hasParam)
+		}
+		if p.peek().Typ == token.LeftParen {
+			return p.parseCall(t.Val)
+		}
+		return p.parseVectorSelector(t.Val)
+	}
+	return nil, p.unexpected(t)
+}
+
+// parseAggregate parses an aggregation, whose grouping clause may precede or follow its arguments
+func (p *parser) parseAggregate(op string, hasParam bool) (Expr, error) {
+	ae := &AggregateExpr{Op: op}
+	grouping := func() error {
+		if !p.isKeyword("by") && !p.isKeyword("without") {
+			return nil
+		}
+		ae.Without = p.isKeyword("without")
+		p.next()
+		var err error
+		ae.Grouping, err = p.parseLabelList()
+		return err
+	}
+	if err := grouping(); err != nil {
+		return nil, err
+	}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	if ae.Grouping == nil {
+		if err = grouping(); err != nil {
+			return nil, err
+		}
+	}
+	expected := 1
+	if hasParam {
+		expected = 2
+	}
+	if len(args) != expected {
+		return nil, parsing.ParserError(parsing.ErrUnexpectedToken, p.peek())
+	}
+	if hasParam {
+		ae.Param = args[0]
+	}
+	ae.Expr = args[len(args)-1]
+	return ae, nil
+}
+
+// parseCall parses the arguments to a function call
+func (p *parser) parseCall(name string) (Expr, error) {
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	return &Call{Func: name, Args: args}, nil
+}
+
+// parseArgs parses a parenthesized, comma-separated list of expressions
+func (p *parser) parseArgs() ([]Expr, error) {
+	if _, err := p.expect(token.LeftParen); err != nil {
+		return nil, err
+	}
+	args := make([]Expr, 0, 2)
+	if p.peek().Typ == token.RightParen {
+		p.next()
+		return args, nil
+	}
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+		t := p.next()
+		if t.Typ == token.RightParen {
+			return args, nil
+		}
+		if t.Typ != token.Comma {
+			return nil, p.unexpected(t)
+		}
+	}
+}
+
+// parseVectorSelector parses the optional label matchers following a metric name
+func (p *parser) parseVectorSelector(name string) (Expr, error) {
+	vs := &VectorSelector{Name: name}
+	if p.peek().Typ != lp.TokenLeftBrace {
+		return vs, nil
+	}
+	p.next()
+	vs.Matchers = make([]*LabelMatcher, 0, 4)
+	var t *token.Token
+	for {
+		t = p.next()
+		if t.Typ == lp.TokenRightBrace {
+			break
+		}
+		if t.Typ != token.Identifier {
+			return nil, p.unexpected(t)
+		}
+		m := &LabelMatcher{Name: t.Val}
+		t = p.next()
+		mt, ok := matchTokens[t.Typ]
+		if !ok {
+			return nil, parsing.ParserError(ErrInvalidMatcher, t)
+		}
+		m.Type = mt
+		t = p.next()
+		if t.Typ != token.String {
+			return nil, parsing.ParserError(ErrInvalidMatcher, t)
+		}
+		v, err := unquote(t)
+		if err != nil {
+			return nil, err
+		}
+		m.Value = v
+		if m.Type == MatchRegexp || m.Type == MatchNotRegexp {
+			if _, err = regexp.Compile("^(?:" + v + ")$"); err != nil {
+				return nil, parsing.ParserError(ErrInvalidMatcher, t)
+			}
+		}
+		vs.Matchers = append(vs.Matchers, m)
+		t = p.next()
+		if t.Typ == lp.TokenRightBrace {
+			break
+		}
+		if t.Typ != token.Comma {
+			return nil, p.unexpected(t)
+		}
+	}
+	// {__name__="metric"} is equivalent to metric
+	if vs.Name == "" && len(vs.Matchers) == 1 && vs.Matchers[0].Name == "__name__" &&
+		vs.Matchers[0].Type == MatchEqual {
+		vs.Name = vs.Matchers[0].Value
+		vs.Matchers = nil
+	}
+	// like Prometheus, reject selectors that would match every series
+	if vs.Name == "" && !hasNonEmptyMatcher(vs.Matchers) {
+		return nil, parsing.ParserError(ErrEmptySelector, t)
+	}
+	return vs, nil
+}
+
+// hasNonEmptyMatcher returns true if any of the matchers does not match the empty string
+func hasNonEmptyMatcher(matchers []*LabelMatcher) bool {
+	for _, m := range matchers {
+		var matchesEmpty bool
+		switch m.Type {
+		case MatchEqual:
+			matchesEmpty = m.Value == ""
+		case MatchNotEqual:
+			matchesEmpty = m.Value != ""
+		case MatchRegexp, MatchNotRegexp:
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				continue
+			}
+			matchesEmpty = re.MatchString("") == (m.Type == MatchRegexp)
+		}
+		if !matchesEmpty {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumber returns the value of a number token, which may be in hexadecimal
+func parseNumber(t *token.Token) (float64, error) {
+	s := strings.ToLower(t.Val)
+	if strings.HasPrefix(s, "0x") {
+		v, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return 0, parsing.ParserError(ErrInvalidNumber, t)
+		}
+		return float64(v), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, parsing.ParserError(ErrInvalidNumber, t)
+	}
+	return v, nil
+}
+
+// unquote returns the value of a string token, which may be double, single or back quoted
+func unquote(t *token.Token) (string, error) {
+	s := t.Val
+	if strings.HasPrefix(s, "'") && len(s) > 1 {
+		// convert to a double-quoted string so that strconv can unquote it
+		b := make([]byte, 0, len(s)+4)
+		b = append(b, '"')
+		in := s[1 : len(s)-1]
+		for i := 0; i < len(in); i++ {
+			switch c := in[i]; {
+			case c == '\\' && i+1 < len(in) && in[i+1] == '\'':
+				b = append(b, '\'')
+				i++
+			case c == '\\' && i+1 < len(in):
+				b = append(b, c, in[i+1])
+				i++
+			case c == '"':
+				b = append(b, '\\', '"')
+			default:
+				b = append(b, c)
+			}
+		}
+		s = string(append(b, '"'))
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return "", parsing.ParserError(ErrInvalidString, t)
+	}
+	return v, nil
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package promql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/parsing"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		in, expected string
+	}{
+		{`up`, `up`},
+		{`  up  # the up metric`, `up`},
+		{`up{job="a",instance="b"}`, `up{instance="b",job="a"}`},
+		{`up{ instance = 'b', job="a", job="a" , }`, `up{instance="b",job="a"}`},
+		{`{__name__="up"}`, `up`},
+		{`{__name__=~"up.*"}`, `{__name__=~"up.*"}`},
+		{`{job!~""}`, `{job!~""}`},
+		{`{job=~".+"}`, `{job=~".+"}`},
+		{`{x="",job="a"}`, `{job="a",x=""}`},
+		{"up{job=~`a\\d`}", `up{job=~"a\\d"}`},
+		{`up{job!='it\'s "x"'}`, `up{job!="it's \"x\""}`},
+		{`rate(http_requests_total{code!~"5.."}[5m])`, `rate(http_requests_total{code!~"5.."}[5m])`},
+		{`rate(x[300s])`, `rate(x[5m])`},
+		{`rate(x[90m])`, `rate(x[1h30m])`},
+		{`rate(x[1h30m] offset 1d)`, `rate(x[1h30m] offset 1d)`},
+		{`x offset -1w`, `x offset -1w`},
+		{`x @ 1609746000 offset 5m`, `x @ 1609746000 offset 5m`},
+		{`x offset 5m @ 1609746000.5`, `x @ 1609746000.5 offset 5m`},
+		{`x @ start()`, `x @ start()`},
+		{`x[1m] @ END()`, `x[1m] @ end()`},
+		{`SUM BY(job, instance)(rate(x[5m]))`, `sum by (instance, job) (rate(x[5m]))`},
+		{`sum(rate(x[5m])) by (job)`, `sum by (job) (rate(x[5m]))`},
+		{`sum by () (x)`, `sum(x)`},
+		{`sum without () (x)`, `sum without () (x)`},
+		{`avg without(b,a) (x)`, `avg without (a, b) (x)`},
+		{`topk(5, x)`, `topk(5, x)`},
+		{`count_values("v", x) by (job)`, `count_values by (job) ("v", x)`},
+		{`quantile(0.9, x)`, `quantile(0.9, x)`},
+		{`histogram_quantile(0.99, sum by (le) (rate(x_bucket[5m])))`,
+			`histogram_quantile(0.99, sum by (le) (rate(x_bucket[5m])))`},
+		{`time()`, `time()`},
+		{`a+b*c`, `a + b * c`},
+		{`(a+b)*c`, `(a + b) * c`},
+		{`((a))`, `a`},
+		{`a - (b - c)`, `a - (b - c)`},
+		{`(a - b) - c`, `a - b - c`},
+		{`a ^ b ^ c`, `a ^ b ^ c`},
+		{`(a ^ b) ^ c`, `(a ^ b) ^ c`},
+		{`-a ^ b`, `-a ^ b`},
+		{`(-a) ^ b`, `(-a) ^ b`},
+		{`-(a + b)`, `-(a + b)`},
+		{`+a`, `a`},
+		{`a * -b`, `a * -b`},
+		{`a > bool 1`, `a > bool 1`},
+		{`a == BOOL 1`, `a == bool 1`},
+		{`a + on(z, y) group_left(w) b`, `a + on(y, z) group_left(w) b`},
+		{`a + ignoring(z) group_right b`, `a + ignoring(z) group_right() b`},
+		{`a + ignoring() b`, `a + b`},
+		{`a + on() b`, `a + on() b`},
+		{`a and on(x) b or c unless d`, `a and on(x) b or c unless d`},
+		{`a or b and c`, `a or b and c`},
+		{`(a or b) and c`, `(a or b) and c`},
+		{`a atan2 b`, `a atan2 b`},
+		{`rate(x[5m])[30m:1m]`, `rate(x[5m])[30m:1m]`},
+		{`max_over_time((a + b)[1h:] offset 1h)`, `max_over_time((a + b)[1h:] offset 1h)`},
+		{`1e3 + 0x10 + .5 + Inf + -inf + NaN`, `1000 + 16 + 0.5 + +Inf + -+Inf + NaN`},
+		{`label_replace(x, "a", "$1", 'b', "(.*)")`, `label_replace(x, "a", "$1", "b", "(.*)")`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			e, err := Parse(test.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := e.String(); s != test.expected {
+				t.Errorf("expected %s got %s", test.expected, s)
+			}
+			// the canonical form must parse to itself
+			e, err = Parse(test.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := e.String(); s != test.expected {
+				t.Errorf("expected %s got %s", test.expected, s)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+
+	tests := []struct {
+		in       string
+		expected error
+	}{
+		{``, ErrUnexpectedEOF},
+		{`up{`, ErrUnexpectedEOF},
+		{`up)`, parsing.ErrUnexpectedToken},
+		{`up{job}`, ErrInvalidMatcher},
+		{`up{job=5}`, ErrInvalidMatcher},
+		{`up{job=~"("}`, ErrInvalidMatcher},
+		{`{}`, ErrEmptySelector},
+		{`{job=""}`, ErrEmptySelector},
+		{`{job=~".*"}`, ErrEmptySelector},
+		{`{job!="a"}`, ErrEmptySelector},
+		{`{job!~"a"}`, ErrEmptySelector},
+		{`{__name__=""}`, ErrEmptySelector},
+		{`up{job="a"`, ErrUnexpectedEOF},
+		{`up{job="a";}`, nil},
+		{`rate(x[5m]`, ErrUnexpectedEOF},
+		{`x[5m][5m]`, ErrInvalidRange},
+		{`x offset 5m[5m]`, ErrInvalidRange},
+		{`x offset 5m offset 5m`, ErrInvalidModifier},
+		{`x @ 1 @ 1`, ErrInvalidModifier},
+		{`sum(x) offset 5m`, ErrInvalidModifier},
+		{`x[5m3h]`, ErrInvalidDuration},
+		{`x[5h5h]`, ErrInvalidDuration},
+		{`x @ foo`, parsing.ErrUnexpectedToken},
+		{`x @ start(`, ErrUnexpectedEOF},
+		{`a and on(x) group_left b`, ErrInvalidVectorMatching},
+		{`a + bool b`, ErrInvalidVectorMatching},
+		{`a + on(x b`, parsing.ErrUnexpectedToken},
+		{`topk(x)`, parsing.ErrUnexpectedToken},
+		{`sum by (a (x)`, parsing.ErrUnexpectedToken},
+		{`f(a b)`, parsing.ErrUnexpectedToken},
+		{`"a\q"`, ErrInvalidString},
+		{`1 +`, ErrUnexpectedEOF},
+		{`0xffffffffffffffffff`, ErrInvalidNumber},
+		{`x @ 0xffffffffffffffffff`, ErrInvalidNumber},
+		{`x[5m:5m`, ErrUnexpectedEOF},
+		{`(a + b`, ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			_, err := Parse(test.in)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if test.expected != nil && !errors.Is(err, test.expected) {
+				t.Errorf("expected %v got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package authenticator provides authentication of client requests to the
+// Trickster frontend, using htpasswd-style basic auth, JWT bearer tokens or
+// TLS client certificates
+package authenticator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+)
+
+// ErrUnauthorized is returned when a request does not provide valid credentials
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Identity describes the authenticated client of a request
+type Identity struct {
+	// Username is the authenticated user
+	Username string
+	// Authenticator is the name of the Authenticator that authenticated the user
+	Authenticator string
+	// Provider is the type of Authenticator that authenticated the user
+	Provider string
+	// Claims provides any additional attributes of the identity, such as JWT claims
+	// or the subject fields of a client certificate
+	Claims map[string]interface{}
+}
+
+// Claim returns the string representation of the named claim, or an empty string
+// if the claim is not present
+func (id *Identity) Claim(name string) string {
+	if id == nil || id.Claims == nil {
+		return ""
+	}
+	v, ok := id.Claims[name]
+	if !ok || v == nil {
+		return ""
+	}
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		s := make([]string, len(t))
+		for i := range t {
+			s[i] = fmt.Sprintf("%v", t[i])
+		}
+		return strings.Join(s, ",")
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Authenticator authenticates client requests
+type Authenticator interface {
+	// Authenticate returns the Identity of the request's client, or an error if the
+	// request does not provide valid credentials
+	Authenticate(*http.Request) (*Identity, error)
+	// Challenge returns the value of the WWW-Authenticate header provided to clients
+	// that fail authentication, if any
+	Challenge() string
+	// Options returns the Authenticator's Options
+	Options() *options.Options
+}
+
+// Lookup is a map of Authenticators
+type Lookup map[string]Authenticator
+
+// ProcessConfigs returns a Lookup of Authenticators built from the provided Options
+func ProcessConfigs(ol options.Lookup) (Lookup, error) {
+	l := make(Lookup, len(ol))
+	for k, o := range ol {
+		a, err := New(k, o)
+		if err != nil {
+			return nil, err
+		}
+		l[k] = a
+	}
+	return l, nil
+}
+
+// New returns a new Authenticator of the type indicated by the Options' Provider
+func New(name string, o *options.Options) (Authenticator, error) {
+	if o == nil {
+		return nil, fmt.Errorf("invalid options for authenticator %s", name)
+	}
+	o.Name = name
+	if o.Realm == "" {
+		o.Realm = "trickster"
+	}
+	switch o.Provider {
+	case "basic":
+		return newBasicAuthenticator(o)
+	case "jwt":
+		return newJWTAuthenticator(o)
+	case "clientcert":
+		return newClientCertAuthenticator(o)
+	}
+	return nil, fmt.Errorf("invalid provider [%s] for authenticator %s", o.Provider, name)
+}
+
+// GetIdentity returns the authenticated Identity of the request's client, if any
+func GetIdentity(r *http.Request) *Identity {
+	if r == nil {
+		return nil
+	}
+	if id, ok := tctx.Identity(r.Context()).(*Identity); ok {
+		return id
+	}
+	return nil
+}
+
+// credentialHeader returns the name of the request header from which the provider
+// reads the client's credentials, if any
+func credentialHeader(provider string) string {
+	switch provider {
+	case "basic", "jwt":
+		return headers.NameAuthorization
+	}
+	return ""
+}
+
+// Handler authenticates requests using the provided Authenticator before passing
+// them to the next handler with the client's Identity attached to the request context.
+// Unless the Authenticator forwards credentials, the credential header is then removed.
+// Requests that fail authentication receive a 401 Unauthorized response
+func Handler(a Authenticator, next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	o := a.Options()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if o.IdentityHeader != "" {
+			r.Header.Del(o.IdentityHeader)
+		}
+		id, err := a.Authenticate(r)
+		if err != nil || id == nil {
+			if c := a.Challenge(); c != "" {
+				w.Header().Set(headers.NameWWWAuthenticate, c)
+			}
+			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+		id.Authenticator = o.Name
+		id.Provider = o.Provider
+		if o.IdentityHeader != "" {
+			r.Header.Set(o.IdentityHeader, id.Username)
+		}
+		if h := credentialHeader(o.Provider); h != "" && !o.ForwardCredentials {
+			r.Header.Del(h)
+		}
+		next.ServeHTTP(w, r.WithContext(tctx.WithIdentity(r.Context(), id)))
+	})
+}
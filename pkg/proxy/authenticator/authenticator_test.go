@@ -0,0 +1,216 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+)
+
+func TestNew(t *testing.T) {
+
+	_, err := New("test", nil)
+	if err == nil {
+		t.Error("expected error for nil options")
+	}
+
+	_, err = New("test", &options.Options{Provider: "invalid"})
+	if err == nil {
+		t.Error("expected error for invalid provider")
+	}
+
+	path, cleanup := testHtpasswd(t)
+	defer cleanup()
+
+	a, err := New("test", &options.Options{Provider: "basic", UsersFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := a.Options()
+	if o.Name != "test" {
+		t.Errorf("expected %s got %s", "test", o.Name)
+	}
+	if o.Realm != "trickster" {
+		t.Errorf("expected %s got %s", "trickster", o.Realm)
+	}
+}
+
+func TestProcessConfigs(t *testing.T) {
+
+	path, cleanup := testHtpasswd(t)
+	defer cleanup()
+
+	l, err := ProcessConfigs(options.Lookup{
+		"test": &options.Options{Provider: "basic", UsersFile: path},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l["test"]; !ok {
+		t.Error("expected authenticator named test")
+	}
+
+	_, err = ProcessConfigs(options.Lookup{
+		"test": &options.Options{Provider: "basic"},
+	})
+	if err == nil {
+		t.Error("expected error for missing users_file")
+	}
+}
+
+func TestHandler(t *testing.T) {
+
+	path, cleanup := testHtpasswd(t)
+	defer cleanup()
+
+	a, err := New("test", &options.Options{Provider: "basic", UsersFile: path,
+		IdentityHeader: "X-Trickster-User"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id *Identity
+	var hv, av string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = GetIdentity(r)
+		hv = r.Header.Get("X-Trickster-User")
+		av = r.Header.Get(headers.NameAuthorization)
+	})
+
+	if h := Handler(nil, next); h == nil {
+		t.Error("expected non-nil handler")
+	}
+
+	h := Handler(a, next)
+
+	// unauthenticated request with a spoofed identity header
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://0/", nil)
+	r.Header.Set("X-Trickster-User", "alice")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d got %d", http.StatusUnauthorized, w.Code)
+	}
+	const expected = `Basic realm="trickster"`
+	if v := w.Header().Get(headers.NameWWWAuthenticate); v != expected {
+		t.Errorf("expected %s got %s", expected, v)
+	}
+	if id != nil {
+		t.Error("expected next handler not to be called")
+	}
+
+	// authenticated request
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "http://0/", nil)
+	r.Header.Set("X-Trickster-User", "bob")
+	r.SetBasicAuth("alice", "wonderland")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	if id == nil {
+		t.Fatal("expected non-nil identity")
+	}
+	if id.Username != "alice" || id.Authenticator != "test" || id.Provider != "basic" {
+		t.Errorf("unexpected identity %v", id)
+	}
+	if hv != "alice" {
+		t.Errorf("expected %s got %s", "alice", hv)
+	}
+	// the credentials are removed once authenticated
+	if av != "" {
+		t.Errorf("expected empty Authorization header got %s", av)
+	}
+
+	// the credentials are retained when the authenticator forwards them
+	a.Options().ForwardCredentials = true
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "http://0/", nil)
+	r.SetBasicAuth("alice", "wonderland")
+	expectedAuth := r.Header.Get(headers.NameAuthorization)
+	h.ServeHTTP(w, r)
+	if av != expectedAuth {
+		t.Errorf("expected %s got %s", expectedAuth, av)
+	}
+}
+
+func TestGetIdentity(t *testing.T) {
+
+	if GetIdentity(nil) != nil {
+		t.Error("expected nil identity")
+	}
+
+	r := httptest.NewRequest("GET", "http://0/", nil)
+	if GetIdentity(r) != nil {
+		t.Error("expected nil identity")
+	}
+
+	r = r.WithContext(tctx.WithIdentity(context.Background(), "invalid"))
+	if GetIdentity(r) != nil {
+		t.Error("expected nil identity")
+	}
+
+	id := &Identity{Username: "alice"}
+	r = r.WithContext(tctx.WithIdentity(context.Background(), id))
+	if GetIdentity(r) != id {
+		t.Error("expected identity")
+	}
+}
+
+func TestClaim(t *testing.T) {
+
+	var id *Identity
+	if v := id.Claim("sub"); v != "" {
+		t.Errorf("expected empty string got %s", v)
+	}
+
+	id = &Identity{Username: "alice"}
+	if v := id.Claim("sub"); v != "" {
+		t.Errorf("expected empty string got %s", v)
+	}
+
+	id.Claims = map[string]interface{}{
+		"sub":    "alice",
+		"groups": []interface{}{"dev", "ops"},
+		"exp":    float64(1600000000),
+		"ratio":  1.5,
+		"admin":  true,
+		"empty":  nil,
+	}
+
+	tests := map[string]string{
+		"sub":     "alice",
+		"groups":  "dev,ops",
+		"exp":     "1600000000",
+		"ratio":   "1.5",
+		"admin":   "true",
+		"empty":   "",
+		"missing": "",
+	}
+
+	for k, expected := range tests {
+		if v := id.Claim(k); v != expected {
+			t.Errorf("claim %s: expected %s got %s", k, expected, v)
+		}
+	}
+}
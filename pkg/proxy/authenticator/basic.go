@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// basicAuthenticator authenticates requests using HTTP Basic Authentication against
+// the users of an htpasswd-formatted file, whose passwords are hashed with bcrypt
+// or SHA1 ({SHA})
+type basicAuthenticator struct {
+	options *options.Options
+	users   map[string]string
+}
+
+func newBasicAuthenticator(o *options.Options) (Authenticator, error) {
+	if o.UsersFile == "" {
+		return nil, fmt.Errorf("missing users_file for authenticator %s", o.Name)
+	}
+	b, err := ioutil.ReadFile(o.UsersFile)
+	if err != nil {
+		return nil, err
+	}
+	users, err := parseHtpasswd(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid users_file for authenticator %s: %s", o.Name, err.Error())
+	}
+	return &basicAuthenticator{options: o, users: users}, nil
+}
+
+// parseHtpasswd parses the user:hash lines of an htpasswd file, ignoring blank lines
+// and comments
+func parseHtpasswd(b []byte) (map[string]string, error) {
+	users := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid entry on line %d", n)
+		}
+		user, hash := line[:i], line[i+1:]
+		if !strings.HasPrefix(hash, "$2") && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("unsupported password hash for user %s", user)
+		}
+		users[user] = hash
+	}
+	return users, s.Err()
+}
+
+func (a *basicAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	hash, ok := a.users[user]
+	if !ok || !checkPassword(hash, pass) {
+		return nil, ErrUnauthorized
+	}
+	return &Identity{Username: user}, nil
+}
+
+func checkPassword(hash, pass string) bool {
+	if strings.HasPrefix(hash, "{SHA}") {
+		sum := sha1.Sum([]byte(pass))
+		return subtle.ConstantTimeCompare([]byte(hash[5:]),
+			[]byte(base64.StdEncoding.EncodeToString(sum[:]))) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil
+}
+
+func (a *basicAuthenticator) Challenge() string {
+	return fmt.Sprintf(`Basic realm="%s"`, a.options.Realm)
+}
+
+func (a *basicAuthenticator) Options() *options.Options {
+	return a.options
+}
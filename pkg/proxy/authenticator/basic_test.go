@@ -0,0 +1,151 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHtpasswd returns the path of a temporary htpasswd file containing the users
+// "alice" (bcrypt, password "wonderland") and "bob" ({SHA}, password "builder")
+func testHtpasswd(t *testing.T) (string, func()) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("wonderland"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "trickster-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "htpasswd")
+	content := "# test users\n\nalice:" + string(hash) +
+		"\nbob:{SHA}9SMYoF5RilWWASry7TjeaKwmpGg=\n"
+	if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestNewBasicAuthenticator(t *testing.T) {
+
+	_, err := newBasicAuthenticator(&options.Options{Name: "test"})
+	if err == nil {
+		t.Error("expected error for missing users_file")
+	}
+
+	_, err = newBasicAuthenticator(&options.Options{Name: "test", UsersFile: "/non/existent/file"})
+	if err == nil {
+		t.Error("expected error for non-existent users_file")
+	}
+
+	path, cleanup := testHtpasswd(t)
+	defer cleanup()
+
+	a, err := newBasicAuthenticator(&options.Options{Name: "test", UsersFile: path, Realm: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `Basic realm="test"`
+	if c := a.Challenge(); c != expected {
+		t.Errorf("expected %s got %s", expected, c)
+	}
+	if a.Options().UsersFile != path {
+		t.Errorf("expected %s got %s", path, a.Options().UsersFile)
+	}
+}
+
+func TestParseHtpasswd(t *testing.T) {
+
+	tests := []struct {
+		content string
+		users   int
+		isErr   bool
+	}{
+		{"", 0, false},
+		{"# comment\n\nuser:{SHA}abc\n", 1, false},
+		{"user:$2y$05$abc\nuser2:{SHA}abc\n", 2, false},
+		{"invalid\n", 0, true},
+		{":{SHA}abc\n", 0, true},
+		{"user:$apr1$salt$hash\n", 0, true},
+		{"user:plaintext\n", 0, true},
+	}
+
+	for i, test := range tests {
+		users, err := parseHtpasswd([]byte(test.content))
+		if (err != nil) != test.isErr {
+			t.Errorf("test %d: unexpected error result %v", i, err)
+		}
+		if err == nil && len(users) != test.users {
+			t.Errorf("test %d: expected %d got %d", i, test.users, len(users))
+		}
+	}
+}
+
+func TestBasicAuthenticate(t *testing.T) {
+
+	path, cleanup := testHtpasswd(t)
+	defer cleanup()
+
+	a, err := newBasicAuthenticator(&options.Options{Name: "test", UsersFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user, pass string
+		setAuth    bool
+		isErr      bool
+	}{
+		{"alice", "wonderland", true, false},
+		{"bob", "builder", true, false},
+		{"alice", "builder", true, true},
+		{"bob", "wonderland", true, true},
+		{"carol", "wonderland", true, true},
+		{"", "", false, true},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "http://0/", nil)
+		if test.setAuth {
+			r.SetBasicAuth(test.user, test.pass)
+		}
+		id, err := a.Authenticate(r)
+		if test.isErr {
+			if err != ErrUnauthorized {
+				t.Errorf("test %d: expected %v got %v", i, ErrUnauthorized, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if id.Username != test.user {
+			t.Errorf("test %d: expected %s got %s", i, test.user, id.Username)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+)
+
+// clientCertAuthenticator authenticates requests by verifying the TLS client
+// certificate presented to the frontend listener against a set of trusted CAs
+type clientCertAuthenticator struct {
+	options *options.Options
+	roots   *x509.CertPool
+}
+
+// newClientCertAuthenticator returns a client certificate Authenticator. When the options
+// do not provide any certificate_authority_paths, the Authenticator trusts no certificates
+// until it is bound to a backend's TLS CA paths with WithCertificateAuthorities
+func newClientCertAuthenticator(o *options.Options) (Authenticator, error) {
+	roots := x509.NewCertPool()
+	for _, path := range o.CertificateAuthorityPaths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !roots.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("unable to append to CA Certs from file %s", path)
+		}
+	}
+	return &clientCertAuthenticator{options: o, roots: roots}, nil
+}
+
+// WithCertificateAuthorities returns a client certificate Authenticator that verifies
+// certificates against the provided CA paths (e.g., those of a backend's TLS options)
+// when the subject Authenticator was not configured with its own. Other Authenticators
+// are returned unchanged
+func WithCertificateAuthorities(a Authenticator, paths []string) (Authenticator, error) {
+	if a == nil || a.Options().Provider != "clientcert" ||
+		len(a.Options().CertificateAuthorityPaths) > 0 {
+		return a, nil
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("missing certificate_authority_paths for authenticator %s",
+			a.Options().Name)
+	}
+	o := a.Options().Clone()
+	o.CertificateAuthorityPaths = paths
+	return newClientCertAuthenticator(o)
+}
+
+func (a *clientCertAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, ErrUnauthorized
+	}
+	cert := r.TLS.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, c := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         a.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil || cert.Subject.CommonName == "" {
+		return nil, ErrUnauthorized
+	}
+	return &Identity{
+		Username: cert.Subject.CommonName,
+		Claims: map[string]interface{}{
+			"cn": cert.Subject.CommonName,
+			"o":  toInterfaces(cert.Subject.Organization),
+			"ou": toInterfaces(cert.Subject.OrganizationalUnit),
+		},
+	}, nil
+}
+
+func toInterfaces(s []string) []interface{} {
+	out := make([]interface{}, len(s))
+	for i := range s {
+		out[i] = s[i]
+	}
+	return out
+}
+
+// Challenge returns an empty string, as client certificates are negotiated during the
+// TLS handshake rather than through a WWW-Authenticate challenge
+func (a *clientCertAuthenticator) Challenge() string {
+	return ""
+}
+
+func (a *clientCertAuthenticator) Options() *options.Options {
+	return a.options
+}
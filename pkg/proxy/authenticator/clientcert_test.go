@@ -0,0 +1,238 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+)
+
+// testCA generates a self-signed CA and writes its certificate to a temporary file,
+// returning its path
+func testCA(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey, string, func()) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	dir, err := ioutil.TempDir("", "trickster-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "ca.pem")
+	err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, path, func() { os.RemoveAll(dir) }
+}
+
+func testClientCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey,
+	cn string, usage x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{CommonName: cn, Organization: []string{"trickster"},
+			OrganizationalUnit: []string{"dev", "ops"}},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func TestNewClientCertAuthenticator(t *testing.T) {
+
+	_, err := newClientCertAuthenticator(&options.Options{Name: "test",
+		CertificateAuthorityPaths: []string{"/non/existent/file"}})
+	if err == nil {
+		t.Error("expected error for non-existent certificate_authority_paths")
+	}
+
+	_, _, path, cleanup := testCA(t, "test-ca")
+	defer cleanup()
+
+	invalid := path + ".invalid"
+	ioutil.WriteFile(invalid, []byte("not a cert"), 0600)
+	_, err = newClientCertAuthenticator(&options.Options{Name: "test",
+		CertificateAuthorityPaths: []string{invalid}})
+	if err == nil {
+		t.Error("expected error for invalid certificate_authority_paths")
+	}
+
+	a, err := newClientCertAuthenticator(&options.Options{Name: "test",
+		CertificateAuthorityPaths: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := a.Challenge(); c != "" {
+		t.Errorf("expected empty challenge got %s", c)
+	}
+	if a.Options().Name != "test" {
+		t.Errorf("expected %s got %s", "test", a.Options().Name)
+	}
+}
+
+func TestClientCertAuthenticate(t *testing.T) {
+
+	ca, caKey, path, cleanup := testCA(t, "test-ca")
+	defer cleanup()
+
+	otherCA, otherKey, _, cleanup2 := testCA(t, "other-ca")
+	defer cleanup2()
+
+	a, err := newClientCertAuthenticator(&options.Options{Name: "test",
+		CertificateAuthorityPaths: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		certs []*x509.Certificate
+		isErr bool
+	}{
+		{[]*x509.Certificate{testClientCert(t, ca, caKey, "alice", x509.ExtKeyUsageClientAuth)}, false},
+		{[]*x509.Certificate{testClientCert(t, ca, caKey, "alice", x509.ExtKeyUsageServerAuth)}, true},
+		{[]*x509.Certificate{testClientCert(t, ca, caKey, "", x509.ExtKeyUsageClientAuth)}, true},
+		{[]*x509.Certificate{testClientCert(t, otherCA, otherKey, "alice",
+			x509.ExtKeyUsageClientAuth), otherCA}, true},
+		{nil, true},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "https://0/", nil)
+		r.TLS = &tls.ConnectionState{PeerCertificates: test.certs}
+		id, err := a.Authenticate(r)
+		if test.isErr {
+			if err != ErrUnauthorized {
+				t.Errorf("test %d: expected %v got %v", i, ErrUnauthorized, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if id.Username != "alice" {
+			t.Errorf("test %d: expected %s got %s", i, "alice", id.Username)
+		}
+		if v := id.Claim("ou"); v != "dev,ops" {
+			t.Errorf("test %d: expected %s got %s", i, "dev,ops", v)
+		}
+	}
+
+	// plain http requests are not authenticated
+	r := httptest.NewRequest("GET", "http://0/", nil)
+	if _, err := a.Authenticate(r); err != ErrUnauthorized {
+		t.Errorf("expected %v got %v", ErrUnauthorized, err)
+	}
+}
+
+func TestWithCertificateAuthorities(t *testing.T) {
+
+	ca, caKey, path, cleanup := testCA(t, "test-ca")
+	defer cleanup()
+
+	a, err := newClientCertAuthenticator(&options.Options{Name: "test", Provider: "clientcert"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with no CA paths, no client certificates are trusted
+	r := httptest.NewRequest("GET", "https://0/", nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		testClientCert(t, ca, caKey, "alice", x509.ExtKeyUsageClientAuth)}}
+	if _, err = a.Authenticate(r); err != ErrUnauthorized {
+		t.Errorf("expected %v got %v", ErrUnauthorized, err)
+	}
+
+	if _, err = WithCertificateAuthorities(a, nil); err == nil {
+		t.Error("expected error for missing certificate_authority_paths")
+	}
+
+	a2, err := WithCertificateAuthorities(a, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a2 == a {
+		t.Error("expected new authenticator")
+	}
+	if _, err = a2.Authenticate(r); err != nil {
+		t.Error(err)
+	}
+
+	// an authenticator with its own CA paths is not changed
+	a3, err := WithCertificateAuthorities(a2, []string{"/non/existent/file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a3 != a2 {
+		t.Error("expected same authenticator")
+	}
+
+	// other providers are not changed
+	a4, err := WithCertificateAuthorities(&basicAuthenticator{
+		options: &options.Options{Provider: "basic"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a4.(*basicAuthenticator); !ok {
+		t.Error("expected basic authenticator")
+	}
+
+	if a5, err := WithCertificateAuthorities(nil, nil); a5 != nil || err != nil {
+		t.Error("expected nil authenticator and error")
+	}
+}
@@ -0,0 +1,332 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+
+	// registers the SHA2 hash functions used by the supported signing algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// jwtAuthenticator authenticates requests bearing a JSON Web Token signed by
+// one of the keys in a local JSON Web Key Set file
+type jwtAuthenticator struct {
+	options *options.Options
+	keys    []*jwk
+	now     func() time.Time
+}
+
+// jwk is a JSON Web Key, along with its parsed public (or symmetric) key
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+
+	key interface{}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// signingAlgorithm describes the key type and hash used by a JWS algorithm
+type signingAlgorithm struct {
+	kty  string
+	hash crypto.Hash
+	pss  bool
+}
+
+var signingAlgorithms = map[string]signingAlgorithm{
+	"RS256": {"RSA", crypto.SHA256, false},
+	"RS384": {"RSA", crypto.SHA384, false},
+	"RS512": {"RSA", crypto.SHA512, false},
+	"PS256": {"RSA", crypto.SHA256, true},
+	"PS384": {"RSA", crypto.SHA384, true},
+	"PS512": {"RSA", crypto.SHA512, true},
+	"ES256": {"EC", crypto.SHA256, false},
+	"ES384": {"EC", crypto.SHA384, false},
+	"ES512": {"EC", crypto.SHA512, false},
+	"HS256": {"oct", crypto.SHA256, false},
+	"HS384": {"oct", crypto.SHA384, false},
+	"HS512": {"oct", crypto.SHA512, false},
+}
+
+func newJWTAuthenticator(o *options.Options) (Authenticator, error) {
+	if o.JWKSFile == "" {
+		return nil, fmt.Errorf("missing jwks_file for authenticator %s", o.Name)
+	}
+	b, err := ioutil.ReadFile(o.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks_file for authenticator %s: %s", o.Name, err.Error())
+	}
+	if o.UserClaim == "" {
+		o.UserClaim = "sub"
+	}
+	return &jwtAuthenticator{options: o, keys: keys, now: time.Now}, nil
+}
+
+// parseJWKS parses the RSA, EC and symmetric (oct) keys of a JSON Web Key Set
+func parseJWKS(b []byte) ([]*jwk, error) {
+	set := struct {
+		Keys []*jwk `json:"keys"`
+	}{}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	keys := make([]*jwk, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var err error
+		switch k.Kty {
+		case "RSA":
+			k.key, err = k.rsaKey()
+		case "EC":
+			k.key, err = k.ecKey()
+		case "oct":
+			k.key, err = decodeSegment(k.K)
+		default:
+			err = fmt.Errorf("unsupported key type %s", k.Kty)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %s", k.Kid, err.Error())
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return keys, nil
+}
+
+func (k *jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeSegment(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeSegment(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid rsa key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+func (k *jwk) ecKey() (*ecdsa.PublicKey, error) {
+	var c elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		c = elliptic.P256()
+	case "P-384":
+		c = elliptic.P384()
+	case "P-521":
+		c = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+	}
+	x, err := decodeSegment(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeSegment(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	pk := &ecdsa.PublicKey{Curve: c, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !c.IsOnCurve(pk.X, pk.Y) {
+		return nil, errors.New("invalid ec key")
+	}
+	return pk, nil
+}
+
+// decodeSegment decodes a base64url-encoded JWT segment or JWK member, with or without padding
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	v := r.Header.Get(headers.NameAuthorization)
+	if len(v) < 7 || !strings.EqualFold(v[:7], "Bearer ") {
+		return nil, ErrUnauthorized
+	}
+	claims, err := a.verify(strings.TrimSpace(v[7:]))
+	if err != nil {
+		return nil, err
+	}
+	user, _ := claims[a.options.UserClaim].(string)
+	if user == "" {
+		return nil, errInvalidToken
+	}
+	return &Identity{Username: user, Claims: claims}, nil
+}
+
+// verify verifies the token's signature and registered claims, and returns its claims
+func (a *jwtAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	b, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	h := &jwtHeader{}
+	if err = json.Unmarshal(b, h); err != nil {
+		return nil, errInvalidToken
+	}
+	alg, ok := signingAlgorithms[h.Alg]
+	if !ok {
+		return nil, errInvalidToken
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	var verified bool
+	for _, k := range a.keys {
+		if k.Kty != alg.kty || (h.Kid != "" && k.Kid != h.Kid) || (k.Alg != "" && k.Alg != h.Alg) {
+			continue
+		}
+		if verifySignature(alg, k.key, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errInvalidToken
+	}
+
+	b, err = decodeSegment(parts[1])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	claims := make(map[string]interface{})
+	if err = json.Unmarshal(b, &claims); err != nil {
+		return nil, errInvalidToken
+	}
+	if err = a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func verifySignature(alg signingAlgorithm, key interface{}, signed, sig []byte) bool {
+	hh := alg.hash.New()
+	hh.Write(signed)
+	digest := hh.Sum(nil)
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg.pss {
+			return rsa.VerifyPSS(k, alg.hash, digest, sig,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(k, alg.hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	case []byte:
+		m := hmac.New(alg.hash.New, k)
+		m.Write(signed)
+		return hmac.Equal(sig, m.Sum(nil))
+	}
+	return false
+}
+
+// validateClaims checks the token's expiration, not-before, issuer and audience claims
+func (a *jwtAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := float64(a.now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now >= exp {
+		return errInvalidToken
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return errInvalidToken
+	}
+	if a.options.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.options.Issuer {
+			return errInvalidToken
+		}
+	}
+	if a.options.Audience != "" && !hasAudience(claims["aud"], a.options.Audience) {
+		return errInvalidToken
+	}
+	return nil
+}
+
+// hasAudience returns true if the aud claim, which may be a string or list of strings,
+// includes the provided audience
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *jwtAuthenticator) Challenge() string {
+	return fmt.Sprintf(`Bearer realm="%s"`, a.options.Realm)
+}
+
+func (a *jwtAuthenticator) Options() *options.Options {
+	return a.options
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authenticator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+)
+
+type testJWTKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	hmac []byte
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// testJWKS generates RSA, EC and HMAC signing keys, and writes their JWKS to
+// a temporary file, returning its path
+func testJWKS(t *testing.T) (*testJWTKeys, string, func()) {
+	t.Helper()
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &testJWTKeys{rsa: rk, ec: ek, hmac: []byte("test-shared-secret")}
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(rk.N.Bytes()),
+				"e": b64(big.NewInt(int64(rk.E)).Bytes())},
+			{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ek.X.Bytes()),
+				"y": b64(ek.Y.Bytes())},
+			{"kty": "oct", "kid": "hmac1", "alg": "HS256", "k": b64(keys.hmac)},
+			{"kty": "RSA", "kid": "enc1", "use": "enc"},
+		},
+	}
+	b, _ := json.Marshal(jwks)
+	dir, err := ioutil.TempDir("", "trickster-jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "jwks.json")
+	if err = ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keys, path, func() { os.RemoveAll(dir) }
+}
+
+func signTestJWT(t *testing.T, alg, kid string, key interface{},
+	claims map[string]interface{}) string {
+	t.Helper()
+	hb, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	cb, _ := json.Marshal(claims)
+	signed := b64(hb) + "." + b64(cb)
+	sa := signingAlgorithms[alg]
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		h := sa.hash.New()
+		h.Write([]byte(signed))
+		if sa.pss {
+			sig, err = rsa.SignPSS(rand.Reader, k, sa.hash, h.Sum(nil),
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, sa.hash, h.Sum(nil))
+		}
+	case *ecdsa.PrivateKey:
+		h := sa.hash.New()
+		h.Write([]byte(signed))
+		r, s, e := ecdsa.Sign(rand.Reader, k, h.Sum(nil))
+		err = e
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	case []byte:
+		m := hmac.New(sa.hash.New, k)
+		m.Write([]byte(signed))
+		sig = m.Sum(nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func TestNewJWTAuthenticator(t *testing.T) {
+
+	_, err := newJWTAuthenticator(&options.Options{Name: "test"})
+	if err == nil {
+		t.Error("expected error for missing jwks_file")
+	}
+
+	_, err = newJWTAuthenticator(&options.Options{Name: "test", JWKSFile: "/non/existent/file"})
+	if err == nil {
+		t.Error("expected error for non-existent jwks_file")
+	}
+
+	_, path, cleanup := testJWKS(t)
+	defer cleanup()
+
+	a, err := newJWTAuthenticator(&options.Options{Name: "test", JWKSFile: path, Realm: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Options().UserClaim != "sub" {
+		t.Errorf("expected %s got %s", "sub", a.Options().UserClaim)
+	}
+	const expected = `Bearer realm="test"`
+	if c := a.Challenge(); c != expected {
+		t.Errorf("expected %s got %s", expected, c)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+
+	tests := []struct {
+		content string
+		keys    int
+		isErr   bool
+	}{
+		{`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, 1, false},
+		{`{"keys":[{"kty":"oct","k":"c2VjcmV0"},{"kty":"oct","use":"enc","k":"c2VjcmV0"}]}`, 1, false},
+		{`{"keys":[]}`, 0, true},
+		{`not json`, 0, true},
+		{`{"keys":[{"kty":"unknown"}]}`, 0, true},
+		{`{"keys":[{"kty":"RSA","n":"","e":"AQAB"}]}`, 0, true},
+		{`{"keys":[{"kty":"RSA","n":"!!","e":"AQAB"}]}`, 0, true},
+		{`{"keys":[{"kty":"RSA","n":"AQAB","e":"!!"}]}`, 0, true},
+		{`{"keys":[{"kty":"EC","crv":"P-999"}]}`, 0, true},
+		{`{"keys":[{"kty":"EC","crv":"P-256","x":"AQAB","y":"AQAB"}]}`, 0, true},
+		{`{"keys":[{"kty":"EC","crv":"P-384","x":"!!","y":"AQAB"}]}`, 0, true},
+		{`{"keys":[{"kty":"EC","crv":"P-521","x":"AQAB","y":"!!"}]}`, 0, true},
+	}
+
+	for i, test := range tests {
+		keys, err := parseJWKS([]byte(test.content))
+		if (err != nil) != test.isErr {
+			t.Errorf("test %d: unexpected error result %v", i, err)
+		}
+		if err == nil && len(keys) != test.keys {
+			t.Errorf("test %d: expected %d got %d", i, test.keys, len(keys))
+		}
+	}
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+
+	keys, path, cleanup := testJWKS(t)
+	defer cleanup()
+
+	a, err := newJWTAuthenticator(&options.Options{Name: "test", JWKSFile: path,
+		Issuer: "https://issuer.example.com", Audience: "trickster"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1600000000, 0)
+	a.(*jwtAuthenticator).now = func() time.Time { return now }
+
+	claims := func(m map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "alice",
+			"iss": "https://issuer.example.com",
+			"aud": "trickster",
+			"exp": now.Unix() + 60,
+			"nbf": now.Unix() - 60,
+		}
+		for k, v := range m {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		token string
+		isErr bool
+	}{
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa, claims(nil)), false},
+		{signTestJWT(t, "RS384", "", keys.rsa, claims(nil)), false},
+		{signTestJWT(t, "RS512", "rsa1", keys.rsa, claims(nil)), false},
+		{signTestJWT(t, "PS256", "rsa1", keys.rsa, claims(nil)), false},
+		{signTestJWT(t, "ES256", "ec1", keys.ec, claims(nil)), false},
+		{signTestJWT(t, "HS256", "hmac1", keys.hmac, claims(nil)), false},
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"aud": []string{"other", "trickster"}})), false},
+		// key id mismatch
+		{signTestJWT(t, "RS256", "ec1", keys.rsa, claims(nil)), true},
+		// unknown signing key
+		{signTestJWT(t, "RS256", "rsa1", otherKey, claims(nil)), true},
+		// algorithm not permitted by the key
+		{signTestJWT(t, "HS384", "hmac1", keys.hmac, claims(nil)), true},
+		// expired
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"exp": now.Unix() - 1})), true},
+		// not yet valid
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"nbf": now.Unix() + 1})), true},
+		// wrong issuer
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"iss": "https://evil.example.com"})), true},
+		// wrong audience
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"aud": []string{"other"}})), true},
+		// missing audience
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"aud": nil})), true},
+		// missing user claim
+		{signTestJWT(t, "RS256", "rsa1", keys.rsa,
+			claims(map[string]interface{}{"sub": nil})), true},
+		{"", true},
+		{"a.b", true},
+		{"!!.b.c", true},
+		{b64([]byte("not json")) + ".b.c", true},
+		{b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{"sub":"alice"}`)) + ".", true},
+		{b64([]byte(`{"alg":"HS256"}`)) + ".b.!!", true},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "http://0/", nil)
+		if test.token != "" {
+			r.Header.Set(headers.NameAuthorization, "Bearer "+test.token)
+		}
+		id, err := a.Authenticate(r)
+		if test.isErr {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if id.Username != "alice" {
+			t.Errorf("test %d: expected %s got %s", i, "alice", id.Username)
+		}
+		if id.Claim("iss") != "https://issuer.example.com" {
+			t.Errorf("test %d: expected %s got %s", i, "https://issuer.example.com", id.Claim("iss"))
+		}
+	}
+
+	// basic auth credentials are not accepted
+	r := httptest.NewRequest("GET", "http://0/", nil)
+	r.SetBasicAuth("alice", "wonderland")
+	if _, err := a.Authenticate(r); err != ErrUnauthorized {
+		t.Errorf("expected %v got %v", ErrUnauthorized, err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	if verifySignature(signingAlgorithms["HS256"], "invalid", []byte("a"), []byte("b")) {
+		t.Error("expected false")
+	}
+	ek, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if verifySignature(signingAlgorithms["ES256"], &ek.PublicKey, []byte("a"), []byte("b")) {
+		t.Error("expected false")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import (
+	strutil "github.com/tricksterproxy/trickster/pkg/util/strings"
+)
+
+// Options is a collection of Options pertaining to a Frontend Authenticator
+type Options struct {
+	// Name is the name of the Authenticator, taken from the Key in the Authenticators map
+	Name string `toml:"-"`
+	// Provider is the type of Authenticator: 'basic', 'jwt' or 'clientcert'
+	Provider string `toml:"provider"`
+	// Realm is the realm presented to clients in the WWW-Authenticate challenge
+	Realm string `toml:"realm"`
+	// UsersFile is the path to the htpasswd-formatted file of users for the 'basic' provider
+	UsersFile string `toml:"users_file"`
+	// JWKSFile is the path to the JSON Web Key Set file used by the 'jwt' provider
+	// to verify token signatures
+	JWKSFile string `toml:"jwks_file"`
+	// Issuer, when set, must match the 'iss' claim of tokens verified by the 'jwt' provider
+	Issuer string `toml:"issuer"`
+	// Audience, when set, must be included in the 'aud' claim of tokens verified by the 'jwt' provider
+	Audience string `toml:"audience"`
+	// UserClaim is the name of the claim that identifies the user for the 'jwt' provider;
+	// the default is 'sub'
+	UserClaim string `toml:"user_claim"`
+	// CertificateAuthorityPaths provides the list of Certificate Authorities against which
+	// client certificates are verified by the 'clientcert' provider. When omitted, the
+	// certificate_authority_paths of the TLS options of each backend using the authenticator apply
+	CertificateAuthorityPaths []string `toml:"certificate_authority_paths"`
+	// IdentityHeader, when set, is the name of the header in which the authenticated user
+	// is forwarded upstream. Any value for this header provided by the client is removed
+	IdentityHeader string `toml:"identity_header"`
+	// ForwardCredentials, when true, forwards the client's credentials (e.g., the Authorization
+	// header) upstream after authentication. By default they are removed, so that they are
+	// neither sent to the origin nor made part of the cache key
+	ForwardCredentials bool `toml:"forward_credentials"`
+}
+
+// Lookup is a map of Options
+type Lookup map[string]*Options
+
+// Clone returns an exact copy of the subject *Options
+func (o *Options) Clone() *Options {
+	o2 := &Options{
+		Name:               o.Name,
+		Provider:           o.Provider,
+		Realm:              o.Realm,
+		UsersFile:          o.UsersFile,
+		JWKSFile:           o.JWKSFile,
+		Issuer:             o.Issuer,
+		Audience:           o.Audience,
+		UserClaim:          o.UserClaim,
+		IdentityHeader:     o.IdentityHeader,
+		ForwardCredentials: o.ForwardCredentials,
+	}
+	if o.CertificateAuthorityPaths != nil {
+		o2.CertificateAuthorityPaths = make([]string, len(o.CertificateAuthorityPaths))
+		copy(o2.CertificateAuthorityPaths, o.CertificateAuthorityPaths)
+	}
+	return o2
+}
+
+// Equal returns true if all TOML-exposed option members are equal
+func (o *Options) Equal(o2 *Options) bool {
+	return o2 != nil &&
+		o.Provider == o2.Provider &&
+		o.Realm == o2.Realm &&
+		o.UsersFile == o2.UsersFile &&
+		o.JWKSFile == o2.JWKSFile &&
+		o.Issuer == o2.Issuer &&
+		o.Audience == o2.Audience &&
+		o.UserClaim == o2.UserClaim &&
+		strutil.Equal(o.CertificateAuthorityPaths, o2.CertificateAuthorityPaths) &&
+		o.IdentityHeader == o2.IdentityHeader &&
+		o.ForwardCredentials == o2.ForwardCredentials
+}
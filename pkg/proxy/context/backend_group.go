@@ -0,0 +1,38 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	"context"
+)
+
+// WithBackendGroup returns a copy of the provided context that also includes the group of
+// backends across which the request's upstream fetches are distributed
+func WithBackendGroup(ctx context.Context, g interface{}) context.Context {
+	if g != nil {
+		return context.WithValue(ctx, backendGroupKey, g)
+	}
+	return ctx
+}
+
+// BackendGroup returns the interface reference to the Request's backend group
+func BackendGroup(ctx context.Context) interface{} {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Value(backendGroupKey)
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBackendGroup(t *testing.T) {
+
+	if BackendGroup(nil) != nil {
+		t.Error("expected nil")
+	}
+
+	ctx := context.Background()
+	if BackendGroup(ctx) != nil {
+		t.Error("expected nil")
+	}
+
+	// cover nil short circuit case
+	ctx = WithBackendGroup(ctx, nil)
+	if BackendGroup(ctx) != nil {
+		t.Error("expected nil")
+	}
+
+	ctx = WithBackendGroup(ctx, "test")
+	if v, ok := BackendGroup(ctx).(string); !ok || v != "test" {
+		t.Error("expected test")
+	}
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	"context"
+)
+
+// WithIdentity returns a copy of the provided context that also includes the
+// identity of the authenticated client that made the request
+func WithIdentity(ctx context.Context, id interface{}) context.Context {
+	if id != nil {
+		return context.WithValue(ctx, identityKey, id)
+	}
+	return ctx
+}
+
+// Identity returns the interface reference to the Request's authenticated identity
+func Identity(ctx context.Context) interface{} {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Value(identityKey)
+}
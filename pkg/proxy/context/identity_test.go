@@ -0,0 +1,45 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+
+	if Identity(nil) != nil {
+		t.Error("expected nil")
+	}
+
+	ctx := context.Background()
+	if Identity(ctx) != nil {
+		t.Error("expected nil")
+	}
+
+	// cover nil short circuit case
+	ctx = WithIdentity(ctx, nil)
+	if Identity(ctx) != nil {
+		t.Error("expected nil")
+	}
+
+	ctx = WithIdentity(ctx, "test")
+	if v, ok := Identity(ctx).(string); !ok || v != "test" {
+		t.Error("expected test")
+	}
+}
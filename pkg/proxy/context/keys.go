@@ -23,4 +23,6 @@ const (
 	hopsKey
 	healthCheckKey
 	requestBodyKey
+	backendGroupKey
+	identityKey
 )
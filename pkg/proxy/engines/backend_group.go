@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/methods"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+)
+
+// backendGroup is implemented by groups of backends across which a request's
+// upstream fetches are distributed
+type backendGroup interface {
+	cacheKeyPrefix() string
+}
+
+// cacheKeyPrefix returns the cache key prefix for the request. When the request is
+// distributed across a backend group, the group's prefix is used so that responses are
+// cached under the same key regardless of the group member that serves the request.
+// For multi-tenant backends, the prefix is further scoped to the request's tenant
+func cacheKeyPrefix(r *http.Request, oc *oo.Options) string {
+	p := oc.CacheKeyPrefix
+	if g, ok := tctx.BackendGroup(r.Context()).(backendGroup); ok && g != nil {
+		if gp := g.cacheKeyPrefix(); gp != "" {
+			p = gp
+		}
+	}
+	if oc.IsMultiTenant() {
+		t, _ := oc.Tenant(r.Header)
+		return oc.TenantCacheKeyPrefix(p, t)
+	}
+	return p
+}
+
+// withBackendGroup returns a copy of the context that includes the request's backend
+// group, if any, so the group is retained by upstream requests that are detached from
+// the client request's context
+func withBackendGroup(ctx context.Context, r *http.Request) context.Context {
+	if g := tctx.BackendGroup(r.Context()); g != nil {
+		return tctx.WithBackendGroup(ctx, g)
+	}
+	return ctx
+}
+
+// readRequestBody reads the request body, if any, so that it can be provided to
+// multiple upstream requests, and resets the request's body to a new reader
+func readRequestBody(r *http.Request) []byte {
+	if !methods.HasBody(r.Method) || r.Body == nil {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// memberRequest returns a copy of the request that is routed to the member backend
+func memberRequest(r *http.Request, mc backends.Client, body []byte) *http.Request {
+
+	rsc := request.GetResources(r)
+	mo := mc.Configuration()
+
+	mr := r.Clone(r.Context())
+	path := mr.URL.Path
+	if rsc.BackendOptions != nil {
+		path = strings.TrimPrefix(path, rsc.BackendOptions.PathPrefix)
+	}
+	mr.URL.Scheme = mo.Scheme
+	mr.URL.Host = mo.Host
+	mr.URL.Path = mo.PathPrefix + path
+	if body != nil {
+		mr.Body = ioutil.NopCloser(bytes.NewReader(body))
+		mr.ContentLength = int64(len(body))
+	}
+
+	rs := request.NewResources(mo, rsc.PathConfig, rsc.CacheConfig, rsc.CacheClient,
+		mc, rsc.Tracer, rsc.Logger)
+	return mr.WithContext(tctx.WithResources(mr.Context(), rs))
+}
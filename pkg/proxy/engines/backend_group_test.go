@@ -0,0 +1,139 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+func TestCacheKeyPrefix(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	oc := rsc.BackendOptions
+	oc.CacheKeyPrefix = "test-prefix"
+
+	if p := cacheKeyPrefix(r, oc); p != "test-prefix" {
+		t.Errorf("expected %s got %s", "test-prefix", p)
+	}
+
+	// a group without a prefix uses the backend's prefix
+	r2 := r.WithContext(tctx.WithBackendGroup(r.Context(), &FirstResponseGroup{}))
+	if p := cacheKeyPrefix(r2, oc); p != "test-prefix" {
+		t.Errorf("expected %s got %s", "test-prefix", p)
+	}
+
+	r2 = r.WithContext(tctx.WithBackendGroup(r.Context(),
+		&FirstResponseGroup{CacheKeyPrefix: "test-group"}))
+	if p := cacheKeyPrefix(r2, oc); p != "test-group" {
+		t.Errorf("expected %s got %s", "test-group", p)
+	}
+
+	// multi-tenant backends scope the prefix to the request's tenant
+	oc.TenantHeader = "X-Scope-OrgID"
+	r.Header.Set(oc.TenantHeader, "team-a")
+	if p := cacheKeyPrefix(r, oc); p != "test-prefix.tenant.team-a" {
+		t.Errorf("expected %s got %s", "test-prefix.tenant.team-a", p)
+	}
+	r2.Header.Set(oc.TenantHeader, "team-a")
+	if p := cacheKeyPrefix(r2, oc); p != "test-group.tenant.team-a" {
+		t.Errorf("expected %s got %s", "test-group.tenant.team-a", p)
+	}
+}
+
+func TestWithBackendGroup(t *testing.T) {
+
+	r, _ := http.NewRequest(http.MethodGet, "http://0/", nil)
+	ctx := withBackendGroup(context.Background(), r)
+	if tctx.BackendGroup(ctx) != nil {
+		t.Error("expected nil group")
+	}
+
+	g := &FirstResponseGroup{}
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), g))
+	ctx = withBackendGroup(context.Background(), r)
+	if tctx.BackendGroup(ctx) != g {
+		t.Error("expected group in context")
+	}
+}
+
+func TestReadRequestBody(t *testing.T) {
+
+	r, _ := http.NewRequest(http.MethodGet, "http://0/", nil)
+	if b := readRequestBody(r); b != nil {
+		t.Error("expected nil body")
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "http://0/", strings.NewReader("trickster"))
+	b := readRequestBody(r)
+	if string(b) != "trickster" {
+		t.Errorf("expected %s got %s", "trickster", string(b))
+	}
+	// the request body should remain readable
+	b, _ = ioutil.ReadAll(r.Body)
+	if string(b) != "trickster" {
+		t.Errorf("expected %s got %s", "trickster", string(b))
+	}
+}
+
+func TestMemberRequest(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	client := rsc.BackendClient.(*TestClient)
+
+	ts2 := tu.NewTestServer(http.StatusOK, "test", nil)
+	defer ts2.Close()
+	mc := newTestMergeMember("test-2", client, ts2)
+	mc.config.PathPrefix = "/member"
+
+	r.Method = http.MethodPost
+	mr := memberRequest(r, mc, []byte("trickster"))
+
+	if mr.URL.Host != mc.config.Host {
+		t.Errorf("expected %s got %s", mc.config.Host, mr.URL.Host)
+	}
+	if !strings.HasPrefix(mr.URL.Path, "/member/") {
+		t.Errorf("expected path prefix %s got %s", "/member/", mr.URL.Path)
+	}
+	b, _ := ioutil.ReadAll(mr.Body)
+	if string(b) != "trickster" {
+		t.Errorf("expected %s got %s", "trickster", string(b))
+	}
+	mrsc := request.GetResources(mr)
+	if mrsc.BackendOptions != mc.config {
+		t.Error("expected member backend options")
+	}
+	if mrsc.BackendClient.(backends.Client).Name() != "test-2" {
+		t.Errorf("expected %s got %s", "test-2", mrsc.BackendClient.(backends.Client).Name())
+	}
+}
@@ -43,8 +43,15 @@ type CachingPolicy struct {
 	HasIfUnmodifiedSince bool `msg:"-"`
 	HasIfNoneMatch       bool `msg:"-"`
 	IfNoneMatchResult    bool `msg:"-"`
+	HasIfRange           bool `msg:"-"`
 
 	FreshnessLifetime int `msg:"freshness_lifetime"`
+	// StaleWhileRevalidate is the number of seconds after the object expires that it may be
+	// served while it is revalidated in the background (RFC 5861)
+	StaleWhileRevalidate int `msg:"stale_while_revalidate"`
+	// StaleIfError is the number of seconds after the object expires that it may be
+	// served when the upstream responds with an error (RFC 5861)
+	StaleIfError int `msg:"stale_if_error"`
 
 	LastModified time.Time `msg:"last_modified"`
 	Expires      time.Time `msg:"expires"`
@@ -56,6 +63,8 @@ type CachingPolicy struct {
 	IfNoneMatchValue      string    `msg:"-"`
 	IfModifiedSinceTime   time.Time `msg:"-"`
 	IfUnmodifiedSinceTime time.Time `msg:"-"`
+	IfRangeETag           string    `msg:"-"`
+	IfRangeTime           time.Time `msg:"-"`
 }
 
 // Clone returns an exact copy of the Caching Policy
@@ -65,6 +74,8 @@ func (cp *CachingPolicy) Clone() *CachingPolicy {
 		NoCache:               cp.NoCache,
 		NoTransform:           cp.NoTransform,
 		FreshnessLifetime:     cp.FreshnessLifetime,
+		StaleWhileRevalidate:  cp.StaleWhileRevalidate,
+		StaleIfError:          cp.StaleIfError,
 		CanRevalidate:         cp.CanRevalidate,
 		MustRevalidate:        cp.MustRevalidate,
 		LastModified:          cp.LastModified,
@@ -82,6 +93,9 @@ func (cp *CachingPolicy) Clone() *CachingPolicy {
 		HasIfUnmodifiedSince:  cp.HasIfUnmodifiedSince,
 		HasIfNoneMatch:        cp.HasIfNoneMatch,
 		IfNoneMatchResult:     cp.IfNoneMatchResult,
+		HasIfRange:            cp.HasIfRange,
+		IfRangeETag:           cp.IfRangeETag,
+		IfRangeTime:           cp.IfRangeTime,
 	}
 }
 
@@ -98,6 +112,9 @@ func (cp *CachingPolicy) ResetClientConditionals() {
 	cp.HasIfUnmodifiedSince = false
 	cp.HasIfNoneMatch = false
 	cp.IfNoneMatchResult = false
+	cp.HasIfRange = false
+	cp.IfRangeETag = ""
+	cp.IfRangeTime = time.Time{}
 }
 
 // Merge merges the source CachingPolicy into the subject CachingPolicy
@@ -116,6 +133,8 @@ func (cp *CachingPolicy) Merge(src *CachingPolicy) {
 
 	cp.IsFresh = src.IsFresh
 	cp.FreshnessLifetime = src.FreshnessLifetime
+	cp.StaleWhileRevalidate = src.StaleWhileRevalidate
+	cp.StaleIfError = src.StaleIfError
 	cp.CanRevalidate = src.CanRevalidate
 	cp.MustRevalidate = src.MustRevalidate
 	cp.LastModified = src.LastModified
@@ -135,6 +154,14 @@ func (cp *CachingPolicy) TTL(multiplier float64, max time.Duration) time.Duratio
 	if cp.CanRevalidate {
 		ttl *= time.Duration(multiplier)
 	}
+	// retain the object in cache for as long as it may be served stale
+	stale := cp.StaleWhileRevalidate
+	if cp.StaleIfError > stale {
+		stale = cp.StaleIfError
+	}
+	if st := time.Duration(cp.FreshnessLifetime+stale) * time.Second; st > ttl {
+		ttl = st
+	}
 	if ttl > max {
 		ttl = max
 	}
@@ -313,8 +340,46 @@ func (cp *CachingPolicy) parseCacheControlDirectives(directives string) {
 		if d == headers.ValueNoTransform {
 			cp.NoTransform = true
 		}
+		if d == headers.ValueStaleWhileRevalidate && dsub != "" {
+			if secs, err := strconv.Atoi(dsub); err == nil && secs > 0 {
+				cp.StaleWhileRevalidate = secs
+			}
+		}
+		if d == headers.ValueStaleIfError && dsub != "" {
+			if secs, err := strconv.Atoi(dsub); err == nil && secs > 0 {
+				cp.StaleIfError = secs
+			}
+		}
+	}
+
+}
+
+// SetStaleDefaults applies the provided stale-while-revalidate and stale-if-error windows
+// to a cacheable response policy that does not include the corresponding directives
+func (cp *CachingPolicy) SetStaleDefaults(staleWhileRevalidate, staleIfError time.Duration) {
+	if cp.NoCache || cp.IsNegativeCache {
+		return
 	}
+	if cp.StaleWhileRevalidate == 0 {
+		cp.StaleWhileRevalidate = int(staleWhileRevalidate.Seconds())
+	}
+	if cp.StaleIfError == 0 {
+		cp.StaleIfError = int(staleIfError.Seconds())
+	}
+}
 
+// CanServeStaleWhileRevalidate returns true if the expired object may be served while
+// it is revalidated in the background, as of the provided time
+func (cp *CachingPolicy) CanServeStaleWhileRevalidate(now time.Time) bool {
+	return cp.StaleWhileRevalidate > 0 && !cp.NoCache &&
+		now.Before(cp.LocalDate.Add(time.Duration(cp.FreshnessLifetime+cp.StaleWhileRevalidate)*time.Second))
+}
+
+// CanServeStaleIfError returns true if the expired object may be served in place of
+// an upstream error response, as of the provided time
+func (cp *CachingPolicy) CanServeStaleIfError(now time.Time) bool {
+	return cp.StaleIfError > 0 && !cp.NoCache &&
+		now.Before(cp.LocalDate.Add(time.Duration(cp.FreshnessLifetime+cp.StaleIfError)*time.Second))
 }
 
 func hasPragmaNoCache(h http.Header) bool {
@@ -358,6 +423,15 @@ func GetRequestCachingPolicy(h http.Header) *CachingPolicy {
 		cp.IfNoneMatchValue = v
 	}
 
+	// If-Range is either an HTTP date or an entity tag
+	if v := h.Get(headers.NameIfRange); v != "" {
+		if date, err := time.Parse(time.RFC1123, v); err == nil {
+			cp.IfRangeTime = date
+		} else {
+			cp.IfRangeETag = v
+		}
+	}
+
 	return cp
 }
 
@@ -390,6 +464,27 @@ func (cp *CachingPolicy) ParseClientConditionals() {
 	cp.HasIfModifiedSince = !cp.IfModifiedSinceTime.IsZero()
 	cp.HasIfUnmodifiedSince = !cp.IfUnmodifiedSinceTime.IsZero()
 	cp.IsClientConditional = cp.HasIfNoneMatch || cp.HasIfModifiedSince || cp.HasIfUnmodifiedSince
+	// If-Range only determines whether the requested ranges or the full object are served,
+	// so it is tracked separately from the conditionals that can result in a 304
+	cp.HasIfRange = cp.IfRangeETag != "" || !cp.IfRangeTime.IsZero()
+}
+
+// IfRangeMatches returns true if the request has no If-Range condition, or if the If-Range
+// validator matches the subject policy's object, meaning the requested ranges may be served.
+// Otherwise, the full object should be served.
+func (cp *CachingPolicy) IfRangeMatches() bool {
+	return cp.ifRangeMatches(cp.ETag, cp.LastModified)
+}
+
+func (cp *CachingPolicy) ifRangeMatches(etag string, lastModified time.Time) bool {
+	if !cp.HasIfRange {
+		return true
+	}
+	if !cp.IfRangeTime.IsZero() {
+		return !lastModified.IsZero() && lastModified.Equal(cp.IfRangeTime)
+	}
+	// If-Range requires a strong comparison, so weak entity tags never match
+	return etag != "" && !strings.HasPrefix(etag, "W/") && etag == cp.IfRangeETag
 }
 
 // CheckIfNoneMatch determines if the provided match value satisfies an "If-None-Match"
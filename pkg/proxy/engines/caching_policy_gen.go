@@ -98,6 +98,16 @@ func (z *CachingPolicy) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "stale_while_revalidate":
+			z.StaleWhileRevalidate, err = dc.ReadInt()
+			if err != nil {
+				return
+			}
+		case "stale_if_error":
+			z.StaleIfError, err = dc.ReadInt()
+			if err != nil {
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -110,9 +120,9 @@ func (z *CachingPolicy) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *CachingPolicy) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 12
+	// map header, size 14
 	// write "is_fresh"
-	err = en.Append(0x8c, 0xa8, 0x69, 0x73, 0x5f, 0x66, 0x72, 0x65, 0x73, 0x68)
+	err = en.Append(0x8e, 0xa8, 0x69, 0x73, 0x5f, 0x66, 0x72, 0x65, 0x73, 0x68)
 	if err != nil {
 		return
 	}
@@ -219,15 +229,33 @@ func (z *CachingPolicy) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "stale_while_revalidate"
+	err = en.Append(0xb6, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x5f, 0x77, 0x68, 0x69, 0x6c, 0x65, 0x5f, 0x72, 0x65, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.StaleWhileRevalidate)
+	if err != nil {
+		return
+	}
+	// write "stale_if_error"
+	err = en.Append(0xae, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x5f, 0x69, 0x66, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.StaleIfError)
+	if err != nil {
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *CachingPolicy) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 12
+	// map header, size 14
 	// string "is_fresh"
-	o = append(o, 0x8c, 0xa8, 0x69, 0x73, 0x5f, 0x66, 0x72, 0x65, 0x73, 0x68)
+	o = append(o, 0x8e, 0xa8, 0x69, 0x73, 0x5f, 0x66, 0x72, 0x65, 0x73, 0x68)
 	o = msgp.AppendBool(o, z.IsFresh)
 	// string "nocache"
 	o = append(o, 0xa7, 0x6e, 0x6f, 0x63, 0x61, 0x63, 0x68, 0x65)
@@ -262,6 +290,12 @@ func (z *CachingPolicy) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "is_negative_cache"
 	o = append(o, 0xb1, 0x69, 0x73, 0x5f, 0x6e, 0x65, 0x67, 0x61, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x63, 0x61, 0x63, 0x68, 0x65)
 	o = msgp.AppendBool(o, z.IsNegativeCache)
+	// string "stale_while_revalidate"
+	o = append(o, 0xb6, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x5f, 0x77, 0x68, 0x69, 0x6c, 0x65, 0x5f, 0x72, 0x65, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65)
+	o = msgp.AppendInt(o, z.StaleWhileRevalidate)
+	// string "stale_if_error"
+	o = append(o, 0xae, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x5f, 0x69, 0x66, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	o = msgp.AppendInt(o, z.StaleIfError)
 	return
 }
 
@@ -341,6 +375,16 @@ func (z *CachingPolicy) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "stale_while_revalidate":
+			z.StaleWhileRevalidate, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				return
+			}
+		case "stale_if_error":
+			z.StaleIfError, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -354,6 +398,6 @@ func (z *CachingPolicy) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *CachingPolicy) Msgsize() (s int) {
-	s = 1 + 9 + msgp.BoolSize + 8 + msgp.BoolSize + 12 + msgp.BoolSize + 19 + msgp.IntSize + 15 + msgp.BoolSize + 16 + msgp.BoolSize + 14 + msgp.TimeSize + 8 + msgp.TimeSize + 5 + msgp.TimeSize + 11 + msgp.TimeSize + 5 + msgp.StringPrefixSize + len(z.ETag) + 18 + msgp.BoolSize
+	s = 1 + 9 + msgp.BoolSize + 8 + msgp.BoolSize + 12 + msgp.BoolSize + 19 + msgp.IntSize + 15 + msgp.BoolSize + 16 + msgp.BoolSize + 14 + msgp.TimeSize + 8 + msgp.TimeSize + 5 + msgp.TimeSize + 11 + msgp.TimeSize + 5 + msgp.StringPrefixSize + len(z.ETag) + 18 + msgp.BoolSize + 23 + msgp.IntSize + 15 + msgp.IntSize
 	return
 }
@@ -314,3 +314,109 @@ func TestCheckIfNoneMatch(t *testing.T) {
 	}
 
 }
+
+func TestGetResponseCachingPolicyStale(t *testing.T) {
+
+	h := http.Header{headers.NameCacheControl: []string{
+		"max-age=60, stale-while-revalidate=30, stale-if-error=600"}}
+	p := GetResponseCachingPolicy(200, nil, h)
+
+	if p.StaleWhileRevalidate != 30 {
+		t.Errorf("expected %d got %d", 30, p.StaleWhileRevalidate)
+	}
+
+	if p.StaleIfError != 600 {
+		t.Errorf("expected %d got %d", 600, p.StaleIfError)
+	}
+
+	// the object should be retained for the largest of the stale windows
+	if ttl := p.TTL(1, time.Hour); ttl != 660*time.Second {
+		t.Errorf("expected %s got %s", 660*time.Second, ttl)
+	}
+
+	// the upstream's directives should take precedence over the defaults
+	p.SetStaleDefaults(time.Second, time.Second)
+	if p.StaleWhileRevalidate != 30 || p.StaleIfError != 600 {
+		t.Errorf("unexpected stale windows %d, %d", p.StaleWhileRevalidate, p.StaleIfError)
+	}
+
+	h = http.Header{headers.NameCacheControl: []string{"max-age=60, stale-while-revalidate=x"}}
+	p = GetResponseCachingPolicy(200, nil, h)
+	if p.StaleWhileRevalidate != 0 {
+		t.Errorf("expected %d got %d", 0, p.StaleWhileRevalidate)
+	}
+
+	p.SetStaleDefaults(10*time.Second, 20*time.Second)
+	if p.StaleWhileRevalidate != 10 || p.StaleIfError != 20 {
+		t.Errorf("unexpected stale windows %d, %d", p.StaleWhileRevalidate, p.StaleIfError)
+	}
+
+	p = &CachingPolicy{NoCache: true}
+	p.SetStaleDefaults(10*time.Second, 20*time.Second)
+	if p.StaleWhileRevalidate != 0 || p.StaleIfError != 0 {
+		t.Errorf("unexpected stale windows %d, %d", p.StaleWhileRevalidate, p.StaleIfError)
+	}
+
+}
+
+func TestCanServeStale(t *testing.T) {
+
+	now := time.Now()
+	p := &CachingPolicy{LocalDate: now.Add(-90 * time.Second), FreshnessLifetime: 60}
+
+	if p.CanServeStaleWhileRevalidate(now) || p.CanServeStaleIfError(now) {
+		t.Error("expected false")
+	}
+
+	p.StaleWhileRevalidate = 60
+	p.StaleIfError = 20
+	if !p.CanServeStaleWhileRevalidate(now) {
+		t.Error("expected true")
+	}
+	if p.CanServeStaleIfError(now) {
+		t.Error("expected false")
+	}
+
+	p.StaleIfError = 40
+	if !p.CanServeStaleIfError(now) {
+		t.Error("expected true")
+	}
+
+}
+
+func TestIfRangeMatches(t *testing.T) {
+
+	lm := time.Unix(1577836800, 0).UTC()
+
+	tests := []struct {
+		ifRange      string
+		etag         string
+		lastModified time.Time
+		expected     bool
+	}{
+		{"", "", time.Time{}, true},                               // 0 - no If-Range
+		{`"abc"`, `"abc"`, time.Time{}, true},                     // 1 - matching strong etag
+		{`"abc"`, `"def"`, time.Time{}, false},                    // 2 - mismatched etag
+		{`W/"abc"`, `W/"abc"`, time.Time{}, false},                // 3 - weak etag
+		{`"abc"`, "", lm, false},                                  // 4 - no etag
+		{lm.Format(time.RFC1123), "", lm, true},                   // 5 - matching date
+		{lm.Format(time.RFC1123), "", lm.Add(time.Second), false}, // 6 - modified since
+		{lm.Format(time.RFC1123), `"abc"`, time.Time{}, false},    // 7 - no last modified
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := http.Header{}
+			if test.ifRange != "" {
+				h.Set(headers.NameIfRange, test.ifRange)
+			}
+			cp := GetRequestCachingPolicy(h)
+			cp.ParseClientConditionals()
+			cp.ETag = test.etag
+			cp.LastModified = test.lastModified
+			if cp.IfRangeMatches() != test.expected {
+				t.Errorf("expected %t got %t", test.expected, !test.expected)
+			}
+		})
+	}
+}
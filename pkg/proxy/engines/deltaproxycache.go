@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/tricksterproxy/trickster/pkg/backends"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	tc "github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/cache/evictionmethods"
 	"github.com/tricksterproxy/trickster/pkg/cache/status"
@@ -33,6 +34,7 @@ import (
 	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
 	tpe "github.com/tricksterproxy/trickster/pkg/proxy/errors"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	tspan "github.com/tricksterproxy/trickster/pkg/tracing/span"
@@ -67,9 +69,11 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 
 	trq, rlo, canOPC, err := client.ParseTimeRangeQuery(r)
 	if err != nil {
-		if canOPC {
+		if ttl := nonTimeseriesTTL(oc, pc); canOPC && ttl > 0 {
 			tl.Debug(rsc.Logger, "could not parse time range query, using object proxy cache", tl.Pairs{"error": err.Error()})
-			rsc.AlternateCacheTTL = time.Second * 30 // TODO: make configurable
+			rsc.AlternateCacheTTL = ttl
+			// trq, when provided, carries the normalized query for deriving the cache key
+			rsc.TimeRangeQuery = trq
 			ObjectProxyCacheRequest(w, r)
 			return
 		}
@@ -94,9 +98,8 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 
 	now := time.Now()
 
-	OldestRetainedTimestamp := time.Time{}
+	OldestRetainedTimestamp := oldestRetainedTimestamp(oc, trq.Step, now)
 	if oc.TimeseriesEvictionMethod == evictionmethods.EvictionMethodOldest {
-		OldestRetainedTimestamp = now.Truncate(trq.Step).Add(-(trq.Step * oc.TimeseriesRetention))
 		if trq.Extent.End.Before(OldestRetainedTimestamp) {
 			tl.Debug(pr.Logger, "timerange end is too early to consider caching",
 				tl.Pairs{"oldestRetainedTimestamp": OldestRetainedTimestamp,
@@ -114,7 +117,8 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 	}
 
 	client.SetExtent(pr.upstreamRequest, trq, &trq.Extent)
-	key := oc.CacheKeyPrefix + ".dpc." + pr.DeriveCacheKey(trq.TemplateURL, "")
+	key := cacheKeyPrefix(r, oc) + ".dpc." + pr.DeriveCacheKey(trq.TemplateURL, "")
+	mg := timeseriesMergeGroup(r)
 	pr.cacheLock, _ = locker.RAcquire(key)
 
 	// this is used to determine if Fast Forward should be activated for this request
@@ -125,6 +129,7 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 	normalizedNow.NormalizeExtent()
 
 	var cts timeseries.Timeseries
+	cw := chunkWidth(oc, trq.Step)
 	var doc *HTTPDocument
 	var elapsed time.Duration
 
@@ -149,6 +154,14 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 		}
 	} else {
 		doc, cacheStatus, _, err = QueryCache(ctx, cache, key, nil)
+		if cw > 0 && err == nil && cacheStatus == status.LookupStatusHit {
+			// with chunked storage, the document holds only the response metadata,
+			// and the timeseries is loaded from the chunks overlapping the request
+			cts, err = queryTimeseriesChunks(ctx, cache, key, trq, cw, OldestRetainedTimestamp, modeler)
+			if err == tc.ErrKNF {
+				cacheStatus = status.LookupStatusKeyMiss
+			}
+		}
 		if cacheStatus == status.LookupStatusKeyMiss && err == tc.ErrKNF {
 			cts, doc, elapsed, err = fetchTimeseries(pr, trq, client, modeler)
 			if err != nil {
@@ -163,7 +176,8 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 			// Load the Cached Timeseries
 			if doc == nil {
 				err = tpe.ErrEmptyDocumentBody
-			} else {
+			} else if cts == nil {
+				// when chunked storage is used, cts has already been loaded from the chunks
 				if cc.Provider == "memory" {
 					cts = doc.timeseries
 				} else {
@@ -236,7 +250,12 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 	var writeLock locks.NamedLock
 
 	if cacheStatus == status.LookupStatusHit {
-		// In a cache hit, nothing changes so we just release the reader lock
+		// In a cache hit, the timeseries is unchanged, so its use is recorded separately
+		// for LRU eviction, and we just release the reader lock
+		if oc.TimeseriesEvictionMethod == evictionmethods.EvictionMethodLRU {
+			go recordLastUsed(cache, key, cts.Extents().Clone(), trq.Extent, trq.Step,
+				oc.TimeseriesTTL, now)
+		}
 		pr.cacheLock.RRelease()
 	} else {
 		// in this case, it's not a cache hit, so something is _likely_ going to be cached now.
@@ -288,6 +307,11 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 		}
 	}
 
+	// gaps larger than the backend's maximum extent size are split into several upstream requests
+	if maxSteps := maxExtentSteps(oc, trq.Step); maxSteps > 0 {
+		missRanges = missRanges.Split(trq.Step, maxSteps)
+	}
+
 	dpStatus := tl.Pairs{
 		"cacheKey":    key,
 		"cacheStatus": cacheStatus,
@@ -303,6 +327,8 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 	wg := sync.WaitGroup{}
 	appendLock := sync.Mutex{}
 	var uncachedValueCount int64
+	var failed *HTTPDocument
+	limiter := newUpstreamLimiter(oc.MaxUpstreamConcurrency)
 
 	// iterate each time range that the client needs and fetch from the upstream origin
 	for i := range missRanges {
@@ -310,8 +336,12 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 		// This fetches the gaps from the origin and adds their datasets to the merge list
 		go func(e *timeseries.Extent, rq *proxyRequest) {
 			defer wg.Done()
+			if limiter != nil {
+				limiter <- struct{}{}
+				defer func() { <-limiter }()
+			}
 			rq.upstreamRequest = rq.WithContext(tctx.WithResources(
-				trace.ContextWithSpan(context.Background(), span),
+				trace.ContextWithSpan(withBackendGroup(context.Background(), r), span),
 				request.NewResources(oc, pc, cc, cache, client, rsc.Tracer, pr.Logger)))
 			client.SetExtent(rq.upstreamRequest, trq, e)
 
@@ -321,14 +351,24 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 				defer spanMR.End()
 			}
 
-			body, resp, _ := rq.Fetch()
-			if resp.StatusCode == http.StatusOK && len(body) > 0 {
-				nts, err := modeler.WireUnmarshaler(body, trq)
-				if err != nil {
-					tl.Error(pr.Logger, "proxy object unmarshaling failed",
-						tl.Pairs{"body": string(body)})
-					return
+			var nts timeseries.Timeseries
+			var resp *http.Response
+			var body []byte
+			if mg != nil {
+				nts, resp, _ = mg.fetch(rq.upstreamRequest, trq, modeler)
+			} else {
+				body, resp, _ = rq.Fetch()
+				if resp.StatusCode == http.StatusOK && len(body) > 0 {
+					var err error
+					nts, err = modeler.WireUnmarshaler(body, trq)
+					if err != nil {
+						tl.Error(pr.Logger, "proxy object unmarshaling failed",
+							tl.Pairs{"body": string(body)})
+						return
+					}
 				}
+			}
+			if nts != nil {
 				doc.headerLock.Lock()
 				headers.Merge(doc.Headers, resp.Header)
 				doc.headerLock.Unlock()
@@ -338,6 +378,12 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 				appendLock.Lock()
 				mts = append(mts, nts)
 				appendLock.Unlock()
+			} else if resp != nil && resp.StatusCode >= http.StatusInternalServerError {
+				appendLock.Lock()
+				if failed == nil {
+					failed = &HTTPDocument{StatusCode: resp.StatusCode, Headers: resp.Header, Body: body}
+				}
+				appendLock.Unlock()
 			}
 		}(&missRanges[i], pr.Clone())
 	}
@@ -381,6 +427,22 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 
 	wg.Wait()
 
+	// when a delta could not be fetched from the origin, the cached extents are served in
+	// place of the error if they are within the backend's stale-if-error window
+	if failed != nil {
+		if !canServeStaleExtentsIfError(oc, cts, trq) {
+			if writeLock != nil {
+				writeLock.Release()
+			}
+			h := failed.SafeHeaderClone()
+			recordDPCResult(r, status.LookupStatusProxyError, failed.StatusCode,
+				r.URL.Path, ffStatus, time.Since(now).Seconds(), missRanges, h)
+			Respond(w, failed.StatusCode, h, bytes.NewReader(failed.Body))
+			return
+		}
+		cacheStatus = status.LookupStatusStaleHit
+	}
+
 	// Merge the new delta timeseries into the cached timeseries
 	if len(mts) > 0 {
 		// on phit, elapsed records the time spent waiting for all upstream requests to complete
@@ -404,6 +466,7 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 			// Backfill Tolerance before storing to cache
 			switch oc.TimeseriesEvictionMethod {
 			case evictionmethods.EvictionMethodLRU:
+				applyLastUsed(cache, key, cts, trq.Step)
 				cts.CropToSize(oc.TimeseriesRetentionFactor, bf.End, trq.Extent)
 			default:
 				cts.CropToRange(timeseries.Extent{End: bf.End, Start: OldestRetainedTimestamp})
@@ -411,6 +474,25 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 			// Don't cache datasets with empty extents
 			// (everything was cropped so there is nothing to cache)
 			if len(cts.Extents()) > 0 {
+				if cw > 0 {
+					// only the chunks overlapping newly-fetched data are written
+					changed := missRanges
+					if len(changed) == 0 {
+						changed = timeseries.ExtentList{trq.Extent}
+					}
+					if err := writeTimeseriesChunks(ctx, cache, key, doc, cts, changed, cw, trq.Step,
+						modeler, oc, pr.Logger); err != nil {
+						tl.Error(pr.Logger, "error writing object to cache",
+							tl.Pairs{
+								"backendName": oc.Name,
+								"cacheName":   cache.Configuration().Name,
+								"cacheKey":    key,
+								"detail":      err.Error(),
+							},
+						)
+					}
+					return
+				}
 				if cc.Provider == "memory" {
 					doc.timeseries = cts
 				} else {
@@ -439,15 +521,16 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 	}
 
 	cachedValueCount := rts.ValueCount() - uncachedValueCount
+	tenant, _ := oc.Tenant(r.Header)
 
 	if uncachedValueCount > 0 {
 		metrics.ProxyRequestElements.WithLabelValues(oc.Name,
-			oc.Provider, "uncached", r.URL.Path).Add(float64(uncachedValueCount))
+			oc.Provider, "uncached", r.URL.Path, tenant).Add(float64(uncachedValueCount))
 	}
 
 	if cachedValueCount > 0 {
 		metrics.ProxyRequestElements.WithLabelValues(oc.Name,
-			oc.Provider, "cached", r.URL.Path).Add(float64(cachedValueCount))
+			oc.Provider, "cached", r.URL.Path, tenant).Add(float64(cachedValueCount))
 	}
 
 	// Merge Fast Forward data if present. This must be done after the Downstream Crop since
@@ -487,12 +570,34 @@ func fetchTimeseries(pr *proxyRequest, trq *timeseries.TimeRangeQuery,
 		handlerName = pc.HandlerName
 	}
 
+	// when the requested extent exceeds the backend's maximum extent size,
+	// it is fetched in several smaller upstream requests whose results are merged
+	if maxSteps := maxExtentSteps(oc, trq.Step); maxSteps > 0 {
+		if el := (timeseries.ExtentList{trq.Extent}).Split(trq.Step, maxSteps); len(el) > 1 {
+			return fetchTimeseriesExtents(pr, trq, el, client, modeler)
+		}
+	}
+
 	ctx, span := tspan.NewChildSpan(pr.upstreamRequest.Context(), rsc.Tracer, "FetchTimeSeries")
 	if span != nil {
 		defer span.End()
 	}
 	pr.upstreamRequest = pr.upstreamRequest.WithContext(ctx)
 
+	// when the request has a merge group, the timeseries is fetched from all of its members
+	if mg := timeseriesMergeGroup(pr.Request); mg != nil {
+		ts, resp, elapsed := mg.fetch(pr.upstreamRequest, trq, modeler)
+		d := &HTTPDocument{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header,
+		}
+		if ts == nil {
+			return nil, d, time.Duration(0), tpe.ErrUnexpectedUpstreamResponse
+		}
+		return ts, d, elapsed, nil
+	}
+
 	start := time.Now()
 	_, resp, _ := PrepareFetchReader(pr.upstreamRequest)
 
@@ -540,8 +645,167 @@ func fetchTimeseries(pr *proxyRequest, trq *timeseries.TimeRangeQuery,
 	return ts, d, elapsed, nil
 }
 
+// fetchTimeseriesExtents fetches each of the provided extents of the time range query in a
+// separate upstream request, limited to the backend's maximum upstream concurrency, and
+// merges the results into a single timeseries
+func fetchTimeseriesExtents(pr *proxyRequest, trq *timeseries.TimeRangeQuery, el timeseries.ExtentList,
+	client backends.TimeseriesClient, modeler *timeseries.Modeler) (timeseries.Timeseries, *HTTPDocument, time.Duration, error) {
+
+	oc := request.GetResources(pr.Request).BackendOptions
+	start := time.Now()
+
+	tss := make([]timeseries.Timeseries, len(el))
+	docs := make([]*HTTPDocument, len(el))
+	errs := make([]error, len(el))
+	limiter := newUpstreamLimiter(oc.MaxUpstreamConcurrency)
+
+	wg := sync.WaitGroup{}
+	for i := range el {
+		wg.Add(1)
+		go func(i int, rq *proxyRequest) {
+			defer wg.Done()
+			if limiter != nil {
+				limiter <- struct{}{}
+				defer func() { <-limiter }()
+			}
+			etrq := trq.Clone()
+			etrq.Extent = el[i]
+			client.SetExtent(rq.upstreamRequest, etrq, &el[i])
+			tss[i], docs[i], _, errs[i] = fetchTimeseries(rq, etrq, client, modeler)
+		}(i, pr.Clone())
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil {
+			return nil, docs[i], time.Duration(0), errs[i]
+		}
+	}
+
+	d := docs[0]
+	for i := 1; i < len(docs); i++ {
+		headers.Merge(d.Headers, docs[i].Headers)
+	}
+
+	ts := tss[0]
+	ts.Merge(true, tss[1:]...)
+	ts.SetTimeRangeQuery(trq)
+
+	return ts, d, time.Since(start), nil
+}
+
+// maxExtentSteps returns the maximum number of timestamps at the provided step that the
+// backend permits in a single upstream request, or 0 if unlimited
+func maxExtentSteps(oc *oo.Options, step time.Duration) int {
+	n := oc.MaxExtentSteps
+	if oc.MaxExtentDuration > 0 && step > 0 {
+		d := int(oc.MaxExtentDuration / step)
+		if d < 1 {
+			d = 1
+		}
+		if n == 0 || d < n {
+			n = d
+		}
+	}
+	return n
+}
+
+// newUpstreamLimiter returns a semaphore channel that limits the number of concurrent
+// upstream requests to the provided maximum, or nil if unlimited
+func newUpstreamLimiter(max int) chan struct{} {
+	if max < 1 {
+		return nil
+	}
+	return make(chan struct{}, max)
+}
+
+// CachedTimeseries returns the portion of the Delta Proxy Cache's timeseries for the provided
+// time range query request that falls within the request's normalized extent, along with the
+// parsed TimeRangeQuery, without making any upstream requests. The request must carry the
+// Resources of the backend's time range query path. tc.ErrKNF is returned when nothing is cached
+func CachedTimeseries(r *http.Request, modeler *timeseries.Modeler) (timeseries.Timeseries,
+	*timeseries.TimeRangeQuery, error) {
+
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.BackendOptions == nil || rsc.CacheClient == nil {
+		return nil, nil, tpe.ErrNotTimeRangeQuery
+	}
+	client, ok := rsc.BackendClient.(backends.TimeseriesClient)
+	if !ok {
+		return nil, nil, tpe.ErrNotTimeRangeQuery
+	}
+	trq, _, _, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	trq.NormalizeExtent()
+
+	oc := rsc.BackendOptions
+	cache := rsc.CacheClient
+	pr := newProxyRequest(r, nil)
+	client.SetExtent(pr.upstreamRequest, trq, &trq.Extent)
+	key := cacheKeyPrefix(r, oc) + ".dpc." + pr.DeriveCacheKey(trq.TemplateURL, "")
+
+	lock, _ := cache.Locker().RAcquire(key)
+	defer lock.RRelease()
+
+	doc, _, _, err := QueryCache(r.Context(), cache, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cts timeseries.Timeseries
+	if cw := chunkWidth(oc, trq.Step); cw > 0 {
+		cts, err = queryTimeseriesChunks(r.Context(), cache, key, trq, cw,
+			oldestRetainedTimestamp(oc, trq.Step, time.Now()), modeler)
+	} else if doc == nil {
+		err = tpe.ErrEmptyDocumentBody
+	} else if cache.Configuration().Provider == "memory" {
+		// the cached timeseries is shared, so it is only read while the lock is held
+		cts = doc.timeseries
+		if cts == nil {
+			err = tpe.ErrEmptyDocumentBody
+		}
+	} else {
+		cts, err = modeler.CacheUnmarshaler(doc.Body, trq)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return cts.CroppedClone(trq.Extent), trq, nil
+}
+
+// RecordCachedTimeseriesResult records the proxy metrics and sets the results header of a
+// response that the caller served from the timeseries returned by CachedTimeseries
+func RecordCachedTimeseriesResult(r *http.Request, httpStatus int, elapsed time.Duration,
+	header http.Header) {
+	recordDPCResult(r, status.LookupStatusHit, httpStatus, r.URL.Path, "", elapsed.Seconds(),
+		nil, header)
+}
+
+// oldestRetainedTimestamp returns the oldest timestamp that is retained in the cache for
+// timeseries of the provided step, or the zero time when the backend does not evict by age
+func oldestRetainedTimestamp(oc *oo.Options, step time.Duration, now time.Time) time.Time {
+	if oc.TimeseriesEvictionMethod != evictionmethods.EvictionMethodOldest {
+		return time.Time{}
+	}
+	return now.Truncate(step).Add(-(step * oc.TimeseriesRetention))
+}
+
 func recordDPCResult(r *http.Request, cacheStatus status.LookupStatus, httpStatus int, path,
 	ffStatus string, elapsed float64, needed []timeseries.Extent, header http.Header) {
 	recordResults(r, "DeltaProxyCache", cacheStatus, httpStatus, path, ffStatus, elapsed,
 		timeseries.ExtentList(needed), header)
 }
+
+// nonTimeseriesTTL returns the cache TTL for read-only queries that are not time range
+// queries. The path's setting takes precedence over the backend's, and is limited by MaxTTL
+func nonTimeseriesTTL(oc *oo.Options, pc *po.Options) time.Duration {
+	ttl := oc.NonTimeseriesTTL
+	if pc != nil && pc.HasNonTimeseriesTTL {
+		ttl = pc.NonTimeseriesTTL
+	}
+	if oc.MaxTTL > 0 && ttl > oc.MaxTTL {
+		ttl = oc.MaxTTL
+	}
+	return ttl
+}
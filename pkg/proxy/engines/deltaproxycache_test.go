@@ -21,14 +21,21 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	mockprom "github.com/tricksterproxy/mockster/pkg/mocks/prometheus"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	tpe "github.com/tricksterproxy/trickster/pkg/proxy/errors"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request"
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+	tst "github.com/tricksterproxy/trickster/pkg/util/testing/timeseries/model"
 )
 
 // test queries
@@ -1440,6 +1447,69 @@ func TestDeltaProxyCacheRequestBadGateway(t *testing.T) {
 
 }
 
+func TestDeltaProxyCacheRequestStaleIfError(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	oc := rsc.BackendOptions
+
+	oc.FastForwardDisable = true
+	// the failing query shares its cache key with the successful one
+	rsc.PathConfig.CacheKeyParams = []string{upStep}
+
+	step := time.Duration(300) * time.Second
+	end := time.Now().Add(-time.Duration(12) * time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(6) * time.Hour), End: end}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+
+	fetch := func(query string, expectedCode int, expectedStatus string) {
+		u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s",
+			int(step.Seconds()), extr.Start.Unix(), extr.End.Unix(), query)
+		r.URL = u
+		w = httptest.NewRecorder()
+		client.QueryRangeHandler(w, r)
+		resp := w.Result()
+		if err := testStatusCodeMatch(resp.StatusCode, expectedCode); err != nil {
+			t.Error(err)
+		}
+		err := testResultHeaderPartMatch(resp.Header, map[string]string{"status": expectedStatus})
+		if err != nil {
+			t.Error(err)
+		}
+		// Give time for the object to be written to cache in a separate goroutine from response
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	fetch(queryReturnsOKNoLatency, http.StatusOK, "kmiss")
+
+	// without a stale-if-error window, the upstream error for the delta is returned
+	extr.End = extr.End.Add(time.Hour)
+	fetch(queryReturnsBadGateway, http.StatusBadGateway, "proxy-error")
+
+	// a delta older than the window is not served from cache
+	oc.StaleIfError = time.Duration(30) * time.Minute
+	fetch(queryReturnsBadGateway, http.StatusBadGateway, "proxy-error")
+
+	// within the window, the cached extents are served in place of the error
+	oc.StaleIfError = time.Duration(2) * time.Hour
+	fetch(queryReturnsBadGateway, http.StatusOK, "stale-hit")
+	expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency,
+		extr.Start.Truncate(step), end.Truncate(step), step)
+	if err := testStringMatch(w.Body.String(), expected); err != nil {
+		t.Error(err)
+	}
+
+	// once the origin recovers, the delta is fetched
+	fetch(queryReturnsOKNoLatency, http.StatusOK, "phit")
+}
+
 func TestDeltaProxyCacheRequest_BackfillTolerance(t *testing.T) {
 
 	ts, w, r, rsc, err := setupTestHarnessDPC()
@@ -1586,3 +1656,364 @@ func TestDeltaProxyCacheRequestFFTTLBiggerThanStep(t *testing.T) {
 	}
 
 }
+
+func TestNonTimeseriesTTL(t *testing.T) {
+
+	oc := oo.New()
+	oc.NonTimeseriesTTL = 30 * time.Second
+	oc.MaxTTL = time.Minute
+
+	if ttl := nonTimeseriesTTL(oc, nil); ttl != 30*time.Second {
+		t.Errorf("expected %s got %s", 30*time.Second, ttl)
+	}
+
+	// the path setting overrides the backend setting, including a value of 0
+	pc := po.New()
+	pc.HasNonTimeseriesTTL = true
+	if ttl := nonTimeseriesTTL(oc, pc); ttl != 0 {
+		t.Errorf("expected %s got %s", time.Duration(0), ttl)
+	}
+
+	// the ttl is limited by MaxTTL
+	pc.NonTimeseriesTTL = time.Hour
+	if ttl := nonTimeseriesTTL(oc, pc); ttl != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, ttl)
+	}
+}
+
+func TestDeltaProxyCacheRequestMaxExtentSize(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	oc := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-maxextent"
+	client.InstantCacheKey = "test-instant-key-maxextent"
+
+	oc.FastForwardDisable = true
+	oc.MaxExtentSteps = 20
+	oc.MaxUpstreamConcurrency = 2
+
+	step := time.Duration(300) * time.Second
+
+	now := time.Now()
+	end := now.Add(-time.Duration(12) * time.Hour)
+
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(18) * time.Hour), End: end}
+	extn := timeseries.Extent{Start: normalizeTime(extr.Start, step), End: normalizeTime(extr.End, step)}
+
+	expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, extn.Start, extn.End, step)
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+
+	// the key miss should be fetched in several upstream requests and merged
+	client.QueryRangeHandler(w, r)
+	resp := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStringMatch(string(bodyBytes), expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStatusCodeMatch(resp.StatusCode, http.StatusOK)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": "kmiss"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the partial hit's gap should also be fetched in several upstream requests
+	phitStart := normalizeTime(extr.End.Add(step), step)
+	extr.End = extr.End.Add(time.Duration(6) * time.Hour)
+	extn.End = normalizeTime(extr.End, step)
+
+	fetched := timeseries.ExtentList{timeseries.Extent{Start: phitStart, End: extn.End}}.
+		Split(step, oc.MaxExtentSteps)
+	if len(fetched) < 2 {
+		t.Errorf("expected multiple extents got %d", len(fetched))
+	}
+	expectedFetched := "[" + strings.Replace(fetched.String(), ";", ",", -1) + "]"
+	expected, _, _ = mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, extn.Start, extn.End, step)
+
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+
+	r.URL = u
+
+	time.Sleep(time.Millisecond * 10)
+
+	w = httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	resp = w.Result()
+
+	bodyBytes, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStringMatch(string(bodyBytes), expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": "phit"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testResultHeaderPartMatch(resp.Header, map[string]string{"fetched": expectedFetched})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxExtentSteps(t *testing.T) {
+
+	step := time.Duration(60) * time.Second
+
+	tests := []struct {
+		maxSteps    int
+		maxDuration time.Duration
+		expected    int
+	}{
+		{0, 0, 0},
+		{100, 0, 100},
+		{0, time.Hour, 60},
+		{100, time.Hour, 60},
+		{30, time.Hour, 30},
+		{0, time.Second, 1},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			oc := &oo.Options{MaxExtentSteps: test.maxSteps, MaxExtentDuration: test.maxDuration}
+			if v := maxExtentSteps(oc, step); v != test.expected {
+				t.Errorf("expected %d got %d", test.expected, v)
+			}
+		})
+	}
+}
+
+func TestDeltaProxyCacheRequestChunks(t *testing.T) {
+
+	for _, provider := range []string{"memory", "test"} {
+		t.Run(provider, func(t *testing.T) {
+
+			ts, w, r, rsc, err := setupTestHarnessDPC()
+			if err != nil {
+				t.Error(err)
+			}
+			defer ts.Close()
+
+			client := rsc.BackendClient.(*TestClient)
+			oc := rsc.BackendOptions
+			rsc.CacheConfig.Provider = provider
+
+			client.RangeCacheKey = "test-range-key-chunks-" + provider
+			client.InstantCacheKey = "test-instant-key-chunks-" + provider
+
+			oc.FastForwardDisable = true
+			oc.TimeseriesChunkFactor = 24
+
+			step := time.Duration(300) * time.Second
+
+			now := time.Now()
+			end := now.Add(-time.Duration(12) * time.Hour)
+
+			extr := timeseries.Extent{Start: end.Add(-time.Duration(18) * time.Hour), End: end}
+			extn := timeseries.Extent{Start: normalizeTime(extr.Start, step), End: normalizeTime(extr.End, step)}
+
+			u := r.URL
+			u.Path = "/prometheus/api/v1/query_range"
+
+			fetch := func(extr, extn timeseries.Extent, expectedStatus string) {
+				expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, extn.Start, extn.End, step)
+				u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+					extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency,
+					client.RangeCacheKey, client.InstantCacheKey)
+				r.URL = u
+
+				w = httptest.NewRecorder()
+				client.QueryRangeHandler(w, r)
+				resp := w.Result()
+
+				bodyBytes, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				err = testStringMatch(string(bodyBytes), expected)
+				if err != nil {
+					t.Error(err)
+				}
+
+				err = testStatusCodeMatch(resp.StatusCode, http.StatusOK)
+				if err != nil {
+					t.Error(err)
+				}
+
+				err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": expectedStatus})
+				if err != nil {
+					t.Error(err)
+				}
+
+				// Give time for the chunks to be written to cache in a separate goroutine from response
+				time.Sleep(time.Millisecond * 10)
+			}
+
+			// the key miss is written to the cache as several chunks
+			fetch(extr, extn, "kmiss")
+
+			// a subset of the cached range is served from its chunks
+			fetch(timeseries.Extent{Start: extr.Start.Add(time.Hour), End: extr.End.Add(-time.Hour)},
+				timeseries.Extent{Start: extn.Start.Add(time.Hour), End: extn.End.Add(-time.Hour)}, "hit")
+
+			// extending the range fetches only the gap, and merges it with the cached chunks
+			extr.End = extr.End.Add(time.Duration(3) * time.Hour)
+			extn.End = normalizeTime(extr.End, step)
+			fetch(extr, extn, "phit")
+
+			// the extended range is now entirely cached
+			fetch(extr, extn, "hit")
+		})
+	}
+}
+
+func TestDeltaProxyCacheRequestChunksRetention(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	oc := rsc.BackendOptions
+	cc := rsc.CacheClient
+
+	client.RangeCacheKey = "test-range-key-chunks-retention"
+	client.InstantCacheKey = "test-instant-key-chunks-retention"
+
+	oc.FastForwardDisable = true
+	oc.TimeseriesChunkFactor = 24
+	oc.TimeseriesRetention = 1440
+
+	step := time.Duration(300) * time.Second
+	width := chunkWidth(oc, step)
+	end := time.Now().Add(-time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(6) * time.Hour), End: end}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency,
+		client.RangeCacheKey, client.InstantCacheKey)
+	r.URL = u
+
+	fetch := func(expectedStatus string) {
+		w = httptest.NewRecorder()
+		client.QueryRangeHandler(w, r)
+		err = testResultHeaderPartMatch(w.Result().Header, map[string]string{"status": expectedStatus})
+		if err != nil {
+			t.Error(err)
+		}
+		// Give time for the chunks to be written to cache in a separate goroutine from response
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	fetch("kmiss")
+	fetch("hit")
+
+	keys := CacheKeys(r)
+	if len(keys) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(keys))
+	}
+	first := chunkKey(keys[1], chunkExtents(timeseries.ExtentList{extr}, width, step)[0].Start)
+	if _, _, err := cc.Retrieve(first, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// once the retention period no longer covers the oldest chunks, they are removed
+	// from the cache and their data is no longer served
+	oc.TimeseriesRetention = 36
+	fetch("phit")
+	if _, _, err := cc.Retrieve(first, false); err != cache.ErrKNF {
+		t.Errorf("expected %v got %v", cache.ErrKNF, err)
+	}
+}
+
+func TestCachedTimeseries(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	oc := rsc.BackendOptions
+
+	oc.FastForwardDisable = true
+	step := time.Duration(300) * time.Second
+
+	end := time.Now().Add(-time.Duration(12) * time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(18) * time.Hour), End: end}
+	extn := timeseries.Extent{Start: extr.Start.Truncate(step), End: extr.End.Truncate(step)}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s",
+		int(step.Seconds()), extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency)
+	r.URL = client.BuildUpstreamURL(r)
+
+	_, _, err = CachedTimeseries(r, tst.Modeler())
+	if err != cache.ErrKNF {
+		t.Errorf("expected %v got %v", cache.ErrKNF, err)
+	}
+
+	client.QueryRangeHandler(w, r)
+	// Give time for the object to be written to cache in a separate goroutine from response
+	time.Sleep(time.Millisecond * 10)
+
+	// a single timestamp inside of the cached extent
+	ext := timeseries.Extent{Start: extn.Start.Add(step * 3), End: extn.Start.Add(step * 3)}
+	r.URL.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s",
+		int(step.Seconds()), ext.Start.Unix(), ext.End.Unix(), queryReturnsOKNoLatency)
+
+	cts, trq, err := CachedTimeseries(r, tst.Modeler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !trq.Extent.Start.Equal(ext.Start) || !trq.Extent.End.Equal(ext.End) {
+		t.Errorf("expected %s got %s", ext.String(), trq.Extent.String())
+	}
+	if d := cts.Extents().CalculateDeltas(trq.Extent, trq.Step); len(d) != 0 {
+		t.Errorf("expected cached extent to cover %s, got %s", ext.String(), cts.Extents().String())
+	}
+	if cts.TimestampCount() != 1 {
+		t.Errorf("expected %d got %d", 1, cts.TimestampCount())
+	}
+
+	rsc.BackendClient = nil
+	_, _, err = CachedTimeseries(r, tst.Modeler())
+	if err != tpe.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", tpe.ErrNotTimeRangeQuery, err)
+	}
+}
@@ -48,6 +48,10 @@ type HTTPDocument struct {
 	RangeParts byterange.MultipartByteRanges `msg:"-"`
 	// StoredRangeParts is a version of RangeParts that can be exported to MessagePack
 	StoredRangeParts map[string]*byterange.MultipartByteRange `msg:"range_parts"`
+	// VaryHeaders is the list of request header names in the response's Vary header.
+	// When stored under an object's primary cache key without a body, the document is
+	// an index identifying how to locate the variant that matches a given request.
+	VaryHeaders []string `msg:"vary"`
 
 	rangePartsLoaded bool
 	isFulfillment    bool
@@ -163,6 +163,23 @@ func (z *HTTPDocument) DecodeMsg(dc *msgp.Reader) (err error) {
 				}
 				z.StoredRangeParts[za0004] = za0005
 			}
+		case "vary":
+			var zb0005 uint32
+			zb0005, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.VaryHeaders) >= int(zb0005) {
+				z.VaryHeaders = (z.VaryHeaders)[:zb0005]
+			} else {
+				z.VaryHeaders = make([]string, zb0005)
+			}
+			for za0006 := range z.VaryHeaders {
+				z.VaryHeaders[za0006], err = dc.ReadString()
+				if err != nil {
+					return
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -175,9 +192,9 @@ func (z *HTTPDocument) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *HTTPDocument) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 9
+	// map header, size 10
 	// write "status_code"
-	err = en.Append(0x89, 0xab, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65)
+	err = en.Append(0x8a, 0xab, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65)
 	if err != nil {
 		return
 	}
@@ -297,15 +314,30 @@ func (z *HTTPDocument) EncodeMsg(en *msgp.Writer) (err error) {
 			}
 		}
 	}
+	// write "vary"
+	err = en.Append(0xa4, 0x76, 0x61, 0x72, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.VaryHeaders)))
+	if err != nil {
+		return
+	}
+	for za0006 := range z.VaryHeaders {
+		err = en.WriteString(z.VaryHeaders[za0006])
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *HTTPDocument) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 9
+	// map header, size 10
 	// string "status_code"
-	o = append(o, 0x89, 0xab, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65)
+	o = append(o, 0x8a, 0xab, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65)
 	o = msgp.AppendInt(o, z.StatusCode)
 	// string "status"
 	o = append(o, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
@@ -359,6 +391,12 @@ func (z *HTTPDocument) MarshalMsg(b []byte) (o []byte, err error) {
 			}
 		}
 	}
+	// string "vary"
+	o = append(o, 0xa4, 0x76, 0x61, 0x72, 0x79)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.VaryHeaders)))
+	for za0006 := range z.VaryHeaders {
+		o = msgp.AppendString(o, z.VaryHeaders[za0006])
+	}
 	return
 }
 
@@ -501,6 +539,23 @@ func (z *HTTPDocument) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				}
 				z.StoredRangeParts[za0004] = za0005
 			}
+		case "vary":
+			var zb0005 uint32
+			zb0005, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.VaryHeaders) >= int(zb0005) {
+				z.VaryHeaders = (z.VaryHeaders)[:zb0005]
+			} else {
+				z.VaryHeaders = make([]string, zb0005)
+			}
+			for za0006 := range z.VaryHeaders {
+				z.VaryHeaders[za0006], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					return
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -542,5 +597,9 @@ func (z *HTTPDocument) Msgsize() (s int) {
 			}
 		}
 	}
+	s += 5 + msgp.ArrayHeaderSize
+	for za0006 := range z.VaryHeaders {
+		s += msgp.StringPrefixSize + len(z.VaryHeaders[za0006])
+	}
 	return
 }
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/util/metrics"
+)
+
+// FirstResponseGroup is a group of backends serving identical data, such as read replicas
+// across zones. When a request's context includes a FirstResponseGroup, each upstream
+// request is sent to every member of the group concurrently, and the first response with
+// a 2xx status is used, while the remaining upstream requests are cancelled
+type FirstResponseGroup struct {
+	// Name identifies the group in metrics and logs
+	Name string
+	// CacheKeyPrefix is used in place of the serving backend's cache key prefix, so the
+	// winning response is cached under the same key regardless of the serving member
+	CacheKeyPrefix string
+	// Members is the list of backends to which upstream requests are sent
+	Members []backends.Client
+}
+
+type firstResponseResult struct {
+	member int
+	reader io.ReadCloser
+	resp   *http.Response
+	length int64
+	cancel context.CancelFunc
+}
+
+// cancelReadCloser cancels the context of its upstream request when closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func (g *FirstResponseGroup) cacheKeyPrefix() string {
+	return g.CacheKeyPrefix
+}
+
+// firstResponseGroup returns the FirstResponseGroup for the request, if any
+func firstResponseGroup(r *http.Request) *FirstResponseGroup {
+	if g, ok := tctx.BackendGroup(r.Context()).(*FirstResponseGroup); ok &&
+		g != nil && len(g.Members) > 0 {
+		return g
+	}
+	return nil
+}
+
+// fetch sends the upstream request to each member of the group concurrently, and returns
+// the first response with a 2xx status. When no member responds with a 2xx status, the
+// last response received is returned
+func (g *FirstResponseGroup) fetch(r *http.Request) (io.ReadCloser, *http.Response, int64) {
+
+	rsc := request.GetResources(r)
+
+	// the request body is read once so that it can be provided to each member's request
+	body := readRequestBody(r)
+
+	results := make(chan *firstResponseResult, len(g.Members))
+	cancels := make([]context.CancelFunc, len(g.Members))
+	for i, mc := range g.Members {
+		ctx, cancel := context.WithCancel(r.Context())
+		cancels[i] = cancel
+		mr := memberRequest(r.WithContext(ctx), mc, body)
+		go func(i int, mr *http.Request, cancel context.CancelFunc) {
+			reader, resp, length := prepareFetchReader(mr)
+			results <- &firstResponseResult{member: i, reader: reader, resp: resp,
+				length: length, cancel: cancel}
+		}(i, mr, cancel)
+	}
+
+	var last *firstResponseResult
+	for n := 0; n < len(g.Members); n++ {
+		res := <-results
+		if res.resp.StatusCode >= 200 && res.resp.StatusCode < 300 {
+			// the remaining requests are cancelled and their responses discarded
+			for i, cancel := range cancels {
+				if i != res.member {
+					cancel()
+				}
+			}
+			go discardFirstResponseResults(results, len(g.Members)-n-1)
+			metrics.ProxyFirstResponseWins.WithLabelValues(g.Name,
+				g.Members[res.member].Name()).Inc()
+			if res.reader == nil {
+				res.cancel()
+				return nil, res.resp, res.length
+			}
+			return &cancelReadCloser{ReadCloser: res.reader, cancel: res.cancel},
+				res.resp, res.length
+		}
+		tl.Debug(rsc.Logger, "first response group member request failed",
+			tl.Pairs{"backendName": g.Members[res.member].Name(),
+				"statusCode": res.resp.StatusCode})
+		if last != nil {
+			closeFirstResponseResult(last)
+		}
+		last = res
+	}
+
+	if last.reader == nil {
+		last.cancel()
+		return nil, last.resp, last.length
+	}
+	return &cancelReadCloser{ReadCloser: last.reader, cancel: last.cancel},
+		last.resp, last.length
+}
+
+func discardFirstResponseResults(results chan *firstResponseResult, n int) {
+	for i := 0; i < n; i++ {
+		closeFirstResponseResult(<-results)
+	}
+}
+
+func closeFirstResponseResult(res *firstResponseResult) {
+	res.cancel()
+	if res.reader != nil {
+		res.reader.Close()
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+)
+
+// newTestSlowServer returns a test server that responds after the provided delay,
+// unless the request is cancelled first
+func newTestSlowServer(delay time.Duration, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestFirstResponseGroupFetch(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	client := rsc.BackendClient.(*TestClient)
+
+	ts1 := newTestSlowServer(time.Second*2, "slow")
+	defer ts1.Close()
+	ts2 := tu.NewTestServer(http.StatusOK, "fast", nil)
+	defer ts2.Close()
+	ts3 := tu.NewTestServer(http.StatusInternalServerError, "", nil)
+	defer ts3.Close()
+
+	g := &FirstResponseGroup{Name: "test-fgr", Members: []backends.Client{
+		newTestMergeMember("test-1", client, ts1),
+		newTestMergeMember("test-2", client, ts2),
+		newTestMergeMember("test-3", client, ts3),
+	}}
+
+	reader, resp, _ := g.fetch(r)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+	if reader == nil {
+		t.Fatal("expected non-nil reader")
+	}
+	b, _ := ioutil.ReadAll(reader)
+	reader.Close()
+	if string(b) != "fast" {
+		t.Errorf("expected %s got %s", "fast", string(b))
+	}
+
+	// when no member responds successfully, a failed response is returned
+	g.Members = []backends.Client{newTestMergeMember("test-3", client, ts3)}
+	reader, resp, _ = g.fetch(r)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected %d got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+	if reader != nil {
+		reader.Close()
+	}
+}
+
+func TestFirstResponseGroupFromContext(t *testing.T) {
+
+	r, _ := http.NewRequest(http.MethodGet, "http://0/", nil)
+	if g := firstResponseGroup(r); g != nil {
+		t.Error("expected nil group")
+	}
+
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), &FirstResponseGroup{}))
+	if g := firstResponseGroup(r); g != nil {
+		t.Error("expected nil group for a group without members")
+	}
+
+	g := &FirstResponseGroup{Members: []backends.Client{&TestClient{}}}
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), g))
+	if g2 := firstResponseGroup(r); g2 != g {
+		t.Error("expected group from context")
+	}
+}
+
+func TestObjectProxyCacheRequestFirstResponseGroup(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	client := rsc.BackendClient.(*TestClient)
+
+	ts1 := newTestSlowServer(time.Second*2, "slow")
+	defer ts1.Close()
+	ts2 := newTestSlowServer(0, "fast")
+	defer ts2.Close()
+
+	g := &FirstResponseGroup{Name: "test-fgr", CacheKeyPrefix: "test-fgr",
+		Members: []backends.Client{
+			newTestMergeMember("test-1", client, ts1),
+			newTestMergeMember("test-2", client, ts2),
+		}}
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), g))
+
+	_, e := testFetchOPC(r, http.StatusOK, "fast", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// the winning response is cached, so the next request is served from cache
+	_, e = testFetchOPC(r, http.StatusOK, "fast", map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+}
+
+func TestDoProxyFirstResponseGroup(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	client := rsc.BackendClient.(*TestClient)
+
+	ts1 := tu.NewTestServer(http.StatusBadGateway, "", nil)
+	defer ts1.Close()
+	ts2 := tu.NewTestServer(http.StatusOK, "fast", nil)
+	defer ts2.Close()
+
+	g := &FirstResponseGroup{Name: "test-fgr", Members: []backends.Client{
+		newTestMergeMember("test-1", client, ts1),
+		newTestMergeMember("test-2", client, ts2),
+	}}
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), g))
+
+	w := httptest.NewRecorder()
+	DoProxy(w, r, true)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	if string(b) != "fast" {
+		t.Errorf("expected %s got %s", "fast", string(b))
+	}
+}
@@ -79,7 +79,7 @@ func DoProxy(w io.Writer, r *http.Request, closeResponse bool) *http.Response {
 		}
 	} else {
 		pr := newProxyRequest(r, w)
-		key := oc.CacheKeyPrefix + "." + pr.DeriveCacheKey(nil, "")
+		key := cacheKeyPrefix(r, oc) + "." + pr.DeriveCacheKey(nil, "")
 		result, ok := reqs.Load(key)
 		if !ok {
 			var contentLength int64
@@ -140,6 +140,14 @@ func PrepareResponseWriter(w io.Writer, code int, header http.Header) io.Writer
 // provide the response data, the response object and the content length.
 // Used in Fetch.
 func PrepareFetchReader(r *http.Request) (io.ReadCloser, *http.Response, int64) {
+	// when the request has a first response group, it is sent to all of its members
+	if g := firstResponseGroup(r); g != nil {
+		return g.fetch(r)
+	}
+	return prepareFetchReader(r)
+}
+
+func prepareFetchReader(r *http.Request) (io.ReadCloser, *http.Response, int64) {
 
 	rsc := request.GetResources(r)
 	oc := rsc.BackendOptions
@@ -279,10 +287,12 @@ func recordResults(r *http.Request, engine string, cacheStatus status.LookupStat
 
 	if pc != nil && !pc.NoMetrics {
 		httpStatus := strconv.Itoa(statusCode)
-		metrics.ProxyRequestStatus.WithLabelValues(oc.Name, oc.Provider, r.Method, status, httpStatus, path).Inc()
+		tenant, _ := oc.Tenant(r.Header)
+		metrics.ProxyRequestStatus.WithLabelValues(oc.Name, oc.Provider, r.Method, status,
+			httpStatus, path, tenant).Inc()
 		if elapsed > 0 {
 			metrics.ProxyRequestDuration.WithLabelValues(oc.Name, oc.Provider,
-				r.Method, status, httpStatus, path).Observe(elapsed)
+				r.Method, status, httpStatus, path, tenant).Observe(elapsed)
 		}
 	}
 	headers.SetResultsHeader(header, engine, status, ffStatus, extents)
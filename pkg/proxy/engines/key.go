@@ -19,11 +19,13 @@ package engines
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/tricksterproxy/trickster/pkg/backends"
 	"github.com/tricksterproxy/trickster/pkg/proxy/errors"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	"github.com/tricksterproxy/trickster/pkg/proxy/methods"
@@ -32,6 +34,23 @@ import (
 	"github.com/tricksterproxy/trickster/pkg/util/md5"
 )
 
+// paramCacheKeyValue returns the value of the named query parameter for use in a cache key.
+// When the parameter is provided multiple times (e.g., match[]), all values are included,
+// sorted so that the key does not depend on the order in which they were provided
+func paramCacheKeyValue(qp url.Values, name string) string {
+	vs := qp[name]
+	switch len(vs) {
+	case 0:
+		return ""
+	case 1:
+		return vs[0]
+	}
+	s := make([]string, len(vs))
+	copy(s, vs)
+	sort.Strings(s)
+	return strings.Join(s, "&")
+}
+
 // DeriveCacheKey calculates a query-specific keyname based on the user request
 func (pr *proxyRequest) DeriveCacheKey(templateURL *url.URL, extra string) string {
 
@@ -78,11 +97,11 @@ func (pr *proxyRequest) DeriveCacheKey(templateURL *url.URL, extra string) strin
 
 	if len(pc.CacheKeyParams) == 1 && pc.CacheKeyParams[0] == "*" {
 		for p := range qp {
-			vals = append(vals, fmt.Sprintf("%s.%s.", p, qp.Get(p)))
+			vals = append(vals, fmt.Sprintf("%s.%s.", p, paramCacheKeyValue(qp, p)))
 		}
 	} else {
 		for _, p := range pc.CacheKeyParams {
-			if v := qp.Get(p); v != "" {
+			if v := paramCacheKeyValue(qp, p); v != "" {
 				vals = append(vals, fmt.Sprintf("%s.%s.", p, v))
 			}
 		}
@@ -167,3 +186,57 @@ func deepSearch(document map[string]interface{}, key string) (string, error) {
 	}
 	return "", errors.CouldNotFindKey(key)
 }
+
+// CacheKeys returns the cache keys under which the engines would store the response
+// to the provided request, which must carry the backend's request Resources. The
+// Object Proxy Cache key is always included, and the Delta Proxy Cache key is
+// included when the request is a time range query to a TimeseriesClient. Objects
+// stored secondary to these keys, such as Vary variants, are found under each
+// key's SecondaryKeyPrefix
+func CacheKeys(r *http.Request) []string {
+
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.BackendOptions == nil {
+		return nil
+	}
+	oc := rsc.BackendOptions
+
+	client, ok := rsc.BackendClient.(backends.TimeseriesClient)
+	if !ok {
+		pr := newProxyRequest(r, nil)
+		return []string{cacheKeyPrefix(r, oc) + ".opc." + pr.DeriveCacheKey(nil, "")}
+	}
+
+	trq, _, canOPC, err := client.ParseTimeRangeQuery(r)
+	var u *url.URL
+	if err != nil && canOPC && trq != nil {
+		// a non-timeseries query may provide the template for its object cache key
+		u = trq.TemplateURL
+	}
+	pr := newProxyRequest(r, nil)
+	keys := []string{cacheKeyPrefix(r, oc) + ".opc." + pr.DeriveCacheKey(u, "")}
+	if err != nil {
+		return keys
+	}
+	trq.NormalizeExtent()
+
+	pr = newProxyRequest(r, nil)
+	client.SetExtent(pr.upstreamRequest, trq, &trq.Extent)
+	return append(keys, cacheKeyPrefix(r, oc)+".dpc."+pr.DeriveCacheKey(trq.TemplateURL, ""))
+}
+
+// SecondaryKeyPrefix returns the prefix of the cache keys under which objects belonging
+// to the object stored at the provided key are cached, such as its Vary variants
+func SecondaryKeyPrefix(key string) string {
+	return key + "."
+}
+
+// objectCacheTemplateURL returns the TemplateURL of the request's non-timeseries query,
+// when present, which is used to derive the Object Proxy Cache key in lieu of the
+// request's own query parameters
+func objectCacheTemplateURL(rsc *request.Resources) *url.URL {
+	if rsc == nil || rsc.TimeRangeQuery == nil {
+		return nil
+	}
+	return rsc.TimeRangeQuery.TemplateURL
+}
@@ -20,21 +20,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/cache/key"
+	"github.com/tricksterproxy/trickster/pkg/cache/status"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
 	ct "github.com/tricksterproxy/trickster/pkg/proxy/context"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
 )
 
@@ -243,6 +248,67 @@ func TestDeriveCacheKeyAuthHeader(t *testing.T) {
 
 }
 
+func TestDeriveCacheKeyMultiValueParams(t *testing.T) {
+
+	client := &TestClient{
+		config: &oo.Options{
+			Paths: map[string]*po.Options{
+				"root": {
+					Path:            "/",
+					CacheKeyParams:  []string{"match[]", "start"},
+					CacheKeyHeaders: []string{},
+				},
+			},
+		},
+	}
+
+	deriveKey := func(u string) string {
+		tr := httptest.NewRequest("GET", u, nil)
+		tr = tr.WithContext(ct.WithResources(context.Background(),
+			request.NewResources(client.Configuration(), client.Configuration().Paths["root"],
+				nil, nil, nil, nil, tl.ConsoleLogger("error"))))
+		return newProxyRequest(tr, nil).DeriveCacheKey(nil, "")
+	}
+
+	k1 := deriveKey("http://127.0.0.1/?match[]=up&match[]=go_info&start=0")
+	k2 := deriveKey("http://127.0.0.1/?match[]=go_info&match[]=up&start=0")
+	k3 := deriveKey("http://127.0.0.1/?match[]=up&start=0")
+
+	if k1 != k2 {
+		t.Errorf("expected %s got %s", k1, k2)
+	}
+
+	if k1 == k3 {
+		t.Error("expected keys to differ when match[] values differ")
+	}
+
+}
+
+func TestParamCacheKeyValue(t *testing.T) {
+
+	qp := url.Values{"a": {"1"}, "b": {"3", "2"}}
+
+	tests := []struct {
+		name, expected string
+	}{
+		{"a", "1"},
+		{"b", "2&3"},
+		{"c", ""},
+	}
+
+	for _, test := range tests {
+		if v := paramCacheKeyValue(qp, test.name); v != test.expected {
+			t.Errorf("expected %s got %s", test.expected, v)
+		}
+	}
+
+	// ensure the original order is unmodified
+	if qp["b"][0] != "3" {
+		t.Errorf("expected %s got %s", "3", qp["b"][0])
+	}
+
+}
+
 func TestDeriveCacheKeyNoPathConfig(t *testing.T) {
 
 	client := &TestClient{
@@ -282,3 +348,64 @@ func TestDeriveCacheKeyNilURL(t *testing.T) {
 		t.Errorf("unexpected cache key: %s", k)
 	}
 }
+
+func TestCacheKeys(t *testing.T) {
+
+	if keys := CacheKeys(httptest.NewRequest("GET", "http://127.0.0.1/", nil)); keys != nil {
+		t.Errorf("expected nil keys got %v", keys)
+	}
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	rsc.BackendOptions.FastForwardDisable = true
+
+	end := time.Now().Add(-time.Duration(12) * time.Hour)
+	r.URL.Path = "/prometheus/api/v1/query_range"
+	r.URL.RawQuery = fmt.Sprintf("step=300&start=%d&end=%d&query=%s",
+		end.Add(-time.Hour).Unix(), end.Unix(), queryReturnsOKNoLatency)
+
+	client.QueryRangeHandler(w, r)
+	time.Sleep(time.Millisecond * 10)
+
+	keys := CacheKeys(r)
+	if len(keys) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(keys))
+	}
+	if !strings.Contains(keys[0], ".opc.") || !strings.Contains(keys[1], ".dpc.") {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	// the dpc key must reference the cached timeseries
+	if _, ls, _ := rsc.CacheClient.Retrieve(keys[1], false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+
+	// a request that is not a time range query only has an opc key
+	r.URL.RawQuery = ""
+	if keys = CacheKeys(r); len(keys) != 1 {
+		t.Errorf("expected %d got %d", 1, len(keys))
+	}
+}
+
+func TestObjectCacheTemplateURL(t *testing.T) {
+
+	if u := objectCacheTemplateURL(nil); u != nil {
+		t.Errorf("expected nil got %v", u)
+	}
+
+	rsc := &request.Resources{}
+	if u := objectCacheTemplateURL(rsc); u != nil {
+		t.Errorf("expected nil got %v", u)
+	}
+
+	u, _ := url.Parse("http://127.0.0.1/?query=select+1")
+	rsc.TimeRangeQuery = &timeseries.TimeRangeQuery{TemplateURL: u}
+	if u2 := objectCacheTemplateURL(rsc); u2 != u {
+		t.Errorf("expected %v got %v", u, u2)
+	}
+}
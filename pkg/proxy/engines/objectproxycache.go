@@ -128,6 +128,14 @@ func confirmTrueCacheHit(pr *proxyRequest) (bool, error) {
 
 	pr.cachingPolicy.Merge(pr.cacheDocument.CachingPolicy)
 
+	// an expired object within its stale-while-revalidate window is served as-is,
+	// while a single background request revalidates it
+	if !pr.checkCacheFreshness() && pr.canServeStaleWhileRevalidate() {
+		pr.cacheStatus = status.LookupStatusStaleHit
+		revalidateInBackground(pr)
+		return true, nil
+	}
+
 	if (!pr.checkCacheFreshness()) && (pr.cachingPolicy.CanRevalidate) {
 		return false, handleCacheRevalidation(pr)
 	}
@@ -215,6 +223,9 @@ func handleCacheRevalidationResponse(pr *proxyRequest) error {
 	}
 
 	pr.revalidation = RevalStatusFailed
+	if pr.canServeStaleIfError() {
+		return handleStaleIfError(pr)
+	}
 	pr.cacheStatus = status.LookupStatusKeyMiss
 	return handleAllWrites(pr)
 }
@@ -266,6 +277,9 @@ func handleCacheKeyMiss(pr *proxyRequest) error {
 
 	pr.prepareUpstreamRequests()
 	handleUpstreamTransactions(pr)
+	if pr.canServeStaleIfError() {
+		return handleStaleIfError(pr)
+	}
 	return handleAllWrites(pr)
 }
 
@@ -310,11 +324,17 @@ func handlePCF(pr *proxyRequest) error {
 	// Check if we know the content length and if it is less than our max object size.
 	if contentLength > 0 && contentLength < int64(oc.MaxObjectSizeBytes) {
 		pcf := NewPCF(resp, contentLength)
-		reqs.Store(pr.key, pcf)
+		// a response that varies on request headers can't be shared with clients that
+		// join by cache key, since their request headers may select a different variant
+		if len(varyHeaders(resp.Header)) == 0 {
+			reqs.Store(pr.key, pcf)
+		}
 		// Blocks until server completes
 
-		pr.cachingPolicy.Merge(GetResponseCachingPolicy(pr.upstreamResponse.StatusCode,
-			rsc.BackendOptions.NegativeCache, pr.upstreamResponse.Header))
+		cp := GetResponseCachingPolicy(pr.upstreamResponse.StatusCode,
+			rsc.BackendOptions.NegativeCache, pr.upstreamResponse.Header)
+		cp.SetStaleDefaults(rsc.BackendOptions.StaleWhileRevalidate, rsc.BackendOptions.StaleIfError)
+		pr.cachingPolicy.Merge(cp)
 		pr.determineCacheability()
 
 		go func() {
@@ -392,7 +412,8 @@ func fetchViaObjectProxyCache(w io.Writer, r *http.Request) (*http.Response, sta
 
 	pr.cachingPolicy = GetRequestCachingPolicy(pr.Header)
 
-	pr.key = oc.CacheKeyPrefix + ".opc." + pr.DeriveCacheKey(nil, "")
+	pr.key = cacheKeyPrefix(r, oc) + ".opc." + pr.DeriveCacheKey(objectCacheTemplateURL(rsc), "")
+	pr.baseKey = pr.key
 
 	// if a PCF entry exists, or the client requested no-cache for this object, proxy out to it
 	pcfResult, pcfExists := reqs.Load(pr.key)
@@ -420,6 +441,35 @@ func fetchViaObjectProxyCache(w io.Writer, r *http.Request) (*http.Response, sta
 	var err error
 	pr.cacheDocument, pr.cacheStatus, pr.neededRanges, err =
 		QueryCache(pr.upstreamRequest.Context(), cc, pr.key, pr.wantedRanges)
+
+	// if the primary key holds a variant index, look up the variant matching this request
+	if err == nil && pr.cacheDocument != nil && len(pr.cacheDocument.VaryHeaders) > 0 {
+		if pr.hasReadLock {
+			pr.cacheLock.RRelease()
+			pr.hasReadLock = false
+		}
+		pr.varyHeaders = pr.cacheDocument.VaryHeaders
+		pr.key = varyCacheKey(pr.baseKey, pr.varyHeaders, pr.Header)
+		if !rsc.NoLock {
+			pr.cacheLock, _ = cc.Locker().RAcquire(pr.key)
+			pr.hasReadLock = true
+		}
+		pr.cacheDocument, pr.cacheStatus, pr.neededRanges, err =
+			QueryCache(pr.upstreamRequest.Context(), cc, pr.key, pr.wantedRanges)
+	}
+
+	// if the client's If-Range validator does not match the cached object, the full
+	// object must be served, so the lookup is repeated without the requested ranges
+	if err == nil && pr.wantsRanges && pr.cachingPolicy.HasIfRange &&
+		pr.cacheStatus != status.LookupStatusKeyMiss && pr.cacheDocument != nil &&
+		pr.cacheDocument.CachingPolicy != nil &&
+		!pr.cachingPolicy.ifRangeMatches(pr.cacheDocument.CachingPolicy.ETag,
+			pr.cacheDocument.CachingPolicy.LastModified) {
+		pr.clearRequestRanges()
+		pr.cacheDocument, pr.cacheStatus, pr.neededRanges, err =
+			QueryCache(pr.upstreamRequest.Context(), cc, pr.key, nil)
+	}
+
 	if err == nil || err == cache.ErrKNF {
 		if f, ok := cacheResponseHandlers[pr.cacheStatus]; ok {
 			f(pr)
@@ -158,6 +158,37 @@ func TestObjectProxyCacheRequest(t *testing.T) {
 
 }
 
+func TestObjectProxyCacheRequestTenants(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=60"}
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	oc := rsc.BackendOptions
+	oc.TenantHeader = "X-Scope-OrgID"
+
+	r.Header.Set(oc.TenantHeader, "team-a")
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// another tenant's identical request must not be served from the first tenant's cache
+	r.Header.Set(oc.TenantHeader, "team-b")
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+}
+
 func TestObjectProxyCachePartialHit(t *testing.T) {
 
 	ts, _, r, rsc, err := setupTestHarnessOPCRange(nil)
@@ -646,6 +677,129 @@ func TestObjectProxyCacheRequestOriginNoCache(t *testing.T) {
 	}
 }
 
+func TestObjectProxyCacheVary(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept, Accept-Language"}
+	ts, _, r, _, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	tests := []struct {
+		accept, status string
+	}{
+		{"application/json", "kmiss"},
+		{"application/json", "hit"},
+		{"text/plain", "kmiss"},
+		{"text/plain", "hit"},
+		{"application/json", "hit"},
+	}
+
+	for i, test := range tests {
+		r.Header.Set(headers.NameAccept, test.accept)
+		_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": test.status})
+		for _, err = range e {
+			t.Errorf("test %d: %s", i, err.Error())
+		}
+	}
+
+	// a different value for the second Vary header should select a new variant
+	r.Header.Set("Accept-Language", "en-US")
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+}
+
+func TestObjectProxyCacheVaryAll(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=60", "Vary": "*"}
+	ts, _, r, _, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+		for _, err = range e {
+			t.Error(err)
+		}
+	}
+
+}
+
+func TestObjectProxyCacheStaleWhileRevalidate(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=1, stale-while-revalidate=30"}
+	ts, _, r, _, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	time.Sleep(time.Millisecond * 1050)
+
+	// the expired object should be served immediately while it is revalidated in the background
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "stale-hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// wait for the background revalidation to refresh the object
+	for i := 0; i < 100; i++ {
+		var inProgress bool
+		staleRevalidations.Range(func(interface{}, interface{}) bool {
+			inProgress = true
+			return false
+		})
+		if !inProgress {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+}
+
+func TestObjectProxyCacheStaleIfError(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=1"}
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the backend default applies since the upstream does not provide stale-if-error
+	rsc.BackendOptions.StaleIfError = time.Minute
+
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// with the upstream unreachable, the expired object should be served in place of the error
+	ts.Close()
+	time.Sleep(time.Millisecond * 1050)
+
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "stale-hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+}
+
 func TestObjectProxyCacheIMS(t *testing.T) {
 
 	hdrs := map[string]string{"Cache-Control": "max-age=1"}
@@ -1191,3 +1345,71 @@ func TestRerunRequest(t *testing.T) {
 		t.Error("expected true")
 	}
 }
+
+func TestObjectProxyCacheIfRange(t *testing.T) {
+
+	ts, _, r, _, err := setupTestHarnessOPCRange(nil)
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	// prime the cache with the full object
+	_, e := testFetchOPC(r, http.StatusOK, byterange.Body, map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// a matching If-Range date should be served the requested range
+	r.Header.Set(headers.NameRange, "bytes=0-10")
+	r.Header.Set(headers.NameIfRange, time.Unix(1577836800, 0).UTC().Format(time.RFC1123))
+	expectedBody, err := getExpectedRangeBody(r, "")
+	if err != nil {
+		t.Error(err)
+	}
+	_, e = testFetchOPC(r, http.StatusPartialContent, expectedBody, map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// a non-matching If-Range date should be served the full object
+	r.Header.Set(headers.NameIfRange, time.Unix(1577836801, 0).UTC().Format(time.RFC1123))
+	_, e = testFetchOPC(r, http.StatusOK, byterange.Body, map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// an If-Range entity tag should not match an object with no ETag
+	r.Header.Set(headers.NameIfRange, `"abc"`)
+	_, e = testFetchOPC(r, http.StatusOK, byterange.Body, map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+}
+
+func TestObjectProxyCacheIfRangePartialCache(t *testing.T) {
+
+	ts, _, r, _, err := setupTestHarnessOPCRange(nil)
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	// prime the cache with a partial object
+	r.Header.Set(headers.NameRange, "bytes=0-10")
+	expectedBody, err := getExpectedRangeBody(r, "")
+	if err != nil {
+		t.Error(err)
+	}
+	_, e := testFetchOPC(r, http.StatusPartialContent, expectedBody, map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// a non-matching If-Range should result in the full object being fetched
+	r.Header.Set(headers.NameIfRange, `"abc"`)
+	_, e = testFetchOPC(r, http.StatusOK, byterange.Body, map[string]string{"status": "phit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+}
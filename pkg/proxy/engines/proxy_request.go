@@ -63,6 +63,8 @@ type proxyRequest struct {
 	mapLock       *sync.Mutex
 
 	key         string
+	baseKey     string
+	varyHeaders []string
 	started     time.Time
 	elapsed     time.Duration
 	cacheStatus status.LookupStatus
@@ -98,7 +100,7 @@ func newProxyRequest(r *http.Request, w io.Writer) *proxyRequest {
 		Request: r,
 		upstreamRequest: r.Clone(
 			tctx.WithResources(
-				trace.ContextWithSpan(context.Background(),
+				trace.ContextWithSpan(withBackendGroup(context.Background(), r),
 					trace.SpanFromContext(r.Context())),
 				rsc)),
 		contentLength:  -1,
@@ -117,17 +119,19 @@ func (pr *proxyRequest) Clone() *proxyRequest {
 	return &proxyRequest{
 		Request: pr.Request.Clone(
 			tctx.WithResources(
-				trace.ContextWithSpan(context.Background(),
+				trace.ContextWithSpan(withBackendGroup(context.Background(), pr.Request),
 					trace.SpanFromContext(pr.Request.Context())),
 				rsc)),
 		upstreamRequest: pr.upstreamRequest.Clone(
 			tctx.WithResources(
-				trace.ContextWithSpan(context.Background(),
+				trace.ContextWithSpan(withBackendGroup(context.Background(), pr.upstreamRequest),
 					trace.SpanFromContext(pr.upstreamRequest.Context())),
 				rsc)),
 		Logger:             pr.Logger,
 		cacheDocument:      pr.cacheDocument,
 		key:                pr.key,
+		baseKey:            pr.baseKey,
+		varyHeaders:        pr.varyHeaders,
 		cacheStatus:        pr.cacheStatus,
 		writeToCache:       pr.writeToCache,
 		wantsRanges:        pr.wantsRanges,
@@ -183,7 +187,7 @@ func (pr *proxyRequest) prepareRevalidationRequest() {
 
 	rsc := request.GetResources(pr.upstreamRequest)
 	pr.revalidation = RevalStatusInProgress
-	pr.revalidationRequest = request.SetResources(pr.upstreamRequest.Clone(context.Background()),
+	pr.revalidationRequest = request.SetResources(pr.upstreamRequest.Clone(withBackendGroup(context.Background(), pr.upstreamRequest)),
 		request.GetResources(pr.Request))
 
 	_, span := tspan.NewChildSpan(pr.revalidationRequest.Context(), rsc.Tracer, "FetchRevlidation")
@@ -262,7 +266,7 @@ func (pr *proxyRequest) prepareUpstreamRequests() {
 	// if we are articulating the origin range requests, break those out here
 	if pr.neededRanges != nil && len(pr.neededRanges) > 0 && rsc.BackendOptions.DearticulateUpstreamRanges {
 		for _, r := range pr.neededRanges {
-			req := request.SetResources(pr.upstreamRequest.Clone(context.Background()), rsc)
+			req := request.SetResources(pr.upstreamRequest.Clone(withBackendGroup(context.Background(), pr.upstreamRequest)), rsc)
 			req.Header.Set(headers.NameRange, "bytes="+r.String())
 			pr.originRequests = append(pr.originRequests, req)
 		}
@@ -345,14 +349,20 @@ func (pr *proxyRequest) parseRequestRanges() bool {
 	// if the client shouldn't support multipart ranges, force a full range
 	rsc := request.GetResources(pr.Request)
 	if rsc.BackendOptions.MultipartRangesDisabled && len(pr.wantedRanges) > 1 {
-		pr.upstreamRequest.Header.Del(headers.NameRange)
-		pr.wantsRanges = false
-		pr.wantedRanges = nil
+		pr.clearRequestRanges()
 	}
 
 	return pr.wantsRanges
 }
 
+// clearRequestRanges converts the request into one for the full object
+func (pr *proxyRequest) clearRequestRanges() {
+	pr.upstreamRequest.Header.Del(headers.NameRange)
+	pr.upstreamRequest.Header.Del(headers.NameIfRange)
+	pr.wantsRanges = false
+	pr.wantedRanges = nil
+}
+
 func (pr *proxyRequest) stripConditionalHeaders() {
 	// don't proxy these up, their scope is only between Trickster and client
 	if pr.cachingPolicy != nil && pr.cachingPolicy.IsClientConditional {
@@ -412,6 +422,12 @@ func (pr *proxyRequest) determineCacheability() {
 		return
 	}
 
+	// a response that varies on all request headers can never be served from cache
+	if resp != nil && variesOnAll(resp.Header) {
+		pr.writeToCache = false
+		return
+	}
+
 	if pr.revalidation == RevalStatusLocal {
 
 		tpc := pr.cachingPolicy.Clone()
@@ -471,8 +487,28 @@ func (pr *proxyRequest) store() error {
 	}
 
 	d.CachingPolicy = pr.cachingPolicy
+	ttl := pr.cachingPolicy.TTL(rf, oc.MaxTTL)
+
+	// when the response varies on request headers, it is stored under a secondary key
+	// for the variant, and a bodyless index document naming the Vary headers is stored
+	// under the primary key, so that subsequent lookups can locate the matching variant
+	d.headerLock.Lock()
+	d.VaryHeaders = varyHeaders(http.Header(d.Headers))
+	d.headerLock.Unlock()
+	if len(d.VaryHeaders) > 0 && pr.baseKey != "" &&
+		!equalVaryHeaders(d.VaryHeaders, pr.varyHeaders) {
+		pr.varyHeaders = d.VaryHeaders
+		pr.key = varyCacheKey(pr.baseKey, pr.varyHeaders, pr.Header)
+		vi := &HTTPDocument{VaryHeaders: pr.varyHeaders, CachingPolicy: pr.cachingPolicy.Clone()}
+		err := WriteCache(pr.upstreamRequest.Context(), rsc.CacheClient, pr.baseKey, vi,
+			ttl, oc.CompressableTypes)
+		if err != nil {
+			return err
+		}
+	}
+
 	err := WriteCache(pr.upstreamRequest.Context(), rsc.CacheClient, pr.key, d,
-		pr.cachingPolicy.TTL(rf, oc.MaxTTL), oc.CompressableTypes)
+		ttl, oc.CompressableTypes)
 	if err != nil {
 		return err
 	}
@@ -517,6 +553,12 @@ func (pr *proxyRequest) prepareResponse() {
 		return
 	}
 
+	// if the client's If-Range validator does not match the object being served,
+	// the full object is served rather than the requested ranges
+	if pr.wantsRanges && !pr.cachingPolicy.IfRangeMatches() {
+		pr.wantsRanges = false
+	}
+
 	if pr.wantsRanges && (resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent) {
 
 		// since the user wants ranges, we have to extract them from what we have already
@@ -725,8 +767,10 @@ func (pr *proxyRequest) reconstituteResponses() {
 	// now we merge the caching policy of the new upstreams
 	if pr.upstreamResponse.StatusCode != http.StatusNotModified {
 		rsc := request.GetResources(pr.Request)
-		pr.cachingPolicy.Merge(GetResponseCachingPolicy(pr.upstreamResponse.StatusCode,
-			rsc.BackendOptions.NegativeCache, pr.upstreamResponse.Header))
+		cp := GetResponseCachingPolicy(pr.upstreamResponse.StatusCode,
+			rsc.BackendOptions.NegativeCache, pr.upstreamResponse.Header)
+		cp.SetStaleDefaults(rsc.BackendOptions.StaleWhileRevalidate, rsc.BackendOptions.StaleIfError)
+		pr.cachingPolicy.Merge(cp)
 
 	}
 
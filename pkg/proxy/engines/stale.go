@@ -0,0 +1,102 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache/status"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+// staleRevalidations holds the cache keys that have a background revalidation in progress,
+// so that only one revalidation is run at a time for a given object
+var staleRevalidations sync.Map
+
+type backgroundRevalidationKey struct{}
+
+// isBackgroundRevalidation returns true if the request is a background revalidation
+func isBackgroundRevalidation(r *http.Request) bool {
+	v, _ := r.Context().Value(backgroundRevalidationKey{}).(bool)
+	return v
+}
+
+// canServeStaleWhileRevalidate returns true if the expired cache object may be served to the client
+// immediately, while it is revalidated in the background
+func (pr *proxyRequest) canServeStaleWhileRevalidate() bool {
+	return pr.cacheStatus == status.LookupStatusHit && pr.cachingPolicy != nil &&
+		!isBackgroundRevalidation(pr.Request) &&
+		pr.cachingPolicy.CanServeStaleWhileRevalidate(time.Now())
+}
+
+// canServeStaleIfError returns true if the upstream response is an error and the expired
+// cache object may be served to the client in its place
+func (pr *proxyRequest) canServeStaleIfError() bool {
+	d := pr.cacheDocument
+	resp := pr.upstreamResponse
+	return d != nil && d.CachingPolicy != nil && len(d.Body) > 0 && resp != nil &&
+		resp.StatusCode >= http.StatusInternalServerError &&
+		d.CachingPolicy.CanServeStaleIfError(time.Now())
+}
+
+// revalidateInBackground revalidates the cache object for the request asynchronously, unless
+// a background revalidation of the object is already in progress
+func revalidateInBackground(pr *proxyRequest) {
+	if _, ok := staleRevalidations.LoadOrStore(pr.key, true); ok {
+		return
+	}
+	rsc := request.GetResources(pr.Request)
+	ctx := context.WithValue(context.Background(), backgroundRevalidationKey{}, true)
+	r := request.SetResources(pr.Request.Clone(ctx), rsc.Clone())
+	stripConditionalHeaders(r.Header)
+	go func(key string) {
+		defer staleRevalidations.Delete(key)
+		fetchViaObjectProxyCache(ioutil.Discard, r)
+	}(pr.key)
+}
+
+// handleStaleIfError serves the expired cache object to the client in place of the
+// upstream error response
+func handleStaleIfError(pr *proxyRequest) error {
+	d := pr.cacheDocument
+	if pr.upstreamResponse.Body != nil {
+		pr.upstreamResponse.Body.Close()
+	}
+	pr.cachingPolicy.Merge(d.CachingPolicy)
+	pr.cachingPolicy.IsNegativeCache = d.CachingPolicy.IsNegativeCache
+	pr.cacheStatus = status.LookupStatusStaleHit
+	pr.writeToCache = false
+	return handleTrueCacheHit(pr)
+}
+
+// canServeStaleExtentsIfError returns true if the cached timeseries may be served in place of an
+// upstream error for the time range query, which is the case when the cached data within the
+// query's range is no older than the backend's stale-if-error window from the query's end
+func canServeStaleExtentsIfError(oc *oo.Options, cts timeseries.Timeseries,
+	trq *timeseries.TimeRangeQuery) bool {
+	if oc.StaleIfError <= 0 || cts == nil {
+		return false
+	}
+	el := cts.Extents().Clone().Crop(trq.Extent)
+	return len(el) > 0 && !el[len(el)-1].End.Before(trq.Extent.End.Add(-oc.StaleIfError))
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/cache/status"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+// When chunking is enabled for a backend, the Delta Proxy Cache stores each query's timeseries
+// in fixed-width, epoch-aligned time chunks, each under its own cache key, rather than as a
+// single object. The document at the query's cache key then holds only the response metadata.
+
+// chunkWidth returns the width of the time chunks used to store timeseries with the
+// provided step, or 0 if the backend does not use chunked storage
+func chunkWidth(oc *oo.Options, step time.Duration) time.Duration {
+	if oc.TimeseriesChunkFactor < 1 || step <= 0 {
+		return 0
+	}
+	return step * time.Duration(oc.TimeseriesChunkFactor)
+}
+
+// chunkExtents returns the chunks of the provided width and step that overlap any of the
+// extents in the provided list, in chronological order and without duplicates
+func chunkExtents(el timeseries.ExtentList, width, step time.Duration) timeseries.ExtentList {
+	out := make(timeseries.ExtentList, 0, len(el))
+	seen := make(map[int64]bool)
+	w := width.Nanoseconds()
+	for _, e := range el {
+		n := e.Start.UnixNano()
+		n -= n % w
+		for start := time.Unix(0, n); !start.After(e.End); start = start.Add(width) {
+			if seen[start.UnixNano()] {
+				continue
+			}
+			seen[start.UnixNano()] = true
+			out = append(out, timeseries.Extent{Start: start, End: start.Add(width - step)})
+		}
+	}
+	return out
+}
+
+// chunkKey returns the cache key of the chunk starting at the provided time
+func chunkKey(key string, start time.Time) string {
+	return SecondaryKeyPrefix(key) + "chunk." + strconv.FormatInt(start.UnixNano(), 10)
+}
+
+// queryTimeseriesChunks retrieves the cached chunks overlapping the provided time range query
+// and merges them into a single timeseries. Chunks that end before the oldest retained
+// timestamp are removed from the cache instead, and any older data in the remaining chunks
+// is cropped, unless oldest is the zero time. cache.ErrKNF is returned when no chunks are cached
+func queryTimeseriesChunks(ctx context.Context, c cache.Cache, key string,
+	trq *timeseries.TimeRangeQuery, width time.Duration, oldest time.Time,
+	modeler *timeseries.Modeler) (timeseries.Timeseries, error) {
+
+	chunks := chunkExtents(timeseries.ExtentList{trq.Extent}, width, trq.Step)
+	tss := make([]timeseries.Timeseries, len(chunks))
+	isMemory := c.Configuration().Provider == "memory"
+
+	var expired []string
+	wg := sync.WaitGroup{}
+	for i := range chunks {
+		if !oldest.IsZero() && chunks[i].End.Before(oldest) {
+			expired = append(expired, chunkKey(key, chunks[i].Start))
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			doc, lookupStatus, _, err := QueryCache(ctx, c, chunkKey(key, chunks[i].Start), nil)
+			if err != nil || lookupStatus != status.LookupStatusHit || doc == nil {
+				return
+			}
+			if isMemory {
+				// chunks retrieved by reference are cloned so that merging does not modify the cache
+				if doc.timeseries != nil {
+					tss[i] = doc.timeseries.Clone()
+				}
+				return
+			}
+			tss[i], _ = modeler.CacheUnmarshaler(doc.Body, trq)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(expired) > 0 {
+		go c.BulkRemove(expired)
+	}
+
+	var ts timeseries.Timeseries
+	rest := make([]timeseries.Timeseries, 0, len(tss))
+	for _, t := range tss {
+		if t == nil {
+			continue
+		}
+		if ts == nil {
+			ts = t
+			continue
+		}
+		rest = append(rest, t)
+	}
+	if ts == nil {
+		return nil, cache.ErrKNF
+	}
+	ts.Merge(true, rest...)
+	if el := ts.Extents(); !oldest.IsZero() && len(el) > 0 && el[0].Start.Before(oldest) {
+		ts.CropToRange(timeseries.Extent{Start: oldest, End: el[len(el)-1].End})
+		if len(ts.Extents()) == 0 {
+			return nil, cache.ErrKNF
+		}
+	}
+	ts.SetTimeRangeQuery(trq)
+	return ts, nil
+}
+
+// writeTimeseriesChunks writes the chunks of the timeseries that overlap the changed extents
+// to the cache, and then writes the metadata document to the query's cache key
+func writeTimeseriesChunks(ctx context.Context, c cache.Cache, key string, doc *HTTPDocument,
+	ts timeseries.Timeseries, changed timeseries.ExtentList, width, step time.Duration,
+	modeler *timeseries.Modeler, oc *oo.Options, logger interface{}) error {
+
+	isMemory := c.Configuration().Provider == "memory"
+	el := ts.Extents()
+	chunks := chunkExtents(changed, width, step)
+
+	wg := sync.WaitGroup{}
+	for i := range chunks {
+		if el.OutsideOf(chunks[i]) {
+			continue
+		}
+		ct := ts.CroppedClone(chunks[i])
+		if len(ct.Extents()) == 0 {
+			continue
+		}
+		cd := &HTTPDocument{}
+		if isMemory {
+			cd.timeseries = ct
+		} else {
+			b, err := modeler.CacheMarshaler(ct, nil, 0)
+			if err != nil {
+				return err
+			}
+			cd.Body = b
+		}
+		wg.Add(1)
+		go func(k string) {
+			defer wg.Done()
+			if err := WriteCache(ctx, c, k, cd, oc.TimeseriesTTL, oc.CompressableTypes); err != nil {
+				tl.Error(logger, "error writing object to cache",
+					tl.Pairs{
+						"backendName": oc.Name,
+						"cacheName":   c.Configuration().Name,
+						"cacheKey":    k,
+						"detail":      err.Error(),
+					},
+				)
+			}
+		}(chunkKey(key, chunks[i].Start))
+	}
+	wg.Wait()
+
+	doc.Body = nil
+	doc.timeseries = nil
+	return WriteCache(ctx, c, key, doc, oc.TimeseriesTTL, oc.CompressableTypes)
+}
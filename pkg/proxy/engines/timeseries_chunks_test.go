@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+func TestChunkWidth(t *testing.T) {
+
+	step := time.Duration(60) * time.Second
+
+	tests := []struct {
+		factor   int
+		step     time.Duration
+		expected time.Duration
+	}{
+		{0, step, 0},
+		{60, step, time.Hour},
+		{60, 0, 0},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			oc := &oo.Options{TimeseriesChunkFactor: test.factor}
+			if v := chunkWidth(oc, test.step); v != test.expected {
+				t.Errorf("expected %s got %s", test.expected, v)
+			}
+		})
+	}
+}
+
+func TestChunkExtents(t *testing.T) {
+
+	step := time.Duration(100) * time.Second
+	width := time.Duration(400) * time.Second
+
+	tests := []struct {
+		el       timeseries.ExtentList
+		expected timeseries.ExtentList
+	}{
+		{ // 0 - inside a single chunk
+			el: timeseries.ExtentList{{Start: time.Unix(500, 0), End: time.Unix(600, 0)}},
+			expected: timeseries.ExtentList{
+				{Start: time.Unix(400, 0), End: time.Unix(700, 0)},
+			},
+		},
+		{ // 1 - spanning multiple chunks
+			el: timeseries.ExtentList{{Start: time.Unix(700, 0), End: time.Unix(1600, 0)}},
+			expected: timeseries.ExtentList{
+				{Start: time.Unix(400, 0), End: time.Unix(700, 0)},
+				{Start: time.Unix(800, 0), End: time.Unix(1100, 0)},
+				{Start: time.Unix(1200, 0), End: time.Unix(1500, 0)},
+				{Start: time.Unix(1600, 0), End: time.Unix(1900, 0)},
+			},
+		},
+		{ // 2 - multiple extents sharing a chunk
+			el: timeseries.ExtentList{
+				{Start: time.Unix(0, 0), End: time.Unix(100, 0)},
+				{Start: time.Unix(300, 0), End: time.Unix(400, 0)},
+			},
+			expected: timeseries.ExtentList{
+				{Start: time.Unix(0, 0), End: time.Unix(300, 0)},
+				{Start: time.Unix(400, 0), End: time.Unix(700, 0)},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			v := chunkExtents(test.el, width, step)
+			if v.String() != test.expected.String() {
+				t.Errorf("expected %s got %s", test.expected, v)
+			}
+		})
+	}
+}
+
+func TestChunkKey(t *testing.T) {
+	const expected = "test.chunk.400000000000"
+	if v := chunkKey("test", time.Unix(400, 0)); v != expected {
+		t.Errorf("expected %s got %s", expected, v)
+	}
+}
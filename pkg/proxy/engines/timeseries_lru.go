@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+// When a backend evicts cached timeseries data by LRU, the Delta Proxy Cache tracks when each
+// portion of a query's timeseries was last used. A full cache hit does not rewrite the
+// timeseries, so hits are recorded in a small, separate object at the query's lastUsedKey,
+// which is merged into the timeseries' extents before it is next cropped to size.
+
+// lastUsedKey returns the cache key of the last used record of the timeseries at key
+func lastUsedKey(key string) string {
+	return SecondaryKeyPrefix(key) + "lru"
+}
+
+// loadLastUsed returns the last used record of the timeseries at key, if any
+func loadLastUsed(c cache.Cache, key string) timeseries.ExtentList {
+	b, _, err := c.Retrieve(lastUsedKey(key), false)
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	var el timeseries.ExtentList
+	if _, err = el.UnmarshalMsg(b); err != nil {
+		return nil
+	}
+	return el
+}
+
+// recordLastUsed records that the used extent of the timeseries at key was used at time t.
+// el provides the cached extents of the timeseries
+func recordLastUsed(c cache.Cache, key string, el timeseries.ExtentList,
+	used timeseries.Extent, step, ttl time.Duration, t time.Time) error {
+	k := lastUsedKey(key)
+	lock, _ := c.Locker().Acquire(k)
+	defer lock.Release()
+	lru := timeseries.ExtentListLRU(el).MergeLastUsed(loadLastUsed(c, key), step).
+		UpdateLastUsedAt(used, step, t)
+	b, err := timeseries.ExtentList(lru).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	return c.Store(k, b, ttl)
+}
+
+// applyLastUsed merges the last used record of the timeseries at key into the extents of ts
+func applyLastUsed(c cache.Cache, key string, ts timeseries.Timeseries, step time.Duration) {
+	lock, _ := c.Locker().RAcquire(lastUsedKey(key))
+	used := loadLastUsed(c, key)
+	lock.RRelease()
+	if len(used) == 0 {
+		return
+	}
+	ts.SetExtents(timeseries.ExtentList(
+		timeseries.ExtentListLRU(ts.Extents()).MergeLastUsed(used, step)))
+}
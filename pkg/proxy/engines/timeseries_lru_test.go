@@ -0,0 +1,105 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"testing"
+	"time"
+
+	cr "github.com/tricksterproxy/trickster/pkg/cache/registration"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/dataset"
+	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
+)
+
+func testLRUDataSet(now time.Time) *dataset.DataSet {
+	pts := make(dataset.Points, 0, 6)
+	for i := int64(1); i <= 6; i++ {
+		pts = append(pts, dataset.Point{Epoch: epoch.Epoch(i * 5 * timeseries.Second),
+			Size: 16, Values: []interface{}{1}})
+	}
+	ds := &dataset.DataSet{
+		Results: []*dataset.Result{{SeriesList: []*dataset.Series{
+			{Header: dataset.SeriesHeader{Name: "test"}, Points: pts},
+		}}},
+		ExtentList: timeseries.ExtentList{
+			timeseries.Extent{Start: time.Unix(5, 0), End: time.Unix(15, 0),
+				LastUsed: now.Add(-2 * time.Hour)},
+			timeseries.Extent{Start: time.Unix(20, 0), End: time.Unix(30, 0),
+				LastUsed: now.Add(-1 * time.Hour)},
+		},
+		TimeRangeQuery: &timeseries.TimeRangeQuery{Step: 5 * time.Second},
+	}
+	ds.Merger = ds.DefaultMerger
+	ds.SizeCropper = ds.DefaultSizeCropper
+	ds.RangeCropper = ds.DefaultRangeCropper
+	ds.Sorter = func() {}
+	return ds
+}
+
+func TestLastUsedKey(t *testing.T) {
+	const expected = "test.key.lru"
+	if k := lastUsedKey("test.key"); k != expected {
+		t.Errorf("expected %s got %s", expected, k)
+	}
+}
+
+func TestApplyLastUsed(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-origin-url", "http://1", "-provider", "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caches := cr.LoadCachesFromConfig(conf, testLogger)
+	cache, ok := caches["default"]
+	if !ok {
+		t.Fatal("could not load cache")
+	}
+
+	const key = "test.dpc.key"
+	now := time.Now().Truncate(time.Second)
+	step := 5 * time.Second
+	used := timeseries.Extent{Start: time.Unix(5, 0), End: time.Unix(15, 0)}
+
+	// without any recorded hits, the least recently written extent is evicted
+	ds := testLRUDataSet(now)
+	applyLastUsed(cache, key, ds, step)
+	ds.CropToSize(3, time.Unix(100, 0), timeseries.Extent{})
+	if ds.ExtentList.String() != "20000-30000" {
+		t.Errorf("expected %s got %s", "20000-30000", ds.ExtentList.String())
+	}
+
+	// a range that keeps getting hits survives the crop
+	for _, d := range []time.Duration{-40 * time.Minute, -20 * time.Minute} {
+		err = recordLastUsed(cache, key, testLRUDataSet(now).ExtentList.Clone(), used,
+			step, time.Minute, now.Add(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds = testLRUDataSet(now)
+	applyLastUsed(cache, key, ds, step)
+	if !ds.ExtentList[0].LastUsed.Equal(now.Add(-20 * time.Minute)) {
+		t.Errorf("expected %s got %s", now.Add(-20*time.Minute), ds.ExtentList[0].LastUsed)
+	}
+	ds.CropToSize(3, time.Unix(100, 0), timeseries.Extent{})
+	if ds.ExtentList.String() != "5000-15000" {
+		t.Errorf("expected %s got %s", "5000-15000", ds.ExtentList.String())
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+)
+
+// TimeseriesMergeGroup is a group of like-provider backends serving the same timeseries
+// data, such as a highly-available pair of Prometheus servers. When a request's context
+// includes a TimeseriesMergeGroup, the DeltaProxyCache requests each upstream range from
+// every member of the group concurrently and merges the responses, so that gaps in one
+// member's data are filled by the others, before caching the merged result
+type TimeseriesMergeGroup struct {
+	// CacheKeyPrefix is used in place of the serving backend's cache key prefix, so the
+	// merged timeseries is cached under the same key regardless of the serving member
+	CacheKeyPrefix string
+	// Members is the list of backends whose responses are merged
+	Members []backends.TimeseriesClient
+}
+
+func (mg *TimeseriesMergeGroup) cacheKeyPrefix() string {
+	return mg.CacheKeyPrefix
+}
+
+type mergeMemberResult struct {
+	ts   timeseries.Timeseries
+	resp *http.Response
+}
+
+// timeseriesMergeGroup returns the TimeseriesMergeGroup for the request, if any
+func timeseriesMergeGroup(r *http.Request) *TimeseriesMergeGroup {
+	if mg, ok := tctx.BackendGroup(r.Context()).(*TimeseriesMergeGroup); ok &&
+		mg != nil && len(mg.Members) > 0 {
+		return mg
+	}
+	return nil
+}
+
+// fetch requests the upstream request from each member of the group concurrently, and
+// returns the merge of the timeseries from all successful responses, along with the first
+// successful response. When no member responds successfully, the returned timeseries
+// is nil and the returned response is that of a failed member
+func (mg *TimeseriesMergeGroup) fetch(r *http.Request, trq *timeseries.TimeRangeQuery,
+	modeler *timeseries.Modeler) (timeseries.Timeseries, *http.Response, time.Duration) {
+
+	rsc := request.GetResources(r)
+
+	// the request body is read once so that it can be provided to each member's request
+	body := readRequestBody(r)
+
+	start := time.Now()
+	results := make([]mergeMemberResult, len(mg.Members))
+	var wg sync.WaitGroup
+	for i, mc := range mg.Members {
+		wg.Add(1)
+		go func(i int, mc backends.TimeseriesClient) {
+			defer wg.Done()
+			mr := memberRequest(r, mc, body)
+			ts, resp := fetchMergeMember(mr, trq, modeler)
+			if ts == nil {
+				tl.Warn(rsc.Logger, "merge group member fetch failed",
+					tl.Pairs{"backendName": mc.Name(), "statusCode": resp.StatusCode})
+			}
+			results[i] = mergeMemberResult{ts: ts, resp: resp}
+		}(i, mc)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var ts timeseries.Timeseries
+	var resp *http.Response
+	mts := make([]timeseries.Timeseries, 0, len(results))
+	for _, mr := range results {
+		if mr.ts == nil {
+			continue
+		}
+		if ts == nil {
+			ts = mr.ts
+			resp = mr.resp
+			continue
+		}
+		mts = append(mts, mr.ts)
+	}
+	if ts == nil {
+		return nil, results[len(results)-1].resp, elapsed
+	}
+	if len(mts) > 0 {
+		ts.Merge(true, mts...)
+	}
+	return ts, resp, elapsed
+}
+
+// fetchMergeMember fetches the member request and unmarshals the response into a
+// timeseries. The returned timeseries is nil if the request was not successful
+func fetchMergeMember(r *http.Request, trq *timeseries.TimeRangeQuery,
+	modeler *timeseries.Modeler) (timeseries.Timeseries, *http.Response) {
+
+	rsc := request.GetResources(r)
+	oc := rsc.BackendOptions
+
+	var handlerName string
+	if rsc.PathConfig != nil {
+		handlerName = rsc.PathConfig.HandlerName
+	}
+
+	start := time.Now()
+	reader, resp, _ := PrepareFetchReader(r)
+	if reader == nil {
+		return nil, resp
+	}
+	body, err := ioutil.ReadAll(reader)
+	resp.Body.Close()
+	if err != nil {
+		tl.Error(rsc.Logger, "error reading body from http response",
+			tl.Pairs{"url": r.URL.String(), "detail": err.Error()})
+		return nil, resp
+	}
+
+	go logUpstreamRequest(rsc.Logger, oc.Name, oc.Provider, handlerName,
+		r.Method, r.URL.String(), r.UserAgent(), resp.StatusCode, len(body),
+		time.Since(start).Seconds())
+
+	if resp.StatusCode != http.StatusOK || len(body) == 0 {
+		return nil, resp
+	}
+
+	ts, err := modeler.WireUnmarshaler(body, trq)
+	if err != nil {
+		tl.Error(rsc.Logger, "proxy object unmarshaling failed",
+			tl.Pairs{"backendName": oc.Name, "detail": err.Error()})
+		return nil, resp
+	}
+	return ts, resp
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
+	tu "github.com/tricksterproxy/trickster/pkg/util/testing"
+	tst "github.com/tricksterproxy/trickster/pkg/util/testing/timeseries/model"
+)
+
+// newTestMergeMember returns a TestClient whose upstream is the provided test server
+func newTestMergeMember(name string, primary *TestClient, ts *httptest.Server) *TestClient {
+	u, _ := url.Parse(ts.URL)
+	oc := primary.config.Clone()
+	oc.Name = name
+	oc.Scheme = u.Scheme
+	oc.Host = u.Host
+	oc.PathPrefix = ""
+	oc.HTTPClient = ts.Client()
+	return &TestClient{name: name, config: oc, cache: primary.cache, webClient: ts.Client()}
+}
+
+func TestDeltaProxyCacheRequestTimeseriesMerge(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	oc := rsc.BackendOptions
+	oc.FastForwardDisable = true
+	step := time.Duration(300) * time.Second
+
+	now := time.Now()
+	end := now.Add(-time.Duration(12) * time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(6) * time.Hour), End: end}
+
+	// the second member serves a series that the primary does not have
+	body := fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[`+
+		`{"metric":{"__name__":"merge_member_series"},"values":[[%d,"1"]]}]}}`,
+		extr.Start.Truncate(step).Add(step).Unix())
+	ts2 := tu.NewTestServer(http.StatusOK, body, nil)
+	defer ts2.Close()
+	// the third member fails, which should not prevent a merged response
+	ts3 := tu.NewTestServer(http.StatusInternalServerError, "", nil)
+	defer ts3.Close()
+
+	mg := &TimeseriesMergeGroup{
+		CacheKeyPrefix: "test-merge",
+		Members: []backends.TimeseriesClient{client,
+			newTestMergeMember("test-2", client, ts2),
+			newTestMergeMember("test-3", client, ts3)},
+	}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s",
+		int(step.Seconds()), extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency)
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), mg))
+
+	for i, status := range []string{"kmiss", "hit"} {
+		w = httptest.NewRecorder()
+		client.QueryRangeHandler(w, r)
+		resp := w.Result()
+
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		err = testStatusCodeMatch(resp.StatusCode, http.StatusOK)
+		if err != nil {
+			t.Error(err)
+		}
+
+		err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": status})
+		if err != nil {
+			t.Errorf("request %d: %s", i, err.Error())
+		}
+
+		if !strings.Contains(string(bodyBytes), "merge_member_series") ||
+			!strings.Contains(string(bodyBytes), "series_id") {
+			t.Errorf("request %d: expected merged series in response: %s", i, string(bodyBytes))
+		}
+
+		// Give time for the object to be written to cache in a separate goroutine from response
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// a partial hit should also fetch the delta from all members
+	extr.End = extr.End.Add(time.Hour)
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s",
+		int(step.Seconds()), extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency)
+	r.URL = u
+	w = httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	err = testResultHeaderPartMatch(w.Result().Header, map[string]string{"status": "phit"})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTimeseriesMergeGroupFetchAllFailed(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	client := rsc.BackendClient.(*TestClient)
+
+	ts2 := tu.NewTestServer(http.StatusInternalServerError, "", nil)
+	defer ts2.Close()
+	ts3 := tu.NewTestServer(http.StatusOK, "{invalid", nil)
+	defer ts3.Close()
+
+	mg := &TimeseriesMergeGroup{
+		Members: []backends.TimeseriesClient{newTestMergeMember("test-2", client, ts2),
+			newTestMergeMember("test-3", client, ts3)},
+	}
+
+	trq := &timeseries.TimeRangeQuery{Step: time.Minute}
+	nts, resp, _ := mg.fetch(r, trq, tst.Modeler())
+	if nts != nil {
+		t.Error("expected nil timeseries")
+	}
+	if resp == nil {
+		t.Error("expected non-nil response")
+	}
+}
+
+func TestTimeseriesMergeGroup(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "http://0/", nil)
+	if timeseriesMergeGroup(r) != nil {
+		t.Error("expected nil merge group")
+	}
+	r = r.WithContext(tctx.WithBackendGroup(r.Context(), &TimeseriesMergeGroup{}))
+	if timeseriesMergeGroup(r) != nil {
+		t.Error("expected nil merge group for a group with no members")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/util/md5"
+)
+
+// varyHeaders returns the sorted, canonicalized list of request header names
+// enumerated by the Vary header(s) in the provided response headers
+func varyHeaders(h http.Header) []string {
+	if h == nil {
+		return nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, v := range h.Values(headers.NameVary) {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variesOnAll returns true if the provided response headers include a Vary value of "*",
+// which indicates the response can never be selected from cache for a subsequent request
+func variesOnAll(h http.Header) bool {
+	for _, name := range varyHeaders(h) {
+		if name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// varyCacheKey returns the secondary cache key under which the variant of the object
+// stored at key is cached, based on the values of the named headers in the request
+func varyCacheKey(key string, vary []string, h http.Header) string {
+	vals := make([]string, 0, len(vary))
+	for _, name := range vary {
+		hv := h.Values(name)
+		vs := make([]string, len(hv))
+		for i, v := range hv {
+			vs[i] = strings.TrimSpace(v)
+		}
+		vals = append(vals, name+"="+strings.Join(vs, ","))
+	}
+	return SecondaryKeyPrefix(key) + "vary." + md5.Checksum(strings.Join(vals, "\n"))
+}
+
+// equalVaryHeaders returns true if the two lists of Vary header names are identical
+func equalVaryHeaders(v1, v2 []string) bool {
+	if len(v1) != len(v2) {
+		return false
+	}
+	for i := range v1 {
+		if v1[i] != v2[i] {
+			return false
+		}
+	}
+	return true
+}
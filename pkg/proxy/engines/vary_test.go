@@ -0,0 +1,117 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVaryHeaders(t *testing.T) {
+
+	if v := varyHeaders(nil); v != nil {
+		t.Errorf("expected nil got %v", v)
+	}
+
+	h := http.Header{"Vary": {"accept-encoding, Accept", "Accept-Language,,accept"}}
+	v := varyHeaders(h)
+	expected := []string{"Accept", "Accept-Encoding", "Accept-Language"}
+	if !equalVaryHeaders(v, expected) {
+		t.Errorf("expected %v got %v", expected, v)
+	}
+
+}
+
+func TestVariesOnAll(t *testing.T) {
+
+	if variesOnAll(http.Header{"Vary": {"Accept"}}) {
+		t.Error("expected false")
+	}
+
+	if !variesOnAll(http.Header{"Vary": {"Accept, *"}}) {
+		t.Error("expected true")
+	}
+
+}
+
+func TestVaryCacheKey(t *testing.T) {
+
+	vary := []string{"Accept", "Accept-Encoding"}
+	h1 := http.Header{"Accept": {" application/json"}, "Accept-Encoding": {"gzip"}}
+	h2 := http.Header{"Accept": {"application/json"}, "Accept-Encoding": {"gzip"}}
+	h3 := http.Header{"Accept": {"application/json"}}
+
+	k1 := varyCacheKey("test", vary, h1)
+	if !strings.HasPrefix(k1, "test.vary.") {
+		t.Errorf("unexpected key %s", k1)
+	}
+
+	if k2 := varyCacheKey("test", vary, h2); k1 != k2 {
+		t.Errorf("expected %s got %s", k1, k2)
+	}
+
+	if k3 := varyCacheKey("test", vary, h3); k1 == k3 {
+		t.Error("expected keys to differ")
+	}
+
+	// ensure the request header is not modified
+	if h1.Get("Accept") != " application/json" {
+		t.Errorf("unexpected header value %s", h1.Get("Accept"))
+	}
+
+}
+
+func TestEqualVaryHeaders(t *testing.T) {
+
+	tests := []struct {
+		v1, v2   []string
+		expected bool
+	}{
+		{nil, nil, true},
+		{[]string{"Accept"}, nil, false},
+		{[]string{"Accept"}, []string{"Accept"}, true},
+		{[]string{"Accept"}, []string{"Accept-Encoding"}, false},
+	}
+
+	for i, test := range tests {
+		if res := equalVaryHeaders(test.v1, test.v2); res != test.expected {
+			t.Errorf("test %d: expected %t got %t", i, test.expected, res)
+		}
+	}
+
+}
+
+func TestVaryHeadersMarshal(t *testing.T) {
+
+	d := &HTTPDocument{VaryHeaders: []string{"Accept", "Accept-Encoding"}}
+	b, err := d.MarshalMsg(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d2 := &HTTPDocument{}
+	_, err = d2.UnmarshalMsg(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equalVaryHeaders(d.VaryHeaders, d2.VaryHeaders) {
+		t.Errorf("expected %v got %v", d.VaryHeaders, d2.VaryHeaders)
+	}
+
+}
@@ -69,6 +69,10 @@ var ErrPCFContentLength = errors.New("content length does not permit PCF")
 // ErrUnsupportedEncoding indicates that the client requested an encoding that is not supported by Trickster
 var ErrUnsupportedEncoding = errors.New("unsupported ecoding format requested")
 
+// ErrRangeDependentQuery indicates that a query's results depend upon its requested time range
+// (e.g., a PromQL @ start() modifier), so it cannot be delta cached
+var ErrRangeDependentQuery = errors.New("query results depend upon the requested time range")
+
 // MissingURLParam returns a Formatted Error
 func MissingURLParam(param string) error {
 	return fmt.Errorf("missing URL parameter: [%s]", param)
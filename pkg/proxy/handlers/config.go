@@ -18,17 +18,40 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/tricksterproxy/trickster/pkg/config"
 	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 )
 
-// ConfigHandleFunc responds to the HTTP request with the running configuration
+// ConfigHandleFunc responds to the HTTP request with the running configuration. The
+// configuration is written as YAML when requested with ?format=yaml or an Accept header
+// containing yaml, or when the running configuration was loaded from a YAML file;
+// ?format=toml always forces TOML output
 func ConfigHandleFunc(conf *config.Config) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set(headers.NameContentType, headers.ValueTextPlain)
 		w.Header().Set(headers.NameCacheControl, headers.ValueNoCache)
+		if wantsYAML(conf, r) {
+			w.Header().Set(headers.NameContentType, headers.ValueApplicationYAML)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(conf.YAMLString()))
+			return
+		}
+		w.Header().Set(headers.NameContentType, headers.ValueTextPlain)
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(conf.String()))
 	}
 }
+
+func wantsYAML(conf *config.Config, r *http.Request) bool {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "yaml", "yml":
+		return true
+	case "toml":
+		return false
+	}
+	if strings.Contains(strings.ToLower(r.Header.Get(headers.NameAccept)), "yaml") {
+		return true
+	}
+	return config.IsYAMLPath(conf.ConfigFilePath())
+}
@@ -19,9 +19,11 @@ package handlers
 import (
 	"io/ioutil"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/tricksterproxy/trickster/pkg/config"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 )
 
 func TestConfigHandler(t *testing.T) {
@@ -57,4 +59,32 @@ func TestConfigHandler(t *testing.T) {
 		t.Errorf("response is not toml format")
 	}
 
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "http://0/trickster/config?format=yaml", nil)
+	configHandler(w, r)
+	resp = w.Result()
+
+	if ct := resp.Header.Get(headers.NameContentType); ct != headers.ValueApplicationYAML {
+		t.Errorf("expected %s got %s", headers.ValueApplicationYAML, ct)
+	}
+
+	bodyBytes, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !strings.Contains(string(bodyBytes), "backends:") {
+		t.Errorf("response is not yaml format")
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "http://0/trickster/config", nil)
+	r.Header.Set(headers.NameAccept, headers.ValueApplicationYAML)
+	configHandler(w, r)
+	resp = w.Result()
+
+	if ct := resp.Header.Get(headers.NameContentType); ct != headers.ValueApplicationYAML {
+		t.Errorf("expected %s got %s", headers.ValueApplicationYAML, ct)
+	}
+
 }
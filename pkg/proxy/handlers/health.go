@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+)
+
+// healthStatus is the aggregated background health check status of all backends
+type healthStatus struct {
+	Status   string                 `json:"status"`
+	Backends []*backendHealthStatus `json:"backends"`
+}
+
+type backendHealthStatus struct {
+	Name         string `json:"name"`
+	Provider     string `json:"provider"`
+	Status       string `json:"status"`
+	Detail       string `json:"detail,omitempty"`
+	FailingSince string `json:"failingSince,omitempty"`
+}
+
+// HealthHandleFunc responds to the HTTP request with the background health check status
+// of all backends registered with the HealthChecker. The response is JSON when the request
+// accepts application/json or includes the 'format=json' query parameter, and otherwise
+// plain text. The response status is 503 Service Unavailable if any backend is failing
+func HealthHandleFunc(hc healthcheck.HealthChecker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		hs := &healthStatus{Status: "passing", Backends: make([]*backendHealthStatus, 0)}
+		code := http.StatusOK
+
+		if hc != nil {
+			for _, s := range hc.Statuses() {
+				bs := &backendHealthStatus{Name: s.Name(), Provider: s.Provider(),
+					Status: s.String(), Detail: s.Detail()}
+				if s.Get() == healthcheck.StatusFailing {
+					hs.Status = "failing"
+					code = http.StatusServiceUnavailable
+					if fs := s.FailingSince(); !fs.IsZero() {
+						bs.FailingSince = fs.UTC().Format(time.RFC3339)
+					}
+				}
+				hs.Backends = append(hs.Backends, bs)
+			}
+			sort.Slice(hs.Backends, func(i, j int) bool {
+				return hs.Backends[i].Name < hs.Backends[j].Name
+			})
+		}
+
+		w.Header().Set(headers.NameCacheControl, headers.ValueNoCache)
+
+		if r.URL.Query().Get("format") == "json" ||
+			strings.Contains(r.Header.Get(headers.NameAccept), headers.ValueApplicationJSON) {
+			b, _ := json.Marshal(hs)
+			w.Header().Set(headers.NameContentType, headers.ValueApplicationJSON)
+			w.WriteHeader(code)
+			w.Write(b)
+			return
+		}
+
+		w.Header().Set(headers.NameContentType, headers.ValueTextPlain)
+		w.WriteHeader(code)
+		w.Write([]byte(hs.String()))
+	}
+}
+
+func (hs *healthStatus) String() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Trickster Backend Health Status: %s\n\n", hs.Status)
+	tw := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	for _, bs := range hs.Backends {
+		fmt.Fprintf(tw, "%s\t%s\t%s", bs.Name, bs.Provider, bs.Status)
+		if bs.FailingSince != "" {
+			fmt.Fprintf(tw, "\tsince %s", bs.FailingSince)
+		}
+		if bs.Detail != "" {
+			fmt.Fprintf(tw, "\t%s", bs.Detail)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	return buf.String()
+}
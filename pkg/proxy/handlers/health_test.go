@@ -0,0 +1,107 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	bo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+)
+
+func TestHealthHandleFunc(t *testing.T) {
+
+	hc := healthcheck.New()
+	defer hc.Shutdown()
+
+	// backends without an upstream origin are registered with an unknown status
+	o := bo.New()
+	o.Provider = "prometheus"
+	s1, _ := hc.Register("test-1", o, nil, nil)
+	o = bo.New()
+	o.Provider = "influxdb"
+	s2, _ := hc.Register("test-2", o, nil, nil)
+
+	h := HealthHandleFunc(hc)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/trickster/health", nil)
+	h(w, r)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+	if resp.Header.Get(headers.NameContentType) != headers.ValueTextPlain {
+		t.Errorf("expected %s got %s", headers.ValueTextPlain, resp.Header.Get(headers.NameContentType))
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(b), "test-1  prometheus  unknown") {
+		t.Errorf("unexpected body: %s", string(b))
+	}
+
+	s1.Set(healthcheck.StatusPassing, "")
+	s2.Set(healthcheck.StatusFailing, "unexpected status code: 500")
+
+	w = httptest.NewRecorder()
+	r.Header.Set(headers.NameAccept, headers.ValueApplicationJSON)
+	h(w, r)
+	resp = w.Result()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected %d got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	if resp.Header.Get(headers.NameContentType) != headers.ValueApplicationJSON {
+		t.Errorf("expected %s got %s", headers.ValueApplicationJSON,
+			resp.Header.Get(headers.NameContentType))
+	}
+	hs := &healthStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(hs); err != nil {
+		t.Fatal(err)
+	}
+	if hs.Status != "failing" || len(hs.Backends) != 2 {
+		t.Fatalf("unexpected health status: %v", hs)
+	}
+	if hs.Backends[0].Name != "test-1" || hs.Backends[0].Status != "passing" {
+		t.Errorf("unexpected backend status: %v", hs.Backends[0])
+	}
+	if hs.Backends[1].Name != "test-2" || hs.Backends[1].Status != "failing" ||
+		hs.Backends[1].FailingSince == "" ||
+		hs.Backends[1].Detail != "unexpected status code: 500" {
+		t.Errorf("unexpected backend status: %v", hs.Backends[1])
+	}
+
+	// the json format can also be requested with a query parameter
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "http://0/trickster/health?format=json", nil)
+	h(w, r)
+	if w.Result().Header.Get(headers.NameContentType) != headers.ValueApplicationJSON {
+		t.Errorf("expected %s got %s", headers.ValueApplicationJSON,
+			w.Result().Header.Get(headers.NameContentType))
+	}
+
+	// a nil health checker reports no backends
+	w = httptest.NewRecorder()
+	HealthHandleFunc(nil)(w, r)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Result().StatusCode)
+	}
+}
@@ -46,3 +46,9 @@ func HandleBadRequestResponse(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusBadRequest)
 	w.Write(nil)
 }
+
+// HandleBadGateway responds to an HTTP Request with 502 Bad Gateway
+func HandleBadGateway(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusBadGateway)
+	w.Write(nil)
+}
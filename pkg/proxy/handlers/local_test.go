@@ -166,3 +166,12 @@ func TestHandleBadRequestResponse(t *testing.T) {
 		t.Errorf("expected %d got %d", 400, w.Result().StatusCode)
 	}
 }
+
+func TestHandleBadGateway(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://0/trickster/", nil)
+	HandleBadGateway(w, r)
+	if w.Result().StatusCode != 502 {
+		t.Errorf("expected %d got %d", 502, w.Result().StatusCode)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
+)
+
+// PurgeHandleFunc responds to authenticated HTTP requests to remove objects from a backend's cache.
+// The request must include the 'backend' query parameter, and may include either a 'key' parameter
+// to remove a single cache key, or a 'path' parameter (with optional 'method') to remove the objects
+// cached for that backend request path and query. When neither is provided, all objects under the
+// backend's CacheKeyPrefix are removed. For multi-tenant backends, the 'tenant' parameter limits
+// the purge to the objects cached for that tenant, and is required when purging by path.
+// The response body is the number of objects removed
+func PurgeHandleFunc(conf *config.Config, clients backends.Backends,
+	logger *tl.Logger) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			purgeResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		// requests that passed the admin authenticator do not also need the purge token
+		if !(conf.Main.AdminAuthenticatorName != "" && authenticator.GetIdentity(r) != nil) &&
+			!purgeAuthorized(conf.Main.PurgeAuthToken, r) {
+			w.Header().Set(headers.NameWWWAuthenticate, "Bearer")
+			purgeResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		qp := r.URL.Query()
+		name := qp.Get("backend")
+		if name == "" {
+			purgeResponse(w, http.StatusBadRequest, "missing backend parameter")
+			return
+		}
+
+		client := clients.Get(name)
+		if client == nil || client.Cache() == nil {
+			purgeResponse(w, http.StatusNotFound, "no cache for backend "+name)
+			return
+		}
+		c := client.Cache()
+		oc := client.Configuration()
+
+		tenant := qp.Get("tenant")
+		if tenant != "" {
+			if !oc.IsMultiTenant() {
+				purgeResponse(w, http.StatusBadRequest, "backend "+name+" is not multi-tenant")
+				return
+			}
+			if err := oo.ValidateTenantID(tenant); err != nil {
+				purgeResponse(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		var n int
+		var err error
+		if k := qp.Get("key"); k != "" {
+			n = removeKey(c, k)
+		} else if p := qp.Get("path"); p != "" {
+			if oc.IsMultiTenant() && tenant == "" {
+				purgeResponse(w, http.StatusBadRequest, "missing tenant parameter")
+				return
+			}
+			n, err = purgePath(client, p, qp.Get("method"), tenant, logger)
+			if err != nil {
+				purgeResponse(w, http.StatusNotFound, err.Error())
+				return
+			}
+		} else {
+			pr, ok := c.(cache.PrefixRemover)
+			if !ok {
+				purgeResponse(w, http.StatusNotImplemented,
+					"cache provider does not support purging by backend")
+				return
+			}
+			n, err = purgePrefixes(pr, oc, tenant)
+			if err != nil {
+				purgeResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		tl.Info(logger, "cache purge", tl.Pairs{"backendName": name, "key": qp.Get("key"),
+			"path": qp.Get("path"), "tenant": tenant, "removed": n})
+		purgeResponse(w, http.StatusOK, fmt.Sprintf("purged %d", n))
+	}
+}
+
+// purgePrefixes removes the objects cached for the provided tenant, or all objects cached for
+// the backend when tenant is empty, including those of tenants with a cache key prefix override
+func purgePrefixes(pr cache.PrefixRemover, oc *oo.Options, tenant string) (int, error) {
+	prefixes := []string{oc.TenantCacheKeyPrefix(oc.CacheKeyPrefix, tenant)}
+	if tenant == "" {
+		for _, p := range oc.TenantCacheKeyPrefixes {
+			if p != "" {
+				prefixes = append(prefixes, p)
+			}
+		}
+	}
+	var n int
+	for _, p := range prefixes {
+		i, err := pr.RemovePrefix(p + ".")
+		n += i
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// purgePath removes the objects cached for the provided backend request path and query,
+// on behalf of the provided tenant when the backend is multi-tenant
+func purgePath(client backends.Client, path, method, tenant string,
+	logger *tl.Logger) (int, error) {
+
+	if method == "" {
+		method = http.MethodGet
+	}
+	method = strings.ToUpper(method)
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return 0, err
+	}
+
+	oc := client.Configuration()
+	pc := matchPath(oc.Paths, u.Path, method)
+	if pc == nil {
+		return 0, fmt.Errorf("no path config for %s %s", method, u.Path)
+	}
+
+	r, err := http.NewRequest(method, urls.BuildUpstreamURL(&http.Request{URL: u},
+		urls.FromParts(oc.Scheme, oc.Host, oc.PathPrefix, "", "")).String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if tenant != "" {
+		r.Header.Set(oc.TenantHeader, tenant)
+	}
+	rsc := request.NewResources(oc, pc, client.Cache().Configuration(), client.Cache(),
+		client, nil, logger)
+	r = r.WithContext(tctx.WithResources(r.Context(), rsc))
+
+	c := client.Cache()
+	pr, _ := c.(cache.PrefixRemover)
+	var n int
+	for _, k := range engines.CacheKeys(r) {
+		n += removeKey(c, k)
+		// objects stored secondary to the key, such as Vary variants, are only listed
+		// by caches that can enumerate their keys
+		if pr == nil {
+			continue
+		}
+		i, err := pr.RemovePrefix(engines.SecondaryKeyPrefix(k))
+		if err != nil {
+			return n, err
+		}
+		n += i
+	}
+	return n, nil
+}
+
+// removeKey removes the object stored under the provided key, and returns the number
+// of objects removed, which is 0 if the key was not present in the cache
+func removeKey(c cache.Cache, key string) int {
+	if _, _, err := c.Retrieve(key, true); err != nil {
+		return 0
+	}
+	c.Remove(key)
+	return 1
+}
+
+// matchPath returns the path config that the router would use for the provided path and method,
+// preferring an exact match over the longest prefix match
+func matchPath(paths map[string]*po.Options, path, method string) *po.Options {
+	var match *po.Options
+	for _, pc := range paths {
+		if pc == nil || !hasMethod(pc.Methods, method) {
+			continue
+		}
+		if pc.MatchType == matching.PathMatchTypeExact {
+			if pc.Path == path {
+				return pc
+			}
+			continue
+		}
+		if strings.HasPrefix(path, pc.Path) && (match == nil || len(pc.Path) > len(match.Path)) {
+			match = pc
+		}
+	}
+	return match
+}
+
+func hasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func purgeAuthorized(token string, r *http.Request) bool {
+	if token == "" {
+		return false
+	}
+	h := r.Header.Get(headers.NameAuthorization)
+	if !strings.HasPrefix(h, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(h, "Bearer ")), []byte(token)) == 1
+}
+
+func purgeResponse(w http.ResponseWriter, code int, body string) {
+	w.Header().Set(headers.NameContentType, headers.ValueTextPlain)
+	w.Header().Set(headers.NameCacheControl, headers.ValueNoCache)
+	w.WriteHeader(code)
+	w.Write([]byte(body + "\n"))
+}
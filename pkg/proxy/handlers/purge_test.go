@@ -0,0 +1,335 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/mockster/pkg/testutil"
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	bo "github.com/tricksterproxy/trickster/pkg/backends/options"
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus"
+	"github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	co "github.com/tricksterproxy/trickster/pkg/cache/options"
+	"github.com/tricksterproxy/trickster/pkg/cache/registration"
+	"github.com/tricksterproxy/trickster/pkg/cache/status"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/engines"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
+	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request"
+)
+
+type testPurgeClient struct {
+	oc *bo.Options
+	c  cache.Cache
+}
+
+func (c *testPurgeClient) Handlers() map[string]http.Handler                     { return nil }
+func (c *testPurgeClient) DefaultPathConfigs(*bo.Options) map[string]*po.Options { return nil }
+func (c *testPurgeClient) Configuration() *bo.Options                            { return c.oc }
+func (c *testPurgeClient) Name() string                                          { return "test" }
+func (c *testPurgeClient) HTTPClient() *http.Client                              { return nil }
+func (c *testPurgeClient) SetCache(cc cache.Cache)                               { c.c = cc }
+func (c *testPurgeClient) Router() http.Handler                                  { return nil }
+func (c *testPurgeClient) Cache() cache.Cache                                    { return c.c }
+
+func newTestPurgeClient() *testPurgeClient {
+	oc := bo.New()
+	oc.Host = "0"
+	oc.Scheme = "http"
+	oc.CacheKeyPrefix = "test"
+	oc.Paths = map[string]*po.Options{
+		"/api/-GET-HEAD": {Path: "/api/", MatchType: matching.PathMatchTypePrefix,
+			Methods: []string{http.MethodGet, http.MethodHead}, CacheKeyParams: []string{"q"}},
+	}
+	return &testPurgeClient{oc: oc, c: registration.NewCache("test", co.New(), nil)}
+}
+
+func testPurgeRequest(h func(http.ResponseWriter, *http.Request),
+	method, query, token string) (int, string) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "http://0/trickster/purge?"+query, nil)
+	if token != "" {
+		r.Header.Set(headers.NameAuthorization, "Bearer "+token)
+	}
+	h(w, r)
+	b, _ := ioutil.ReadAll(w.Result().Body)
+	return w.Result().StatusCode, string(b)
+}
+
+func TestPurgeHandleFunc(t *testing.T) {
+
+	conf := config.NewConfig()
+	conf.Main.PurgeAuthToken = "test-token"
+	client := newTestPurgeClient()
+	defer client.c.Close()
+	h := PurgeHandleFunc(conf, backends.Backends{"test": client}, tl.ConsoleLogger("error"))
+
+	tests := []struct {
+		method, query, token string
+		code                 int
+	}{
+		{http.MethodGet, "backend=test", "test-token", http.StatusMethodNotAllowed},
+		{http.MethodPost, "backend=test", "", http.StatusUnauthorized},
+		{http.MethodPost, "backend=test", "wrong-token", http.StatusUnauthorized},
+		{http.MethodPost, "", "test-token", http.StatusBadRequest},
+		{http.MethodPost, "backend=other", "test-token", http.StatusNotFound},
+		{http.MethodPost, "backend=test&path=/other/", "test-token", http.StatusNotFound},
+		{http.MethodPost, "backend=test&path=/api/&method=POST", "test-token", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		if code, body := testPurgeRequest(h, test.method, test.query, test.token); code != test.code {
+			t.Errorf("%s %s: expected %d got %d: %s", test.method, test.query, test.code, code, body)
+		}
+	}
+
+	// purge by key
+	client.c.Store("test.key", []byte("data"), time.Minute)
+	code, body := testPurgeRequest(h, http.MethodDelete, "backend=test&key=test.key", "test-token")
+	if code != http.StatusOK || body != "purged 1\n" {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if _, ls, _ := client.c.Retrieve("test.key", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+
+	// purging a key that is not present removes nothing
+	code, body = testPurgeRequest(h, http.MethodDelete, "backend=test&key=test.key", "test-token")
+	if code != http.StatusOK || body != "purged 0\n" {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+
+	// purge by path
+	r, _ := http.NewRequest(http.MethodGet, "http://0/api/v1?q=up&other=1", nil)
+	r = r.WithContext(tctx.WithResources(r.Context(), request.NewResources(client.oc,
+		client.oc.Paths["/api/-GET-HEAD"], nil, client.c, client, nil, nil)))
+	keys := engines.CacheKeys(r)
+	if len(keys) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(keys))
+	}
+	// the path's Vary variants are purged along with its index document
+	variant := engines.SecondaryKeyPrefix(keys[0]) + "vary.test"
+	client.c.Store(keys[0], []byte("data"), time.Minute)
+	client.c.Store(variant, []byte("data"), time.Minute)
+	time.Sleep(time.Millisecond * 10)
+	code, body = testPurgeRequest(h, http.MethodPost,
+		"backend=test&path="+"%2Fapi%2Fv1%3Fq%3Dup", "test-token")
+	if code != http.StatusOK || body != "purged 2\n" {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	for _, k := range []string{keys[0], variant} {
+		if _, ls, _ := client.c.Retrieve(k, false); ls != status.LookupStatusKeyMiss {
+			t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+		}
+	}
+
+	// purge by backend, once the index has processed the removals above
+	time.Sleep(time.Millisecond * 10)
+	client.c.Store("test.a", []byte("data"), time.Minute)
+	client.c.Store("test.b", []byte("data"), time.Minute)
+	client.c.Store("other.a", []byte("data"), time.Minute)
+	code, body = testPurgeRequest(h, http.MethodPost, "backend=test", "test-token")
+	if code != http.StatusOK || body != "purged 2\n" {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if _, ls, _ := client.c.Retrieve("other.a", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+}
+
+func TestPurgeHandleFuncAdminAuthenticator(t *testing.T) {
+
+	conf := config.NewConfig()
+	conf.Main.AdminAuthenticatorName = "test"
+	client := newTestPurgeClient()
+	defer client.c.Close()
+	h := PurgeHandleFunc(conf, backends.Backends{"test": client}, tl.ConsoleLogger("error"))
+
+	code, _ := testPurgeRequest(h, http.MethodPost, "backend=test", "")
+	if code != http.StatusUnauthorized {
+		t.Errorf("expected %d got %d", http.StatusUnauthorized, code)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "http://0/trickster/purge?backend=test", nil)
+	r = r.WithContext(tctx.WithIdentity(r.Context(), &authenticator.Identity{Username: "admin"}))
+	h(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestPurgeHandleFuncTenant(t *testing.T) {
+
+	conf := config.NewConfig()
+	conf.Main.PurgeAuthToken = "test-token"
+	client := newTestPurgeClient()
+	defer client.c.Close()
+	h := PurgeHandleFunc(conf, backends.Backends{"test": client}, tl.ConsoleLogger("error"))
+
+	// a tenant may not be provided to a backend that is not multi-tenant
+	code, body := testPurgeRequest(h, http.MethodPost, "backend=test&tenant=team-a", "test-token")
+	if code != http.StatusBadRequest {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+
+	client.oc.TenantHeader = "X-Scope-OrgID"
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"backend=test&tenant=team%2Fa", http.StatusBadRequest},
+		{"backend=test&path=%2Fapi%2Fv1%3Fq%3Dup", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if code, body := testPurgeRequest(h, http.MethodPost, test.query, "test-token"); code != test.code {
+			t.Errorf("%s: expected %d got %d: %s", test.query, test.code, code, body)
+		}
+	}
+
+	// purge by path for a tenant
+	r, _ := http.NewRequest(http.MethodGet, "http://0/api/v1?q=up", nil)
+	r.Header.Set(client.oc.TenantHeader, "team-a")
+	r = r.WithContext(tctx.WithResources(r.Context(), request.NewResources(client.oc,
+		client.oc.Paths["/api/-GET-HEAD"], nil, client.c, client, nil, nil)))
+	keys := engines.CacheKeys(r)
+	if len(keys) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(keys))
+	}
+	client.c.Store(keys[0], []byte("data"), time.Minute)
+	code, body = testPurgeRequest(h, http.MethodPost,
+		"backend=test&tenant=team-a&path=%2Fapi%2Fv1%3Fq%3Dup", "test-token")
+	if code != http.StatusOK {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if _, ls, _ := client.c.Retrieve(keys[0], false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+
+	// purge a single tenant, once the index has processed the removals above
+	time.Sleep(time.Millisecond * 10)
+	client.c.Store("test.tenant.team-a.a", []byte("data"), time.Minute)
+	client.c.Store("test.tenant.team-a.b", []byte("data"), time.Minute)
+	client.c.Store("test.tenant.team-ab.a", []byte("data"), time.Minute)
+	code, body = testPurgeRequest(h, http.MethodPost, "backend=test&tenant=team-a", "test-token")
+	if code != http.StatusOK || body != "purged 2\n" {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if _, ls, _ := client.c.Retrieve("test.tenant.team-ab.a", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+
+	// purging the backend also purges the tenants with a cache key prefix override
+	client.oc.TenantCacheKeyPrefixes = map[string]string{"team-b": "prefix-team-b"}
+	client.c.Store("prefix-team-b.a", []byte("data"), time.Minute)
+	client.c.Store("prefix-team-bc.a", []byte("data"), time.Minute)
+	time.Sleep(time.Millisecond * 10)
+	code, body = testPurgeRequest(h, http.MethodPost, "backend=test", "test-token")
+	if code != http.StatusOK || body != "purged 2\n" {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	if _, ls, _ := client.c.Retrieve("prefix-team-b.a", false); ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %s got %s", status.LookupStatusKeyMiss, ls)
+	}
+	if _, ls, _ := client.c.Retrieve("prefix-team-bc.a", false); ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+}
+
+func TestPurgeHandleFuncChunks(t *testing.T) {
+
+	ts := testutil.NewTestServer()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	oc := bo.New()
+	oc.Provider = "prometheus"
+	oc.Scheme = u.Scheme
+	oc.Host = u.Host
+	oc.PathPrefix = "/prometheus"
+	oc.CacheKeyPrefix = "test"
+	oc.FastForwardDisable = true
+	oc.TimeseriesChunkFactor = 24
+
+	c := registration.NewCache("test", co.New(), nil)
+	defer c.Close()
+	client, err := prometheus.NewClient("test", oc, nil, c, model.NewModeler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	oc.Paths = client.DefaultPathConfigs(oc)
+	oc.HTTPClient = client.HTTPClient()
+	logger := tl.ConsoleLogger("error")
+
+	end := time.Now().Add(-time.Hour)
+	path := "/api/v1/query_range?" + url.Values{
+		"query": {`up{series_count="1",latency_ms="0",range_latency_ms="0"}`},
+		"start": {fmt.Sprint(end.Add(-6 * time.Hour).Unix())},
+		"end":   {fmt.Sprint(end.Unix())},
+		"step":  {"300"},
+	}.Encode()
+
+	// the query's timeseries is cached as several chunks
+	r := httptest.NewRequest(http.MethodGet, "http://0"+path, nil)
+	r = r.WithContext(tctx.WithResources(r.Context(), request.NewResources(oc,
+		matchPath(oc.Paths, "/api/v1/query_range", http.MethodGet), c.Configuration(), c,
+		client, nil, logger)))
+	w := httptest.NewRecorder()
+	client.(*prometheus.Client).QueryRangeHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	time.Sleep(time.Millisecond * 10)
+
+	conf := config.NewConfig()
+	conf.Main.PurgeAuthToken = "test-token"
+	h := PurgeHandleFunc(conf, backends.Backends{"test": client}, logger)
+
+	// the chunks are purged along with the query's metadata document
+	code, body := testPurgeRequest(h, http.MethodPost,
+		"backend=test&path="+url.QueryEscape(path), "test-token")
+	var n int
+	fmt.Sscanf(body, "purged %d", &n)
+	if code != http.StatusOK || n < 4 {
+		t.Errorf("unexpected response %d %s", code, body)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if n, _ = c.(cache.PrefixRemover).RemovePrefix("test."); n != 0 {
+		t.Errorf("expected %d got %d", 0, n)
+	}
+}
+
+func TestPurgeHandleFuncDisabled(t *testing.T) {
+	// an empty token never authorizes a purge
+	h := PurgeHandleFunc(config.NewConfig(), backends.Backends{}, tl.ConsoleLogger("error"))
+	if code, _ := testPurgeRequest(h, http.MethodPost, "backend=test", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected %d got %d", http.StatusUnauthorized, code)
+	}
+}
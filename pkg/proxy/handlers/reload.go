@@ -20,7 +20,6 @@ import (
 	"net/http"
 	"sync"
 
-	"github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	"github.com/tricksterproxy/trickster/pkg/config/reload"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
@@ -29,8 +28,7 @@ import (
 
 // ReloadHandleFunc will reload the running configuration if it has changed
 func ReloadHandleFunc(f reload.ReloaderFunc, conf *config.Config, wg *sync.WaitGroup,
-	log *tl.Logger, caches map[string]cache.Cache,
-	args []string) func(http.ResponseWriter, *http.Request) {
+	log *tl.Logger, args []string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if conf != nil {
 			conf.Main.ReloaderLock.Lock()
@@ -38,7 +36,7 @@ func ReloadHandleFunc(f reload.ReloaderFunc, conf *config.Config, wg *sync.WaitG
 			if conf.IsStale() {
 				tl.Warn(log,
 					"configuration reload starting now", tl.Pairs{"source": "reloadEndpoint"})
-				err := f(conf, wg, log, caches, args, false)
+				err := f(conf, wg, log, args, false)
 				if err == nil {
 					w.Header().Set(headers.NameContentType, headers.ValueTextPlain)
 					w.Header().Set(headers.NameCacheControl, headers.ValueNoCache)
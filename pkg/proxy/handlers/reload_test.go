@@ -26,7 +26,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
 )
@@ -34,7 +33,7 @@ import (
 func TestReloadHandleFunc(t *testing.T) {
 
 	var emptyFunc = func(*config.Config, *sync.WaitGroup, *tl.Logger,
-		map[string]cache.Cache, []string, bool) error {
+		[]string, bool) error {
 		return nil
 	}
 
@@ -57,7 +56,7 @@ func TestReloadHandleFunc(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/", nil)
 
-	f := ReloadHandleFunc(emptyFunc, cfg, nil, log, nil, nil)
+	f := ReloadHandleFunc(emptyFunc, cfg, nil, log, nil)
 	f(w, r)
 	os.Remove(testFile)
 	time.Sleep(time.Millisecond * 500)
@@ -34,6 +34,12 @@ const (
 	ValueApplicationCSV = "application/csv"
 	// ValueApplicationJSON represents the HTTP Header Value of "application/json"
 	ValueApplicationJSON = "application/json"
+	// ValueApplicationFlux represents the HTTP Header Value of "application/vnd.flux"
+	ValueApplicationFlux = "application/vnd.flux"
+	// ValueApplicationYAML represents the HTTP Header Value of "application/yaml"
+	ValueApplicationYAML = "application/yaml"
+	// ValueApplicationProtobuf represents the HTTP Header Value of "application/x-protobuf"
+	ValueApplicationProtobuf = "application/x-protobuf"
 	// ValueChunked represents the HTTP Header Value of "chunked"
 	ValueChunked = "chunked"
 	// ValueMaxAge represents the HTTP Header Value of "max-age"
@@ -56,8 +62,16 @@ const (
 	ValuePublic = "public"
 	// ValueSharedMaxAge represents the HTTP Header Value of "s-maxage"
 	ValueSharedMaxAge = "s-maxage"
+	// ValueStaleIfError represents the HTTP Header Value of "stale-if-error"
+	ValueStaleIfError = "stale-if-error"
+	// ValueStaleWhileRevalidate represents the HTTP Header Value of "stale-while-revalidate"
+	ValueStaleWhileRevalidate = "stale-while-revalidate"
+	// ValueSnappy represents the HTTP Header Value of "snappy"
+	ValueSnappy = "snappy"
 	// ValueTextPlain represents the HTTP Header Value of "text/plain"
 	ValueTextPlain = "text/plain"
+	// ValueTextCSV represents the HTTP Header Value of "text/csv"
+	ValueTextCSV = "text/csv"
 	// ValueXFormURLEncoded represents the HTTP Header Value of "application/x-www-form-urlencoded"
 	ValueXFormURLEncoded = "application/x-www-form-urlencoded"
 
@@ -82,6 +96,10 @@ const (
 	NameContentLength = "Content-Length"
 	// NameAuthorization represents the HTTP Header Name of "Authorization"
 	NameAuthorization = "Authorization"
+	// NameWWWAuthenticate represents the HTTP Header Name of "WWW-Authenticate"
+	NameWWWAuthenticate = "WWW-Authenticate"
+	// NameRetryAfter represents the HTTP Header Name of "Retry-After"
+	NameRetryAfter = "Retry-After"
 	// NameContentRange represents the HTTP Header Name of "Content-Range"
 	NameContentRange = "Content-Range"
 	// NameTricksterResult represents the HTTP Header Name of "X-Trickster-Result"
@@ -102,6 +120,8 @@ const (
 	NameIfNoneMatch = "If-None-Match"
 	// NameIfMatch represents the HTTP Header Name of "If-Match"
 	NameIfMatch = "If-Match"
+	// NameIfRange represents the HTTP Header Name of "If-Range"
+	NameIfRange = "If-Range"
 	// NameDate represents the HTTP Header Name of "date"
 	NameDate = "Date"
 	// NamePragma represents the HTTP Header Name of "pragma"
@@ -128,6 +148,8 @@ const (
 	NameTrailer = "Trailer"
 	// NameUpgrade represents the HTTP Header Name of "Upgrade"
 	NameUpgrade = "Upgrade"
+	// NameVary represents the HTTP Header Name of "Vary"
+	NameVary = "Vary"
 )
 
 // Lookup represents a simple lookup for internal header manipulation
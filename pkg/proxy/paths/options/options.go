@@ -21,12 +21,15 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/tricksterproxy/trickster/pkg/cache/key"
 	"github.com/tricksterproxy/trickster/pkg/proxy/forwarding"
 	"github.com/tricksterproxy/trickster/pkg/proxy/methods"
 	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
+	"github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter"
+	rlo "github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter"
 	strutil "github.com/tricksterproxy/trickster/pkg/util/strings"
 )
@@ -64,6 +67,14 @@ type Options struct {
 	// ReqRewriterName is the name of a configured Rewriter that will modify the request prior to
 	// processing by the backend client
 	ReqRewriterName string `toml:"req_rewriter_name"`
+	// RateLimiterName is the name of a configured Rate Limiter that is applied to requests to this path,
+	// in addition to any rate limiter of the backend
+	RateLimiterName string `toml:"rate_limiter_name"`
+	// NonTimeseriesTTLMS overrides the backend's non_timeseries_ttl_ms for this path
+	NonTimeseriesTTLMS int `toml:"non_timeseries_ttl_ms"`
+	// TimeNormalizationMS is the granularity to which time parameters (e.g., start and end)
+	// are rounded down before proxying and caching, for handlers that support it
+	TimeNormalizationMS int `toml:"time_normalization_ms"`
 
 	// Handler is the HTTP Handler represented by the Path's HandlerName
 	Handler http.Handler `toml:"-"`
@@ -81,12 +92,24 @@ type Options struct {
 	Custom []string `toml:"-"`
 	// ReqRewriter is the rewriter handler as indicated by RuleName
 	ReqRewriter rewriter.RewriteInstructions
+	// RateLimiter is the path's instance of the rate limiter indicated by RateLimiterName
+	RateLimiter *ratelimiter.Limiter `toml:"-"`
+	// NonTimeseriesTTL is the parsed value of NonTimeseriesTTLMS
+	NonTimeseriesTTL time.Duration `toml:"-"`
+	// TimeNormalization is the parsed value of TimeNormalizationMS
+	TimeNormalization time.Duration `toml:"-"`
 
 	// NoMetrics, when set to true, disables metrics decoration for the path
 	NoMetrics bool `toml:"no_metrics"`
 	// HasCustomResponseBody is a boolean indicating if the response body is custom
 	// this flag allows an empty string response to be configured as a return value
 	HasCustomResponseBody bool `toml:"-"`
+	// HasNonTimeseriesTTL is a boolean indicating if the path overrides the backend's
+	// NonTimeseriesTTL; this flag allows a TTL of 0 to disable caching for the path
+	HasNonTimeseriesTTL bool `toml:"-"`
+	// HasTimeNormalization is a boolean indicating if TimeNormalization is set for the path,
+	// rather than the handler's default; this flag allows a value of 0 to disable normalization
+	HasTimeNormalization bool `toml:"-"`
 }
 
 // Lookup is a map of Options
@@ -126,6 +149,8 @@ func (o *Options) Clone() *Options {
 		RequestParams:           strutil.CloneMap(o.RequestParams),
 		ReqRewriter:             o.ReqRewriter,
 		ReqRewriterName:         o.ReqRewriterName,
+		RateLimiter:             o.RateLimiter,
+		RateLimiterName:         o.RateLimiterName,
 		ResponseHeaders:         strutil.CloneMap(o.ResponseHeaders),
 		ResponseBody:            o.ResponseBody,
 		ResponseBodyBytes:       o.ResponseBodyBytes,
@@ -133,6 +158,12 @@ func (o *Options) Clone() *Options {
 		CollapsedForwardingType: o.CollapsedForwardingType,
 		NoMetrics:               o.NoMetrics,
 		HasCustomResponseBody:   o.HasCustomResponseBody,
+		NonTimeseriesTTLMS:      o.NonTimeseriesTTLMS,
+		NonTimeseriesTTL:        o.NonTimeseriesTTL,
+		HasNonTimeseriesTTL:     o.HasNonTimeseriesTTL,
+		TimeNormalizationMS:     o.TimeNormalizationMS,
+		TimeNormalization:       o.TimeNormalization,
+		HasTimeNormalization:    o.HasTimeNormalization,
 		Methods:                 make([]string, len(o.Methods)),
 		CacheKeyParams:          make([]string, len(o.CacheKeyParams)),
 		CacheKeyHeaders:         make([]string, len(o.CacheKeyHeaders)),
@@ -192,6 +223,17 @@ func (o *Options) Merge(o2 *Options) {
 		case "req_rewriter_name":
 			o.ReqRewriterName = o2.ReqRewriterName
 			o.ReqRewriter = o2.ReqRewriter
+		case "rate_limiter_name":
+			o.RateLimiterName = o2.RateLimiterName
+			o.RateLimiter = o2.RateLimiter
+		case "non_timeseries_ttl_ms":
+			o.NonTimeseriesTTLMS = o2.NonTimeseriesTTLMS
+			o.NonTimeseriesTTL = o2.NonTimeseriesTTL
+			o.HasNonTimeseriesTTL = true
+		case "time_normalization_ms":
+			o.TimeNormalizationMS = o2.TimeNormalizationMS
+			o.TimeNormalization = o2.TimeNormalization
+			o.HasTimeNormalization = true
 		}
 	}
 	o.Custom = strutil.Unique(o.Custom)
@@ -200,7 +242,7 @@ func (o *Options) Merge(o2 *Options) {
 var pathMembers = []string{"path", "match_type", "handler", "methods", "cache_key_params",
 	"cache_key_headers", "default_ttl_ms", "request_headers", "response_headers",
 	"response_headers", "response_code", "response_body", "no_metrics", "collapsed_forwarding",
-	"req_rewriter_name",
+	"req_rewriter_name", "non_timeseries_ttl_ms", "time_normalization_ms", "rate_limiter_name",
 }
 
 func ProcessTOML(
@@ -208,11 +250,19 @@ func ProcessTOML(
 	metadata *toml.MetaData,
 	paths Lookup,
 	crw map[string]rewriter.RewriteInstructions,
+	rateLimiters rlo.Lookup,
 ) error {
 	if metadata == nil {
 		return errors.New("invalid config metadata")
 	}
-	for k, p := range paths {
+	// paths are re-keyed by their path and methods below, so the configured keys
+	// are collected first, to ensure the re-keyed entries are not processed again
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		p := paths[k]
 		if metadata.IsDefined("backends", backendName, "paths", k, "req_rewriter_name") &&
 			p.ReqRewriterName != "" {
 			ri, ok := crw[p.ReqRewriterName]
@@ -222,6 +272,15 @@ func ProcessTOML(
 			}
 			p.ReqRewriter = ri
 		}
+		if metadata.IsDefined("backends", backendName, "paths", k, "rate_limiter_name") &&
+			p.RateLimiterName != "" {
+			rl, ok := rateLimiters[p.RateLimiterName]
+			if !ok {
+				return fmt.Errorf("invalid rate limiter name %s in path %s of backend config %s",
+					p.RateLimiterName, k, backendName)
+			}
+			p.RateLimiter = ratelimiter.New(rl)
+		}
 		if len(p.Methods) == 0 {
 			p.Methods = []string{http.MethodGet, http.MethodHead}
 		}
@@ -235,6 +294,14 @@ func ProcessTOML(
 			p.ResponseBodyBytes = []byte(p.ResponseBody)
 			p.HasCustomResponseBody = true
 		}
+		if metadata.IsDefined("backends", backendName, "paths", k, "non_timeseries_ttl_ms") {
+			p.NonTimeseriesTTL = time.Duration(p.NonTimeseriesTTLMS) * time.Millisecond
+			p.HasNonTimeseriesTTL = true
+		}
+		if metadata.IsDefined("backends", backendName, "paths", k, "time_normalization_ms") {
+			p.TimeNormalization = time.Duration(p.TimeNormalizationMS) * time.Millisecond
+			p.HasTimeNormalization = true
+		}
 		if metadata.IsDefined("backends", backendName, "paths", k, "collapsed_forwarding") {
 			if _, ok := forwarding.CollapsedForwardingTypeNames[p.CollapsedForwardingName]; !ok {
 				return fmt.Errorf("invalid collapsed_forwarding name: %s", p.CollapsedForwardingName)
@@ -251,6 +318,7 @@ func ProcessTOML(
 			p.MatchType = matching.PathMatchTypeExact
 			p.MatchTypeName = p.MatchType.String()
 		}
+		delete(paths, k)
 		paths[p.Path+"-"+strings.Join(p.Methods, "-")] = p
 	}
 	return nil
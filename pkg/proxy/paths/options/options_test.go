@@ -18,8 +18,11 @@ package options
 
 import (
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/tricksterproxy/trickster/pkg/proxy/forwarding"
 	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
 )
@@ -61,7 +64,8 @@ func TestPathMerge(t *testing.T) {
 	pc2.Custom = []string{"path", "match_type", "handler", "methods",
 		"cache_key_params", "cache_key_headers", "cache_key_form_fields",
 		"request_headers", "request_params", "response_headers",
-		"response_code", "response_body", "no_metrics", "collapsed_forwarding"}
+		"response_code", "response_body", "no_metrics", "collapsed_forwarding",
+		"non_timeseries_ttl_ms", "time_normalization_ms", "rate_limiter_name"}
 
 	expectedPath := "testPath"
 	expectedHandlerName := "testHandler"
@@ -81,6 +85,11 @@ func TestPathMerge(t *testing.T) {
 	pc2.NoMetrics = true
 	pc2.CollapsedForwardingName = "progressive"
 	pc2.CollapsedForwardingType = forwarding.CFTypeProgressive
+	pc2.NonTimeseriesTTLMS = 5000
+	pc2.NonTimeseriesTTL = 5 * time.Second
+	pc2.TimeNormalizationMS = 30000
+	pc2.TimeNormalization = 30 * time.Second
+	pc2.RateLimiterName = "test"
 
 	pc.Merge(pc2)
 
@@ -141,6 +150,18 @@ func TestPathMerge(t *testing.T) {
 		t.Errorf("expected %s got %s", "progressive", pc.CollapsedForwardingName)
 	}
 
+	if !pc.HasNonTimeseriesTTL || pc.NonTimeseriesTTL != 5*time.Second {
+		t.Errorf("expected %s got %s", 5*time.Second, pc.NonTimeseriesTTL)
+	}
+
+	if !pc.HasTimeNormalization || pc.TimeNormalization != 30*time.Second {
+		t.Errorf("expected %s got %s", 30*time.Second, pc.TimeNormalization)
+	}
+
+	if pc.RateLimiterName != "test" {
+		t.Errorf("expected %s got %s", "test", pc.RateLimiterName)
+	}
+
 }
 
 func TestMerge(t *testing.T) {
@@ -154,3 +175,63 @@ func TestMerge(t *testing.T) {
 	}
 
 }
+
+const testPathsTOML = `
+[backends.test.paths]
+  [backends.test.paths.render]
+  path = '/render'
+  methods = [ 'GET', 'POST' ]
+  time_normalization_ms = 10000
+
+  [backends.test.paths.root]
+  path = '/'
+  rate_limiter_name = 'invalid'
+`
+
+func TestProcessTOML(t *testing.T) {
+
+	decode := func(s string) (Lookup, *toml.MetaData) {
+		var conf struct {
+			Backends map[string]struct {
+				Paths Lookup `toml:"paths"`
+			} `toml:"backends"`
+		}
+		md, err := toml.Decode(s, &conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conf.Backends["test"].Paths, &md
+	}
+
+	paths, md := decode(testPathsTOML)
+	if err := ProcessTOML("test", nil, paths, nil, nil); err == nil {
+		t.Error("expected error for nil metadata")
+	}
+
+	if err := ProcessTOML("test", md, paths, nil, nil); err == nil {
+		t.Error("expected error for invalid rate limiter name")
+	}
+
+	paths, md = decode(strings.Replace(testPathsTOML, "rate_limiter_name = 'invalid'", "", -1))
+	if err := ProcessTOML("test", md, paths, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// paths are re-keyed by path and methods, so they can overlay the default paths
+	if len(paths) != 2 {
+		t.Errorf("expected %d got %d", 2, len(paths))
+	}
+	p, ok := paths["/render-GET-POST"]
+	if !ok {
+		t.Fatalf("expected path %s", "/render-GET-POST")
+	}
+	if !p.HasTimeNormalization || p.TimeNormalization != 10*time.Second {
+		t.Errorf("expected %s got %s", 10*time.Second, p.TimeNormalization)
+	}
+	if len(p.Custom) != 3 {
+		t.Errorf("expected %d got %d", 3, len(p.Custom))
+	}
+	if _, ok := paths["/-GET-HEAD"]; !ok {
+		t.Errorf("expected path %s", "/-GET-HEAD")
+	}
+}
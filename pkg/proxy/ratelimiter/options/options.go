@@ -0,0 +1,125 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package options provides options for Frontend Rate Limiters
+package options
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tricksterproxy/trickster/pkg/config/defaults"
+)
+
+// Options is a collection of Options pertaining to a token bucket Rate Limiter
+type Options struct {
+	// Name is the name of the Rate Limiter, taken from the Key in the RateLimiters map
+	Name string `toml:"-"`
+	// RequestsPerSecond is the rate at which each key's token bucket is refilled
+	RequestsPerSecond float64 `toml:"requests_per_second"`
+	// Burst is the capacity of each key's token bucket, which is the maximum number of requests
+	// that can be made at once; the default is RequestsPerSecond, rounded up
+	Burst int `toml:"burst"`
+	// KeySource indicates the request property by which requests are assigned to a bucket. Possible options:
+	//  Source      Description
+	//  client_ip   the IP address of the client (default)
+	//  header      the value of the request header named by KeyName
+	//  param       the value of the query parameter named by KeyName
+	//  identity    the username of the authenticated client
+	//  global      all requests share a single bucket
+	KeySource string `toml:"key_source"`
+	// KeyName provides the target header or param name when KeySource is header or param
+	KeyName string `toml:"key_name"`
+	// MaxKeys is the maximum number of buckets tracked by the Rate Limiter; once reached, requests
+	// with new keys share a single overflow bucket until idle buckets are released
+	MaxKeys int `toml:"max_keys"`
+}
+
+// Lookup is a map of Options
+type Lookup map[string]*Options
+
+var keySources = map[string]bool{
+	"client_ip": true,
+	"header":    true,
+	"param":     true,
+	"identity":  true,
+	"global":    true,
+}
+
+// New returns a new Options reference with default values set
+func New() *Options {
+	return &Options{
+		KeySource: defaults.DefaultRateLimiterKeySource,
+		MaxKeys:   defaults.DefaultRateLimiterMaxKeys,
+	}
+}
+
+// Clone returns an exact copy of the subject *Options
+func (o *Options) Clone() *Options {
+	return &Options{
+		Name:              o.Name,
+		RequestsPerSecond: o.RequestsPerSecond,
+		Burst:             o.Burst,
+		KeySource:         o.KeySource,
+		KeyName:           o.KeyName,
+		MaxKeys:           o.MaxKeys,
+	}
+}
+
+// Validate sets any unconfigured values to their defaults, and returns an error
+// if the Options are invalid
+func (o *Options) Validate() error {
+	if o.RequestsPerSecond <= 0 || math.IsInf(o.RequestsPerSecond, 0) ||
+		math.IsNaN(o.RequestsPerSecond) {
+		return fmt.Errorf("invalid requests_per_second for rate limiter %s", o.Name)
+	}
+	if o.Burst < 0 {
+		return fmt.Errorf("invalid burst for rate limiter %s", o.Name)
+	}
+	if o.Burst == 0 {
+		o.Burst = int(math.Ceil(o.RequestsPerSecond))
+	}
+	if o.KeySource == "" {
+		o.KeySource = defaults.DefaultRateLimiterKeySource
+	}
+	if _, ok := keySources[o.KeySource]; !ok {
+		return fmt.Errorf("invalid key_source [%s] for rate limiter %s", o.KeySource, o.Name)
+	}
+	if (o.KeySource == "header" || o.KeySource == "param") && o.KeyName == "" {
+		return fmt.Errorf("missing key_name for rate limiter %s", o.Name)
+	}
+	if o.MaxKeys < 0 {
+		return fmt.Errorf("invalid max_keys for rate limiter %s", o.Name)
+	}
+	if o.MaxKeys == 0 {
+		o.MaxKeys = defaults.DefaultRateLimiterMaxKeys
+	}
+	return nil
+}
+
+// Validate names and validates each of the Options in the Lookup
+func (l Lookup) Validate() error {
+	for k, o := range l {
+		if o == nil {
+			return fmt.Errorf("invalid options for rate limiter %s", k)
+		}
+		o.Name = k
+		if err := o.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
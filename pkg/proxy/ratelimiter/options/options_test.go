@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	o := &Options{Name: "test", RequestsPerSecond: 2.5, Burst: 5, KeySource: "header",
+		KeyName: "X-API-Key", MaxKeys: 10}
+	o2 := o.Clone()
+	if *o2 != *o {
+		t.Error("clone mismatch")
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	tests := []struct {
+		o          *Options
+		isErr      bool
+		expBurst   int
+		expSource  string
+		expMaxKeys int
+	}{
+		{&Options{RequestsPerSecond: 2.5}, false, 3, "client_ip", 10000},
+		{&Options{RequestsPerSecond: 10, Burst: 20, KeySource: "identity", MaxKeys: 5},
+			false, 20, "identity", 5},
+		{&Options{RequestsPerSecond: 1, KeySource: "header", KeyName: "X-API-Key"},
+			false, 1, "header", 10000},
+		{&Options{RequestsPerSecond: 1, KeySource: "global"}, false, 1, "global", 10000},
+		{&Options{}, true, 0, "", 0},
+		{&Options{RequestsPerSecond: -1}, true, 0, "", 0},
+		{&Options{RequestsPerSecond: 1, Burst: -1}, true, 0, "", 0},
+		{&Options{RequestsPerSecond: 1, KeySource: "invalid"}, true, 0, "", 0},
+		{&Options{RequestsPerSecond: 1, KeySource: "param"}, true, 0, "", 0},
+		{&Options{RequestsPerSecond: 1, MaxKeys: -1}, true, 0, "", 0},
+	}
+
+	for i, test := range tests {
+		err := test.o.Validate()
+		if test.isErr {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if test.o.Burst != test.expBurst {
+			t.Errorf("test %d: expected %d got %d", i, test.expBurst, test.o.Burst)
+		}
+		if test.o.KeySource != test.expSource {
+			t.Errorf("test %d: expected %s got %s", i, test.expSource, test.o.KeySource)
+		}
+		if test.o.MaxKeys != test.expMaxKeys {
+			t.Errorf("test %d: expected %d got %d", i, test.expMaxKeys, test.o.MaxKeys)
+		}
+	}
+}
+
+func TestLookupValidate(t *testing.T) {
+
+	l := Lookup{"test": &Options{RequestsPerSecond: 1}}
+	if err := l.Validate(); err != nil {
+		t.Error(err)
+	}
+	if l["test"].Name != "test" {
+		t.Errorf("expected %s got %s", "test", l["test"].Name)
+	}
+
+	l["invalid"] = &Options{}
+	if err := l.Validate(); err == nil {
+		t.Error("expected error for invalid options")
+	}
+
+	if err := (Lookup{"nil": nil}).Validate(); err == nil {
+		t.Error("expected error for nil options")
+	}
+
+	n := New()
+	if n.KeySource != "client_ip" || n.MaxKeys != 10000 {
+		t.Error("unexpected defaults")
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ratelimiter provides token bucket rate limiting of client requests
+// to the Trickster frontend
+package ratelimiter
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter/options"
+	"github.com/tricksterproxy/trickster/pkg/util/metrics"
+)
+
+// overflowKey is the key of the bucket shared by new keys once MaxKeys is reached
+const overflowKey = "\x00overflow"
+
+type keyFunc func(*http.Request, string) string
+
+var keyFuncs = map[string]keyFunc{
+	"client_ip": clientIPKey,
+	"header":    headerKey,
+	"param":     paramKey,
+	"identity":  identityKey,
+	"global":    globalKey,
+}
+
+// Limiter is a token bucket Rate Limiter that maintains a bucket for each
+// key derived from its requests
+type Limiter struct {
+	options *options.Options
+	key     keyFunc
+	buckets map[string]*bucket
+	mtx     sync.Mutex
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a new Limiter using the provided Options, which must already be validated
+func New(o *options.Options) *Limiter {
+	f, ok := keyFuncs[o.KeySource]
+	if !ok {
+		f = clientIPKey
+	}
+	return &Limiter{
+		options: o,
+		key:     f,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Options returns the Limiter's Options
+func (l *Limiter) Options() *options.Options {
+	return l.options
+}
+
+// Allow takes a token from the bucket for the request's key, returning true if a token
+// was available. When false, the returned duration is the time until a token will be available
+func (l *Limiter) Allow(r *http.Request) (bool, time.Duration) {
+	k := l.key(r, l.options.KeyName)
+	now := l.now()
+	rate := l.options.RequestsPerSecond
+	burst := float64(l.options.Burst)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	b, ok := l.buckets[k]
+	if !ok {
+		if len(l.buckets) >= l.options.MaxKeys {
+			l.release(now)
+		}
+		if len(l.buckets) >= l.options.MaxKeys {
+			k = overflowKey
+			b = l.buckets[k]
+		}
+		if b == nil {
+			b = &bucket{tokens: burst, last: now}
+			l.buckets[k] = b
+		}
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// release removes the buckets that have been idle long enough to be full again,
+// as they are indistinguishable from new buckets. The caller must hold the lock
+func (l *Limiter) release(now time.Time) {
+	idle := time.Duration(float64(l.options.Burst) / l.options.RequestsPerSecond * float64(time.Second))
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// Handler applies the Limiter to requests before passing them to the next handler.
+// Requests that exceed the rate limit receive a 429 Too Many Requests response with
+// a Retry-After header
+func Handler(l *Limiter, backendName, path string, next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.Allow(r)
+		if !ok {
+			metrics.ProxyRateLimiterRequests.WithLabelValues(backendName, path,
+				l.options.Name, "limited").Inc()
+			w.Header().Set(headers.NameRetryAfter,
+				strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		metrics.ProxyRateLimiterRequests.WithLabelValues(backendName, path,
+			l.options.Name, "allowed").Inc()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func clientIPKey(r *http.Request, unused string) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func headerKey(r *http.Request, name string) string {
+	return r.Header.Get(name)
+}
+
+func paramKey(r *http.Request, name string) string {
+	if r.URL == nil {
+		return ""
+	}
+	return r.URL.Query().Get(name)
+}
+
+func identityKey(r *http.Request, unused string) string {
+	if id := authenticator.GetIdentity(r); id != nil {
+		return id.Username
+	}
+	return ""
+}
+
+func globalKey(r *http.Request, unused string) string {
+	return ""
+}
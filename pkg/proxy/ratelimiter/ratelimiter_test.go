@@ -0,0 +1,201 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
+	"github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter/options"
+)
+
+func testLimiter(t *testing.T, o *options.Options) (*Limiter, *time.Time) {
+	if err := o.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	o.Name = "test"
+	l := New(o)
+	now := time.Unix(1577836800, 0)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestAllow(t *testing.T) {
+
+	l, now := testLimiter(t, &options.Options{RequestsPerSecond: 2, Burst: 2})
+	r := httptest.NewRequest("GET", "http://0/", nil)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow(r); !ok {
+			t.Errorf("expected request %d to be allowed", i)
+		}
+	}
+
+	ok, wait := l.Allow(r)
+	if ok {
+		t.Error("expected request to be limited")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected %s got %s", 500*time.Millisecond, wait)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow(r); !ok {
+		t.Error("expected request to be allowed after refill")
+	}
+	if ok, _ := l.Allow(r); ok {
+		t.Error("expected request to be limited")
+	}
+
+	// a full refill must not exceed the burst
+	*now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow(r); !ok {
+			t.Errorf("expected request %d to be allowed", i)
+		}
+	}
+	if ok, _ := l.Allow(r); ok {
+		t.Error("expected request to be limited")
+	}
+
+	if l.Options().Name != "test" {
+		t.Errorf("expected %s got %s", "test", l.Options().Name)
+	}
+}
+
+func TestAllowKeySources(t *testing.T) {
+
+	newRequest := func(addr, header, param, user string) *http.Request {
+		r := httptest.NewRequest("GET", "http://0/?key="+param, nil)
+		r.RemoteAddr = addr
+		r.Header.Set("X-API-Key", header)
+		if user != "" {
+			r = r.WithContext(tctx.WithIdentity(r.Context(),
+				&authenticator.Identity{Username: user}))
+		}
+		return r
+	}
+
+	tests := []struct {
+		source string
+		r1, r2 *http.Request
+		shared bool
+	}{
+		{"client_ip", newRequest("1.2.3.4:1000", "", "", ""),
+			newRequest("1.2.3.4:2000", "", "", ""), true},
+		{"client_ip", newRequest("1.2.3.4:1000", "", "", ""),
+			newRequest("5.6.7.8:1000", "", "", ""), false},
+		{"header", newRequest("1.2.3.4:1000", "a", "", ""),
+			newRequest("1.2.3.4:1000", "b", "", ""), false},
+		{"header", newRequest("1.2.3.4:1000", "a", "", ""),
+			newRequest("5.6.7.8:1000", "a", "", ""), true},
+		{"param", newRequest("1.2.3.4:1000", "", "a", ""),
+			newRequest("1.2.3.4:1000", "", "b", ""), false},
+		{"identity", newRequest("1.2.3.4:1000", "", "", "alice"),
+			newRequest("1.2.3.4:1000", "", "", "bob"), false},
+		{"identity", newRequest("1.2.3.4:1000", "", "", "alice"),
+			newRequest("5.6.7.8:1000", "", "", "alice"), true},
+		{"global", newRequest("1.2.3.4:1000", "a", "a", "alice"),
+			newRequest("5.6.7.8:1000", "b", "b", "bob"), true},
+	}
+
+	for i, test := range tests {
+		l, _ := testLimiter(t, &options.Options{RequestsPerSecond: 1, Burst: 1,
+			KeySource: test.source, KeyName: "key"})
+		if test.source == "header" {
+			l.options.KeyName = "X-API-Key"
+		}
+		if ok, _ := l.Allow(test.r1); !ok {
+			t.Errorf("test %d: expected first request to be allowed", i)
+		}
+		if ok, _ := l.Allow(test.r2); ok == test.shared {
+			t.Errorf("test %d: expected shared bucket to be %t", i, test.shared)
+		}
+	}
+}
+
+func TestAllowMaxKeys(t *testing.T) {
+
+	l, now := testLimiter(t, &options.Options{RequestsPerSecond: 1, Burst: 1,
+		KeySource: "header", KeyName: "X-API-Key", MaxKeys: 2})
+
+	newRequest := func(key string) *http.Request {
+		r := httptest.NewRequest("GET", "http://0/", nil)
+		r.Header.Set("X-API-Key", key)
+		return r
+	}
+
+	l.Allow(newRequest("a"))
+	l.Allow(newRequest("b"))
+
+	// new keys share the overflow bucket while the map is full
+	if ok, _ := l.Allow(newRequest("c")); !ok {
+		t.Error("expected first overflow request to be allowed")
+	}
+	if ok, _ := l.Allow(newRequest("d")); ok {
+		t.Error("expected second overflow request to be limited")
+	}
+	if _, ok := l.buckets[overflowKey]; !ok {
+		t.Error("expected overflow bucket")
+	}
+
+	// idle buckets are released once they have refilled
+	*now = now.Add(time.Second)
+	if ok, _ := l.Allow(newRequest("c")); !ok {
+		t.Error("expected request to be allowed after release")
+	}
+	if _, ok := l.buckets["c"]; !ok {
+		t.Error("expected bucket for released key")
+	}
+	if len(l.buckets) > 2 {
+		t.Errorf("expected at most %d buckets got %d", 2, len(l.buckets))
+	}
+}
+
+func TestHandler(t *testing.T) {
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	if h := Handler(nil, "test", "/", next); h == nil {
+		t.Error("expected non-nil handler")
+	}
+
+	l, _ := testLimiter(t, &options.Options{RequestsPerSecond: 0.5, Burst: 1})
+	h := Handler(l, "test", "/", next)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://0/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://0/", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected %d got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if v := w.Header().Get(headers.NameRetryAfter); v != "2" {
+		t.Errorf("expected %s got %s", "2", v)
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
 )
 
 type rewriteInstruction interface {
@@ -187,6 +189,40 @@ func checkTokens(input string) bool {
 	return false
 }
 
+// expandTokens replaces any identity tokens in the input with the values from the
+// request's authenticated Identity: ${identity} with the username, and
+// ${identity_claim:NAME} with the named claim. Unknown tokens are left as-is,
+// and identity tokens are replaced with an empty string for unauthenticated requests
+func expandTokens(r *http.Request, input string) string {
+	id := authenticator.GetIdentity(r)
+	var sb strings.Builder
+	for {
+		i := strings.Index(input, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.Index(input[i:], "}")
+		if j < 0 {
+			break
+		}
+		sb.WriteString(input[:i])
+		token := input[i+2 : i+j]
+		switch {
+		case token == "identity":
+			if id != nil {
+				sb.WriteString(id.Username)
+			}
+		case strings.HasPrefix(token, "identity_claim:"):
+			sb.WriteString(id.Claim(token[15:]))
+		default:
+			sb.WriteString(input[i : i+j+1])
+		}
+		input = input[i+j+1:]
+	}
+	sb.WriteString(input)
+	return sb.String()
+}
+
 type rwiKeyBasedSetter struct {
 	key, value string
 	hasTokens  bool
@@ -213,8 +249,12 @@ func (ri *rwiKeyBasedSetter) Parse(parts []string) error {
 }
 
 func (ri *rwiKeyBasedSetter) Execute(r *http.Request) {
+	value := ri.value
+	if ri.hasTokens {
+		value = expandTokens(r, value)
+	}
 	dict := ri.dict(r)
-	dict.Set(ri.key, ri.value)
+	dict.Set(ri.key, value)
 	if qp, ok := dict.(url.Values); ok {
 		r.URL.RawQuery = qp.Encode()
 	}
@@ -253,6 +293,11 @@ type mappable map[string][]string
 
 func (ri *rwiKeyBasedAppender) Execute(r *http.Request) {
 
+	value := ri.value
+	if ri.hasTokens {
+		value = expandTokens(r, value)
+	}
+
 	dict := ri.dict(r)
 	var m mappable
 	var ok bool
@@ -272,7 +317,7 @@ func (ri *rwiKeyBasedAppender) Execute(r *http.Request) {
 	vals, ok = m[ri.key]
 	// key does not exist, so set value instead of appending
 	if !ok {
-		dict.Set(ri.key, ri.value)
+		dict.Set(ri.key, value)
 		if q != nil {
 			r.URL.RawQuery = q.Encode()
 		}
@@ -282,12 +327,12 @@ func (ri *rwiKeyBasedAppender) Execute(r *http.Request) {
 	// appending to url param value
 	if q != nil {
 		for _, v := range vals {
-			if v == ri.value {
+			if v == value {
 				// the desired value is already in the query, do nothing
 				return
 			}
 		}
-		m[ri.key] = append(vals, ri.value)
+		m[ri.key] = append(vals, value)
 		r.URL.RawQuery = q.Encode()
 		return
 	}
@@ -295,11 +340,11 @@ func (ri *rwiKeyBasedAppender) Execute(r *http.Request) {
 	// appending to header value
 
 	var subkey string
-	j := strings.Index(ri.value, "=")
+	j := strings.Index(value, "=")
 	if j > 0 {
-		subkey = ri.value[:j]
+		subkey = value[:j]
 	} else {
-		subkey = ri.value
+		subkey = value
 	}
 
 	// this might look redundant, but it normalizes something like:
@@ -309,19 +354,19 @@ func (ri *rwiKeyBasedAppender) Execute(r *http.Request) {
 
 	var found bool
 	for i, part := range parts {
-		if part == ri.value {
+		if part == value {
 			// value exists in header already, nothing to do
 			return
 		}
 		if strings.HasPrefix(part, subkey+"=") {
 			// a right-subkey=wrong-value exists, set it to the right value
-			parts[i] = ri.value
+			parts[i] = value
 			found = true
 		}
 	}
 
 	if !found {
-		parts = append(parts, ri.value)
+		parts = append(parts, value)
 	}
 
 	h.Set(ri.key, strings.Join(parts, ", "))
@@ -386,8 +431,12 @@ func (ri *rwiKeyBasedReplacer) Execute(r *http.Request) {
 		return
 	}
 
+	replacement := ri.replacement
+	if ri.hasTokens {
+		replacement = expandTokens(r, replacement)
+	}
 	for i := range vals {
-		vals[i] = strings.Replace(vals[i], ri.search, ri.replacement, ri.depth)
+		vals[i] = strings.Replace(vals[i], ri.search, replacement, ri.depth)
 	}
 	m[ri.key] = vals
 
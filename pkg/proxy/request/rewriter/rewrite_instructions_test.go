@@ -23,6 +23,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	tctx "github.com/tricksterproxy/trickster/pkg/proxy/context"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter/options"
 	"github.com/tricksterproxy/trickster/pkg/proxy/urls"
 )
@@ -367,6 +369,69 @@ func TestHasTokens(t *testing.T) {
 
 }
 
+func TestExpandTokens(t *testing.T) {
+
+	r, _ := http.NewRequest("GET", testURLRaw, nil)
+	ri := r.WithContext(tctx.WithIdentity(r.Context(), &authenticator.Identity{Username: "alice",
+		Claims: map[string]interface{}{"org": "acme", "groups": []interface{}{"dev", "ops"}}}))
+
+	tests := []struct {
+		r        *http.Request
+		input    string
+		expected string
+	}{
+		{ri, "static", "static"},
+		{ri, "${identity}", "alice"},
+		{ri, "user=${identity}; org=${identity_claim:org}", "user=alice; org=acme"},
+		{ri, "${identity_claim:groups}", "dev,ops"},
+		{ri, "${identity_claim:missing}", ""},
+		{ri, "${trickster}-${identity}", "${trickster}-alice"},
+		{ri, "${identity", "${identity"},
+		{r, "user=${identity}", "user="},
+		{r, "${identity_claim:org}", ""},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := expandTokens(test.r, test.input); got != test.expected {
+				t.Errorf("expected %s got %s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestExecuteIdentityTokens(t *testing.T) {
+
+	ris, err := parseRewriteList(options.RewriteList{
+		[]string{"header", "set", "X-Scope-OrgID", "${identity_claim:org}"},
+		[]string{"header", "append", "Forwarded", "user=${identity}"},
+		[]string{"header", "replace", "X-Test", "anonymous", "${identity}"},
+		[]string{"param", "set", "user", "${identity}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := http.NewRequest("GET", testURLRaw, nil)
+	r.Header.Set("X-Test", "user: anonymous")
+	r = r.WithContext(tctx.WithIdentity(r.Context(), &authenticator.Identity{Username: "alice",
+		Claims: map[string]interface{}{"org": "acme"}}))
+	ris.Execute(r)
+
+	if v := r.Header.Get("X-Scope-OrgID"); v != "acme" {
+		t.Errorf("expected %s got %s", "acme", v)
+	}
+	if v := r.Header.Get("Forwarded"); v != "user=alice" {
+		t.Errorf("expected %s got %s", "user=alice", v)
+	}
+	if v := r.Header.Get("X-Test"); v != "user: alice" {
+		t.Errorf("expected %s got %s", "user: alice", v)
+	}
+	if v := r.URL.Query().Get("user"); v != "alice" {
+		t.Errorf("expected %s got %s", "alice", v)
+	}
+}
+
 func TestNilRequestGetters(t *testing.T) {
 	for _, f := range scalarGets {
 		v := f(nil)
@@ -25,12 +25,18 @@ import (
 	"strings"
 
 	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/alb"
 	"github.com/tricksterproxy/trickster/pkg/backends/clickhouse"
 	modelch "github.com/tricksterproxy/trickster/pkg/backends/clickhouse/model"
+	"github.com/tricksterproxy/trickster/pkg/backends/graphite"
+	modelgraphite "github.com/tricksterproxy/trickster/pkg/backends/graphite/model"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
 	"github.com/tricksterproxy/trickster/pkg/backends/influxdb"
 	modelflux "github.com/tricksterproxy/trickster/pkg/backends/influxdb/model"
 	"github.com/tricksterproxy/trickster/pkg/backends/irondb"
 	modeliron "github.com/tricksterproxy/trickster/pkg/backends/irondb/model"
+	"github.com/tricksterproxy/trickster/pkg/backends/loki"
+	modelloki "github.com/tricksterproxy/trickster/pkg/backends/loki/model"
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/backends/prometheus"
 	modelprom "github.com/tricksterproxy/trickster/pkg/backends/prometheus/model"
@@ -41,9 +47,11 @@ import (
 	"github.com/tricksterproxy/trickster/pkg/cache"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
 	"github.com/tricksterproxy/trickster/pkg/proxy/methods"
 	"github.com/tricksterproxy/trickster/pkg/proxy/paths/matching"
 	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter"
 	"github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter"
 	"github.com/tricksterproxy/trickster/pkg/tracing"
 	"github.com/tricksterproxy/trickster/pkg/util/middleware"
@@ -63,9 +71,11 @@ func RegisterPprofRoutes(routerName string, h *http.ServeMux, logger interface{}
 }
 
 // RegisterProxyRoutes iterates the Trickster Configuration and
-// registers the routes for the configured backends
+// registers the routes for the configured backends. When a HealthChecker is provided,
+// each backend with an upstream origin is registered with it for background health checks,
+// as are ALB pool members, so that unhealthy members are taken out of rotation
 func RegisterProxyRoutes(conf *config.Config, router *mux.Router,
-	caches map[string]cache.Cache, tracers tracing.Tracers,
+	caches map[string]cache.Cache, tracers tracing.Tracers, hc healthcheck.HealthChecker,
 	logger interface{}, dryRun bool) (backends.Backends, error) {
 
 	// a fake "top-level" backend representing the main frontend, so rules can route
@@ -132,15 +142,42 @@ func RegisterProxyRoutes(conf *config.Config, router *mux.Router,
 	if err != nil {
 		return nil, err
 	}
+	err = registerHealthChecks(clients, hc, logger)
+	if err != nil {
+		return nil, err
+	}
+	err = alb.ValidatePools(clients, hc, logger)
+	if err != nil {
+		return nil, err
+	}
 
 	return clients, nil
 }
 
+// registerHealthChecks registers each backend that has an upstream origin with the HealthChecker
+func registerHealthChecks(clients backends.Backends, hc healthcheck.HealthChecker,
+	logger interface{}) error {
+	if hc == nil {
+		return nil
+	}
+	for k, c := range clients {
+		o := c.Configuration()
+		if k == "frontend" || o == nil || o.Host == "" {
+			continue
+		}
+		if _, err := hc.Register(k, o, c.HTTPClient(), logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var noCacheBackends = map[string]bool{
 	"rp":           true,
 	"reverseproxy": true,
 	"proxy":        true,
 	"rule":         true,
+	"alb":          true,
 }
 
 func registerBackendRoutes(router *mux.Router, conf *config.Config, k string,
@@ -172,12 +209,18 @@ func registerBackendRoutes(router *mux.Router, conf *config.Config, k string,
 		client, err = irondb.NewClient(k, o, mux.NewRouter(), c, modeliron.NewModeler())
 	case "clickhouse":
 		client, err = clickhouse.NewClient(k, o, mux.NewRouter(), c, modelch.NewModeler())
+	case "graphite":
+		client, err = graphite.NewClient(k, o, mux.NewRouter(), c, modelgraphite.NewModeler())
+	case "loki":
+		client, err = loki.NewClient(k, o, mux.NewRouter(), c, modelloki.NewModeler())
 	case "rpc", "reverseproxycache":
 		client, err = reverseproxycache.NewClient(k, o, mux.NewRouter(), c)
 	case "rp", "reverseproxy", "proxy":
 		client, err = reverseproxy.NewClient(k, o, mux.NewRouter())
 	case "rule":
 		client, err = rule.NewClient(k, o, mux.NewRouter(), clients)
+	case "alb":
+		client, err = alb.NewClient(k, o, mux.NewRouter(), clients)
 	}
 	if err != nil {
 		return err
@@ -222,6 +265,8 @@ func RegisterPathRoutes(router *mux.Router, handlers map[string]http.Handler,
 		}
 		// add Origin, Cache, and Path Configs to the HTTP Request's context
 		h = middleware.WithResourcesContext(client, oo, c, po, tr, logger, h)
+		// reject requests to multi-tenant backends that do not identify their tenant
+		h = middleware.RequireTenant(oo, h)
 		// attach any request rewriters
 		if len(oo.ReqRewriter) > 0 {
 			h = rewriter.Rewrite(oo.ReqRewriter, h)
@@ -229,9 +274,14 @@ func RegisterPathRoutes(router *mux.Router, handlers map[string]http.Handler,
 		if len(po.ReqRewriter) > 0 {
 			h = rewriter.Rewrite(po.ReqRewriter, h)
 		}
+		// apply any path and backend rate limits
+		h = ratelimiter.Handler(po.RateLimiter, oo.Name, po.Path, h)
+		h = ratelimiter.Handler(oo.RateLimiter, oo.Name, "", h)
+		// authenticate the client before any rewriters or rate limiters, so they may use its identity
+		h = authenticator.Handler(oo.Authenticator, h)
 		// decorate frontend prometheus metrics
 		if !po.NoMetrics {
-			h = middleware.Decorate(oo.Name, oo.Provider, po.Path, h)
+			h = middleware.Decorate(oo.Name, oo.Provider, oo.TenantHeader, po.Path, h)
 		}
 		return h
 	}
@@ -261,8 +311,8 @@ func RegisterPathRoutes(router *mux.Router, handlers map[string]http.Handler,
 	}
 
 	// now we will iterate through the configured paths, and overlay them on those default paths.
-	// for a rule backend provider, only the default paths are used with no overlay or importable config
-	if oo.Provider != "rule" {
+	// for rule and alb backend providers, only the default paths are used with no overlay or importable config
+	if oo.Provider != "rule" && oo.Provider != "alb" {
 		for k, p := range oo.Paths {
 			if p2, ok := pathsWithVerbs[k]; ok {
 				p2.Merge(p)
@@ -17,19 +17,31 @@
 package routing
 
 import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
 	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/backends/reverseproxycache"
 	"github.com/tricksterproxy/trickster/pkg/backends/rule"
 	"github.com/tricksterproxy/trickster/pkg/cache/registration"
 	"github.com/tricksterproxy/trickster/pkg/config"
 	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	"github.com/tricksterproxy/trickster/pkg/proxy/authenticator"
+	authopts "github.com/tricksterproxy/trickster/pkg/proxy/authenticator/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/headers"
 	po "github.com/tricksterproxy/trickster/pkg/proxy/paths/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter"
+	rlo "github.com/tricksterproxy/trickster/pkg/proxy/ratelimiter/options"
+	"github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter"
+	rwo "github.com/tricksterproxy/trickster/pkg/proxy/request/rewriter/options"
 	"github.com/tricksterproxy/trickster/pkg/tracing"
 	"github.com/tricksterproxy/trickster/pkg/tracing/exporters/zipkin"
 	to "github.com/tricksterproxy/trickster/pkg/tracing/options"
@@ -61,7 +73,7 @@ func TestRegisterProxyRoutes(t *testing.T) {
 	}
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	proxyClients, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, log, false)
+	proxyClients, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, log, false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -76,7 +88,7 @@ func TestRegisterProxyRoutes(t *testing.T) {
 	oc.Hosts = []string{"test", "test2"}
 
 	registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
-	RegisterProxyRoutes(conf, mux.NewRouter(), caches, tr, log, false)
+	RegisterProxyRoutes(conf, mux.NewRouter(), caches, tr, nil, log, false)
 
 	if len(proxyClients) == 0 {
 		t.Errorf("expected %d got %d", 1, 0)
@@ -97,33 +109,33 @@ func TestRegisterProxyRoutes(t *testing.T) {
 	conf.Backends["2"] = o2
 
 	router := mux.NewRouter()
-	_, err = RegisterProxyRoutes(conf, router, caches, tr, log, false)
+	_, err = RegisterProxyRoutes(conf, router, caches, tr, nil, log, false)
 	if err == nil {
 		t.Error("Expected error for too many default backends.")
 	}
 
 	o1.IsDefault = false
 	o1.CacheName = "invalid"
-	_, err = RegisterProxyRoutes(conf, router, caches, tr, log, false)
+	_, err = RegisterProxyRoutes(conf, router, caches, tr, nil, log, false)
 	if err == nil {
 		t.Errorf("Expected error for invalid cache name")
 	}
 
 	o1.CacheName = o2.CacheName
-	_, err = RegisterProxyRoutes(conf, router, caches, tr, log, false)
+	_, err = RegisterProxyRoutes(conf, router, caches, tr, nil, log, false)
 	if err != nil {
 		t.Error(err)
 	}
 
 	o2.IsDefault = false
 	o2.CacheName = "invalid"
-	_, err = RegisterProxyRoutes(conf, router, caches, tr, log, false)
+	_, err = RegisterProxyRoutes(conf, router, caches, tr, nil, log, false)
 	if err == nil {
 		t.Errorf("Expected error for invalid cache name")
 	}
 
 	o2.CacheName = "default"
-	_, err = RegisterProxyRoutes(conf, router, caches, tr, log, false)
+	_, err = RegisterProxyRoutes(conf, router, caches, tr, nil, log, false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -138,7 +150,7 @@ func TestRegisterProxyRoutes(t *testing.T) {
 
 	o1.Paths["/-GET-HEAD"].Methods = nil
 
-	_, err = RegisterProxyRoutes(conf, router, caches, tr, log, false)
+	_, err = RegisterProxyRoutes(conf, router, caches, tr, nil, log, false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -154,7 +166,7 @@ func TestRegisterProxyRoutesInflux(t *testing.T) {
 
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -175,7 +187,49 @@ func TestRegisterProxyRoutesClickHouse(t *testing.T) {
 
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(proxyClients) == 0 {
+		t.Errorf("expected %d got %d", 1, 0)
+	}
+
+}
+
+func TestRegisterProxyRoutesGraphite(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-log-level", "debug", "-origin-url", "http://1", "-provider", "graphite"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer registration.CloseCaches(caches)
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(proxyClients) == 0 {
+		t.Errorf("expected %d got %d", 1, 0)
+	}
+
+}
+
+func TestRegisterProxyRoutesLoki(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-log-level", "debug", "-origin-url", "http://1", "-provider", "loki"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer registration.CloseCaches(caches)
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -196,7 +250,7 @@ func TestRegisterProxyRoutesIRONdb(t *testing.T) {
 
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -220,7 +274,7 @@ func TestRegisterProxyRoutesWithReqRewriters(t *testing.T) {
 
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -230,6 +284,103 @@ func TestRegisterProxyRoutesWithReqRewriters(t *testing.T) {
 	}
 }
 
+func TestRegisterProxyRoutesALB(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-config", "../../testdata/test.routing.alb.conf"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer registration.CloseCaches(caches)
+	hc := healthcheck.New()
+	defer hc.Shutdown()
+	proxyClients, err := RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, hc,
+		tl.ConsoleLogger("info"), false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(proxyClients) != 4 {
+		t.Errorf("expected %d got %d", 4, len(proxyClients))
+	}
+
+	if len(hc.Statuses()) != 2 {
+		t.Errorf("expected %d got %d", 2, len(hc.Statuses()))
+	}
+
+	conf.Backends["test"].ALBOptions.Pool = []string{"test1", "invalid"}
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil,
+		tl.ConsoleLogger("info"), false)
+	if err == nil {
+		t.Error("expected error for invalid pool member")
+	}
+}
+
+func TestRegisterHealthChecks(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-origin-url", "http://1", "-provider", "prometheus"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer registration.CloseCaches(caches)
+	hc := healthcheck.New()
+	defer hc.Shutdown()
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, hc,
+		tl.ConsoleLogger("error"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := hc.Status("default")
+	if s == nil {
+		t.Fatal("expected default backend to be registered for health checks")
+	}
+	if s.Provider() != "prometheus" {
+		t.Errorf("expected %s got %s", "prometheus", s.Provider())
+	}
+	if hc.Status("frontend") != nil {
+		t.Error("expected frontend to not be registered for health checks")
+	}
+
+	if err = registerHealthChecks(nil, nil, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegisterHealthChecksClickHouse(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-origin-url", "http://1", "-provider", "clickhouse"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer registration.CloseCaches(caches)
+	hc := healthcheck.New()
+	defer hc.Shutdown()
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, hc,
+		tl.ConsoleLogger("error"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hc.Status("default") == nil {
+		t.Fatal("expected default backend to be registered for health checks")
+	}
+	// the ClickHouse defaults are populated before registration, so the backend is probed
+	o := conf.Backends["default"]
+	if o.HealthCheckUpstreamPath != "/" || o.HealthCheckVerb != http.MethodGet {
+		t.Errorf("expected health check %s %s got %s %s", http.MethodGet, "/",
+			o.HealthCheckVerb, o.HealthCheckUpstreamPath)
+	}
+}
+
 func TestRegisterProxyRoutesMultipleDefaults(t *testing.T) {
 	expected1 := "only one backend can be marked as default. Found both test and test2"
 	expected2 := "only one backend can be marked as default. Found both test2 and test"
@@ -241,7 +392,7 @@ func TestRegisterProxyRoutesMultipleDefaults(t *testing.T) {
 	}
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err == nil {
 		t.Errorf("expected error `%s` got nothing", expected1)
 	} else if err.Error() != expected1 && err.Error() != expected2 {
@@ -275,7 +426,7 @@ func TestRegisterProxyRoutesInvalidCert(t *testing.T) {
 	}
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err == nil {
 		t.Errorf("expected error: %s", expected)
 	}
@@ -304,7 +455,7 @@ func TestRegisterProxyRoutesBadProvider(t *testing.T) {
 	}
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err == nil {
 		t.Errorf("expected error `%s` got nothing", expected)
 	} else if err.Error() != expected {
@@ -320,7 +471,7 @@ func TestRegisterMultipleBackends(t *testing.T) {
 	}
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -334,7 +485,7 @@ func TestRegisterMultipleBackendsPlusDefault(t *testing.T) {
 	}
 	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
 	defer registration.CloseCaches(caches)
-	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err != nil {
 		t.Error(err)
 	}
@@ -361,6 +512,223 @@ func TestRegisterPathRoutes(t *testing.T) {
 
 }
 
+func TestRegisterPathRoutesTenant(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-log-level", "debug", "-origin-url", "http://1", "-provider", "rpc"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	oo := conf.Backends["default"]
+	oo.TenantHeader = "X-Scope-OrgID"
+	rpc, _ := reverseproxycache.NewClient("test", oo, mux.NewRouter(), nil)
+	h := map[string]http.Handler{"test": http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	p := map[string]*po.Options{"/": {Path: "/", HandlerName: "test",
+		Methods: []string{http.MethodGet}}}
+	router := mux.NewRouter()
+	RegisterPathRoutes(router, h, rpc, oo, nil, p, nil, "", tl.ConsoleLogger("error"))
+
+	tests := []struct {
+		tenant string
+		code   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"team/a", http.StatusBadRequest},
+		{"team-a", http.StatusOK},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://0/default/", nil)
+		if test.tenant != "" {
+			r.Header.Set(oo.TenantHeader, test.tenant)
+		}
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: expected %d got %d", test.tenant, test.code, w.Code)
+		}
+	}
+}
+
+func TestRegisterPathRoutesAuthenticator(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-log-level", "debug", "-origin-url", "http://1", "-provider", "rpc"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	oo := conf.Backends["default"]
+	oo.Authenticator, err = authenticator.New("test", &authopts.Options{Provider: "basic",
+		UsersFile: "../../testdata/test.htpasswd", IdentityHeader: "X-Trickster-User"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw, err := rewriter.ProcessConfigs(map[string]*rwo.Options{"test": {
+		Instructions: rwo.RewriteList{[]string{"header", "set", "X-Rewritten-User", "${identity}"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oo.ReqRewriter = rw["test"]
+	rpc, _ := reverseproxycache.NewClient("test", oo, mux.NewRouter(), nil)
+	var user, rewritten string
+	h := map[string]http.Handler{"test": http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		user = r.Header.Get("X-Trickster-User")
+		rewritten = r.Header.Get("X-Rewritten-User")
+		w.WriteHeader(http.StatusOK)
+	})}
+	p := map[string]*po.Options{"/": {Path: "/", HandlerName: "test",
+		Methods: []string{http.MethodGet}}}
+	router := mux.NewRouter()
+	RegisterPathRoutes(router, h, rpc, oo, nil, p, nil, "", tl.ConsoleLogger("error"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/default/", nil)
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "http://0/default/", nil)
+	r.SetBasicAuth("test", "trickster")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	if user != "test" {
+		t.Errorf("expected %s got %s", "test", user)
+	}
+	if rewritten != "test" {
+		t.Errorf("expected %s got %s", "test", rewritten)
+	}
+}
+
+func TestRegisterProxyRoutesAuthenticatorSharedCache(t *testing.T) {
+
+	var hits int
+	var auth string
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		auth = r.Header.Get(headers.NameAuthorization)
+		w.Header().Set(headers.NameCacheControl, "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("test"))
+	}))
+	defer es.Close()
+
+	// two users, each with their own credentials
+	f, err := ioutil.TempFile("", "trickster-htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	for _, u := range []string{"alice", "bob"} {
+		sum := sha1.Sum([]byte(u + "-password"))
+		fmt.Fprintf(f, "%s:{SHA}%s\n", u, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	f.Close()
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-log-level", "debug", "-origin-url", es.URL, "-provider", "rpc"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+	conf.Backends["default"].Authenticator, err = authenticator.New("test",
+		&authopts.Options{Provider: "basic", UsersFile: f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caches := registration.LoadCachesFromConfig(conf, tl.ConsoleLogger("error"))
+	defer registration.CloseCaches(caches)
+	router := mux.NewRouter()
+	_, err = RegisterProxyRoutes(conf, router, caches, nil, nil, tl.ConsoleLogger("error"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []string{"alice", "bob"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://0/default/some/path", nil)
+		r.SetBasicAuth(u, u+"-password")
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+		}
+	}
+
+	// the second user is served the object cached for the first, and the credentials
+	// are not forwarded to the origin
+	if hits != 1 {
+		t.Errorf("expected %d got %d", 1, hits)
+	}
+	if auth != "" {
+		t.Errorf("expected empty Authorization header got %s", auth)
+	}
+}
+
+func TestRegisterPathRoutesRateLimiter(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test",
+		[]string{"-log-level", "debug", "-origin-url", "http://1", "-provider", "rpc"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	newLimiter := func(burst int) *ratelimiter.Limiter {
+		o := &rlo.Options{Name: "test", RequestsPerSecond: 0.001, Burst: burst}
+		if err := o.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		return ratelimiter.New(o)
+	}
+
+	oo := conf.Backends["default"]
+	oo.RateLimiter = newLimiter(3)
+	rpc, _ := reverseproxycache.NewClient("test", oo, mux.NewRouter(), nil)
+	h := map[string]http.Handler{"test": http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	p := map[string]*po.Options{
+		"/": {Path: "/", HandlerName: "test", Methods: []string{http.MethodGet}},
+		"/limited": {Path: "/limited", HandlerName: "test", Methods: []string{http.MethodGet},
+			RateLimiter: newLimiter(1)},
+	}
+	router := mux.NewRouter()
+	RegisterPathRoutes(router, h, rpc, oo, nil, p, nil, "", tl.ConsoleLogger("error"))
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/default/limited", http.StatusOK},
+		// the path limiter is exhausted while the backend limiter has tokens remaining
+		{"/default/limited", http.StatusTooManyRequests},
+		{"/default/", http.StatusOK},
+		// the backend limiter is now exhausted
+		{"/default/", http.StatusTooManyRequests},
+	}
+
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://0"+test.path, nil)
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("test %d: expected %d got %d", i, test.code, w.Code)
+		}
+		if test.code == http.StatusTooManyRequests &&
+			w.Header().Get(headers.NameRetryAfter) == "" {
+			t.Errorf("test %d: expected %s header", i, headers.NameRetryAfter)
+		}
+	}
+}
+
 func TestValidateRuleClients(t *testing.T) {
 
 	var cl = backends.Backends{"test": &rule.Client{}}
@@ -378,7 +746,7 @@ func TestValidateRuleClients(t *testing.T) {
 	oc := conf.Backends["default"]
 	oc.Provider = "rule"
 
-	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, tl.ConsoleLogger("info"), false)
+	_, err = RegisterProxyRoutes(conf, mux.NewRouter(), caches, nil, nil, tl.ConsoleLogger("info"), false)
 	if err == nil {
 		t.Error("expected error")
 	}
@@ -118,8 +118,12 @@ func (ds *DataSet) CroppedClone(e timeseries.Extent) timeseries.Timeseries {
 	// if the extent of the series is entirely outside the extent of the crop
 	// range, return empty set and bail
 	if ds.ExtentList.OutsideOf(e) {
-		for i := range ds.Results {
-			clone.Results[i].SeriesList = make([]*Series, 0)
+		for i, r := range ds.Results {
+			if r == nil {
+				continue
+			}
+			clone.Results[i] = &Result{StatementID: r.StatementID, Error: r.Error,
+				SeriesList: make([]*Series, 0)}
 		}
 		clone.ExtentList = timeseries.ExtentList{}
 		return clone
@@ -297,10 +301,9 @@ func (ds *DataSet) DefaultMerger(sortSeries bool, collection ...timeseries.Times
 					// or appends new points, if any, to the pre-existing series.
 					go func(gs *Series, ggr1 *Result) {
 						defer wg.Done()
-						var es *Series
 						key := SeriesLookupKey{StatementID: ggr1.StatementID, Hash: gs.Header.CalculateHash()}
 						slmtx.RLock()
-						es, ok = sl[key]
+						es, ok := sl[key]
 						slmtx.RUnlock()
 						if !ok && gs != nil {
 							slmtx.Lock()
@@ -361,9 +364,115 @@ func (ds *DataSet) CropToSize(sz int, t time.Time, lur timeseries.Extent) {
 	ds.DefaultSizeCropper(sz, t, lur)
 }
 
-// DefaultSizeCropper is the default SizeCropper Function
+// DefaultSizeCropper is the default SizeCropper Function. It evicts the least-recently-used
+// timestamps until the DataSet contains no more than sz unique timestamps. Any timestamps
+// newer than t are removed before sizing, in order to support backfill tolerance, and the
+// provided lur extent is marked as used before any eviction decisions are made.
 func (ds *DataSet) DefaultSizeCropper(sz int, t time.Time, lur timeseries.Extent) {
-	// TODO: Complete this method
+	x := len(ds.ExtentList)
+	// The DataSet has no extents, so no need to do anything
+	if x == 0 {
+		for i := range ds.Results {
+			ds.Results[i].SeriesList = make([]*Series, 0)
+		}
+		ds.ExtentList = timeseries.ExtentList{}
+		return
+	}
+
+	// Crop to the Backfill Tolerance Value if needed
+	if ds.ExtentList[x-1].End.After(t) {
+		ds.CropToRange(timeseries.Extent{Start: ds.ExtentList[0].Start, End: t})
+	}
+
+	step := ds.Step()
+	if step <= 0 || len(ds.ExtentList) == 0 {
+		return
+	}
+
+	el := timeseries.ExtentListLRU(ds.ExtentList).UpdateLastUsed(lur, step)
+	ds.ExtentList = timeseries.ExtentList(el).Compress(step)
+
+	// series may be sparse, so the actual timestamps are counted rather than
+	// inferring them from the extents and step
+	ts := ds.timestamps()
+	tc := int64(len(ts))
+	if sz < 0 || tc <= int64(sz) {
+		return
+	}
+
+	// rc is the number of timestamps that must be evicted to meet the retention policy
+	rc := tc - int64(sz)
+
+	// walk the extents from least to most recently used, marking timestamps for
+	// eviction (oldest first within an extent) until enough have been marked
+	sort.Stable(el)
+	removals := make(timeseries.ExtentList, 0, len(el))
+	kept := make(timeseries.ExtentList, 0, len(el))
+	for _, e := range el {
+		if rc <= 0 {
+			kept = append(kept, e)
+			continue
+		}
+		startNS := epoch.Epoch(e.Start.UnixNano())
+		endNS := epoch.Epoch(e.End.UnixNano())
+		i := sort.Search(len(ts), func(j int) bool { return ts[j] >= startNS })
+		j := sort.Search(len(ts), func(j int) bool { return ts[j] > endNS })
+		n := int64(j - i)
+		if n <= rc {
+			removals = append(removals, e)
+			rc -= n
+			continue
+		}
+		cut := time.Unix(0, int64(ts[i+int(rc)]))
+		removals = append(removals, timeseries.Extent{Start: e.Start,
+			End: time.Unix(0, int64(ts[i+int(rc)-1]))})
+		kept = append(kept, timeseries.Extent{Start: cut, End: e.End, LastUsed: e.LastUsed})
+		rc = 0
+	}
+
+	for i := range ds.Results {
+		if len(ds.Results[i].SeriesList) == 0 {
+			continue
+		}
+		sl := make([]*Series, 0, len(ds.Results[i].SeriesList))
+		for _, s := range ds.Results[i].SeriesList {
+			if s == nil {
+				continue
+			}
+			s.Points = s.Points.evict(removals)
+			s.PointSize = s.Points.Size()
+			if len(s.Points) > 0 {
+				sl = append(sl, s)
+			}
+		}
+		ds.Results[i].SeriesList = sl
+	}
+
+	ds.ExtentList = kept.Compress(step)
+}
+
+// timestamps returns the sorted, unique timestamps of all Points in the DataSet
+func (ds *DataSet) timestamps() epoch.Epochs {
+	m := make(map[epoch.Epoch]struct{})
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		for _, s := range r.SeriesList {
+			if s == nil {
+				continue
+			}
+			for _, p := range s.Points {
+				m[p.Epoch] = struct{}{}
+			}
+		}
+	}
+	out := make(epoch.Epochs, 0, len(m))
+	for e := range m {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
 }
 
 // CropToRange reduces the DataSet down to timestamps contained within the provided Extents (inclusive).
@@ -397,11 +506,10 @@ func (ds *DataSet) DefaultRangeCropper(e timeseries.Extent) {
 		return
 	}
 
-	ds.ExtentList = ds.ExtentList.Crop(e)
-
 	// if the series extent is entirely inside the extent of the crop range,
 	// simply adjust down its ExtentList
 	if ds.ExtentList.InsideOf(e) {
+		ds.ExtentList = ds.ExtentList.Crop(e)
 		if ds.ValueCount() == 0 {
 			for i := range ds.Results {
 				ds.Results[i].SeriesList = make([]*Series, 0)
@@ -410,6 +518,8 @@ func (ds *DataSet) DefaultRangeCropper(e timeseries.Extent) {
 		return
 	}
 
+	ds.ExtentList = ds.ExtentList.Crop(e)
+
 	startNS := epoch.Epoch(e.Start.UnixNano())
 	endNS := epoch.Epoch(e.End.UnixNano())
 
@@ -418,6 +528,7 @@ func (ds *DataSet) DefaultRangeCropper(e timeseries.Extent) {
 		if len(ds.Results[i].SeriesList) == 0 {
 			continue
 		}
+		var slmtx sync.Mutex
 		sl := make([]*Series, 0, len(ds.Results[i].SeriesList))
 		for _, s := range ds.Results[i].SeriesList {
 			if s == nil || len(s.Points) == 0 {
@@ -444,8 +555,10 @@ func (ds *DataSet) DefaultRangeCropper(e timeseries.Extent) {
 					s2.Points = pts
 					s2.PointSize = pts.Size()
 				}
-				wg.Done()
+				slmtx.Lock()
 				sl = append(sl, s2)
+				slmtx.Unlock()
+				wg.Done()
 			}(s)
 		}
 		wg.Wait()
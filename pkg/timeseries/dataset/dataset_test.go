@@ -131,6 +131,19 @@ func TestDataSetClone(t *testing.T) {
 	}
 }
 
+func TestCroppedCloneOutsideOf(t *testing.T) {
+	ds := testDataSet2()
+	e := timeseries.Extent{Start: time.Unix(60, 0), End: time.Unix(90, 0)}
+	clone := ds.CroppedClone(e).(*DataSet)
+	if len(clone.ExtentList) != 0 {
+		t.Errorf("expected %d got %d", 0, len(clone.ExtentList))
+	}
+	if len(clone.Results) != 2 || clone.Results[1].StatementID != 1 ||
+		len(clone.Results[1].SeriesList) != 0 {
+		t.Error("expected empty results")
+	}
+}
+
 func TestSort(t *testing.T) {
 	var x int
 	testFunc := func() {
@@ -216,3 +229,80 @@ func TestMerge(t *testing.T) {
 	}
 
 }
+
+func TestCropToSize(t *testing.T) {
+
+	now := time.Now().Truncate(time.Second)
+
+	// empty extents should clear all series
+	ds := testDataSet2()
+	ds.ExtentList = timeseries.ExtentList{}
+	ds.CropToSize(10, now, timeseries.Extent{})
+	if ds.SeriesCount() != 0 {
+		t.Errorf("expected %d got %d", 0, ds.SeriesCount())
+	}
+
+	// backfill tolerance should crop newer timestamps even when under size
+	ds = testDataSet2()
+	ds.CropToSize(10, time.Unix(20, 0), timeseries.Extent{Start: time.Unix(5, 0), End: time.Unix(20, 0)})
+	if ds.ValueCount() != 16 {
+		t.Errorf("expected %d got %d", 16, ds.ValueCount())
+	}
+	if ds.ExtentList.String() != "5000-20000" {
+		t.Errorf("expected %s got %s", "5000-20000", ds.ExtentList.String())
+	}
+	if len(ds.ExtentList) != 1 || ds.ExtentList[0].LastUsed.Before(now) {
+		t.Error("expected last used extent to be updated")
+	}
+
+	// least-recently-used timestamps should be evicted first
+	ds = testDataSet2()
+	ds.ExtentList = timeseries.ExtentList{
+		timeseries.Extent{Start: time.Unix(5, 0), End: time.Unix(15, 0), LastUsed: now.Add(-1 * time.Hour)},
+		timeseries.Extent{Start: time.Unix(20, 0), End: time.Unix(30, 0), LastUsed: now.Add(-2 * time.Hour)},
+	}
+	ds.CropToSize(3, time.Unix(100, 0), timeseries.Extent{Start: time.Unix(25, 0), End: time.Unix(30, 0)})
+	if ds.TimestampCount() != 3 {
+		t.Errorf("expected %d got %d", 3, ds.TimestampCount())
+	}
+	const expected = "15000-15000;25000-30000"
+	if ds.ExtentList.String() != expected {
+		t.Errorf("expected %s got %s", expected, ds.ExtentList.String())
+	}
+	if ds.SeriesCount() != 4 || ds.ValueCount() != 12 {
+		t.Errorf("expected %d got %d", 12, ds.ValueCount())
+	}
+	pts := ds.Results[0].SeriesList[0].Points
+	if pts[0].Epoch != epoch.Epoch(15*timeseries.Second) || pts[1].Epoch != epoch.Epoch(25*timeseries.Second) {
+		t.Error("unexpected points retained after crop")
+	}
+	if ds.ExtentList[1].LastUsed.Before(now) {
+		t.Error("expected last used extent to be updated")
+	}
+
+	// sparse series should be sized by their actual timestamps, not by their extents
+	ds = testDataSet2()
+	for _, r := range ds.Results {
+		for _, s := range r.SeriesList {
+			s.Points = Points{s.Points[0], s.Points[1], s.Points[2], s.Points[5]}
+		}
+	}
+	ds.ExtentList = timeseries.ExtentList{
+		timeseries.Extent{Start: time.Unix(5, 0), End: time.Unix(15, 0), LastUsed: now.Add(-2 * time.Hour)},
+		timeseries.Extent{Start: time.Unix(20, 0), End: time.Unix(30, 0), LastUsed: now.Add(-1 * time.Hour)},
+	}
+	ds.CropToSize(2, time.Unix(100, 0), timeseries.Extent{})
+	if ds.ExtentList.String() != "15000-15000;20000-30000" {
+		t.Errorf("expected %s got %s", "15000-15000;20000-30000", ds.ExtentList.String())
+	}
+	if ds.SeriesCount() != 4 || ds.ValueCount() != 8 {
+		t.Errorf("expected %d got %d", 8, ds.ValueCount())
+	}
+
+	// a size of zero should evict everything
+	ds = testDataSet2()
+	ds.CropToSize(0, time.Unix(100, 0), timeseries.Extent{})
+	if len(ds.ExtentList) != 0 || ds.SeriesCount() != 0 {
+		t.Errorf("expected empty dataset got %d series", ds.SeriesCount())
+	}
+}
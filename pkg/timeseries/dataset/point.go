@@ -21,7 +21,9 @@ package dataset
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
 )
 
@@ -122,3 +124,26 @@ func (p Points) onOrJustBefore(ts epoch.Epoch, s, e int) int {
 	}
 	return p.onOrJustBefore(ts, s, mid)
 }
+
+// evict returns a copy of p excluding any Points whose epochs fall within one of the
+// provided extents (inclusive)
+func (p Points) evict(el timeseries.ExtentList) Points {
+	if len(el) == 0 || len(p) == 0 {
+		return p
+	}
+	out := make(Points, 0, len(p))
+	for _, pt := range p {
+		t := time.Unix(0, int64(pt.Epoch))
+		var evicted bool
+		for i := range el {
+			if el[i].Includes(t) {
+				evicted = true
+				break
+			}
+		}
+		if !evicted {
+			out = append(out, pt)
+		}
+	}
+	return out
+}
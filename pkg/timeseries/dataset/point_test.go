@@ -19,6 +19,7 @@ package dataset
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/tricksterproxy/trickster/pkg/timeseries"
 	"github.com/tricksterproxy/trickster/pkg/timeseries/epoch"
@@ -87,3 +88,16 @@ func TestPointsSort(t *testing.T) {
 		t.Error("sort mismatch")
 	}
 }
+
+func TestPointsEvict(t *testing.T) {
+	pts := testPoints()
+	if len(pts.evict(nil)) != 2 {
+		t.Error("expected no evictions")
+	}
+	pts2 := pts.evict(timeseries.ExtentList{
+		timeseries.Extent{Start: time.Unix(0, int64(pts[0].Epoch)), End: time.Unix(0, int64(pts[0].Epoch))},
+	})
+	if len(pts2) != 1 || pts2[0].Epoch != pts[1].Epoch {
+		t.Error("unexpected eviction result")
+	}
+}
@@ -224,6 +224,27 @@ func (el ExtentList) CalculateDeltas(want Extent, step time.Duration) ExtentList
 	return ins
 }
 
+// Split returns a copy of the ExtentList in which any Extent spanning more than maxSteps
+// timestamps at the provided step is divided into consecutive Extents of no more than
+// maxSteps timestamps each. A maxSteps value < 1 results in no splitting.
+func (el ExtentList) Split(step time.Duration, maxSteps int) ExtentList {
+	if maxSteps < 1 || step <= 0 {
+		return el.Clone()
+	}
+	span := step * time.Duration(maxSteps-1)
+	out := make(ExtentList, 0, len(el))
+	for _, e := range el {
+		for start := e.Start; !start.After(e.End); start = start.Add(span + step) {
+			end := start.Add(span)
+			if end.After(e.End) {
+				end = e.End
+			}
+			out = append(out, Extent{Start: start, End: end, LastUsed: e.LastUsed})
+		}
+	}
+	return out
+}
+
 // Size returns the approximate memory utilization in bytes of the timeseries
 func (el ExtentList) Size() int {
 	return len(el) * 72
@@ -272,6 +293,13 @@ func (el ExtentListLRU) String() string {
 // UpdateLastUsed updates the ExtentListLRU's LastUsed field for the provided extent.
 // The step is required in order to properly split extents.
 func (el ExtentListLRU) UpdateLastUsed(lur Extent, step time.Duration) ExtentListLRU {
+	return el.UpdateLastUsedAt(lur, step, time.Now())
+}
+
+// UpdateLastUsedAt updates the ExtentListLRU's LastUsed field for the provided extent
+// to the provided time. The step is required in order to properly split extents.
+func (el ExtentListLRU) UpdateLastUsedAt(lur Extent, step time.Duration,
+	t time.Time) ExtentListLRU {
 
 	if el == nil {
 		return nil
@@ -281,7 +309,7 @@ func (el ExtentListLRU) UpdateLastUsed(lur Extent, step time.Duration) ExtentLis
 		return ExtentListLRU{}
 	}
 
-	now := time.Now().Truncate(time.Second)
+	now := t.Truncate(time.Second)
 	el2 := make(ExtentList, 0, len(el))
 
 	for _, x := range el {
@@ -331,3 +359,38 @@ func (el ExtentListLRU) UpdateLastUsed(lur Extent, step time.Duration) ExtentLis
 	}
 	return ExtentListLRU(el2.Compress(step))
 }
+
+// MergeLastUsed returns a copy of the ExtentListLRU in which the portions of its extents that
+// overlap any of the provided used extents take the used extent's LastUsed time, when it is
+// more recent. The used extents must not overlap one another. The step is required in order to
+// properly split extents.
+func (el ExtentListLRU) MergeLastUsed(used ExtentList, step time.Duration) ExtentListLRU {
+	if len(el) == 0 || len(used) == 0 {
+		return el.Clone()
+	}
+	used = used.Clone()
+	sort.Sort(used)
+	el2 := make(ExtentList, 0, len(el))
+	for _, x := range el {
+		for _, u := range used {
+			if u.End.Before(x.Start) || x.End.Before(u.Start) || !u.LastUsed.After(x.LastUsed) {
+				continue
+			}
+			// the portion of x before u retains its LastUsed time
+			if u.Start.After(x.Start) {
+				el2 = append(el2, Extent{Start: x.Start, End: u.Start.Add(-step), LastUsed: x.LastUsed})
+				x.Start = u.Start
+			}
+			// u ends within x, so the remainder of x may overlap subsequent used extents
+			if u.End.Before(x.End) {
+				el2 = append(el2, Extent{Start: x.Start, End: u.End, LastUsed: u.LastUsed})
+				x.Start = u.End.Add(step)
+				continue
+			}
+			x.LastUsed = u.LastUsed
+			break
+		}
+		el2 = append(el2, x)
+	}
+	return ExtentListLRU(el2.Compress(step))
+}
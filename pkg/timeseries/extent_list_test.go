@@ -32,6 +32,7 @@ var t101 = time.Unix(101, 0)
 var t200 = time.Unix(200, 0)
 var t201 = time.Unix(201, 0)
 var t300 = time.Unix(300, 0)
+var t500 = time.Unix(500, 0)
 var t600 = time.Unix(600, 0)
 var t900 = time.Unix(900, 0)
 var t1000 = time.Unix(1000, 0)
@@ -131,6 +132,41 @@ func TestUpdateLastUsed(t *testing.T) {
 
 }
 
+func TestMergeLastUsed(t *testing.T) {
+	step := 100 * time.Second
+	el := ExtentListLRU{
+		Extent{Start: t100, End: t600, LastUsed: t600},
+		Extent{Start: t900, End: t1300, LastUsed: t1300},
+	}
+	tests := []struct {
+		used     ExtentList
+		expected string
+	}{
+		{nil, "100-600:600;900-1300:1300"},
+		// portions used more recently take the used time
+		{ExtentList{Extent{Start: t200, End: t300, LastUsed: t1400},
+			Extent{Start: t500, End: t900, LastUsed: t1400}},
+			"100-100:600;200-300:1400;400-400:600;500-600:1400;900-900:1400;1000-1300:1300"},
+		// portions used less recently than the extent are unchanged
+		{ExtentList{Extent{Start: t100, End: t1300, LastUsed: t900}},
+			"100-600:900;900-1300:1300"},
+	}
+	for i, test := range tests {
+		if v := el.MergeLastUsed(test.used, step).String(); v != test.expected {
+			t.Errorf("%d: expected %s got %s", i, test.expected, v)
+		}
+	}
+}
+
+func TestUpdateLastUsedAt(t *testing.T) {
+	el := ExtentListLRU{Extent{Start: t100, End: t1300, LastUsed: t1300}}
+	el = el.UpdateLastUsedAt(Extent{Start: t200, End: t600}, 100*time.Second, t1400)
+	const expected = "100-100:1300;200-600:1400;700-1300:1300"
+	if el.String() != expected {
+		t.Errorf("expected %s got %s", expected, el.String())
+	}
+}
+
 func TestInsideOf(t *testing.T) {
 
 	el := ExtentList{
@@ -679,3 +715,64 @@ func TestCalculateDeltas(t *testing.T) {
 		})
 	}
 }
+
+func TestSplit(t *testing.T) {
+
+	step := time.Duration(100) * time.Second
+
+	tests := []struct {
+		el       ExtentList
+		maxSteps int
+		expected ExtentList
+	}{
+		{ // 0 - no splitting
+			el:       ExtentList{Extent{Start: t100, End: t1300}},
+			maxSteps: 0,
+			expected: ExtentList{Extent{Start: t100, End: t1300}},
+		},
+		{ // 1 - extent smaller than max
+			el:       ExtentList{Extent{Start: t100, End: t300}},
+			maxSteps: 5,
+			expected: ExtentList{Extent{Start: t100, End: t300}},
+		},
+		{ // 2 - split into even chunks
+			el:       ExtentList{Extent{Start: t100, End: t1200}},
+			maxSteps: 4,
+			expected: ExtentList{
+				Extent{Start: t100, End: time.Unix(400, 0)},
+				Extent{Start: time.Unix(500, 0), End: time.Unix(800, 0)},
+				Extent{Start: t900, End: t1200},
+			},
+		},
+		{ // 3 - split with remainder, multiple extents
+			el: ExtentList{
+				Extent{Start: t100, End: t600},
+				Extent{Start: t1000, End: t1000},
+			},
+			maxSteps: 4,
+			expected: ExtentList{
+				Extent{Start: t100, End: time.Unix(400, 0)},
+				Extent{Start: time.Unix(500, 0), End: t600},
+				Extent{Start: t1000, End: t1000},
+			},
+		},
+		{ // 4 - one timestamp per extent
+			el:       ExtentList{Extent{Start: t100, End: t300}},
+			maxSteps: 1,
+			expected: ExtentList{
+				Extent{Start: t100, End: t100},
+				Extent{Start: t200, End: t200},
+				Extent{Start: t300, End: t300},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := test.el.Split(step, test.maxSteps)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %s got %s", test.expected, result)
+			}
+		})
+	}
+}
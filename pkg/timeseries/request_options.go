@@ -33,6 +33,9 @@ type RequestOptions struct {
 	// BaseTimestampFieldName holds the name of the Base Timestamp Field (in case it is aliased with AS) to help
 	// parse WHERE clauses during the initial parsing of a query
 	BaseTimestampFieldName string
+	// OutputExtent is a field usable by time series implementations whose queries are not aligned to the step,
+	// to indicate to the data marshaler the exact time range requested by the client, to which output is cropped
+	OutputExtent *Extent
 }
 
 // ExtractFastForwardDisabled will look for the FastForwardUserDisableFlag in the provided string
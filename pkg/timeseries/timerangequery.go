@@ -54,6 +54,10 @@ type TimeRangeQuery struct {
 	TagFieldDefintions []FieldDefinition `msg:"tfdefs"`
 	// ValueFieldDefinitions contains the definitions for Value columns in the timeseries, based on the query
 	ValueFieldDefinitions []FieldDefinition `msg:"vfdefs"`
+	// ParsedQuery is a Backend-specific representation of the query, for use by Backend providers
+	// that must rewrite queries whose timestamps are not conveyed by the TemplateURL. It is shared,
+	// not copied, by Clone, and must not be modified once set
+	ParsedQuery interface{} `msg:"-"`
 }
 
 // Clone returns an exact copy of a TimeRangeQuery
@@ -65,6 +69,7 @@ func (trq *TimeRangeQuery) Clone() *TimeRangeQuery {
 		Extent:              Extent{Start: trq.Extent.Start, End: trq.Extent.End},
 		IsOffset:            trq.IsOffset,
 		TimestampDefinition: trq.TimestampDefinition.Clone(),
+		ParsedQuery:         trq.ParsedQuery,
 	}
 
 	if trq.TagFieldDefintions != nil {
@@ -0,0 +1,270 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package trickster provides Trickster's caching proxy as an http.Handler that
+// can be embedded into other Go services, without running the trickster binary
+package trickster
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tricksterproxy/trickster/pkg/backends"
+	"github.com/tricksterproxy/trickster/pkg/backends/healthcheck"
+	"github.com/tricksterproxy/trickster/pkg/cache"
+	"github.com/tricksterproxy/trickster/pkg/cache/memory"
+	"github.com/tricksterproxy/trickster/pkg/cache/providers"
+	"github.com/tricksterproxy/trickster/pkg/cache/registration"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+	th "github.com/tricksterproxy/trickster/pkg/proxy/handlers"
+	"github.com/tricksterproxy/trickster/pkg/routing"
+	"github.com/tricksterproxy/trickster/pkg/runtime"
+	"github.com/tricksterproxy/trickster/pkg/tracing"
+	tr "github.com/tricksterproxy/trickster/pkg/tracing/registration"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+// ErrNilConfig is returned when a nil configuration is provided to New
+var ErrNilConfig = errors.New("trickster: configuration must not be nil")
+
+// Handler is an embeddable Trickster caching proxy. It routes requests to the backends
+// in its configuration, and owns the caches, tracers and health checks built for it.
+// Each Handler is independent; multiple Handlers can run in the same process
+type Handler struct {
+	handler http.Handler
+	conf    *config.Config
+	logger  *tl.Logger
+	caches  map[string]cache.Cache
+	tracers tracing.Tracers
+	hc      healthcheck.HealthChecker
+	clients backends.Backends
+
+	previous   *Handler
+	ownsLogger bool
+	closeOnce  sync.Once
+	closeErr   error
+}
+
+// Option is a functional option for configuring a Handler
+type Option func(*Handler)
+
+// WithLogger sets the logger used by the Handler. When not provided, the Handler
+// creates its own logger from the configuration's logging options, and closes it on Close
+func WithLogger(logger *tl.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// WithPrevious provides the Handler that the new Handler replaces, such as on a configuration
+// reload. Caches whose options are unchanged are moved from the previous Handler to the new one
+// rather than being reconnected. Once the new Handler is built, the previous Handler's health
+// checks are stopped, and the previous Handler is closed after the configuration's drain timeout
+func WithPrevious(prev *Handler) Option {
+	return func(h *Handler) {
+		h.previous = prev
+	}
+}
+
+// NewFromArgs loads a configuration from the provided command line-style arguments
+// (e.g., []string{"-config", "/path/to/trickster.yaml"}), then returns a new Handler
+func NewFromArgs(args []string, opts ...Option) (*Handler, error) {
+	conf, _, err := config.Load(runtime.ApplicationName, runtime.ApplicationVersion, args)
+	if err != nil {
+		return nil, err
+	}
+	return New(conf, opts...)
+}
+
+// New returns a new Handler that serves the backends defined in the provided configuration.
+// The configuration's frontend and reload listener options are ignored, since the caller
+// decides where the Handler is served. The configuration is not modified by New, and must not
+// be modified while the Handler is in use. Caches that fail to connect are logged rather than
+// returned as an error. Close must be called to release the Handler's resources
+func New(conf *config.Config, opts ...Option) (*Handler, error) {
+
+	if conf == nil {
+		return nil, ErrNilConfig
+	}
+
+	h := &Handler{conf: conf}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.logger == nil {
+		h.logger = tl.New(conf)
+		h.ownsLogger = true
+	}
+
+	var err error
+	h.tracers, err = tr.RegisterAll(conf, h.logger, false)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	h.caches = make(map[string]cache.Cache, len(conf.Caches))
+	reused := h.reuseCaches()
+	// abort closes the resources built so far, leaving the previous Handler's caches intact
+	abort := func(err error) (*Handler, error) {
+		for k := range reused {
+			delete(h.caches, k)
+		}
+		h.Close()
+		return nil, err
+	}
+	for k, v := range conf.Caches {
+		if _, ok := h.caches[k]; ok {
+			continue
+		}
+		// as with the trickster binary, a cache that fails to connect (e.g., an unreachable
+		// Redis) is logged and kept, so the Handler can serve once the cache is reachable
+		c, err := registration.ConnectCache(k, v, h.logger)
+		if err != nil {
+			tl.Error(h.logger, "cache failed to connect", tl.Pairs{"cacheName": k,
+				"cacheProvider": v.Provider, "detail": err.Error()})
+		}
+		h.caches[k] = c
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc(conf.Main.PingHandlerPath, th.PingHandleFunc(conf)).Methods(http.MethodGet)
+
+	h.hc = healthcheck.New()
+	if conf.Main.HealthHandlerPath != "" {
+		router.HandleFunc(conf.Main.HealthHandlerPath, th.HealthHandleFunc(h.hc)).
+			Methods(http.MethodGet, http.MethodHead)
+	}
+
+	h.clients, err = routing.RegisterProxyRoutes(conf, router, h.caches, h.tracers, h.hc,
+		h.logger, false)
+	if err != nil {
+		return abort(err)
+	}
+
+	h.handler = handlers.CompressHandler(router)
+	if h.previous != nil {
+		var drainTimeout time.Duration
+		if conf.ReloadConfig != nil {
+			drainTimeout = time.Duration(conf.ReloadConfig.DrainTimeoutMS) * time.Millisecond
+		}
+		h.previous.retire(reused, drainTimeout)
+		h.previous = nil
+	}
+	return h, nil
+}
+
+// reuseCaches adds the previous Handler's caches that can serve the new configuration to
+// the Handler's caches, and returns the names of the reused caches
+func (h *Handler) reuseCaches() map[string]bool {
+	reused := make(map[string]bool)
+	if h.previous == nil {
+		return reused
+	}
+	for k, v := range h.conf.Caches {
+		c, ok := h.previous.caches[k]
+		if !ok || c == nil {
+			continue
+		}
+		ocfg := c.Configuration()
+		// a cache that is in both the old and new config, and unchanged, is reused as-is
+		if v.Equal(ocfg) {
+			h.caches[k] = c
+			reused[k] = true
+			continue
+		}
+		// a memory cache is preserved when its index configuration is the only change,
+		// by applying the new index configuration to the existing cache
+		if ocfg.ProviderID == v.ProviderID && ocfg.ProviderID == providers.Memory {
+			if mc, ok := c.(*memory.Cache); ok {
+				if v.Index != nil {
+					mc.Index.UpdateOptions(v.Index)
+				}
+				h.caches[k] = c
+				reused[k] = true
+			}
+		}
+	}
+	return reused
+}
+
+// retire hands the reused caches over to the Handler that replaces h, stops h's health
+// checks, and closes h after the drain timeout, so in-flight requests can complete
+func (h *Handler) retire(reused map[string]bool, drainTimeout time.Duration) {
+	for k := range reused {
+		delete(h.caches, k)
+	}
+	if h.hc != nil {
+		h.hc.Shutdown()
+	}
+	go func() {
+		time.Sleep(drainTimeout)
+		h.Close()
+	}()
+}
+
+// ServeHTTP serves the request through the Handler's router
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// Config returns the configuration the Handler was built from
+func (h *Handler) Config() *config.Config {
+	return h.conf
+}
+
+// ConfigHandler returns an http.Handler that responds with the Handler's running configuration
+func (h *Handler) ConfigHandler() http.Handler {
+	return http.HandlerFunc(th.ConfigHandleFunc(h.conf))
+}
+
+// Tracers returns the tracers registered for the Handler's configuration
+func (h *Handler) Tracers() tracing.Tracers {
+	return h.tracers
+}
+
+// PurgeHandler returns an http.Handler that purges objects from the Handler's caches,
+// authorized by the configuration's purge_auth_token. Since purging is an administrative
+// function, it is not routed by ServeHTTP; the caller should serve it where appropriate
+func (h *Handler) PurgeHandler() http.Handler {
+	return http.HandlerFunc(th.PurgeHandleFunc(h.conf, h.clients, h.logger))
+}
+
+// Close stops the Handler's health checks, flushes its tracers and closes its caches.
+// The Handler must not serve requests after it is closed. Subsequent calls are no-ops
+func (h *Handler) Close() error {
+	h.closeOnce.Do(func() {
+		if h.hc != nil {
+			h.hc.Shutdown()
+		}
+		for _, t := range h.tracers {
+			if t != nil && t.Flusher != nil {
+				t.Flusher()
+			}
+		}
+		h.closeErr = registration.CloseCaches(h.caches)
+		if h.ownsLogger && h.logger != nil {
+			h.logger.Close()
+		}
+	})
+	return h.closeErr
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trickster
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tricksterproxy/trickster/pkg/cache/providers"
+	"github.com/tricksterproxy/trickster/pkg/config"
+	tl "github.com/tricksterproxy/trickster/pkg/logging"
+)
+
+func newTestOrigin(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestNew(t *testing.T) {
+
+	_, err := New(nil)
+	if err != ErrNilConfig {
+		t.Errorf("expected %v got %v", ErrNilConfig, err)
+	}
+
+	es := newTestOrigin("test")
+	defer es.Close()
+
+	h, err := NewFromArgs([]string{"-origin-url", es.URL, "-provider", "rpc"},
+		WithLogger(tl.ConsoleLogger("error")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	if h.Config() == nil {
+		t.Error("expected non-nil config")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/some/path", nil)
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	if string(b) != "test" {
+		t.Errorf("expected %s got %s", "test", string(b))
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "http://0"+h.Config().Main.PingHandlerPath, nil)
+	h.ServeHTTP(w, r)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Result().StatusCode)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "http://0/trickster/config", nil)
+	h.ConfigHandler().ServeHTTP(w, r)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Result().StatusCode)
+	}
+
+	if h.PurgeHandler() == nil {
+		t.Error("expected non-nil purge handler")
+	}
+
+}
+
+func TestNewFromArgsError(t *testing.T) {
+	_, err := NewFromArgs([]string{"-config", "/path/does/not/exist.conf"})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestNewCacheConnectFailed(t *testing.T) {
+
+	es := newTestOrigin("test")
+	defer es.Close()
+
+	conf, _, err := config.Load("trickster", "test", []string{"-origin-url", es.URL, "-provider", "rpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := conf.Caches["default"]
+	cc.Provider = "redis"
+	cc.ProviderID = providers.Redis
+	cc.Redis.Endpoint = "127.0.0.1:1"
+
+	// an unreachable cache does not prevent the Handler from starting
+	h, err := New(conf, WithLogger(tl.ConsoleLogger("error")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	h.ServeHTTP(w, r)
+	b, _ := ioutil.ReadAll(w.Result().Body)
+	if string(b) != "test" {
+		t.Errorf("expected %s got %s", "test", string(b))
+	}
+
+}
+
+func TestMultipleHandlers(t *testing.T) {
+
+	es1 := newTestOrigin("one")
+	defer es1.Close()
+	es2 := newTestOrigin("two")
+	defer es2.Close()
+
+	h1, err := NewFromArgs([]string{"-origin-url", es1.URL, "-provider", "rpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+
+	h2, err := NewFromArgs([]string{"-origin-url", es2.URL, "-provider", "rpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+
+	for _, test := range []struct {
+		h        *Handler
+		expected string
+	}{{h1, "one"}, {h2, "two"}} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+		test.h.ServeHTTP(w, r)
+		b, _ := ioutil.ReadAll(w.Result().Body)
+		if string(b) != test.expected {
+			t.Errorf("expected %s got %s", test.expected, string(b))
+		}
+	}
+
+}
+
+func TestClose(t *testing.T) {
+
+	es := newTestOrigin("test")
+	defer es.Close()
+
+	h, err := NewFromArgs([]string{"-origin-url", es.URL, "-provider", "rpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Error(err)
+	}
+	// subsequent calls are no-ops
+	if err := h.Close(); err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestWithPrevious(t *testing.T) {
+
+	es := newTestOrigin("test")
+	defer es.Close()
+
+	h1, err := NewFromArgs([]string{"-origin-url", es.URL, "-provider", "rpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 := h1.caches["default"]
+	if c1 == nil {
+		t.Fatal("expected non-nil default cache")
+	}
+
+	conf := h1.Config()
+	conf.ReloadConfig.DrainTimeoutMS = 0
+	h2, err := New(conf, WithPrevious(h1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+
+	if h2.caches["default"] != c1 {
+		t.Error("expected the unchanged cache to be reused")
+	}
+	if _, ok := h1.caches["default"]; ok {
+		t.Error("expected the reused cache to be handed over from the previous handler")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "http://0/", nil)
+	h2.ServeHTTP(w, r)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Result().StatusCode)
+	}
+
+}
@@ -31,6 +31,7 @@ const (
 	configSubsystem   = "config"
 	buildSubsystem    = "build"
 	frontendSubsystem = "frontend"
+	backendSubsystem  = "backend"
 )
 
 // Default histogram buckets used by trickster
@@ -65,6 +66,15 @@ var ProxyRequestElements *prometheus.CounterVec
 // ProxyRequestDuration is a Histogram of time required in seconds to proxy a given Prometheus query
 var ProxyRequestDuration *prometheus.HistogramVec
 
+// ProxyFirstResponseWins is a Counter of upstream requests won by each member of a first good response backend group
+var ProxyFirstResponseWins *prometheus.CounterVec
+
+// ProxyRateLimiterRequests is a Counter of client requests evaluated by a rate limiter, by result
+var ProxyRateLimiterRequests *prometheus.CounterVec
+
+// BackendHealthStatus is a Gauge representing the background health check status of a backend
+var BackendHealthStatus *prometheus.GaugeVec
+
 // CacheObjectOperations is a Counter of operations (in # of objects) performed on a Trickster cache
 var CacheObjectOperations *prometheus.CounterVec
 
@@ -142,7 +152,7 @@ func init() {
 			Name:      "requests_total",
 			Help:      "Count of front end requests handled by Trickster",
 		},
-		[]string{"backend_name", "provider", "method", "path", "http_status"},
+		[]string{"backend_name", "provider", "method", "path", "http_status", "tenant"},
 	)
 
 	FrontendRequestDuration = prometheus.NewHistogramVec(
@@ -153,7 +163,7 @@ func init() {
 			Help:      "Histogram of front end request durations handled by Trickster",
 			Buckets:   defaultBuckets,
 		},
-		[]string{"backend_name", "provider", "method", "path", "http_status"},
+		[]string{"backend_name", "provider", "method", "path", "http_status", "tenant"},
 	)
 
 	FrontendRequestWrittenBytes = prometheus.NewCounterVec(
@@ -163,7 +173,7 @@ func init() {
 			Name:      "written_bytes_total",
 			Help:      "Count of bytes written in front end requests handled by Trickster",
 		},
-		[]string{"backend_name", "provider", "method", "path", "http_status"})
+		[]string{"backend_name", "provider", "method", "path", "http_status", "tenant"})
 
 	ProxyRequestStatus = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -172,7 +182,7 @@ func init() {
 			Name:      "requests_total",
 			Help:      "Count of downstream client requests handled by Trickster",
 		},
-		[]string{"backend_name", "provider", "method", "cache_status", "http_status", "path"},
+		[]string{"backend_name", "provider", "method", "cache_status", "http_status", "path", "tenant"},
 	)
 
 	ProxyRequestElements = prometheus.NewCounterVec(
@@ -182,7 +192,7 @@ func init() {
 			Name:      "points_total",
 			Help:      "Count of data points in the timeseries returned to the requesting client.",
 		},
-		[]string{"backend_name", "provider", "cache_status", "path"},
+		[]string{"backend_name", "provider", "cache_status", "path", "tenant"},
 	)
 
 	ProxyRequestDuration = prometheus.NewHistogramVec(
@@ -193,7 +203,37 @@ func init() {
 			Help:      "Time required in seconds to proxy a given Prometheus query.",
 			Buckets:   defaultBuckets,
 		},
-		[]string{"backend_name", "provider", "method", "status", "http_status", "path"},
+		[]string{"backend_name", "provider", "method", "status", "http_status", "path", "tenant"},
+	)
+
+	ProxyFirstResponseWins = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: proxySubsystem,
+			Name:      "first_response_wins_total",
+			Help:      "Count of upstream requests won by each member of a first good response backend group.",
+		},
+		[]string{"backend_name", "member_name"},
+	)
+
+	ProxyRateLimiterRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: proxySubsystem,
+			Name:      "rate_limiter_requests_total",
+			Help:      "Count of client requests evaluated by a rate limiter, by result ('allowed' or 'limited').",
+		},
+		[]string{"backend_name", "path", "rate_limiter", "result"},
+	)
+
+	BackendHealthStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: backendSubsystem,
+			Name:      "health_status",
+			Help:      "Background health check status of the backend: 1 is passing, 0 is unknown, -1 is failing.",
+		},
+		[]string{"backend_name", "provider"},
 	)
 
 	ProxyMaxConnections = prometheus.NewGauge(
@@ -326,6 +366,9 @@ func init() {
 	prometheus.MustRegister(ProxyRequestStatus)
 	prometheus.MustRegister(ProxyRequestElements)
 	prometheus.MustRegister(ProxyRequestDuration)
+	prometheus.MustRegister(ProxyFirstResponseWins)
+	prometheus.MustRegister(ProxyRateLimiterRequests)
+	prometheus.MustRegister(BackendHealthStatus)
 	prometheus.MustRegister(ProxyMaxConnections)
 	prometheus.MustRegister(ProxyActiveConnections)
 	prometheus.MustRegister(ProxyConnectionRequested)
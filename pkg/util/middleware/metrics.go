@@ -20,13 +20,15 @@ import (
 	"net/http"
 	"time"
 
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
 	"github.com/tricksterproxy/trickster/pkg/util/metrics"
 )
 
 // Decorate decorates a function in such a way that it captures both the
 // returned status and the time used to execute a request from the front end
-// perspective
-func Decorate(backendName, backendProvider, path string, next http.Handler) http.Handler {
+// perspective. When tenantHeader is provided, metrics are labeled with the
+// request's tenant
+func Decorate(backendName, backendProvider, tenantHeader, path string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		observer := &responseObserver{
 			w,
@@ -34,15 +36,20 @@ func Decorate(backendName, backendProvider, path string, next http.Handler) http
 			0,
 		}
 
+		var tenant string
+		if tenantHeader != "" {
+			tenant, _ = oo.TenantID(tenantHeader, r.Header)
+		}
+
 		n := time.Now()
 		next.ServeHTTP(observer, r)
 
 		metrics.FrontendRequestDuration.WithLabelValues(backendName, backendProvider,
-			r.Method, path, observer.status).Observe(time.Since(n).Seconds())
+			r.Method, path, observer.status, tenant).Observe(time.Since(n).Seconds())
 		metrics.FrontendRequestStatus.WithLabelValues(backendName, backendProvider,
-			r.Method, path, observer.status).Inc()
+			r.Method, path, observer.status, tenant).Inc()
 		metrics.FrontendRequestWrittenBytes.WithLabelValues(backendName, backendProvider,
-			r.Method, path, observer.status).Add(observer.bytesWritten)
+			r.Method, path, observer.status, tenant).Add(observer.bytesWritten)
 	})
 }
 
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2018 Comcast Cable Communications Management, LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"net/http"
+
+	oo "github.com/tricksterproxy/trickster/pkg/backends/options"
+)
+
+// RequireTenant rejects requests to a multi-tenant backend that do not provide a valid
+// tenant identifier in the backend's tenant header. Requests missing the header receive
+// a 401 Unauthorized, and those with an invalid identifier receive a 400 Bad Request
+func RequireTenant(oc *oo.Options, next http.Handler) http.Handler {
+	if !oc.IsMultiTenant() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := oc.Tenant(r.Header); err != nil {
+			code := http.StatusBadRequest
+			if err == oo.ErrMissingTenant {
+				code = http.StatusUnauthorized
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}